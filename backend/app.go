@@ -3,20 +3,25 @@ package backend
 import (
 	"context"
 	"debug/pe"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"path"
 	"path/filepath"
 	"reflect"
 	"runtime"
 	"slices"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/dweymouth/supersonic/backend/ipc"
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/mpd"
 	"github.com/dweymouth/supersonic/backend/player"
 	"github.com/dweymouth/supersonic/backend/player/mpv"
 	"github.com/dweymouth/supersonic/backend/util"
@@ -29,13 +34,15 @@ import (
 )
 
 const (
-	configFile               = "config.toml"
-	portableDir              = "supersonic_portable"
-	savedQueueFile           = "saved_queue.json"
-	savedUnshuffledQueueFile = "saved_unshuffled_queue.json"
-	savedShuffledQueueFile   = "saved_shuffled_queue.json"
-	themesDir                = "themes"
-	audioCacheSubdir         = "audio"
+	configFile          = "config.toml"
+	portableDir         = "supersonic_portable"
+	savedQueueFile      = "saved_queue.json"
+	themesDir           = "themes"
+	audioCacheSubdir    = "audio"
+	waveformCacheSubdir = "waveforms"
+
+	legacySavedUnshuffledQueueFile = "saved_unshuffled_queue.json"
+	legacySavedShuffledQueueFile   = "saved_shuffled_queue.json"
 )
 
 var (
@@ -51,19 +58,33 @@ type App struct {
 	LyricsManager   *LyricsManager
 	ImageManager    *ImageManager
 	AudioCache      *AudioCache
+	WaveformCache   *WaveformCache
 	AutoEQManager   *AutoEQManager
 	EQPresetManager *EQPresetManager
+	PlayHistory     *PlayHistory
 	PlaybackManager *PlaybackManager
+	HookManager     *HookManager
 	LocalPlayer     *mpv.Player
 	UpdateChecker   UpdateChecker
 	MPRISHandler    *MPRISHandler
 	WinSMTC         *windows.SMTC
 	ipcServer       ipc.IPCServer
+	remoteControl   *remoteControl
+	mpdServer       *mpd.Server
+	mqttClient      *mqttClient
+	discordRPC      *discordRPC
+
+	// the supersonic:// link the app was launched to open, if any
+	PendingDeepLink *DeepLink
 
 	// UI callbacks to be set in main
 	OnReactivate  func()
 	OnExit        func()
 	OnReloadTheme func()
+	// invoked to navigate to the item of a supersonic:// link
+	OnOpenDeepLink func(*DeepLink)
+	// invoked when a newer play queue from another device is detected
+	OnRemotePlayQueue func(*mediaprovider.SavedPlayQueue)
 
 	appName        string
 	displayAppName string
@@ -77,10 +98,36 @@ type App struct {
 	cancel        context.CancelFunc
 
 	lastWrittenCfg Config
+	queueHandoff   queueHandoffState
+
+	// runs backend changes to state shared with the UI, see SetMainThreadRunner
+	mainThreadLock   sync.Mutex
+	mainThreadRunner func(func())
 
 	logFile *os.File
 }
 
+// SetMainThreadRunner sets the function which runs changes that background
+// goroutines make to state shared with the UI, such as the config, on the UI
+// thread. Until it is set, and without a UI, they run serialized by a lock.
+func (a *App) SetMainThreadRunner(run func(func())) {
+	a.mainThreadLock.Lock()
+	defer a.mainThreadLock.Unlock()
+	a.mainThreadRunner = run
+}
+
+func (a *App) runOnMainThread(f func()) {
+	a.mainThreadLock.Lock()
+	run := a.mainThreadRunner
+	if run == nil {
+		defer a.mainThreadLock.Unlock()
+		f()
+		return
+	}
+	a.mainThreadLock.Unlock()
+	run(f)
+}
+
 func (a *App) VersionTag() string {
 	return a.appVersionTag
 }
@@ -101,8 +148,9 @@ func StartupApp(appName, displayAppName, appVersion, appVersionTag, latestReleas
 	configdir.MakePath(cacheDir)
 
 	var logFile *os.File
-	if isWindowsGUI() {
-		// Can't log to console in Windows GUI app so log to file instead
+	if isWindowsGUI() || *FlagTUI {
+		// Can't log to console in Windows GUI app or while the
+		// terminal UI owns the screen, so log to file instead
 		if f, err := os.Create(filepath.Join(confDir, "supersonic.log")); err == nil {
 			log.SetOutput(f)
 			logFile = f
@@ -122,7 +170,20 @@ func StartupApp(appName, displayAppName, appVersion, appVersionTag, latestReleas
 	a.readConfig()
 
 	cli, _ := ipc.Connect()
-	if HaveCommandLineOptions() {
+	if link := DeepLinkCLIArg(); link != "" {
+		if cli != nil {
+			// let the running instance open it
+			if err := cli.OpenLink(link); err != nil {
+				log.Fatalf("error opening link: %s", err.Error())
+			}
+			return nil, ErrAnotherInstance
+		}
+		if l, err := ParseDeepLink(link); err == nil {
+			a.PendingDeepLink = l
+		} else {
+			log.Printf("error opening link: %s", err.Error())
+		}
+	} else if HaveCommandLineOptions() {
 		if err := a.checkFlagsAndSendIPCMsg(cli); err != nil {
 			// we were supposed to control another instance and couldn't
 			log.Fatalf("error sending IPC message: %s", err.Error())
@@ -152,7 +213,7 @@ func StartupApp(appName, displayAppName, appVersion, appVersionTag, latestReleas
 
 	a.ServerManager = NewServerManager(appName, appVersion, a.Config, !portableMode && a.Config.Application.EnablePasswordStorage)
 	a.ImageManager = NewImageManager(a.bgrndCtx, a.ServerManager, cacheDir)
-	if a.Config.Playback.UseWaveformSeekbar {
+	if a.Config.Playback.UseWaveformSeekbar || a.Config.Playback.SkipSilence || a.Config.ReplayGain.AnalyzeLoudness {
 		ac, err := NewAudioCache(a.bgrndCtx, a.ServerManager, filepath.Join(cacheDir, audioCacheSubdir))
 		if err != nil {
 			log.Printf("failed to create audio cache: %s", err.Error())
@@ -160,6 +221,18 @@ func StartupApp(appName, displayAppName, appVersion, appVersionTag, latestReleas
 		a.AudioCache = ac
 	}
 	a.PlaybackManager = NewPlaybackManager(a.bgrndCtx, a.ServerManager, a.AudioCache, a.LocalPlayer, &a.Config.Playback, &a.Config.Scrobbling, &a.Config.Transcoding, &a.Config.Application)
+	if a.Config.Playback.UseWaveformSeekbar || a.Config.Playback.SkipSilence {
+		a.Config.Playback.MaxWaveformCacheSizeMB = clamp(a.Config.Playback.MaxWaveformCacheSizeMB, 1, 500)
+		a.WaveformCache = NewWaveformCache(filepath.Join(cacheDir, waveformCacheSubdir),
+			int64(a.Config.Playback.MaxWaveformCacheSizeMB)*1_048_576)
+		a.PlaybackManager.SetWaveformCache(a.WaveformCache)
+	}
+	if a.Config.ReplayGain.AnalyzeLoudness && a.AudioCache != nil {
+		a.PlaybackManager.SetLoudnessAnalyzer(NewLoudnessAnalyzer(a.bgrndCtx, a.AudioCache, a.configDir))
+	}
+	a.PlaybackManager.SetReplayGainOptions(a.Config.ReplayGain)
+	a.LocalPlayer.OnAudioDevicesChanged(func() { go a.updateAudioDeviceProfile() })
+	go watchDefaultAudioDevice(a.bgrndCtx, a.updateAudioDeviceProfile)
 	a.PlaybackManager.CoverArtPathFn = func(coverArtID string) (string, error) {
 		// Ensure the thumbnail is cached on disk, then return its path so
 		// the DLNA player can expose it through the local proxy as
@@ -179,6 +252,9 @@ func StartupApp(appName, displayAppName, appVersion, appVersionTag, latestReleas
 	}
 	a.LyricsManager = NewLyricsManager(a.ServerManager, fetch)
 	a.EQPresetManager = NewEQPresetManager(confDir)
+	a.PlayHistory = NewPlayHistory(confDir)
+	a.HookManager = NewHookManager(a.bgrndCtx, &a.Config.Hooks)
+	a.registerHooks()
 
 	// Initialize AutoEQ manager
 	autoEQTimeout := time.Duration(a.Config.Application.RequestTimeoutSeconds) * time.Second
@@ -215,6 +291,23 @@ func StartupApp(appName, displayAppName, appVersion, appVersionTag, latestReleas
 	a.PlaybackManager.OnSongChange(func(_ mediaprovider.MediaItem, _ *mediaprovider.Track) {
 		go a.SavePlayQueueIfEnabled()
 	})
+	a.PlaybackManager.OnSongChange(func(_ mediaprovider.MediaItem, justScrobbled *mediaprovider.Track) {
+		if justScrobbled == nil {
+			return
+		}
+		serverID := a.ServerManager.ServerID.String()
+		go func() {
+			if err := a.PlayHistory.Record(serverID, justScrobbled, time.Now()); err != nil {
+				log.Printf("error recording play history: %v", err)
+			}
+		}()
+	})
+
+	// start the queue handoff once connected, by which time
+	// the UI has set OnRemotePlayQueue to offer remote queues
+	a.ServerManager.OnServerConnected(func(*ServerConfig) {
+		a.queueHandoff.start.Do(a.startQueueHandoff)
+	})
 
 	// Start IPC server if another not already running in a different instance
 	if cli == nil {
@@ -225,27 +318,46 @@ func StartupApp(appName, displayAppName, appVersion, appVersionTag, latestReleas
 				if s := a.ServerManager.GetServer(); s != nil {
 					if tr := a.PlaybackManager.NowPlaying(); tr != nil && tr.Metadata().Type == mediaprovider.MediaItemTypeTrack {
 						if supportsRating, ok := s.(mediaprovider.SupportsRating); ok {
-							supportsRating.SetRating(mediaprovider.RatingFavoriteParameters{
+							err := supportsRating.SetRating(mediaprovider.RatingFavoriteParameters{
 								TrackIDs: []string{tr.Metadata().ID},
 							}, rating)
+							if err == nil {
+								a.PlaybackManager.OnTrackRatingChanged(tr.Metadata().ID, rating)
+							}
 						}
 					}
 				}
 			}
 
 			a.ipcServer = ipc.NewServer(
-				a.PlaybackManager,
+				ipcPlaybackHandler{a.PlaybackManager},
+				&ipcQueueHandler{pm: a.PlaybackManager, sm: a.ServerManager},
 				ipcRatingHandler,
 				a.ServerManager,
 				a.callOnReactivate,
 				func() { _ = a.callOnExit() },
-				a.callOnReloadTheme)
+				a.callOnReloadTheme,
+				a.OpenDeepLink)
+			a.publishIPCEvents()
 			go a.ipcServer.Serve(listener)
+			if a.Config.RemoteControl.Enabled {
+				a.startRemoteControl()
+			}
 		} else {
 			log.Printf("error starting IPC server: %s", err.Error())
 		}
 	}
 
+	if a.Config.MPDServer.Enabled {
+		a.startMPDServer()
+	}
+	if a.Config.MQTT.Enabled {
+		a.startMQTT()
+	}
+	if a.Config.DiscordRPC.Enabled {
+		a.startDiscordRPC()
+	}
+
 	// OS media center integrations
 	if a.Config.Application.EnableOSMediaPlayerAPIs {
 		// Linux MPRIS
@@ -296,6 +408,19 @@ func (a *App) ClearCaches() {
 	a.ImageManager.ClearInMemoryCache()
 }
 
+// ClearWaveformCache deletes the persisted waveforms of the waveform seekbar.
+func (a *App) ClearWaveformCache() {
+	var err error
+	if a.WaveformCache != nil {
+		err = a.WaveformCache.Clear()
+	} else if a.cacheDir != "" {
+		err = os.RemoveAll(filepath.Join(a.cacheDir, waveformCacheSubdir))
+	}
+	if err != nil {
+		log.Printf("failed to clear waveform cache: %s", err.Error())
+	}
+}
+
 func checkPortablePath() string {
 	if p, err := os.Executable(); err == nil {
 		pdirPath := path.Join(filepath.Dir(p), portableDir)
@@ -378,9 +503,6 @@ func (a *App) initMPV() error {
 }
 
 func (a *App) setupMPV() error {
-	a.Config.LocalPlayback.Volume = clamp(a.Config.LocalPlayback.Volume, 0, 100)
-	a.LocalPlayer.SetVolume(a.Config.LocalPlayback.Volume)
-
 	devs, err := a.LocalPlayer.ListAudioDevices()
 	if err != nil {
 		return err
@@ -402,6 +524,10 @@ func (a *App) setupMPV() error {
 		desiredDevice = "auto"
 	}
 	a.LocalPlayer.SetAudioDevice(desiredDevice)
+	a.Config.switchAudioDeviceProfile(a.currentAudioDevice(), a.Config.LocalPlayback.Volume)
+
+	a.Config.LocalPlayback.Volume = clamp(a.Config.LocalPlayback.Volume, 0, 100)
+	a.LocalPlayer.SetVolume(a.Config.LocalPlayback.Volume)
 
 	rgainOpts := []string{ReplayGainNone, ReplayGainAlbum, ReplayGainTrack, ReplayGainAuto}
 	if !slices.Contains(rgainOpts, a.Config.ReplayGain.Mode) {
@@ -425,38 +551,17 @@ func (a *App) setupMPV() error {
 	a.LocalPlayer.SetAudioExclusive(a.Config.LocalPlayback.AudioExclusive)
 	a.LocalPlayer.SetPauseFade(a.Config.LocalPlayback.PauseFade)
 
-	// Initialize the appropriate equalizer type based on config
-	var eq mpv.Equalizer
-	if a.Config.LocalPlayback.EqualizerType == "ISO10Band" {
-		eq10 := &mpv.ISO10BandEqualizer{
-			EQPreamp: a.Config.LocalPlayback.EqualizerPreamp,
-			Disabled: !a.Config.LocalPlayback.EqualizerEnabled,
-		}
-		// Copy up to 10 bands
-		numBands := min(len(a.Config.LocalPlayback.GraphicEqualizerBands), 10)
-		for i := 0; i < numBands; i++ {
-			eq10.BandGains[i] = a.Config.LocalPlayback.GraphicEqualizerBands[i]
-		}
-		eq = eq10
-	} else {
-		eq15 := &mpv.ISO15BandEqualizer{
-			EQPreamp: a.Config.LocalPlayback.EqualizerPreamp,
-			Disabled: !a.Config.LocalPlayback.EqualizerEnabled,
-		}
-		// Copy up to 15 bands
-		numBands := min(len(a.Config.LocalPlayback.GraphicEqualizerBands), 15)
-		for i := 0; i < numBands; i++ {
-			eq15.BandGains[i] = a.Config.LocalPlayback.GraphicEqualizerBands[i]
-		}
-		eq = eq15
+	a.LocalPlayer.SetEqualizer(NewEqualizerFromConfig(&a.Config.LocalPlayback))
+	a.Config.LocalPlayback.DSPChain = mpv.NormalizeDSPChain(a.Config.LocalPlayback.DSPChain)
+	if err := a.LocalPlayer.SetDSPChain(a.Config.LocalPlayback.DSPChain); err != nil {
+		log.Printf("error applying DSP chain: %v", err)
 	}
-	a.LocalPlayer.SetEqualizer(eq)
 
 	return nil
 }
 
 func (a *App) setupMPRIS(mprisAppName string) {
-	a.MPRISHandler = NewMPRISHandler(mprisAppName, a.PlaybackManager)
+	a.MPRISHandler = NewMPRISHandler(mprisAppName, a.PlaybackManager, a.ServerManager)
 	a.MPRISHandler.ArtURLLookup = func(id string) (string, error) {
 		a.ImageManager.GetCoverThumbnail(id) // ensure image is cached locally
 		return a.ImageManager.GetCoverArtUrl(id)
@@ -598,6 +703,15 @@ func (a *App) Shutdown() {
 	if a.ipcServer != nil {
 		a.ipcServer.Shutdown(a.bgrndCtx)
 	}
+	if a.remoteControl != nil {
+		a.remoteControl.server.Shutdown(a.bgrndCtx)
+	}
+	if a.mpdServer != nil {
+		a.mpdServer.Close()
+	}
+	if a.mqttClient != nil {
+		a.mqttClient.Close()
+	}
 	if a.MPRISHandler != nil {
 		a.MPRISHandler.Shutdown()
 	}
@@ -623,46 +737,26 @@ func (a *App) SavePlayQueueIfEnabled() {
 			queueServer = qs
 		}
 	}
-	SavePlayQueue(a.ServerManager.ServerID.String(), a.PlaybackManager.GetActivePlayQueue(), a.PlaybackManager, path.Join(a.configDir, savedQueueFile), queueServer)
-	if a.Config.Playback.Shuffle {
-		// if shuffle
-		// save the unshuffled queue to enable unshuffling on restarting supersonic
-		// save the shuffled queue again to enable checking if the playQueue was changed server side on start up
-
-		// both files are just saved locally
-		SavePlayQueue(a.ServerManager.ServerID.String(), a.PlaybackManager.GetPlayQueue(), a.PlaybackManager, path.Join(a.configDir, savedUnshuffledQueueFile), nil)
-		SavePlayQueue(a.ServerManager.ServerID.String(), a.PlaybackManager.GetShuffledPlayQueue(), a.PlaybackManager, path.Join(a.configDir, savedShuffledQueueFile), nil)
-	}
+	SavePlayQueue(a.ServerManager.ServerID.String(), a.PlaybackManager, path.Join(a.configDir, savedQueueFile), queueServer)
 }
 
 func (a *App) LoadSavedPlayQueue() error {
 	queueFilePath := path.Join(a.configDir, savedQueueFile)
+	// the shuffle ordering used to be saved in separate files,
+	// which are superseded by the saved queue file
+	if err := migrateLegacyShuffledQueue(queueFilePath,
+		path.Join(a.configDir, legacySavedUnshuffledQueueFile),
+		path.Join(a.configDir, legacySavedShuffledQueueFile),
+		a.Config.Playback.Shuffle, a.PlaybackManager.GetLoopMode()); err != nil {
+		log.Printf("error migrating saved play queue: %v", err)
+	}
+
 	playQueue, err := LoadPlayQueue(queueFilePath, a.ServerManager, a.Config.Application.SaveQueueToServer)
 	if err != nil {
 		return err
 	}
 
-	var unshuffledPlayQueue *SavedPlayQueue
-	var shuffledPlayQueue *SavedPlayQueue
-
-	isShuffle := a.Config.Playback.Shuffle
-	if isShuffle {
-		unshuffledQueueFilePath := path.Join(a.configDir, savedUnshuffledQueueFile)
-		unshuffledPlayQueue, err = LoadPlayQueue(unshuffledQueueFilePath, a.ServerManager, false)
-
-		if err != nil {
-			return err
-		}
-
-		shuffledQueueFilePath := path.Join(a.configDir, savedShuffledQueueFile)
-		shuffledPlayQueue, err = LoadPlayQueue(shuffledQueueFilePath, a.ServerManager, false)
-
-		if err != nil {
-			return err
-		}
-	}
-
-	if len(playQueue.Tracks) == 0 {
+	if len(playQueue.Items) == 0 {
 		return nil
 	}
 	if len(a.PlaybackManager.GetActivePlayQueue()) > 0 {
@@ -670,32 +764,93 @@ func (a *App) LoadSavedPlayQueue() error {
 		return nil
 	}
 
-	if isShuffle {
-		serverStatePlayQueue := sharedutil.CopyTrackSliceToMediaItemSlice(playQueue.Tracks)
-		clientStatePlayQueue := sharedutil.CopyTrackSliceToMediaItemSlice(shuffledPlayQueue.Tracks)
-
-		// Compare items by ID. This fails if any 2 elements don't match up. Two queues with the same items but different order will thus not count as same
-		if slices.EqualFunc(serverStatePlayQueue, clientStatePlayQueue, func(a, b mediaprovider.MediaItem) bool {
-			return (a.Metadata().ID == b.Metadata().ID)
-		}) {
-			a.PlaybackManager.SetQueueState(playQueue.Tracks, ShuffledPlayQueue)
-			a.PlaybackManager.SetQueueState(unshuffledPlayQueue.Tracks, PlayQueue)
-		} else {
-			a.PlaybackManager.SetShuffle(false)
-			a.PlaybackManager.SetQueueState(playQueue.Tracks, PlayQueue)
-		}
-
-	} else {
-		a.PlaybackManager.SetQueueState(playQueue.Tracks, PlayQueue)
+	// set shuffle while the queue is still empty so
+	// the engine doesn't generate a new shuffle ordering
+	a.PlaybackManager.SetShuffle(playQueue.Shuffle)
+	a.PlaybackManager.SetQueueState(playQueue.Items, PlayQueue)
+	if playQueue.Shuffle {
+		a.PlaybackManager.SetQueueState(playQueue.ShuffledItems, ShuffledPlayQueue)
+	}
+	if playQueue.hasPlaybackModes {
+		a.PlaybackManager.SetLoopMode(playQueue.LoopMode)
+		a.PlaybackManager.SetPauseAfterCurrent(playQueue.PauseAfterCurrent)
 	}
 
-	if playQueue.TrackIndex >= 0 && playQueue.TrackIndex < len(playQueue.Tracks) {
+	if playQueue.TrackIndex >= 0 && playQueue.TrackIndex < len(playQueue.ActiveQueue()) {
 		a.PlaybackManager.LoadTrackPaused(playQueue.TrackIndex, playQueue.TimePos)
 	}
 
 	return nil
 }
 
+// ExportPlayHistory writes the recorded play history to the given file path,
+// as CSV if the path has a .csv extension and as JSONL otherwise.
+func (a *App) ExportPlayHistory(filePath string) error {
+	f, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return a.PlayHistory.Export(f, strings.EqualFold(filepath.Ext(filePath), ".csv"))
+}
+
+// ImportPlayHistory imports plays from a ListenBrainz or Last.fm export file
+// into the play history, matching them to tracks in the current server's library.
+// Plays already in the history are skipped. If submitToServer is true and the server
+// can record plays at their original time, matched plays are also submitted to it.
+// Returns the number of imported and matched plays.
+func (a *App) ImportPlayHistory(filePath string, submitToServer bool) (int, int, error) {
+	entries, err := ReadPlayHistoryImportFile(filePath)
+	if err != nil {
+		return 0, 0, err
+	}
+	existing, err := a.PlayHistory.Entries()
+	if err != nil {
+		return 0, 0, err
+	}
+	type playKey struct {
+		unixTime int64
+		title    string
+	}
+	seen := make(map[playKey]bool, len(existing))
+	for _, e := range existing {
+		seen[playKey{e.Time.Unix(), normalizeForMatch(e.Title)}] = true
+	}
+	entries = sharedutil.FilterSlice(entries, func(e PlayHistoryEntry) bool {
+		key := playKey{e.Time.Unix(), normalizeForMatch(e.Title)}
+		if seen[key] {
+			return false
+		}
+		seen[key] = true
+		return true
+	})
+	if len(entries) == 0 {
+		return 0, 0, nil
+	}
+
+	var matched int
+	if mp := a.ServerManager.Server; mp != nil {
+		matched = MatchPlayHistoryEntries(mp, a.ServerManager.ServerID.String(), entries)
+	}
+	if err := a.PlayHistory.Append(entries); err != nil {
+		return 0, 0, err
+	}
+
+	// only submit plays if the server can record them at their original
+	// time, otherwise every imported play would be scrobbled as of now
+	if sub, ok := a.ServerManager.Server.(mediaprovider.CanSubmitPastPlays); ok && submitToServer && matched > 0 {
+		for _, e := range entries {
+			if e.TrackID == "" {
+				continue
+			}
+			if err := sub.SubmitPlayAt(e.TrackID, e.Time); err != nil {
+				log.Printf("error submitting imported play: %v", err)
+			}
+		}
+	}
+	return len(entries), matched, nil
+}
+
 func (a *App) SaveConfigFile() {
 	a.Config.WriteConfigFile(a.configFilePath())
 	a.lastWrittenCfg = *a.Config
@@ -716,6 +871,10 @@ func (a *App) checkFlagsAndSendIPCMsg(cli *ipc.Client) error {
 		return cli.SeekBackOrPrevious()
 	case *FlagNext:
 		return cli.SeekNext()
+	case *FlagPreviousChapter:
+		return cli.SeekPreviousChapter()
+	case *FlagNextChapter:
+		return cli.SeekNextChapter()
 	case *FlagStop:
 		return cli.Stop()
 	case *FlagPauseAfterCurrent:
@@ -764,11 +923,83 @@ func (a *App) checkFlagsAndSendIPCMsg(cli *ipc.Client) error {
 		return err
 	case RateCurrentCLIArg >= 0:
 		return cli.RateCurrentTrack(RateCurrentCLIArg)
+	case *FlagQueue:
+		data, err := cli.Queue()
+		if err == nil {
+			fmt.Println(data)
+		}
+		return err
+	case EnqueueTrackCLIArg != "":
+		return cli.Enqueue(ipc.EnqueueTypeTrack, EnqueueTrackCLIArg, *FlagEnqueueNext)
+	case EnqueueAlbumCLIArg != "":
+		return cli.Enqueue(ipc.EnqueueTypeAlbum, EnqueueAlbumCLIArg, *FlagEnqueueNext)
+	case EnqueuePlaylistCLIArg != "":
+		return cli.Enqueue(ipc.EnqueueTypePlaylist, EnqueuePlaylistCLIArg, *FlagEnqueueNext)
+	case len(RemoveFromQueueCLIArg) > 0:
+		return cli.RemoveFromQueue(RemoveFromQueueCLIArg)
+	case len(MoveInQueueCLIArg) > 0:
+		return cli.MoveInQueue(MoveInQueueCLIArg, MoveToCLIArg)
+	case *FlagClearQueue:
+		return cli.ClearQueue()
+	case *FlagToggleShuffle:
+		return cli.ToggleShuffle()
+	case LoopModeCLIArg != "":
+		return cli.SetLoopMode(LoopModeCLIArg)
+	case *FlagWatch:
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+		return cli.Watch(ctx, func(ev ipc.Event) {
+			if b, err := json.Marshal(ev); err == nil {
+				fmt.Println(string(b))
+			}
+		})
 	default:
 		return nil
 	}
 }
 
+// publishIPCEvents forwards playback events to clients
+// listening on the IPC server's event stream.
+func (a *App) publishIPCEvents() {
+	pm := a.PlaybackManager
+	publish := a.ipcServer.PublishEvent
+	pm.OnSongChange(func(nowPlaying mediaprovider.MediaItem, _ *mediaprovider.Track) {
+		var meta *mediaprovider.MediaItemMetadata
+		if nowPlaying != nil {
+			m := nowPlaying.Metadata()
+			meta = &m
+		}
+		publish(ipc.EventTrackChange, meta)
+	})
+	pm.OnPlaying(func() { publish(ipc.EventPlaying, nil) })
+	pm.OnPaused(func() { publish(ipc.EventPaused, nil) })
+	pm.OnStopped(func() { publish(ipc.EventStopped, nil) })
+	pm.OnSeek(func() {
+		publish(ipc.EventSeek, ipc.SeekEvent{TimePos: pm.PlaybackStatus().TimePos})
+	})
+	pm.OnVolumeChange(func(vol int) {
+		publish(ipc.EventVolumeChange, ipc.VolumeEvent{Volume: vol})
+	})
+	pm.OnQueueChange(func() {
+		publish(ipc.EventQueueChange, ipc.QueueEvent{
+			Length:          len(pm.GetActivePlayQueue()),
+			NowPlayingIndex: pm.NowPlayingIndex(),
+		})
+	})
+	pm.OnLoopModeChange(func(mode LoopMode) {
+		publish(ipc.EventLoopModeChange, ipc.LoopModeEvent{LoopMode: loopModeToIPC(mode)})
+	})
+	pm.OnShuffleChange(func(shuffle bool) {
+		publish(ipc.EventShuffleChange, ipc.ShuffleEvent{Shuffle: shuffle})
+	})
+	pm.OnRatingChange(func(trackID string, rating int) {
+		publish(ipc.EventRatingChange, ipc.RatingEvent{TrackID: trackID, Rating: rating})
+	})
+	pm.OnFavoriteChange(func(trackID string, favorite bool) {
+		publish(ipc.EventFavoriteChange, ipc.FavoriteEvent{TrackID: trackID, Favorite: favorite})
+	})
+}
+
 func (a *App) configFilePath() string {
 	return path.Join(a.configDir, configFile)
 }
@@ -0,0 +1,55 @@
+package backend
+
+/*
+#cgo LDFLAGS: -framework CoreAudio -framework CoreFoundation
+#include <stdlib.h>
+#include <CoreAudio/CoreAudio.h>
+
+// writes the UID of the default output device to buf, returns 0 on failure
+static int default_output_device_uid(char *buf, int len) {
+	AudioObjectPropertyAddress addr = {
+		kAudioHardwarePropertyDefaultOutputDevice,
+		kAudioObjectPropertyScopeGlobal,
+		0, // main element
+	};
+	AudioDeviceID dev;
+	UInt32 size = sizeof(dev);
+	if (AudioObjectGetPropertyData(kAudioObjectSystemObject, &addr, 0, NULL, &size, &dev) != noErr) {
+		return 0;
+	}
+	addr.mSelector = kAudioDevicePropertyDeviceUID;
+	CFStringRef uid = NULL;
+	size = sizeof(uid);
+	if (AudioObjectGetPropertyData(dev, &addr, 0, NULL, &size, &uid) != noErr || uid == NULL) {
+		return 0;
+	}
+	Boolean ok = CFStringGetCString(uid, buf, len, kCFStringEncodingUTF8);
+	CFRelease(uid);
+	return ok;
+}
+*/
+import "C"
+
+import (
+	"context"
+	"time"
+	"unsafe"
+)
+
+// defaultAudioDevice returns the UID of the default CoreAudio output device,
+// which mpv uses as the device name, or "" if it can't be determined.
+func defaultAudioDevice() string {
+	const bufLen = 512
+	buf := (*C.char)(C.malloc(bufLen))
+	defer C.free(unsafe.Pointer(buf))
+	if C.default_output_device_uid(buf, bufLen) == 0 {
+		return ""
+	}
+	return C.GoString(buf)
+}
+
+// watchDefaultAudioDevice calls onChange whenever the default
+// output device changes, until ctx is done.
+func watchDefaultAudioDevice(ctx context.Context, onChange func()) {
+	pollDefaultAudioDevice(ctx, 2*time.Second, onChange)
+}
@@ -0,0 +1,49 @@
+package backend
+
+import (
+	"bufio"
+	"context"
+	"log"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// defaultAudioDevice returns the name of the default PulseAudio
+// or PipeWire sink, or "" if it can't be determined.
+func defaultAudioDevice() string {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "pactl", "get-default-sink").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// watchDefaultAudioDevice calls onChange whenever the default sink changes,
+// until ctx is done. The sound server reports these as server change events.
+func watchDefaultAudioDevice(ctx context.Context, onChange func()) {
+	cmd := exec.CommandContext(ctx, "pactl", "subscribe")
+	out, err := cmd.StdoutPipe()
+	if err == nil {
+		err = cmd.Start()
+	}
+	if err != nil {
+		log.Printf("not watching the default audio device: %v", err)
+		return
+	}
+	defer cmd.Wait()
+
+	last := defaultAudioDevice()
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		if !strings.Contains(scanner.Text(), "on server") {
+			continue
+		}
+		if def := defaultAudioDevice(); def != last {
+			last = def
+			onChange()
+		}
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !windows && !darwin
+
+package backend
+
+import "context"
+
+// defaultAudioDevice returns the name of the OS default audio device,
+// or "" if it can't be determined, which is always the case here.
+func defaultAudioDevice() string {
+	return ""
+}
+
+func watchDefaultAudioDevice(_ context.Context, _ func()) {}
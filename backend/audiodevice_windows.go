@@ -0,0 +1,71 @@
+package backend
+
+/*
+#cgo LDFLAGS: -lole32
+#define COBJMACROS
+#include <stdlib.h>
+#include <initguid.h>
+#include <windows.h>
+#include <mmdeviceapi.h>
+
+// returns the ID of the default render endpoint as UTF-8, to be freed by the caller, or NULL
+static char *default_render_endpoint_id() {
+	HRESULT hr = CoInitializeEx(NULL, COINIT_MULTITHREADED);
+	// RPC_E_CHANGED_MODE means COM is already initialized on this thread
+	int uninit = SUCCEEDED(hr);
+	IMMDeviceEnumerator *enumerator = NULL;
+	IMMDevice *dev = NULL;
+	LPWSTR id = NULL;
+	char *out = NULL;
+
+	if (FAILED(CoCreateInstance(&CLSID_MMDeviceEnumerator, NULL, CLSCTX_ALL,
+			&IID_IMMDeviceEnumerator, (void **)&enumerator))) {
+		goto done;
+	}
+	if (FAILED(IMMDeviceEnumerator_GetDefaultAudioEndpoint(enumerator, eRender, eMultimedia, &dev))) {
+		goto done;
+	}
+	if (FAILED(IMMDevice_GetId(dev, &id))) {
+		goto done;
+	}
+	int n = WideCharToMultiByte(CP_UTF8, 0, id, -1, NULL, 0, NULL, NULL);
+	if (n > 0 && (out = malloc(n)) != NULL) {
+		WideCharToMultiByte(CP_UTF8, 0, id, -1, out, n, NULL, NULL);
+	}
+
+done:
+	if (id) CoTaskMemFree(id);
+	if (dev) IMMDevice_Release(dev);
+	if (enumerator) IMMDeviceEnumerator_Release(enumerator);
+	if (uninit) CoUninitialize();
+	return out;
+}
+*/
+import "C"
+
+import (
+	"context"
+	"runtime"
+	"time"
+	"unsafe"
+)
+
+// defaultAudioDevice returns the endpoint ID of the default WASAPI output
+// device, which mpv uses as the device name, or "" if it can't be determined.
+func defaultAudioDevice() string {
+	// COM is initialized per OS thread
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	id := C.default_render_endpoint_id()
+	if id == nil {
+		return ""
+	}
+	defer C.free(unsafe.Pointer(id))
+	return C.GoString(id)
+}
+
+// watchDefaultAudioDevice calls onChange whenever the default
+// output device changes, until ctx is done.
+func watchDefaultAudioDevice(ctx context.Context, onChange func()) {
+	pollDefaultAudioDevice(ctx, 2*time.Second, onChange)
+}
@@ -0,0 +1,193 @@
+package backend
+
+import (
+	"context"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/player/mpv"
+)
+
+// AudioDeviceProfile holds the equalizer, volume and ReplayGain preamp
+// settings that are remembered separately for each audio output device.
+type AudioDeviceProfile struct {
+	Device      string // mpv audio device name
+	Description string
+
+	Volume                int
+	ReplayGainPreampDB    float64
+	EqualizerEnabled      bool
+	EqualizerType         string
+	EqualizerPreamp       float64
+	GraphicEqualizerBands []float64
+	ActiveEQPresetName    string
+	AutoEQProfilePath     string
+	AutoEQProfileName     string
+	ParametricEQPreamp    float64
+	ParametricEQFilters   []mpv.ParametricFilter
+	ParametricEQName      string
+}
+
+// AudioDeviceProfileName returns the description of the audio device
+// the current equalizer, volume and ReplayGain preamp settings belong to.
+func (c *Config) AudioDeviceProfileName() string {
+	lp := &c.LocalPlayback
+	if i := lp.audioDeviceProfileIdx(lp.ActiveAudioDeviceProfile); i >= 0 {
+		return lp.AudioDeviceProfiles[i].Description
+	}
+	return lp.ActiveAudioDeviceProfile
+}
+
+func (c *LocalPlaybackConfig) audioDeviceProfileIdx(device string) int {
+	return slices.IndexFunc(c.AudioDeviceProfiles, func(p AudioDeviceProfile) bool {
+		return p.Device == device
+	})
+}
+
+// switchAudioDeviceProfile saves the current settings to the profile of
+// the previously active device and loads those of dev, if it has a profile.
+// Returns true if the active device changed.
+func (c *Config) switchAudioDeviceProfile(dev mpv.AudioDevice, volume int) bool {
+	lp := &c.LocalPlayback
+	if lp.ActiveAudioDeviceProfile == dev.Name {
+		return false
+	}
+	if lp.ActiveAudioDeviceProfile != "" {
+		c.saveAudioDeviceProfile(volume)
+	}
+	lp.ActiveAudioDeviceProfile = dev.Name
+	if i := lp.audioDeviceProfileIdx(dev.Name); i >= 0 {
+		c.loadAudioDeviceProfile(&lp.AudioDeviceProfiles[i])
+	} else {
+		// a new device starts out with the current settings
+		lp.Volume = volume
+		c.saveAudioDeviceProfile(volume)
+	}
+	lp.AudioDeviceProfiles[lp.audioDeviceProfileIdx(dev.Name)].Description = dev.Description
+	return true
+}
+
+func (c *Config) saveAudioDeviceProfile(volume int) {
+	lp := &c.LocalPlayback
+	p := AudioDeviceProfile{
+		Device:                lp.ActiveAudioDeviceProfile,
+		Volume:                volume,
+		ReplayGainPreampDB:    c.ReplayGain.PreampGainDB,
+		EqualizerEnabled:      lp.EqualizerEnabled,
+		EqualizerType:         lp.EqualizerType,
+		EqualizerPreamp:       lp.EqualizerPreamp,
+		GraphicEqualizerBands: slices.Clone(lp.GraphicEqualizerBands),
+		ActiveEQPresetName:    lp.ActiveEQPresetName,
+		AutoEQProfilePath:     lp.AutoEQProfilePath,
+		AutoEQProfileName:     lp.AutoEQProfileName,
+		ParametricEQPreamp:    lp.ParametricEQPreamp,
+		ParametricEQFilters:   slices.Clone(lp.ParametricEQFilters),
+		ParametricEQName:      lp.ParametricEQName,
+	}
+	if i := lp.audioDeviceProfileIdx(p.Device); i >= 0 {
+		p.Description = lp.AudioDeviceProfiles[i].Description
+		lp.AudioDeviceProfiles[i] = p
+	} else {
+		lp.AudioDeviceProfiles = append(lp.AudioDeviceProfiles, p)
+	}
+}
+
+func (c *Config) loadAudioDeviceProfile(p *AudioDeviceProfile) {
+	lp := &c.LocalPlayback
+	lp.Volume = p.Volume
+	c.ReplayGain.PreampGainDB = p.ReplayGainPreampDB
+	lp.EqualizerEnabled = p.EqualizerEnabled
+	lp.EqualizerType = p.EqualizerType
+	lp.EqualizerPreamp = p.EqualizerPreamp
+	lp.GraphicEqualizerBands = slices.Clone(p.GraphicEqualizerBands)
+	lp.ActiveEQPresetName = p.ActiveEQPresetName
+	lp.AutoEQProfilePath = p.AutoEQProfilePath
+	lp.AutoEQProfileName = p.AutoEQProfileName
+	lp.ParametricEQPreamp = p.ParametricEQPreamp
+	lp.ParametricEQFilters = slices.Clone(p.ParametricEQFilters)
+	lp.ParametricEQName = p.ParametricEQName
+}
+
+// SetAudioDevice sets the output device of the local player
+// and switches to the device's EQ and volume settings.
+func (a *App) SetAudioDevice(deviceName string) error {
+	if err := a.LocalPlayer.SetAudioDevice(deviceName); err != nil {
+		return err
+	}
+	a.applyAudioDeviceProfile(a.currentAudioDevice())
+	return nil
+}
+
+// updateAudioDeviceProfile is called from background goroutines when the
+// audio devices or the OS default device changed. It applies the profile of
+// the current output device on the main thread, which owns the config.
+func (a *App) updateAudioDeviceProfile() {
+	dev := a.currentAudioDevice()
+	a.runOnMainThread(func() { a.applyAudioDeviceProfile(dev) })
+}
+
+// applyAudioDeviceProfile applies the profile of dev if it is not the active one.
+func (a *App) applyAudioDeviceProfile(dev mpv.AudioDevice) {
+	if !a.Config.switchAudioDeviceProfile(dev, a.LocalPlayer.GetVolume()) {
+		return
+	}
+	log.Printf("Switched to audio device profile %q", a.Config.LocalPlayback.ActiveAudioDeviceProfile)
+	lp := &a.Config.LocalPlayback
+	lp.Volume = clamp(lp.Volume, 0, 100)
+	if a.PlaybackManager.CurrentPlayer() == a.LocalPlayer {
+		// updates the volume shown in the UI
+		a.PlaybackManager.SetVolume(lp.Volume)
+	} else {
+		a.LocalPlayer.SetVolume(lp.Volume)
+	}
+	a.LocalPlayer.SetEqualizer(NewEqualizerFromConfig(lp))
+	a.PlaybackManager.SetReplayGainOptions(a.Config.ReplayGain)
+}
+
+// currentAudioDevice returns the device the local player outputs to.
+// With the "auto" device, it is resolved to the OS default device
+// where supported, otherwise "auto" has a profile of its own.
+func (a *App) currentAudioDevice() mpv.AudioDevice {
+	name := a.LocalPlayer.GetAudioDevice()
+	devs, err := a.LocalPlayer.ListAudioDevices()
+	if err != nil {
+		log.Printf("error listing audio devices: %v", err)
+	}
+	if name == "auto" {
+		if def := defaultAudioDevice(); def != "" {
+			for _, dev := range devs {
+				// mpv device names are prefixed with the audio output driver, e.g. "pulse/"
+				if _, devName, _ := strings.Cut(dev.Name, "/"); devName == def {
+					return dev
+				}
+			}
+		}
+	}
+	for _, dev := range devs {
+		if dev.Name == name {
+			return dev
+		}
+	}
+	return mpv.AudioDevice{Name: name, Description: name}
+}
+
+// pollDefaultAudioDevice calls onChange whenever defaultAudioDevice
+// returns a different device, checking every interval until ctx is done.
+func pollDefaultAudioDevice(ctx context.Context, interval time.Duration, onChange func()) {
+	last := defaultAudioDevice()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if def := defaultAudioDevice(); def != last {
+			last = def
+			onChange()
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/dweymouth/supersonic/backend/player/mpv"
+)
+
+func TestSwitchAudioDeviceProfile(t *testing.T) {
+	c := DefaultConfig("")
+	lp := &c.LocalPlayback
+	headphones := mpv.AudioDevice{Name: "pulse/headphones", Description: "Headphones"}
+	speakers := mpv.AudioDevice{Name: "pulse/speakers", Description: "Speakers"}
+
+	lp.EqualizerEnabled = true
+	lp.GraphicEqualizerBands = []float64{1, 2, 3}
+	lp.AutoEQProfileName = "Sennheiser HD 650"
+	if !c.switchAudioDeviceProfile(headphones, 40) {
+		t.Fatal("expected profile switch on first run")
+	}
+
+	// a new device starts out with the current settings
+	c.switchAudioDeviceProfile(speakers, 40)
+	if !lp.EqualizerEnabled || lp.Volume != 40 || c.AudioDeviceProfileName() != "Speakers" {
+		t.Fatalf("unexpected settings for new device: %+v", lp)
+	}
+	lp.EqualizerEnabled = false
+	lp.GraphicEqualizerBands[0] = -5
+	lp.AutoEQProfileName = ""
+	c.ReplayGain.PreampGainDB = 3
+
+	c.switchAudioDeviceProfile(headphones, 80)
+	if !lp.EqualizerEnabled || lp.Volume != 40 || lp.GraphicEqualizerBands[0] != 1 ||
+		lp.AutoEQProfileName != "Sennheiser HD 650" || c.ReplayGain.PreampGainDB != 0 {
+		t.Errorf("headphone settings not restored: %+v", lp)
+	}
+
+	c.switchAudioDeviceProfile(speakers, 40)
+	if lp.EqualizerEnabled || lp.Volume != 80 || lp.GraphicEqualizerBands[0] != -5 || c.ReplayGain.PreampGainDB != 3 {
+		t.Errorf("speaker settings not restored: %+v", lp)
+	}
+	if c.switchAudioDeviceProfile(speakers, 40) {
+		t.Error("expected no switch to the active device")
+	}
+}
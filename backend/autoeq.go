@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/20after4/configdir"
+	"github.com/dweymouth/supersonic/backend/player/mpv"
 )
 
 const (
@@ -29,6 +30,9 @@ const (
 	indexCacheTTL      = 7 * 24 * time.Hour  // 7 days
 	profileCacheTTL    = 30 * 24 * time.Hour // 30 days
 	maxMemoryCacheSize = 20                  // LRU cache size
+
+	fixedBandEQSuffix  = " FixedBandEQ.txt"
+	parametricEQSuffix = " ParametricEQ.txt"
 )
 
 var (
@@ -44,6 +48,10 @@ type AutoEQProfile struct {
 	Type   string      // Headphone type (e.g., "over-ear")
 	Preamp float64     // Preamp gain in dB
 	Bands  [10]float64 // 10-band equalizer gains in dB
+
+	// Filters of the parametric equalizer, set only for
+	// profiles fetched with FetchParametricProfile
+	Filters []mpv.ParametricFilter
 }
 
 // AutoEQProfileMetadata contains just the metadata without the EQ data
@@ -256,33 +264,49 @@ func (m *AutoEQManager) parseIndex(r io.Reader) ([]AutoEQProfileMetadata, error)
 // FetchProfile fetches a specific AutoEQ profile by its path
 // Results are cached with LRU eviction
 func (m *AutoEQManager) FetchProfile(ctx context.Context, path string) (*AutoEQProfile, error) {
+	return m.fetchProfile(ctx, path, false)
+}
+
+// FetchParametricProfile fetches the parametric equalizer
+// (ParametricEQ.txt) variant of an AutoEQ profile by its path.
+// Results are cached like those of FetchProfile
+func (m *AutoEQManager) FetchParametricProfile(ctx context.Context, path string) (*AutoEQProfile, error) {
+	return m.fetchProfile(ctx, path, true)
+}
+
+func (m *AutoEQManager) fetchProfile(ctx context.Context, path string, parametric bool) (*AutoEQProfile, error) {
+	key := path
+	if parametric {
+		key = "parametric:" + path
+	}
+
 	// Check memory cache
 	m.memCacheMutex.RLock()
-	if entry, ok := m.memCache[path]; ok {
+	if entry, ok := m.memCache[key]; ok {
 		entry.lastAccessed = time.Now()
 		profile := entry.profile
 		m.memCacheMutex.RUnlock()
-		m.updateLRU(path)
+		m.updateLRU(key)
 		return profile, nil
 	}
 	m.memCacheMutex.RUnlock()
 
 	// Check disk cache
-	profile, err := m.loadProfileFromDisk(path)
+	profile, err := m.loadProfileFromDisk(key)
 	if err == nil {
-		m.addToMemoryCache(path, profile)
+		m.addToMemoryCache(key, profile)
 		return profile, nil
 	}
 
 	// Fetch from network
-	profile, err = m.fetchProfileFromNetwork(ctx, path)
+	profile, err = m.fetchProfileFromNetwork(ctx, path, parametric)
 	if err != nil {
 		return nil, err
 	}
 
 	// Cache in memory and disk
-	m.addToMemoryCache(path, profile)
-	m.saveProfileToDisk(path, profile)
+	m.addToMemoryCache(key, profile)
+	m.saveProfileToDisk(key, profile)
 
 	return profile, nil
 }
@@ -335,7 +359,7 @@ func (m *AutoEQManager) profileCacheKey(path string) string {
 	return hex.EncodeToString(hash[:])
 }
 
-func (m *AutoEQManager) fetchProfileFromNetwork(ctx context.Context, path string) (*AutoEQProfile, error) {
+func (m *AutoEQManager) fetchProfileFromNetwork(ctx context.Context, path string, parametric bool) (*AutoEQProfile, error) {
 	// URL-decode the path first (INDEX.md contains HTML-encoded paths like %20 for spaces)
 	decodedPath, err := url.QueryUnescape(path)
 	if err != nil {
@@ -357,8 +381,12 @@ func (m *AutoEQManager) fetchProfileFromNetwork(ctx context.Context, path string
 	}
 	encodedPath := strings.Join(pathComponents, "/")
 
-	// The file is named "{HeadphoneName} FixedBandEQ.txt"
-	encodedFileName := url.PathEscape(headphoneName + " FixedBandEQ.txt")
+	// The file is named "{HeadphoneName} FixedBandEQ.txt" or "{HeadphoneName} ParametricEQ.txt"
+	suffix := fixedBandEQSuffix
+	if parametric {
+		suffix = parametricEQSuffix
+	}
+	encodedFileName := url.PathEscape(headphoneName + suffix)
 	profileURL := autoEQBaseURL + encodedPath + "/" + encodedFileName
 
 	ctx, cancel := context.WithTimeout(ctx, m.timeout)
@@ -383,6 +411,9 @@ func (m *AutoEQManager) fetchProfileFromNetwork(ctx context.Context, path string
 		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
 	}
 
+	if parametric {
+		return m.parseParametricProfile(path, resp.Body)
+	}
 	return m.parseProfile(path, resp.Body)
 }
 
@@ -446,7 +477,69 @@ func (m *AutoEQManager) parseProfile(path string, r io.Reader) (*AutoEQProfile,
 		bands[i] = gain
 	}
 
-	// Extract name and metadata from path
+	profile := newAutoEQProfile(path)
+	profile.Preamp = preamp
+	profile.Bands = bands
+	return profile, nil
+}
+
+// parseParametricProfile parses the ParametricEQ.txt file
+// Format:
+// Preamp: -6.4 dB
+// Filter 1: ON LSC Fc 105 Hz Gain 6.2 dB Q 0.70
+// Filter 2: ON PK Fc 160 Hz Gain -2.5 dB Q 0.41
+// ... (any number of peaking (PK), low shelf (LSC) and high shelf (HSC) filters)
+var parametricFilterRegex = regexp.MustCompile(`Filter\s+\d+:\s*(ON|OFF)\s+([A-Z]+)\s+Fc\s+(\d+\.?\d*)\s*Hz\s+Gain\s+([-+]?\d+\.?\d*)\s*dB\s+Q\s+(\d+\.?\d*)`)
+
+func (m *AutoEQManager) parseParametricProfile(path string, r io.Reader) (*AutoEQProfile, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading profile: %w", err)
+	}
+
+	content := string(data)
+
+	preampMatch := preampRegex.FindStringSubmatch(content)
+	if len(preampMatch) < 2 {
+		return nil, fmt.Errorf("%w: preamp not found", ErrInvalidFormat)
+	}
+	preamp, err := strconv.ParseFloat(preampMatch[1], 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid preamp value", ErrInvalidFormat)
+	}
+
+	var filters []mpv.ParametricFilter
+	for i, match := range parametricFilterRegex.FindAllStringSubmatch(content, -1) {
+		if match[1] != "ON" {
+			continue
+		}
+		typ, err := mpv.ParseFilterType(match[2])
+		if err != nil {
+			return nil, fmt.Errorf("%w: filter %d: %v", ErrInvalidFormat, i+1, err)
+		}
+		// the regex only matches valid numbers
+		freq, _ := strconv.ParseFloat(match[3], 64)
+		gain, _ := strconv.ParseFloat(match[4], 64)
+		q, _ := strconv.ParseFloat(match[5], 64)
+		filters = append(filters, mpv.ParametricFilter{
+			Type:      typ,
+			Frequency: freq,
+			Gain:      gain,
+			Q:         q,
+		})
+	}
+	if len(filters) == 0 {
+		return nil, fmt.Errorf("%w: no filters found", ErrInvalidFormat)
+	}
+
+	profile := newAutoEQProfile(path)
+	profile.Preamp = preamp
+	profile.Filters = filters
+	return profile, nil
+}
+
+// newAutoEQProfile returns a profile with the name and metadata extracted from its path
+func newAutoEQProfile(path string) *AutoEQProfile {
 	// URL-decode the path first to get clean names
 	decodedPath, err := url.QueryUnescape(path)
 	if err != nil {
@@ -454,24 +547,16 @@ func (m *AutoEQManager) parseProfile(path string, r io.Reader) (*AutoEQProfile,
 	}
 
 	parts := strings.Split(decodedPath, "/")
-	name := decodedPath
-	source := ""
-	typ := ""
-
+	profile := &AutoEQProfile{
+		Name: decodedPath,
+		Path: path,
+	}
 	if len(parts) >= 3 {
-		name = parts[len(parts)-1]
-		source = parts[0]
-		typ = parts[1]
-	}
-
-	return &AutoEQProfile{
-		Name:   name,
-		Path:   path,
-		Source: source,
-		Type:   typ,
-		Preamp: preamp,
-		Bands:  bands,
-	}, nil
+		profile.Name = parts[len(parts)-1]
+		profile.Source = parts[0]
+		profile.Type = parts[1]
+	}
+	return profile
 }
 
 func (m *AutoEQManager) addToMemoryCache(path string, profile *AutoEQProfile) {
@@ -0,0 +1,74 @@
+package backend
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/dweymouth/supersonic/backend/player/mpv"
+)
+
+const testParametricEQ = `Preamp: -6.4 dB
+Filter 1: ON LSC Fc 105 Hz Gain 6.2 dB Q 0.70
+Filter 2: ON PK Fc 160.5 Hz Gain -2.5 dB Q 0.41
+Filter 3: OFF PK Fc 2000 Hz Gain 1.0 dB Q 1.00
+Filter 4: ON HSC Fc 10000 Hz Gain -1.1 dB Q 0.70
+`
+
+func TestParseParametricProfile(t *testing.T) {
+	m := &AutoEQManager{}
+	profile, err := m.parseParametricProfile("oratory1990/over-ear/Sennheiser%20HD%20650", strings.NewReader(testParametricEQ))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Name != "Sennheiser HD 650" || profile.Source != "oratory1990" || profile.Type != "over-ear" {
+		t.Errorf("unexpected profile metadata: %+v", profile)
+	}
+	if profile.Preamp != -6.4 {
+		t.Errorf("expected preamp -6.4, got %v", profile.Preamp)
+	}
+	want := []mpv.ParametricFilter{
+		{Type: mpv.FilterTypeLowShelf, Frequency: 105, Gain: 6.2, Q: 0.7},
+		{Type: mpv.FilterTypePeaking, Frequency: 160.5, Gain: -2.5, Q: 0.41},
+		{Type: mpv.FilterTypeHighShelf, Frequency: 10000, Gain: -1.1, Q: 0.7},
+	}
+	if len(profile.Filters) != len(want) {
+		t.Fatalf("expected %d filters, got %d", len(want), len(profile.Filters))
+	}
+	for i, f := range profile.Filters {
+		if f != want[i] {
+			t.Errorf("filter %d: expected %+v, got %+v", i+1, want[i], f)
+		}
+	}
+
+	eq := &mpv.ParametricEqualizer{Filters: profile.Filters}
+	wantCurve := "lowshelf=f=105:g=6.20:t=q:w=0.70,equalizer=f=161:g=-2.50:t=q:w=0.41,highshelf=f=10000:g=-1.10:t=q:w=0.70"
+	if s := eq.Curve().String(); s != wantCurve {
+		t.Errorf("expected curve %q, got %q", wantCurve, s)
+	}
+}
+
+func TestParseParametricProfile_NoFilters(t *testing.T) {
+	m := &AutoEQManager{}
+	if _, err := m.parseParametricProfile("a/b/c", strings.NewReader("Preamp: 0 dB\n")); err == nil {
+		t.Error("expected error for profile without filters")
+	}
+}
+
+func TestParametricFilterResponse(t *testing.T) {
+	peak := mpv.ParametricFilter{Type: mpv.FilterTypePeaking, Frequency: 1000, Gain: 6, Q: 1}
+	if g := peak.Response(1000); math.Abs(g-6) > 0.01 {
+		t.Errorf("expected 6 dB at center frequency, got %v", g)
+	}
+	if g := peak.Response(20); math.Abs(g) > 0.1 {
+		t.Errorf("expected ~0 dB far from center frequency, got %v", g)
+	}
+
+	shelf := mpv.ParametricFilter{Type: mpv.FilterTypeLowShelf, Frequency: 100, Gain: -4, Q: 0.7}
+	if g := shelf.Response(20); math.Abs(g+4) > 0.2 {
+		t.Errorf("expected ~-4 dB below low shelf frequency, got %v", g)
+	}
+	if g := shelf.Response(5000); math.Abs(g) > 0.1 {
+		t.Errorf("expected ~0 dB above low shelf frequency, got %v", g)
+	}
+}
@@ -1,6 +1,7 @@
 package backend
 
 import (
+	"errors"
 	"flag"
 	"os"
 	"strconv"
@@ -23,18 +24,35 @@ var (
 	SearchPlaylistCLIArg string  = ""
 	SearchTrackCLIArg    string  = ""
 
+	EnqueueTrackCLIArg    string = ""
+	EnqueueAlbumCLIArg    string = ""
+	EnqueuePlaylistCLIArg string = ""
+	RemoveFromQueueCLIArg []int
+	MoveInQueueCLIArg     []int
+	MoveToCLIArg          int    = -1
+	LoopModeCLIArg        string = ""
+
 	FlagPlay              = flag.Bool("play", false, "unpause or begin playback")
 	FlagPause             = flag.Bool("pause", false, "pause playback")
 	FlagPlayPause         = flag.Bool("play-pause", false, "toggle play/pause state")
 	FlagPrevious          = flag.Bool("previous", false, "seek to previous track or beginning of current")
 	FlagNext              = flag.Bool("next", false, "seek to next track")
+	FlagPreviousChapter   = flag.Bool("previous-chapter", false, "seek to previous chapter or beginning of current in files with chapters")
+	FlagNextChapter       = flag.Bool("next-chapter", false, "seek to next chapter in files with chapters")
 	FlagStop              = flag.Bool("stop", false, "stop playback")
 	FlagPauseAfterCurrent = flag.Bool("pause-after-current", false, "pause playback after current track")
 	FlagStartMinimized    = flag.Bool("start-minimized", false, "start app minimized")
+	FlagHeadless          = flag.Bool("headless", false, "run without a window, controlled only through IPC, the command line options and MPRIS")
+	FlagTUI               = flag.Bool("tui", false, "run the terminal user interface instead of opening a window")
 	FlagShow              = flag.Bool("show", false, "show minimized app")
 	FlagReloadTheme       = flag.Bool("reload-theme", false, "reload the current theme")
 	FlagShuffle           = flag.Bool("shuffle", false, "shuffle the tracklist (to be used with either -play-album-by-id or -play-playlist-by-id)")
 	FlagCurrentTrack      = flag.Bool("current-track", false, "print current track metadata as JSON")
+	FlagQueue             = flag.Bool("queue", false, "print the play queue and now playing index as JSON")
+	FlagEnqueueNext       = flag.Bool("enqueue-next", false, "insert after the current track instead of at the end of the queue (to be used with the -enqueue-* options)")
+	FlagClearQueue        = flag.Bool("clear-queue", false, "stop playback and clear the play queue")
+	FlagToggleShuffle     = flag.Bool("toggle-shuffle", false, "toggle shuffle mode")
+	FlagWatch             = flag.Bool("watch", false, "print playback events as JSON lines as they happen, until interrupted")
 	FlagVersion           = flag.Bool("version", false, "print app version and exit")
 	FlagHelp              = flag.Bool("help", false, "print command line options and exit")
 
@@ -105,6 +123,40 @@ func init() {
 		SearchTrackCLIArg = s
 		return nil
 	})
+	flag.Func("enqueue-track", "add the track with the given ID to the play queue", func(s string) error {
+		EnqueueTrackCLIArg = s
+		return nil
+	})
+	flag.Func("enqueue-album", "add the album with the given ID to the play queue", func(s string) error {
+		EnqueueAlbumCLIArg = s
+		return nil
+	})
+	flag.Func("enqueue-playlist", "add the playlist with the given ID to the play queue", func(s string) error {
+		EnqueuePlaylistCLIArg = s
+		return nil
+	})
+	flag.Func("remove-from-queue", "remove the items at the given comma-separated queue indexes (starting at 0)", func(s string) error {
+		v, err := parseIntList(s)
+		RemoveFromQueueCLIArg = v
+		return err
+	})
+	flag.Func("move-in-queue", "move the items at the given comma-separated queue indexes (to be used with -move-to)", func(s string) error {
+		v, err := parseIntList(s)
+		MoveInQueueCLIArg = v
+		return err
+	})
+	flag.Func("move-to", "queue index to move items in front of (use the queue length to move to the end)", func(s string) error {
+		v, err := strconv.Atoi(s)
+		MoveToCLIArg = v
+		return err
+	})
+	flag.Func("loop-mode", "set the loop mode (none, all, one)", func(s string) error {
+		if s != "none" && s != "all" && s != "one" {
+			return errors.New("must be one of none, all, one")
+		}
+		LoopModeCLIArg = s
+		return nil
+	})
 	flag.Func("rate-current", "rate the current track with the given rating (0-5)", func(s string) error {
 		v, err := strconv.Atoi(s)
 		if err == nil {
@@ -114,11 +166,34 @@ func init() {
 	})
 }
 
+func parseIntList(s string) ([]int, error) {
+	var ints []int
+	for _, str := range strings.Split(s, ",") {
+		i, err := strconv.Atoi(strings.TrimSpace(str))
+		if err != nil {
+			return nil, err
+		}
+		ints = append(ints, i)
+	}
+	return ints, nil
+}
+
+// DeepLinkCLIArg returns the supersonic:// link passed as
+// an argument when the app is opened to handle a link.
+func DeepLinkCLIArg() string {
+	for _, arg := range flag.Args() {
+		if strings.HasPrefix(strings.ToLower(arg), DeepLinkScheme+"://") {
+			return arg
+		}
+	}
+	return ""
+}
+
 func HaveCommandLineOptions() bool {
 	visitedAny := false
 	flag.Visit(func(f *flag.Flag) {
-		// We skip `start-minimized` because it should't send an IPC message.
-		if f.Name != "start-minimized" {
+		// We skip `start-minimized`, `headless` and `tui` because they shouldn't send an IPC message.
+		if f.Name != "start-minimized" && f.Name != "headless" && f.Name != "tui" {
 			visitedAny = true
 		}
 	})
@@ -4,6 +4,8 @@ import (
 	"os"
 	"sync"
 
+	"github.com/dweymouth/supersonic/backend/ipc"
+	"github.com/dweymouth/supersonic/backend/player/mpv"
 	"github.com/google/uuid"
 	"github.com/pelletier/go-toml/v2"
 )
@@ -30,6 +32,19 @@ type ServerConfig struct {
 	Nickname        string
 	Default         bool
 	SelectedLibrary string
+	DiscordPresence DiscordPresenceServerConfig
+	// Albums flagged as continuous (gapless), whose
+	// tracks never have their silence skipped
+	ContinuousAlbumIDs []string
+}
+
+// DiscordPresenceServerConfig holds per-server privacy
+// settings for the Discord Rich Presence integration.
+type DiscordPresenceServerConfig struct {
+	Disabled        bool     // don't show presence while connected to this server
+	HideAlbum       bool     // don't show the album name
+	ShowCoverArt    bool     // show cover art, if the server can share it without credentials
+	HiddenLibraries []string // don't show presence while one of these libraries is selected
 }
 
 type AppConfig struct {
@@ -45,6 +60,7 @@ type AppConfig struct {
 	MaxImageCacheSizeMB         int
 	SavePlayQueue               bool
 	SaveQueueToServer           bool
+	EnableQueueHandoff          bool
 	DefaultPlaylistID           string
 	AddToPlaylistSkipDuplicates bool
 	ShowTrackChangeNotification bool
@@ -64,6 +80,10 @@ type AppConfig struct {
 
 	PreventScreensaverOnNowPlayingPage bool
 
+	// Nickname or ID of the server to connect to in headless and terminal UI modes.
+	// If empty, the default server is used.
+	HeadlessServer string
+
 	FontNormalTTF string
 	FontBoldTTF   string
 	UIScaleSize   string
@@ -119,6 +139,7 @@ type TracksPageConfig struct {
 type NowPlayingPageConfig struct {
 	InitialView        string
 	UseBackgroundImage bool
+	Visualization      string
 }
 
 type PlaybackConfig struct {
@@ -128,6 +149,14 @@ type PlaybackConfig struct {
 	SkipOneStarWhenShuffling bool
 	SkipKeywordWhenShuffling string
 	UseWaveformSeekbar       bool
+	WaveformMode             string // "Mono", "Stereo" or "Frequency"
+	MaxWaveformCacheSizeMB   int
+
+	// Skip silence at the start and end of tracks,
+	// detected from the analyzed waveform
+	SkipSilence            bool
+	SkipSilenceThresholdDB float64 // level below which audio is considered silent
+	SkipSilenceMinSeconds  float64 // shortest lead-in or lead-out that is skipped
 }
 
 type LocalPlaybackConfig struct {
@@ -136,13 +165,22 @@ type LocalPlaybackConfig struct {
 	InMemoryCacheSizeMB   int
 	Volume                int
 	EqualizerEnabled      bool
-	EqualizerType         string    // "ISO10Band" or "ISO15Band"
+	EqualizerType         string // "ISO10Band", "ISO15Band" or "Parametric"
 	EqualizerPreamp       float64
 	GraphicEqualizerBands []float64
 	ActiveEQPresetName    string // Name of currently selected EQ preset
 	AutoEQProfilePath     string // Path to applied AutoEQ profile (e.g., "oratory1990/over-ear/Sennheiser HD 650")
 	AutoEQProfileName     string // Display name of applied profile (e.g., "Sennheiser HD 650")
+	ParametricEQPreamp    float64
+	ParametricEQFilters   []mpv.ParametricFilter
+	ParametricEQName      string // Name of the loaded parametric preset or AutoEQ profile
 	PauseFade             bool
+	// Applied in order after the equalizer
+	DSPChain []mpv.DSPStage
+	// Volume, ReplayGain preamp and EQ settings of each output device
+	AudioDeviceProfiles []AudioDeviceProfile
+	// Device of the profile the current settings belong to
+	ActiveAudioDeviceProfile string
 }
 
 type ScrobbleConfig struct {
@@ -155,6 +193,9 @@ type ReplayGainConfig struct {
 	Mode            string
 	PreampGainDB    float64
 	PreventClipping bool
+	AnalyzeLoudness bool    // measure the loudness of tracks without ReplayGain tags
+	TargetLUFS      float64 // loudness that analyzed tracks are normalized to
+	NormalizeRadio  bool    // apply a dynamic normalizer to radio streams
 }
 
 type ThemeConfig struct {
@@ -170,9 +211,57 @@ type TranscodingConfig struct {
 	MaxBitRateKBPS   int
 }
 
+type RemoteControlConfig struct {
+	Enabled bool
+	Port    int
+	UseTLS  bool
+	Devices []ipc.PairedDevice
+}
+
+type MPDServerConfig struct {
+	Enabled       bool
+	ListenAddress string
+	Password      string // required from clients if set; moved into the keyring on startup, if one is available
+}
+
+type MQTTConfig struct {
+	Enabled                bool
+	BrokerURL              string // e.g. tcp://host:1883, ssl://host:8883, ws://host:9001
+	Username               string
+	Password               string // moved into the keyring on startup, if one is available
+	ClientID               string // defaults to <app name>-<hostname> if empty
+	TopicPrefix            string
+	HomeAssistantDiscovery bool
+	DiscoveryPrefix        string
+}
+
+type DiscordRPCConfig struct {
+	Enabled       bool
+	ApplicationID string // ID of the Discord application shown as the activity name, if not Supersonic's
+}
+
+// HooksConfig configures user commands run on playback and server events,
+// e.g. in the config file:
+//
+//	[[Hooks.Commands]]
+//	Event = "track_change"
+//	Command = 'notify-send "$SUPERSONIC_TRACK_TITLE"'
+type HooksConfig struct {
+	Enabled        bool
+	TimeoutSeconds int // commands still running after this long are killed
+	MaxConcurrent  int // hooks triggered while this many are running are skipped
+	Commands       []HookConfig
+}
+
+type HookConfig struct {
+	Event   string // one of the HookEvent* constants
+	Command string // run with sh -c (cmd /C on Windows)
+}
+
 type PeakMeterConfig struct {
-	WindowHeight int
-	WindowWidth  int
+	WindowHeight  int
+	WindowWidth   int
+	Visualization string // "Peak Meter", "Loudness" or "Spectrum"
 }
 
 type Config struct {
@@ -195,6 +284,11 @@ type Config struct {
 	Transcoding      TranscodingConfig
 	Theme            ThemeConfig
 	PeakMeter        PeakMeterConfig
+	RemoteControl    RemoteControlConfig
+	MPDServer        MPDServerConfig
+	MQTT             MQTTConfig
+	DiscordRPC       DiscordRPCConfig
+	Hooks            HooksConfig
 }
 
 var SupportedStartupPages = []string{"Albums", "Favorites", "Playlists", "Artists", "All Tracks"}
@@ -215,6 +309,7 @@ func DefaultConfig(appVersionTag string) *Config {
 			UIScaleSize:                        "Normal",
 			SavePlayQueue:                      true,
 			SaveQueueToServer:                  false,
+			EnableQueueHandoff:                 true,
 			ShowTrackChangeNotification:        false,
 			EnableLrcLib:                       true,
 			EnablePasswordStorage:              true,
@@ -263,10 +358,14 @@ func DefaultConfig(appVersionTag string) *Config {
 			TracklistColumns: []string{"Album", "Time", "Plays"},
 		},
 		Playback: PlaybackConfig{
-			Autoplay:           false,
-			Shuffle:            false,
-			RepeatMode:         "None",
-			UseWaveformSeekbar: false,
+			Autoplay:               false,
+			Shuffle:                false,
+			RepeatMode:             "None",
+			UseWaveformSeekbar:     false,
+			WaveformMode:           WaveformModeMono,
+			MaxWaveformCacheSizeMB: 20,
+			SkipSilenceThresholdDB: -40,
+			SkipSilenceMinSeconds:  3,
 		},
 		LocalPlayback: LocalPlaybackConfig{
 			// "auto" is the name to pass to MPV for autoselecting the output device
@@ -289,6 +388,9 @@ func DefaultConfig(appVersionTag string) *Config {
 			Mode:            ReplayGainNone,
 			PreampGainDB:    0.0,
 			PreventClipping: true,
+			AnalyzeLoudness: false,
+			TargetLUFS:      -18, // the ReplayGain 2.0 reference level
+			NormalizeRadio:  false,
 		},
 		Transcoding: TranscodingConfig{
 			ForceRawFile:     false,
@@ -301,8 +403,33 @@ func DefaultConfig(appVersionTag string) *Config {
 			UseRoundedImageCorners: true,
 		},
 		PeakMeter: PeakMeterConfig{
-			WindowWidth:  375,
-			WindowHeight: 100,
+			WindowWidth:   375,
+			WindowHeight:  140,
+			Visualization: "Peak Meter",
+		},
+		RemoteControl: RemoteControlConfig{
+			Enabled: false,
+			Port:    7380,
+			UseTLS:  true,
+		},
+		MPDServer: MPDServerConfig{
+			Enabled:       false,
+			ListenAddress: "localhost:6600",
+		},
+		MQTT: MQTTConfig{
+			Enabled:                false,
+			BrokerURL:              "tcp://localhost:1883",
+			TopicPrefix:            "supersonic",
+			HomeAssistantDiscovery: true,
+			DiscoveryPrefix:        "homeassistant",
+		},
+		DiscordRPC: DiscordRPCConfig{
+			Enabled: false,
+		},
+		Hooks: HooksConfig{
+			Enabled:        false,
+			TimeoutSeconds: 10,
+			MaxConcurrent:  4,
 		},
 	}
 }
@@ -0,0 +1,186 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+)
+
+// DeepLinkScheme is the URL scheme of links that open items in the app:
+//
+//	supersonic://<server>/<type>/<id>[?action=<action>]
+//
+// where server is the host (and port) of a configured server's URL, type is one
+// of the DeepLinkType* constants and action one of the DeepLinkAction* constants.
+// Links are matched to servers by host so that they work in other installs.
+const DeepLinkScheme = "supersonic"
+
+const (
+	DeepLinkTypeAlbum    = "album"
+	DeepLinkTypeArtist   = "artist"
+	DeepLinkTypePlaylist = "playlist"
+	DeepLinkTypeTrack    = "track"
+)
+
+const (
+	DeepLinkActionPlay     = "play"
+	DeepLinkActionEnqueue  = "enqueue"
+	DeepLinkActionPlayNext = "next"
+)
+
+type DeepLink struct {
+	Server string // server host[:port]
+	Type   string
+	ID     string
+	Action string // optional
+}
+
+// ParseDeepLink parses a supersonic:// link.
+func ParseDeepLink(link string) (*DeepLink, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(u.Scheme, DeepLinkScheme) {
+		return nil, fmt.Errorf("not a %s:// link", DeepLinkScheme)
+	}
+	itemType, id, ok := strings.Cut(strings.Trim(u.Path, "/"), "/")
+	if u.Host == "" || !ok || id == "" {
+		return nil, errors.New("invalid link: expected " + DeepLinkScheme + "://<server>/<type>/<id>")
+	}
+	l := &DeepLink{
+		Server: u.Host,
+		Type:   strings.ToLower(itemType),
+		ID:     id,
+		Action: strings.ToLower(u.Query().Get("action")),
+	}
+	switch l.Type {
+	case DeepLinkTypeAlbum, DeepLinkTypeArtist, DeepLinkTypePlaylist, DeepLinkTypeTrack:
+	default:
+		return nil, fmt.Errorf("invalid link item type %q", itemType)
+	}
+	switch l.Action {
+	case "", DeepLinkActionPlay, DeepLinkActionEnqueue, DeepLinkActionPlayNext:
+	default:
+		return nil, fmt.Errorf("invalid link action %q", l.Action)
+	}
+	return l, nil
+}
+
+func (l DeepLink) String() string {
+	u := url.URL{
+		Scheme: DeepLinkScheme,
+		Host:   l.Server,
+		Path:   "/" + l.Type + "/" + l.ID,
+	}
+	if l.Action != "" {
+		u.RawQuery = url.Values{"action": {l.Action}}.Encode()
+	}
+	return u.String()
+}
+
+// DeepLinkTo returns a link to the item on the currently connected server.
+func (a *App) DeepLinkTo(itemType, id string) string {
+	var server string
+	for _, s := range a.Config.Servers {
+		if s.ID == a.ServerManager.ServerID {
+			server = deepLinkHost(s.Hostname)
+			break
+		}
+	}
+	return DeepLink{Server: server, Type: itemType, ID: id}.String()
+}
+
+// FindDeepLinkServer returns the configured server the link refers to, or nil.
+// Servers are matched by the host of either of their URLs.
+func (a *App) FindDeepLinkServer(l *DeepLink) *ServerConfig {
+	for _, s := range a.Config.Servers {
+		for _, hostname := range []string{s.Hostname, s.AltHostname} {
+			if host := deepLinkHost(hostname); host != "" && strings.EqualFold(host, l.Server) {
+				return s
+			}
+		}
+	}
+	return nil
+}
+
+// deepLinkHost returns the host[:port] of a server URL, which may omit the scheme.
+func deepLinkHost(hostname string) string {
+	if !strings.Contains(hostname, "://") {
+		hostname = "//" + hostname
+	}
+	u, err := url.Parse(hostname)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// OpenDeepLink opens a supersonic:// link, e.g. forwarded over IPC by another
+// instance launched to open it. With the main UI, it navigates to the item;
+// in other modes only the link's action is run.
+func (a *App) OpenDeepLink(link string) error {
+	l, err := ParseDeepLink(link)
+	if err != nil {
+		return err
+	}
+	if a.OnOpenDeepLink != nil {
+		a.callOnReactivate()
+		a.OnOpenDeepLink(l)
+		return nil
+	}
+	if a.ServerManager.Server == nil {
+		a.PendingDeepLink = l
+		return nil
+	}
+	if s := a.FindDeepLinkServer(l); s == nil || s.ID != a.ServerManager.ServerID {
+		return errors.New("link is not for the connected server")
+	}
+	return a.RunDeepLinkAction(l)
+}
+
+// RunDeepLinkAction plays or enqueues the link's item, if it has an action.
+// It must be called while connected to the link's server.
+func (a *App) RunDeepLinkAction(l *DeepLink) error {
+	pm := a.PlaybackManager
+	switch l.Action {
+	case DeepLinkActionPlay:
+		switch l.Type {
+		case DeepLinkTypeAlbum:
+			return pm.PlayAlbum(l.ID, 0, false)
+		case DeepLinkTypeArtist:
+			pm.PlayArtistDiscography(l.ID, false)
+		case DeepLinkTypePlaylist:
+			return pm.PlayPlaylist(l.ID, 0, false)
+		case DeepLinkTypeTrack:
+			return pm.PlayTrack(l.ID)
+		}
+	case DeepLinkActionEnqueue, DeepLinkActionPlayNext:
+		if l.Type == DeepLinkTypeArtist {
+			return errors.New("artists can't be enqueued")
+		}
+		// the link item types match the IPC enqueue types
+		q := &ipcQueueHandler{pm: pm, sm: a.ServerManager}
+		return q.Enqueue(l.Type, l.ID, l.Action == DeepLinkActionPlayNext)
+	}
+	return nil
+}
+
+// openPendingDeepLink runs the action of the link the app was launched
+// with once connected, in modes other than the main UI.
+func (a *App) openPendingDeepLink() {
+	l := a.PendingDeepLink
+	if l == nil {
+		return
+	}
+	a.PendingDeepLink = nil
+	if s := a.FindDeepLinkServer(l); s == nil || s.ID != a.ServerManager.ServerID {
+		log.Printf("not opening link %s: not for the connected server", l)
+		return
+	}
+	if err := a.RunDeepLinkAction(l); err != nil {
+		log.Printf("error opening link %s: %v", l, err)
+	}
+}
@@ -0,0 +1,61 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestParseDeepLink(t *testing.T) {
+	l, err := ParseDeepLink("supersonic://music.example.com:4533/Album/al-1%2F2?action=next")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := DeepLink{Server: "music.example.com:4533", Type: DeepLinkTypeAlbum, ID: "al-1/2", Action: DeepLinkActionPlayNext}
+	if *l != want {
+		t.Errorf("got %+v, want %+v", *l, want)
+	}
+	if l2, err := ParseDeepLink(l.String()); err != nil || *l2 != want {
+		t.Errorf("round trip of %s: got %+v, %v", l, l2, err)
+	}
+
+	for _, invalid := range []string{
+		"https://example.com/album/1",
+		"supersonic://example.com/album",
+		"supersonic:///album/1",
+		"supersonic://example.com/genre/Jazz",
+		"supersonic://example.com/album/1?action=delete",
+	} {
+		if _, err := ParseDeepLink(invalid); err == nil {
+			t.Errorf("expected error parsing %s", invalid)
+		}
+	}
+}
+
+func TestFindDeepLinkServer(t *testing.T) {
+	home := &ServerConfig{ID: uuid.New()}
+	home.Hostname = "http://192.168.1.10:4533"
+	home.AltHostname = "https://music.example.com"
+	jf := &ServerConfig{ID: uuid.New()}
+	jf.Hostname = "jellyfin.example.com:8096"
+	a := &App{Config: &Config{Servers: []*ServerConfig{home, jf}}, ServerManager: &ServerManager{ServerID: home.ID}}
+
+	link, err := ParseDeepLink(a.DeepLinkTo(DeepLinkTypeTrack, "tr-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.Server != "192.168.1.10:4533" {
+		t.Errorf("expected link to identify the server by host, got %q", link.Server)
+	}
+	for server, want := range map[string]*ServerConfig{
+		"192.168.1.10:4533":         home,
+		"MUSIC.example.com":         home,
+		"jellyfin.example.com:8096": jf,
+		"jellyfin.example.com":      nil,
+		home.ID.String():            nil,
+	} {
+		if got := a.FindDeepLinkServer(&DeepLink{Server: server}); got != want {
+			t.Errorf("server %q: got %v, want %v", server, got, want)
+		}
+	}
+}
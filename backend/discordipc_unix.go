@@ -0,0 +1,38 @@
+//go:build !windows
+
+package backend
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// dialDiscordIPC connects to the Unix socket of a running Discord client.
+func dialDiscordIPC() (io.ReadWriteCloser, error) {
+	var dirs []string
+	for _, env := range []string{"XDG_RUNTIME_DIR", "TMPDIR", "TMP", "TEMP"} {
+		if d := os.Getenv(env); d != "" {
+			dirs = append(dirs, d)
+		}
+	}
+	dirs = append(dirs, "/tmp")
+
+	var lastErr error
+	for _, dir := range dirs {
+		// Flatpak and Snap builds of Discord put the socket in a subdirectory
+		for _, sub := range []string{"", "app/com.discordapp.Discord", "snap.discord"} {
+			for i := 0; i < 10; i++ {
+				path := filepath.Join(dir, sub, "discord-ipc-"+strconv.Itoa(i))
+				conn, err := net.Dial("unix", path)
+				if err == nil {
+					return conn, nil
+				}
+				lastErr = err
+			}
+		}
+	}
+	return nil, lastErr
+}
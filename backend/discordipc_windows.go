@@ -0,0 +1,20 @@
+package backend
+
+import (
+	"io"
+	"os"
+	"strconv"
+)
+
+// dialDiscordIPC connects to the named pipe of a running Discord client.
+func dialDiscordIPC() (io.ReadWriteCloser, error) {
+	var lastErr error
+	for i := 0; i < 10; i++ {
+		pipe, err := os.OpenFile(`\\.\pipe\discord-ipc-`+strconv.Itoa(i), os.O_RDWR, 0)
+		if err == nil {
+			return pipe, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
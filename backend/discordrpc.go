@@ -0,0 +1,332 @@
+package backend
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2/lang"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/player"
+	"github.com/dweymouth/supersonic/res"
+)
+
+// Discord IPC frame opcodes
+const (
+	discordOpHandshake = 0
+	discordOpFrame     = 1
+	discordOpClose     = 2
+)
+
+const (
+	discordActivityTypeListening = 2
+	discordCoverSize             = 300
+	// Discord rejects text fields outside this length range
+	discordMinFieldLen = 2
+	discordMaxFieldLen = 128
+
+	discordMinRetryInterval = 5 * time.Second
+	discordMaxRetryInterval = 2 * time.Minute
+)
+
+type discordActivity struct {
+	Type       int                        `json:"type"`
+	Details    string                     `json:"details,omitempty"`
+	State      string                     `json:"state,omitempty"`
+	Timestamps *discordActivityTimestamps `json:"timestamps,omitempty"`
+	Assets     *discordActivityAssets     `json:"assets,omitempty"`
+}
+
+type discordActivityTimestamps struct {
+	Start int64 `json:"start,omitempty"` // Unix ms
+	End   int64 `json:"end,omitempty"`   // Unix ms
+}
+
+type discordActivityAssets struct {
+	LargeImage string `json:"large_image,omitempty"`
+	LargeText  string `json:"large_text,omitempty"`
+}
+
+// discordRPC shows the now playing track as the user's
+// Discord activity, through the local Discord client's IPC socket.
+type discordRPC struct {
+	appID string
+	pm    *PlaybackManager
+	sm    *ServerManager
+
+	serverLock sync.Mutex
+	server     *ServerConfig // currently connected server, or nil
+
+	conn   io.ReadWriteCloser
+	nonce  int
+	update chan struct{}
+}
+
+// startDiscordRPC starts updating the Discord activity on playback changes.
+// It connects in the background and reconnects if Discord is restarted.
+func (a *App) startDiscordRPC() {
+	appID := a.Config.DiscordRPC.ApplicationID
+	if appID == "" {
+		appID = res.DiscordApplicationID
+	}
+	if appID == "" {
+		log.Println("Discord Rich Presence is enabled but no application ID is configured")
+		return
+	}
+	d := &discordRPC{
+		appID:  appID,
+		pm:     a.PlaybackManager,
+		sm:     a.ServerManager,
+		update: make(chan struct{}, 1),
+	}
+	a.ServerManager.OnServerConnected(func(conf *ServerConfig) { d.setServer(conf) })
+	a.ServerManager.OnLogout(func() { d.setServer(nil) })
+	a.PlaybackManager.OnSongChange(func(mediaprovider.MediaItem, *mediaprovider.Track) { d.requestUpdate() })
+	a.PlaybackManager.OnPlaying(d.requestUpdate)
+	a.PlaybackManager.OnPaused(d.requestUpdate)
+	a.PlaybackManager.OnStopped(d.requestUpdate)
+	a.PlaybackManager.OnSeek(d.requestUpdate)
+	a.discordRPC = d
+	go d.run(a.bgrndCtx)
+}
+
+// DiscordPresenceConfig returns the Discord privacy settings of the connected server.
+func (a *App) DiscordPresenceConfig() DiscordPresenceServerConfig {
+	if conf := a.ServerManager.connectedServer(); conf != nil {
+		return conf.DiscordPresence
+	}
+	return DiscordPresenceServerConfig{}
+}
+
+// SetDiscordPresenceConfig sets the Discord privacy settings
+// of the connected server and updates the shown activity.
+func (a *App) SetDiscordPresenceConfig(presence DiscordPresenceServerConfig) {
+	conf := a.ServerManager.connectedServer()
+	if conf == nil {
+		return
+	}
+	d := a.discordRPC
+	if d == nil {
+		conf.DiscordPresence = presence
+		return
+	}
+	d.serverLock.Lock()
+	conf.DiscordPresence = presence
+	d.serverLock.Unlock()
+	d.requestUpdate()
+}
+
+func (d *discordRPC) setServer(server *ServerConfig) {
+	d.serverLock.Lock()
+	d.server = server
+	d.serverLock.Unlock()
+	d.requestUpdate()
+}
+
+func (d *discordRPC) requestUpdate() {
+	select {
+	case d.update <- struct{}{}:
+	default: // update already pending
+	}
+}
+
+func (d *discordRPC) run(ctx context.Context) {
+	retryInterval := discordMinRetryInterval
+	retry := time.NewTimer(0)
+	defer retry.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if d.conn != nil {
+				d.setActivity(nil)
+				d.close()
+			}
+			return
+		case <-d.update:
+		case <-retry.C:
+		}
+
+		if d.conn == nil {
+			if err := d.connect(); err != nil {
+				// Discord is probably not running; back off until it is
+				retry.Reset(retryInterval)
+				retryInterval = min(retryInterval*2, discordMaxRetryInterval)
+				continue
+			}
+			retryInterval = discordMinRetryInterval
+		}
+		if err := d.setActivity(d.currentActivity()); err != nil {
+			log.Printf("error updating Discord activity: %v", err)
+			d.close()
+			retry.Reset(retryInterval)
+		}
+	}
+}
+
+// currentActivity returns the activity for the playback state,
+// or nil if no activity should be shown.
+func (d *discordRPC) currentActivity() *discordActivity {
+	d.serverLock.Lock()
+	server := d.server
+	var presence DiscordPresenceServerConfig
+	if server != nil {
+		presence = server.DiscordPresence
+	}
+	d.serverLock.Unlock()
+	if server == nil || presence.Disabled ||
+		slices.Contains(presence.HiddenLibraries, server.SelectedLibrary) {
+		return nil
+	}
+	status := d.pm.PlaybackStatus()
+	item := d.pm.NowPlaying()
+	if item == nil || status.State == player.Stopped {
+		return nil
+	}
+
+	meta := item.Metadata()
+	activity := &discordActivity{
+		Type:    discordActivityTypeListening,
+		Details: discordField(meta.Name),
+		State:   discordField(discordState(meta.Artists, status.State == player.Paused)),
+	}
+	if status.State != player.Paused {
+		start := time.Now().Add(-time.Duration(status.TimePos * float64(time.Second)))
+		activity.Timestamps = &discordActivityTimestamps{Start: start.UnixMilli()}
+		if status.Duration > 0 {
+			end := start.Add(time.Duration(status.Duration * float64(time.Second)))
+			activity.Timestamps.End = end.UnixMilli()
+		}
+	}
+
+	var assets discordActivityAssets
+	if !presence.HideAlbum {
+		assets.LargeText = discordField(meta.Album)
+	}
+	if presence.ShowCoverArt && meta.CoverArtID != "" {
+		if mp, ok := d.sm.Server.(mediaprovider.SupportsPublicCoverArtURL); ok {
+			assets.LargeImage = mp.PublicCoverArtURL(meta.CoverArtID, discordCoverSize)
+		}
+	}
+	if assets != (discordActivityAssets{}) {
+		activity.Assets = &assets
+	}
+	return activity
+}
+
+// discordState returns the artist line of the activity, which is marked when paused.
+func discordState(artists []string, paused bool) string {
+	state := strings.Join(artists, ", ")
+	if !paused {
+		return state
+	}
+	if state == "" {
+		return lang.L("Paused")
+	}
+	return fmt.Sprintf("%s (%s)", state, lang.L("Paused"))
+}
+
+// discordField pads or truncates s to a length Discord accepts.
+func discordField(s string) string {
+	if s == "" {
+		return ""
+	}
+	if r := []rune(s); len(r) > discordMaxFieldLen {
+		return string(r[:discordMaxFieldLen-1]) + "…"
+	}
+	for len(s) < discordMinFieldLen {
+		s += " "
+	}
+	return s
+}
+
+func (d *discordRPC) connect() error {
+	conn, err := dialDiscordIPC()
+	if err != nil {
+		return err
+	}
+	d.conn = conn
+	if err := d.writeFrame(discordOpHandshake, map[string]any{"v": 1, "client_id": d.appID}); err != nil {
+		d.close()
+		return err
+	}
+	if _, err := d.readFrame(); err != nil {
+		d.close()
+		return fmt.Errorf("Discord handshake failed: %w", err)
+	}
+	log.Println("Connected to Discord")
+	return nil
+}
+
+func (d *discordRPC) close() {
+	d.conn.Close()
+	d.conn = nil
+}
+
+// setActivity sets the user's activity, or clears it if activity is nil.
+func (d *discordRPC) setActivity(activity *discordActivity) error {
+	d.nonce++
+	err := d.writeFrame(discordOpFrame, map[string]any{
+		"cmd": "SET_ACTIVITY",
+		"args": map[string]any{
+			"pid":      os.Getpid(),
+			"activity": activity,
+		},
+		"nonce": strconv.Itoa(d.nonce),
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := d.readFrame()
+	if err != nil {
+		return err
+	}
+	var r struct {
+		Evt  string `json:"evt"`
+		Data struct {
+			Message string `json:"message"`
+		} `json:"data"`
+	}
+	if json.Unmarshal(resp, &r) == nil && r.Evt == "ERROR" {
+		// the request was rejected but the connection is still usable
+		log.Printf("Discord rejected activity: %s", r.Data.Message)
+	}
+	return nil
+}
+
+func (d *discordRPC) writeFrame(op uint32, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	frame := make([]byte, 8, 8+len(body))
+	binary.LittleEndian.PutUint32(frame[0:4], op)
+	binary.LittleEndian.PutUint32(frame[4:8], uint32(len(body)))
+	_, err = d.conn.Write(append(frame, body...))
+	return err
+}
+
+func (d *discordRPC) readFrame() ([]byte, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(d.conn, header[:]); err != nil {
+		return nil, err
+	}
+	op := binary.LittleEndian.Uint32(header[0:4])
+	body := make([]byte, binary.LittleEndian.Uint32(header[4:8]))
+	if _, err := io.ReadFull(d.conn, body); err != nil {
+		return nil, err
+	}
+	if op == discordOpClose {
+		return nil, errors.New("connection closed by Discord: " + string(body))
+	}
+	return body, nil
+}
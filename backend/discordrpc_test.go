@@ -0,0 +1,60 @@
+package backend
+
+import (
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestDiscordRPCSetActivity(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	d := &discordRPC{appID: "123", conn: client}
+
+	got := make(chan map[string]any, 1)
+	go func() {
+		s := &discordRPC{conn: server}
+		body, err := s.readFrame()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		var req map[string]any
+		json.Unmarshal(body, &req)
+		s.writeFrame(discordOpFrame, map[string]any{"cmd": "SET_ACTIVITY", "evt": nil, "nonce": req["nonce"]})
+		got <- req
+	}()
+
+	activity := &discordActivity{Type: discordActivityTypeListening, Details: discordField("A")}
+	if err := d.setActivity(activity); err != nil {
+		t.Fatal(err)
+	}
+	req := <-got
+	args := req["args"].(map[string]any)
+	details := args["activity"].(map[string]any)["details"]
+	if req["cmd"] != "SET_ACTIVITY" || details != "A " {
+		t.Errorf("unexpected request %v", req)
+	}
+}
+
+func TestDiscordField(t *testing.T) {
+	if s := discordField(strings.Repeat("x", 200)); len([]rune(s)) != discordMaxFieldLen {
+		t.Errorf("field not truncated: %d runes", len([]rune(s)))
+	}
+	if s := discordField(""); s != "" {
+		t.Errorf("empty field was padded: %q", s)
+	}
+}
+
+func TestDiscordState(t *testing.T) {
+	if s := discordState([]string{"A", "B"}, false); s != "A, B" {
+		t.Errorf("got %q for playing track", s)
+	}
+	if s := discordState([]string{"A", "B"}, true); s != "A, B (Paused)" {
+		t.Errorf("got %q for paused track, want artists kept", s)
+	}
+	if s := discordState(nil, true); s != "Paused" {
+		t.Errorf("got %q for paused track without artists", s)
+	}
+}
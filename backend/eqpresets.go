@@ -6,29 +6,32 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+
+	"github.com/dweymouth/supersonic/backend/player/mpv"
 )
 
 const eqPresetsDir = "eq_presets"
 
 // EQPreset represents an equalizer preset that can be saved/loaded
 type EQPreset struct {
-	Name      string    `json:"name"`
-	Type      string    `json:"type"`           // "ISO10Band" or "ISO15Band"
-	Preamp    float64   `json:"preamp"`
-	Bands     []float64 `json:"bands"`
-	IsBuiltin bool      `json:"-"` // not saved to file, determined at load time
+	Name      string                 `json:"name"`
+	Type      string                 `json:"type"` // "ISO10Band", "ISO15Band" or "Parametric"
+	Preamp    float64                `json:"preamp"`
+	Bands     []float64              `json:"bands"`
+	Filters   []mpv.ParametricFilter `json:"filters,omitempty"` // only for "Parametric" presets
+	IsBuiltin bool                   `json:"-"`                 // not saved to file, determined at load time
 }
 
 // EQPresetManager handles loading and saving EQ presets
 type EQPresetManager struct {
-	presetsDir string
+	presetsDir     string
 	builtinPresets []EQPreset
 }
 
 // NewEQPresetManager creates a new preset manager
 func NewEQPresetManager(configDir string) *EQPresetManager {
 	return &EQPresetManager{
-		presetsDir: filepath.Join(configDir, eqPresetsDir),
+		presetsDir:     filepath.Join(configDir, eqPresetsDir),
 		builtinPresets: getBuiltinPresets(),
 	}
 }
@@ -0,0 +1,55 @@
+package backend
+
+import "github.com/dweymouth/supersonic/backend/player/mpv"
+
+const (
+	EqualizerTypeISO10Band  = "ISO10Band"
+	EqualizerTypeISO15Band  = "ISO15Band"
+	EqualizerTypeParametric = "Parametric"
+)
+
+// NewEqualizerFromConfig creates the equalizer of the configured type.
+func NewEqualizerFromConfig(c *LocalPlaybackConfig) mpv.Equalizer {
+	if c.EqualizerType == EqualizerTypeParametric {
+		return &mpv.ParametricEqualizer{
+			Disabled: !c.EqualizerEnabled,
+			EQPreamp: c.ParametricEQPreamp,
+			Filters:  c.ParametricEQFilters,
+		}
+	}
+	return NewGraphicEqualizerFromConfig(c)
+}
+
+// NewGraphicEqualizerFromConfig creates the configured graphic equalizer,
+// which is kept in the config while the parametric equalizer is in use.
+func NewGraphicEqualizerFromConfig(c *LocalPlaybackConfig) mpv.Equalizer {
+	if GraphicEqualizerType(c) == EqualizerTypeISO10Band {
+		eq10 := &mpv.ISO10BandEqualizer{
+			EQPreamp: c.EqualizerPreamp,
+			Disabled: !c.EqualizerEnabled,
+		}
+		// Copy up to 10 bands
+		copy(eq10.BandGains[:], c.GraphicEqualizerBands)
+		return eq10
+	}
+	eq15 := &mpv.ISO15BandEqualizer{
+		EQPreamp: c.EqualizerPreamp,
+		Disabled: !c.EqualizerEnabled,
+	}
+	// Copy up to 15 bands
+	copy(eq15.BandGains[:], c.GraphicEqualizerBands)
+	return eq15
+}
+
+// GraphicEqualizerType returns the type of the configured graphic equalizer.
+func GraphicEqualizerType(c *LocalPlaybackConfig) string {
+	switch c.EqualizerType {
+	case EqualizerTypeISO10Band, EqualizerTypeISO15Band:
+		return c.EqualizerType
+	case EqualizerTypeParametric:
+		if len(c.GraphicEqualizerBands) == 10 {
+			return EqualizerTypeISO10Band
+		}
+	}
+	return EqualizerTypeISO15Band
+}
@@ -0,0 +1,112 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/zalando/go-keyring"
+)
+
+// environment variable to read the server password from in headless
+// and terminal UI modes, for systems without a keyring service
+const headlessPasswordEnvVar = "SUPERSONIC_PASSWORD"
+
+// how long to wait between attempts to connect to the server in headless mode,
+// e.g. if the network isn't up yet at boot
+const headlessConnectRetryInterval = 15 * time.Second
+
+// RunHeadless connects to the configured server and runs the app without
+// any UI, until it is asked to quit over IPC or receives SIGINT or SIGTERM.
+// The caller should call Shutdown after it returns.
+func (a *App) RunHeadless() error {
+	ctx, stop := signal.NotifyContext(a.bgrndCtx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	a.OnExit = stop
+	a.OnReactivate = func() { log.Println("ignoring request to show window in headless mode") }
+	a.OnReloadTheme = func() {}
+
+	serverCfg, err := a.ConfiguredServer()
+	if err != nil {
+		return err
+	}
+	pass, err := a.StoredServerPassword(serverCfg)
+	if err != nil {
+		return err
+	}
+
+	a.ServerManager.OnServerConnected(a.RestoreServerSession)
+	go a.connectHeadless(ctx, serverCfg, pass)
+
+	log.Println("Running in headless mode")
+	<-ctx.Done()
+	return nil
+}
+
+func (a *App) connectHeadless(ctx context.Context, serverCfg *ServerConfig, pass string) {
+	for {
+		err := a.ServerManager.ConnectToServer(serverCfg, pass)
+		if err == nil {
+			log.Printf("Connected to server %q", serverCfg.Nickname)
+			return
+		}
+		log.Printf("error connecting to server %q (retrying in %v): %v",
+			serverCfg.Nickname, headlessConnectRetryInterval, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(headlessConnectRetryInterval):
+		}
+	}
+}
+
+// StoredServerPassword returns the password for the server from the keyring,
+// falling back to the SUPERSONIC_PASSWORD environment variable.
+func (a *App) StoredServerPassword(serverCfg *ServerConfig) (string, error) {
+	pass, err := keyring.Get(a.appName, serverCfg.ID.String())
+	if err != nil {
+		if pass = os.Getenv(headlessPasswordEnvVar); pass == "" {
+			return "", fmt.Errorf("no password for server %q in keyring or %s: %v", serverCfg.Nickname, headlessPasswordEnvVar, err)
+		}
+	}
+	return pass, nil
+}
+
+// RestoreServerSession selects the server's saved library and loads the
+// saved play queue if enabled. It is meant to be registered as an
+// OnServerConnected callback by frontends other than the main UI.
+func (a *App) RestoreServerSession(conf *ServerConfig) {
+	if conf.SelectedLibrary != "" {
+		a.ServerManager.Server.SetLibrary(conf.SelectedLibrary)
+	}
+	if a.Config.Application.SavePlayQueue {
+		if err := a.LoadSavedPlayQueue(); err != nil {
+			log.Printf("failed to load saved play queue: %s", err.Error())
+		}
+	}
+	a.openPendingDeepLink()
+}
+
+// ConfiguredServer returns the server selected by Application.HeadlessServer,
+// or the default server if not set.
+func (a *App) ConfiguredServer() (*ServerConfig, error) {
+	name := a.Config.Application.HeadlessServer
+	if name == "" {
+		if s := a.ServerManager.GetDefaultServer(); s != nil {
+			return s, nil
+		}
+		return nil, ErrNoServers
+	}
+	for _, s := range a.Config.Servers {
+		if s.Nickname == name || s.ID.String() == name {
+			return s, nil
+		}
+	}
+	return nil, errors.New("configured server not found: " + name)
+}
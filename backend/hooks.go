@@ -0,0 +1,216 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+// Events that hooks can be configured to run on
+const (
+	HookEventTrackChange   = "track_change"
+	HookEventPlay          = "play"
+	HookEventPause         = "pause"
+	HookEventStop          = "stop"
+	HookEventFavorite      = "favorite"
+	HookEventRating        = "rating"
+	HookEventServerConnect = "server_connect"
+)
+
+// how long to wait for a hook's output pipes to close after it is killed
+const hookWaitDelay = 2 * time.Second
+
+// HookPayload describes the event a hook is run for.
+// It is written to the hook command's stdin as JSON.
+type HookPayload struct {
+	Event string `json:"event"`
+
+	// the track the event is about, if any. For play, pause and stop,
+	// the now playing item; for favorite and rating, the changed track,
+	// which may contain only the ID if it isn't in the play queue.
+	Track  *mediaprovider.MediaItemMetadata `json:"track,omitempty"`
+	Genres []string                         `json:"genres,omitempty"`
+	Year   int                              `json:"year,omitempty"`
+
+	Favorite *bool `json:"favorite,omitempty"`
+	Rating   *int  `json:"rating,omitempty"`
+
+	Server *HookServerInfo `json:"server,omitempty"`
+}
+
+type HookServerInfo struct {
+	Nickname string `json:"nickname"`
+	Hostname string `json:"hostname"`
+	Username string `json:"username"`
+}
+
+// HookManager runs the user commands configured in HooksConfig when events happen.
+// Commands run in the background, so hooks never block the caller.
+type HookManager struct {
+	ctx context.Context
+	cfg *HooksConfig
+	// limits the number of commands running at once
+	sem chan struct{}
+}
+
+func NewHookManager(ctx context.Context, cfg *HooksConfig) *HookManager {
+	return &HookManager{
+		ctx: ctx,
+		cfg: cfg,
+		sem: make(chan struct{}, max(cfg.MaxConcurrent, 1)),
+	}
+}
+
+// Run starts all commands configured for the payload's event.
+// If the concurrency limit is reached, the commands are skipped.
+func (h *HookManager) Run(payload HookPayload) {
+	if !h.cfg.Enabled {
+		return
+	}
+	for _, hook := range h.cfg.Commands {
+		if hook.Event != payload.Event || strings.TrimSpace(hook.Command) == "" {
+			continue
+		}
+		select {
+		case h.sem <- struct{}{}:
+			go func(command string) {
+				defer func() { <-h.sem }()
+				h.runCommand(command, payload)
+			}(hook.Command)
+		default:
+			log.Printf("skipping %s hook %q: too many hooks running", payload.Event, hook.Command)
+		}
+	}
+}
+
+func (h *HookManager) runCommand(command string, payload HookPayload) {
+	timeout := time.Duration(max(h.cfg.TimeoutSeconds, 1)) * time.Second
+	ctx, cancel := context.WithTimeout(h.ctx, timeout)
+	defer cancel()
+
+	stdin, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("error encoding hook payload: %v", err)
+		return
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Env = append(os.Environ(), payload.environment()...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.WaitDelay = hookWaitDelay
+	out, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		log.Printf("%s hook %q timed out after %v", payload.Event, command, timeout)
+	} else if err != nil {
+		log.Printf("%s hook %q failed: %v: %s", payload.Event, command, err, bytes.TrimSpace(out))
+	}
+}
+
+// environment returns the payload as SUPERSONIC_* environment variables
+func (p HookPayload) environment() []string {
+	env := []string{"SUPERSONIC_EVENT=" + p.Event}
+	add := func(name, value string) {
+		env = append(env, "SUPERSONIC_"+name+"="+value)
+	}
+	if t := p.Track; t != nil {
+		add("TRACK_ID", t.ID)
+		add("TRACK_TITLE", t.Name)
+		add("TRACK_ARTISTS", strings.Join(t.Artists, ", "))
+		add("TRACK_ALBUM", t.Album)
+		add("TRACK_ALBUM_ID", t.AlbumID)
+		add("TRACK_DURATION", strconv.Itoa(int(t.Duration.Seconds())))
+		add("TRACK_GENRES", strings.Join(p.Genres, ", "))
+		if p.Year > 0 {
+			add("TRACK_YEAR", strconv.Itoa(p.Year))
+		}
+	}
+	if p.Favorite != nil {
+		add("FAVORITE", strconv.FormatBool(*p.Favorite))
+	}
+	if p.Rating != nil {
+		add("RATING", strconv.Itoa(*p.Rating))
+	}
+	if s := p.Server; s != nil {
+		add("SERVER_NAME", s.Nickname)
+		add("SERVER_HOST", s.Hostname)
+		add("SERVER_USER", s.Username)
+	}
+	return env
+}
+
+func newItemHookPayload(event string, item mediaprovider.MediaItem) HookPayload {
+	payload := HookPayload{Event: event}
+	if item == nil {
+		return payload
+	}
+	meta := item.Metadata()
+	payload.Track = &meta
+	if tr, ok := item.(*mediaprovider.Track); ok {
+		payload.Genres = tr.Genres
+		payload.Year = tr.Year
+	}
+	return payload
+}
+
+// registerHooks runs the configured hooks on playback and server events.
+func (a *App) registerHooks() {
+	pm := a.PlaybackManager
+	run := a.HookManager.Run
+
+	// findTrack returns the item with the ID from the play queue,
+	// or an item with only the ID set if it isn't queued.
+	findTrack := func(id string) mediaprovider.MediaItem {
+		if np := pm.NowPlaying(); np != nil && np.Metadata().ID == id {
+			return np
+		}
+		for _, item := range pm.GetPlayQueue() {
+			if item.Metadata().ID == id {
+				return item
+			}
+		}
+		return &mediaprovider.Track{ID: id}
+	}
+
+	pm.OnSongChange(func(nowPlaying mediaprovider.MediaItem, _ *mediaprovider.Track) {
+		if nowPlaying != nil {
+			run(newItemHookPayload(HookEventTrackChange, nowPlaying))
+		}
+	})
+	pm.OnPlaying(func() { run(newItemHookPayload(HookEventPlay, pm.NowPlaying())) })
+	pm.OnPaused(func() { run(newItemHookPayload(HookEventPause, pm.NowPlaying())) })
+	pm.OnStopped(func() { run(newItemHookPayload(HookEventStop, pm.NowPlaying())) })
+	pm.OnFavoriteChange(func(trackID string, favorite bool) {
+		payload := newItemHookPayload(HookEventFavorite, findTrack(trackID))
+		payload.Favorite = &favorite
+		run(payload)
+	})
+	pm.OnRatingChange(func(trackID string, rating int) {
+		payload := newItemHookPayload(HookEventRating, findTrack(trackID))
+		payload.Rating = &rating
+		run(payload)
+	})
+	a.ServerManager.OnServerConnected(func(conf *ServerConfig) {
+		run(HookPayload{
+			Event: HookEventServerConnect,
+			Server: &HookServerInfo{
+				Nickname: conf.Nickname,
+				Hostname: conf.Hostname,
+				Username: conf.Username,
+			},
+		})
+	})
+}
@@ -0,0 +1,79 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+func TestHookManager(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test uses sh")
+	}
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	cfg := &HooksConfig{
+		Enabled:        true,
+		TimeoutSeconds: 1,
+		MaxConcurrent:  1,
+		Commands: []HookConfig{
+			{Event: HookEventRating, Command: `echo "$SUPERSONIC_TRACK_TITLE $SUPERSONIC_RATING" > ` + out + `; cat >> ` + out},
+			{Event: HookEventStop, Command: "sleep 10"},
+		},
+	}
+	h := NewHookManager(context.Background(), cfg)
+
+	payload := newItemHookPayload(HookEventRating, &mediaprovider.Track{ID: "1", Title: "Song"})
+	rating := 4
+	payload.Rating = &rating
+	h.Run(payload)
+	waitForHooks(t, h)
+
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, stdin, _ := strings.Cut(string(b), "\n")
+	if env != "Song 4" {
+		t.Errorf("got env output %q", env)
+	}
+	var got HookPayload
+	if err := json.Unmarshal([]byte(stdin), &got); err != nil {
+		t.Fatalf("invalid JSON on stdin %q: %v", stdin, err)
+	}
+	if got.Event != HookEventRating || got.Track.Name != "Song" || *got.Rating != 4 {
+		t.Errorf("got payload %+v", got)
+	}
+
+	// a hanging hook is killed after the timeout, and other hooks
+	// are skipped while it occupies the only slot
+	start := time.Now()
+	h.Run(HookPayload{Event: HookEventStop})
+	os.Remove(out)
+	h.Run(payload)
+	waitForHooks(t, h)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("hook was not killed after timeout (%v)", elapsed)
+	}
+	if _, err := os.Stat(out); err == nil {
+		t.Error("expected hook to be skipped at concurrency limit")
+	}
+}
+
+func waitForHooks(t *testing.T, h *HookManager) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for len(h.sem) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for hooks")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
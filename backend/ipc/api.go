@@ -4,6 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
 )
 
 const (
@@ -21,6 +25,8 @@ const (
 	PauseAfterCurrentPath = "/transport/pause-after-current"
 	PreviousPath          = "/transport/previous"
 	NextPath              = "/transport/next"
+	PreviousChapterPath   = "/transport/previous-chapter"
+	NextChapterPath       = "/transport/next-chapter"
 	TimePosPath           = "/transport/timepos" // ?s=<seconds>
 	SeekByPath            = "/transport/seek-by" // ?s=<+/- seconds>
 	VolumePath            = "/volume"            // ?v=<vol>
@@ -28,8 +34,78 @@ const (
 	ShowPath              = "/window/show"
 	ReloadThemePath       = "/window/reload-theme"
 	QuitPath              = "/window/quit"
+	OpenLinkPath          = "/window/open-link" // ?url=<supersonic:// link>
 	CurrentTrackPath      = "/current_track"
 	RateCurrentTrackPath  = "/current_track/rate" // ?r=<rating 0-5>
+	EventsPath            = "/events"             // server-sent event stream
+	StatusPath            = "/status"
+	QueuePath             = "/queue"
+	QueueEnqueuePath      = "/queue/enqueue" // ?type=<track|album|playlist>&id=<ID>&next=<bool>
+	QueueRemovePath       = "/queue/remove"  // ?idx=<comma-separated indexes>
+	QueueMovePath         = "/queue/move"    // ?idx=<comma-separated indexes>&to=<insert before index>
+	QueueClearPath        = "/queue/clear"
+	QueueShufflePath      = "/queue/shuffle" // ?s=<bool> (toggles if omitted)
+	QueueLoopModePath     = "/queue/loop"    // ?m=<none|all|one>
+
+	LibraryArtistsPath       = "/library/artists"        // ?n=<max results>
+	LibraryArtistPath        = "/library/artist"         // ?id=<artist ID>
+	LibraryAlbumPath         = "/library/album"          // ?id=<album ID>
+	LibraryGenresPath        = "/library/genres"         //
+	LibraryFavoritesPath     = "/library/favorites"      //
+	LibraryRandomTracksPath  = "/library/random-tracks"  // ?n=<count>&genre=<genre>
+	LibraryRadioStationsPath = "/library/radio-stations" //
+	LibraryFavoritePath      = "/library/favorite"       // ?type=<track|album|artist>&id=<ID>&f=<bool>
+	PlaylistCreatePath       = "/playlist/create"        // ?name=<name>&desc=<description>&public=<bool>&tracks=<comma-separated track IDs> (desc and public can't be set with tracks)
+	PlaylistAddTracksPath    = "/playlist/add-tracks"    // ?id=<playlist ID>&tracks=<comma-separated track IDs>
+	PlaylistRemoveTracksPath = "/playlist/remove-tracks" // ?id=<playlist ID>&idx=<comma-separated track indexes>
+
+	// only served by the remote control listener
+	RemoteUIPath   = "/remote/"
+	RemotePairPath = "/remote/pair"  // POST code=<pairing code>
+	CoverArtPath   = "/remote/cover" // ?id=<cover art ID>
+)
+
+// Playback states reported by StatusResponse
+const (
+	StatePlaying = "playing"
+	StatePaused  = "paused"
+	StateStopped = "stopped"
+)
+
+// Item types accepted by LibraryFavoritePath
+const (
+	FavoriteTypeTrack  = "track"
+	FavoriteTypeAlbum  = "album"
+	FavoriteTypeArtist = "artist"
+)
+
+// Item types accepted by QueueEnqueuePath
+const (
+	EnqueueTypeTrack    = "track"
+	EnqueueTypeAlbum    = "album"
+	EnqueueTypePlaylist = "playlist"
+)
+
+// Loop modes accepted by QueueLoopModePath and reported by LoopModeEvent
+const (
+	LoopModeNone = "none"
+	LoopModeAll  = "all"
+	LoopModeOne  = "one"
+)
+
+// Event types sent on the EventsPath stream
+const (
+	EventTrackChange    = "track-change"  // data: now playing item metadata, or null
+	EventPlaying        = "playing"       // data: null
+	EventPaused         = "paused"        // data: null
+	EventStopped        = "stopped"       // data: null
+	EventSeek           = "seek"          // data: SeekEvent
+	EventVolumeChange   = "volume-change" // data: VolumeEvent
+	EventQueueChange    = "queue-change"  // data: QueueEvent
+	EventLoopModeChange = "loop-mode"     // data: LoopModeEvent
+	EventShuffleChange  = "shuffle"       // data: ShuffleEvent
+	EventRatingChange   = "rating"        // data: RatingEvent
+	EventFavoriteChange = "favorite"      // data: FavoriteEvent
 )
 
 type Response struct {
@@ -37,6 +113,62 @@ type Response struct {
 	Error string          `json:"error"`
 }
 
+type QueueResponse struct {
+	Items           []mediaprovider.MediaItemMetadata `json:"items"`
+	NowPlayingIndex int                               `json:"nowPlayingIndex"`
+	Shuffle         bool                              `json:"shuffle"`
+	LoopMode        string                            `json:"loopMode"`
+}
+
+type StatusResponse struct {
+	State      string                           `json:"state"` // one of the State* constants
+	TimePos    float64                          `json:"timePos"`
+	Duration   float64                          `json:"duration"`
+	Volume     int                              `json:"volume"`
+	NowPlaying *mediaprovider.MediaItemMetadata `json:"nowPlaying"`
+}
+
+// PairResponse is the data returned from a successful RemotePairPath request.
+type PairResponse struct {
+	Token string `json:"token"`
+}
+
+type Event struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+type SeekEvent struct {
+	TimePos float64 `json:"timePos"`
+}
+
+type VolumeEvent struct {
+	Volume int `json:"volume"`
+}
+
+type QueueEvent struct {
+	Length          int `json:"length"`
+	NowPlayingIndex int `json:"nowPlayingIndex"`
+}
+
+type LoopModeEvent struct {
+	LoopMode string `json:"loopMode"` // one of the LoopMode* constants
+}
+
+type ShuffleEvent struct {
+	Shuffle bool `json:"shuffle"`
+}
+
+type RatingEvent struct {
+	TrackID string `json:"trackID"`
+	Rating  int    `json:"rating"`
+}
+
+type FavoriteEvent struct {
+	TrackID  string `json:"trackID"`
+	Favorite bool   `json:"favorite"`
+}
+
 func SetVolumePath(vol int) string {
 	return fmt.Sprintf("%s?v=%d", VolumePath, vol)
 }
@@ -80,6 +212,71 @@ func BuildSearchTrackPath(search string) string {
 	return fmt.Sprintf("%s?s=%s", SearchTrackPath, s)
 }
 
+func BuildEnqueuePath(itemType, id string, next bool) string {
+	return fmt.Sprintf("%s?type=%s&id=%s&next=%t", QueueEnqueuePath, itemType, url.QueryEscape(id), next)
+}
+
+func BuildQueueRemovePath(idxs []int) string {
+	return fmt.Sprintf("%s?idx=%s", QueueRemovePath, joinInts(idxs))
+}
+
+func BuildQueueMovePath(idxs []int, to int) string {
+	return fmt.Sprintf("%s?idx=%s&to=%d", QueueMovePath, joinInts(idxs), to)
+}
+
+func BuildQueueShufflePath(shuffle bool) string {
+	return fmt.Sprintf("%s?s=%t", QueueShufflePath, shuffle)
+}
+
+func BuildQueueLoopModePath(mode string) string {
+	return fmt.Sprintf("%s?m=%s", QueueLoopModePath, mode)
+}
+
+func joinInts(ints []int) string {
+	strs := make([]string, len(ints))
+	for i, n := range ints {
+		strs[i] = strconv.Itoa(n)
+	}
+	return strings.Join(strs, ",")
+}
+
+func BuildLibraryArtistsPath(maxResults int) string {
+	return fmt.Sprintf("%s?n=%d", LibraryArtistsPath, maxResults)
+}
+
+func BuildLibraryArtistPath(id string) string {
+	return fmt.Sprintf("%s?id=%s", LibraryArtistPath, url.QueryEscape(id))
+}
+
+func BuildLibraryAlbumPath(id string) string {
+	return fmt.Sprintf("%s?id=%s", LibraryAlbumPath, url.QueryEscape(id))
+}
+
+func BuildLibraryRandomTracksPath(count int, genre string) string {
+	return fmt.Sprintf("%s?n=%d&genre=%s", LibraryRandomTracksPath, count, url.QueryEscape(genre))
+}
+
+func BuildLibraryFavoritePath(itemType, id string, favorite bool) string {
+	return fmt.Sprintf("%s?type=%s&id=%s&f=%t", LibraryFavoritePath, itemType, url.QueryEscape(id), favorite)
+}
+
+func BuildPlaylistCreatePath(name, description string, public bool, trackIDs []string) string {
+	return fmt.Sprintf("%s?name=%s&desc=%s&public=%t&tracks=%s", PlaylistCreatePath,
+		url.QueryEscape(name), url.QueryEscape(description), public, url.QueryEscape(strings.Join(trackIDs, ",")))
+}
+
+func BuildPlaylistAddTracksPath(id string, trackIDs []string) string {
+	return fmt.Sprintf("%s?id=%s&tracks=%s", PlaylistAddTracksPath, url.QueryEscape(id), url.QueryEscape(strings.Join(trackIDs, ",")))
+}
+
+func BuildPlaylistRemoveTracksPath(id string, idxs []int) string {
+	return fmt.Sprintf("%s?id=%s&idx=%s", PlaylistRemoveTracksPath, url.QueryEscape(id), joinInts(idxs))
+}
+
+func BuildOpenLinkPath(link string) string {
+	return fmt.Sprintf("%s?url=%s", OpenLinkPath, url.QueryEscape(link))
+}
+
 func BuildRateCurrentTrackPath(rating int) string {
 	return fmt.Sprintf("%s?r=%d", RateCurrentTrackPath, rating)
 }
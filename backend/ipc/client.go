@@ -1,11 +1,13 @@
 package ipc
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"errors"
 	"net"
 	"net/http"
+	"strings"
 )
 
 var ErrPingFail = errors.New("ping failed")
@@ -98,6 +100,16 @@ func (c *Client) SeekBackOrPrevious() error {
 	return err
 }
 
+func (c *Client) SeekNextChapter() error {
+	_, err := c.sendRequest(NextChapterPath)
+	return err
+}
+
+func (c *Client) SeekPreviousChapter() error {
+	_, err := c.sendRequest(PreviousChapterPath)
+	return err
+}
+
 func (c *Client) SeekSeconds(secs float64) error {
 	_, err := c.sendRequest(SeekToSecondsPath(secs))
 	return err
@@ -122,6 +134,93 @@ func (c *Client) CurrentTrack() (string, error) {
 	return c.sendRequest(CurrentTrackPath)
 }
 
+func (c *Client) Queue() (string, error) {
+	return c.sendRequest(QueuePath)
+}
+
+func (c *Client) Enqueue(itemType, id string, next bool) error {
+	_, err := c.sendRequest(BuildEnqueuePath(itemType, id, next))
+	return err
+}
+
+func (c *Client) RemoveFromQueue(idxs []int) error {
+	_, err := c.sendRequest(BuildQueueRemovePath(idxs))
+	return err
+}
+
+func (c *Client) MoveInQueue(idxs []int, insertIdx int) error {
+	_, err := c.sendRequest(BuildQueueMovePath(idxs, insertIdx))
+	return err
+}
+
+func (c *Client) ClearQueue() error {
+	_, err := c.sendRequest(QueueClearPath)
+	return err
+}
+
+func (c *Client) ToggleShuffle() error {
+	_, err := c.sendRequest(QueueShufflePath)
+	return err
+}
+
+func (c *Client) SetShuffle(shuffle bool) error {
+	_, err := c.sendRequest(BuildQueueShufflePath(shuffle))
+	return err
+}
+
+func (c *Client) SetLoopMode(mode string) error {
+	_, err := c.sendRequest(BuildQueueLoopModePath(mode))
+	return err
+}
+
+func (c *Client) Artists(maxResults int) (string, error) {
+	return c.sendRequest(BuildLibraryArtistsPath(maxResults))
+}
+
+func (c *Client) Artist(id string) (string, error) {
+	return c.sendRequest(BuildLibraryArtistPath(id))
+}
+
+func (c *Client) Album(id string) (string, error) {
+	return c.sendRequest(BuildLibraryAlbumPath(id))
+}
+
+func (c *Client) Genres() (string, error) {
+	return c.sendRequest(LibraryGenresPath)
+}
+
+func (c *Client) Favorites() (string, error) {
+	return c.sendRequest(LibraryFavoritesPath)
+}
+
+func (c *Client) RandomTracks(count int, genre string) (string, error) {
+	return c.sendRequest(BuildLibraryRandomTracksPath(count, genre))
+}
+
+func (c *Client) RadioStations() (string, error) {
+	return c.sendRequest(LibraryRadioStationsPath)
+}
+
+func (c *Client) SetFavorite(itemType, id string, favorite bool) error {
+	_, err := c.sendRequest(BuildLibraryFavoritePath(itemType, id, favorite))
+	return err
+}
+
+func (c *Client) CreatePlaylist(name, description string, public bool, trackIDs []string) error {
+	_, err := c.sendRequest(BuildPlaylistCreatePath(name, description, public, trackIDs))
+	return err
+}
+
+func (c *Client) AddPlaylistTracks(id string, trackIDs []string) error {
+	_, err := c.sendRequest(BuildPlaylistAddTracksPath(id, trackIDs))
+	return err
+}
+
+func (c *Client) RemovePlaylistTracks(id string, idxs []int) error {
+	_, err := c.sendRequest(BuildPlaylistRemoveTracksPath(id, idxs))
+	return err
+}
+
 func (c *Client) RateCurrentTrack(rating int) error {
 	_, err := c.sendRequest(BuildRateCurrentTrackPath(rating))
 	return err
@@ -132,6 +231,11 @@ func (c *Client) Show() error {
 	return err
 }
 
+func (c *Client) OpenLink(link string) error {
+	_, err := c.sendRequest(BuildOpenLinkPath(link))
+	return err
+}
+
 func (c *Client) ReloadTheme() error {
 	_, err := c.sendRequest(ReloadThemePath)
 	return err
@@ -142,6 +246,48 @@ func (c *Client) Quit() error {
 	return err
 }
 
+// Watch subscribes to the server's event stream and invokes onEvent
+// for each event received, until the stream ends or ctx is canceled.
+func (c *Client) Watch(ctx context.Context, onEvent func(Event)) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://supersonic"+EventsPath, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpC.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var r Response
+		json.NewDecoder(resp.Body).Decode(&r)
+		return errors.New(r.Error)
+	}
+
+	var ev Event
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			// blank line terminates an event
+			if ev.Type != "" {
+				onEvent(ev)
+			}
+			ev = Event{}
+		case strings.HasPrefix(line, "event:"):
+			ev.Type = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			ev.Data = json.RawMessage(strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil
+	}
+	return scanner.Err()
+}
+
 func (c *Client) sendRequest(path string) (string, error) {
 	resp, err := c.httpC.Get("http://supersonic/" + path)
 	if err != nil {
@@ -0,0 +1,99 @@
+package ipc
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+)
+
+// events buffered per subscriber before new events are dropped for a slow client
+const eventBufferSize = 64
+
+// eventBroker fans out published events to all connected /events subscribers.
+type eventBroker struct {
+	mutex       sync.Mutex
+	subscribers map[chan Event]struct{}
+	closed      bool
+	done        chan struct{}
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{
+		subscribers: make(map[chan Event]struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+func (b *eventBroker) subscribe() chan Event {
+	ch := make(chan Event, eventBufferSize)
+	b.mutex.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mutex.Unlock()
+	return ch
+}
+
+func (b *eventBroker) unsubscribe(ch chan Event) {
+	b.mutex.Lock()
+	delete(b.subscribers, ch)
+	b.mutex.Unlock()
+}
+
+func (b *eventBroker) publish(eventType string, data any) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("error marshaling IPC event %s: %v", eventType, err)
+		return
+	}
+	ev := Event{Type: eventType, Data: raw}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- ev:
+		default:
+			// subscriber isn't keeping up - drop event
+		}
+	}
+}
+
+// close ends all event streams, so that the HTTP server can shut down
+func (b *eventBroker) close() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if !b.closed {
+		b.closed = true
+		close(b.done)
+	}
+}
+
+func (s *serverImpl) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.writeErr(w, fmt.Errorf("streaming not supported"))
+		return
+	}
+	ch := s.events.subscribe()
+	defer s.events.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.events.done:
+			return
+		case ev := <-ch:
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, ev.Data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
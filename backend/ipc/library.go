@@ -0,0 +1,203 @@
+package ipc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+// ErrNotSupported is returned when the current server doesn't support the requested operation.
+var ErrNotSupported = errors.New("not supported by the current server")
+
+// default number of results for the random tracks endpoint
+const defaultRandomTrackCount = 25
+
+// badRequestError is an error caused by missing or invalid request parameters.
+type badRequestError struct {
+	msg string
+}
+
+func (e *badRequestError) Error() string { return e.msg }
+
+func badRequest(format string, args ...any) error {
+	return &badRequestError{msg: fmt.Sprintf(format, args...)}
+}
+
+func (s *serverImpl) addLibraryHandlers(m *http.ServeMux) {
+	m.HandleFunc(LibraryArtistsPath, s.makeLibraryEndpointHandler(func(mp mediaprovider.MediaProvider, q url.Values) (any, error) {
+		limit, err := optionalIntParam(q, "n", 0)
+		if err != nil {
+			return nil, err
+		}
+		iter := mp.IterateArtists(mediaprovider.ArtistSortNameAZ, mediaprovider.NewArtistFilter(mediaprovider.ArtistFilterOptions{}))
+		artists := make([]*mediaprovider.Artist, 0)
+		for ar := iter.Next(); ar != nil; ar = iter.Next() {
+			artists = append(artists, ar)
+			if limit > 0 && len(artists) >= limit {
+				break
+			}
+		}
+		return artists, nil
+	}))
+	m.HandleFunc(LibraryArtistPath, s.makeLibraryEndpointHandler(func(mp mediaprovider.MediaProvider, q url.Values) (any, error) {
+		id, err := requiredParam(q, "id")
+		if err != nil {
+			return nil, err
+		}
+		return mp.GetArtist(id)
+	}))
+	m.HandleFunc(LibraryAlbumPath, s.makeLibraryEndpointHandler(func(mp mediaprovider.MediaProvider, q url.Values) (any, error) {
+		id, err := requiredParam(q, "id")
+		if err != nil {
+			return nil, err
+		}
+		return mp.GetAlbum(id)
+	}))
+	m.HandleFunc(LibraryGenresPath, s.makeLibraryEndpointHandler(func(mp mediaprovider.MediaProvider, _ url.Values) (any, error) {
+		return mp.GetGenres()
+	}))
+	m.HandleFunc(LibraryFavoritesPath, s.makeLibraryEndpointHandler(func(mp mediaprovider.MediaProvider, _ url.Values) (any, error) {
+		return mp.GetFavorites()
+	}))
+	m.HandleFunc(LibraryRandomTracksPath, s.makeLibraryEndpointHandler(func(mp mediaprovider.MediaProvider, q url.Values) (any, error) {
+		count, err := optionalIntParam(q, "n", defaultRandomTrackCount)
+		if err != nil {
+			return nil, err
+		}
+		return mp.GetRandomTracks(q.Get("genre"), count)
+	}))
+	m.HandleFunc(LibraryRadioStationsPath, s.makeLibraryEndpointHandler(func(mp mediaprovider.MediaProvider, _ url.Values) (any, error) {
+		rp, ok := mp.(mediaprovider.RadioProvider)
+		if !ok {
+			return nil, ErrNotSupported
+		}
+		return rp.GetRadioStations()
+	}))
+	m.HandleFunc(LibraryFavoritePath, s.makeLibraryEndpointHandler(func(mp mediaprovider.MediaProvider, q url.Values) (any, error) {
+		id, err := requiredParam(q, "id")
+		if err != nil {
+			return nil, err
+		}
+		fav, err := strconv.ParseBool(q.Get("f"))
+		if err != nil {
+			return nil, badRequest("invalid favorite value %q", q.Get("f"))
+		}
+		var params mediaprovider.RatingFavoriteParameters
+		switch q.Get("type") {
+		case FavoriteTypeTrack:
+			params.TrackIDs = []string{id}
+		case FavoriteTypeAlbum:
+			params.AlbumIDs = []string{id}
+		case FavoriteTypeArtist:
+			params.ArtistIDs = []string{id}
+		default:
+			return nil, badRequest("unknown item type %q", q.Get("type"))
+		}
+		if err := mp.SetFavorite(params, fav); err != nil {
+			return nil, err
+		}
+		if len(params.TrackIDs) > 0 {
+			// update the queue and notify listeners of the change
+			s.pbHandler.OnTrackFavoriteStatusChanged(id, fav)
+		}
+		return nil, nil
+	}))
+	m.HandleFunc(PlaylistCreatePath, s.makeLibraryEndpointHandler(func(mp mediaprovider.MediaProvider, q url.Values) (any, error) {
+		name, err := requiredParam(q, "name")
+		if err != nil {
+			return nil, err
+		}
+		public, _ := strconv.ParseBool(q.Get("public"))
+		if trackIDs := splitIDs(q.Get("tracks")); len(trackIDs) > 0 {
+			// servers create playlists with tracks by name only
+			if q.Get("desc") != "" || public {
+				return nil, badRequest("description and public can't be set when creating a playlist with tracks")
+			}
+			return nil, mp.CreatePlaylistWithTracks(name, trackIDs)
+		}
+		return nil, mp.CreatePlaylist(name, q.Get("desc"), public && mp.CanMakePublicPlaylist())
+	}))
+	m.HandleFunc(PlaylistAddTracksPath, s.makeLibraryEndpointHandler(func(mp mediaprovider.MediaProvider, q url.Values) (any, error) {
+		id, err := requiredParam(q, "id")
+		if err != nil {
+			return nil, err
+		}
+		trackIDs := splitIDs(q.Get("tracks"))
+		if len(trackIDs) == 0 {
+			return nil, badRequest("no tracks given")
+		}
+		return nil, mp.AddPlaylistTracks(id, trackIDs)
+	}))
+	m.HandleFunc(PlaylistRemoveTracksPath, s.makeLibraryEndpointHandler(func(mp mediaprovider.MediaProvider, q url.Values) (any, error) {
+		id, err := requiredParam(q, "id")
+		if err != nil {
+			return nil, err
+		}
+		pl, err := mp.GetPlaylist(id)
+		if err != nil {
+			return nil, err
+		}
+		idxs, err := parseIndexes(q.Get("idx"), len(pl.Tracks))
+		if err != nil {
+			return nil, badRequest("%s", err.Error())
+		}
+		return nil, mp.RemovePlaylistTracks(id, idxs)
+	}))
+}
+
+// makeLibraryEndpointHandler creates a handler for an endpoint that operates on the
+// current server. A nil result from f is written as an empty OK response.
+func (s *serverImpl) makeLibraryEndpointHandler(f func(mediaprovider.MediaProvider, url.Values) (any, error)) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		mp := s.sm.GetServer()
+		if mp == nil {
+			s.writeErr(w, ErrNoServerConnection)
+			return
+		}
+		data, err := f(mp, r.URL.Query())
+		if err != nil {
+			s.writeErr(w, err)
+			return
+		}
+		if data == nil {
+			s.writeOK(w)
+			return
+		}
+		s.writeJSON(w, data)
+	}
+}
+
+func requiredParam(q url.Values, name string) (string, error) {
+	v := q.Get(name)
+	if v == "" {
+		return "", badRequest("missing parameter %q", name)
+	}
+	return v, nil
+}
+
+func optionalIntParam(q url.Values, name string, def int) (int, error) {
+	v := q.Get(name)
+	if v == "" {
+		return def, nil
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil || i < 0 {
+		return 0, badRequest("invalid value %q for parameter %q", v, name)
+	}
+	return i, nil
+}
+
+func splitIDs(str string) []string {
+	var ids []string
+	for _, id := range strings.Split(str, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,65 @@
+package ipc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+// playlistTestProvider records the playlists created on it
+type playlistTestProvider struct {
+	mediaprovider.MediaProvider
+	created []string
+}
+
+func (p *playlistTestProvider) CreatePlaylistWithTracks(name string, trackIDs []string) error {
+	p.created = append(p.created, name)
+	return nil
+}
+
+func (p *playlistTestProvider) CreatePlaylist(name, description string, public bool) error {
+	p.created = append(p.created, name)
+	return nil
+}
+
+func (p *playlistTestProvider) CanMakePublicPlaylist() bool { return true }
+
+type testServerManager struct {
+	mp mediaprovider.MediaProvider
+}
+
+func (t testServerManager) GetServer() mediaprovider.MediaProvider { return t.mp }
+
+func TestPlaylistCreate(t *testing.T) {
+	mp := &playlistTestProvider{}
+	s := &serverImpl{sm: testServerManager{mp: mp}}
+	handler := http.NewServeMux()
+	s.addLibraryHandlers(handler)
+	create := func(name, desc string, public bool, trackIDs []string) int {
+		req := httptest.NewRequest(http.MethodGet, BuildPlaylistCreatePath(name, desc, public, trackIDs), nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := create("a", "desc", true, nil); code != http.StatusOK {
+		t.Errorf("expected playlist without tracks to be created, got %d", code)
+	}
+	if code := create("b", "", false, []string{"1", "2"}); code != http.StatusOK {
+		t.Errorf("expected playlist with tracks to be created, got %d", code)
+	}
+	if code := create("c", "desc", false, []string{"1"}); code != http.StatusBadRequest {
+		t.Errorf("expected description with tracks to be rejected, got %d", code)
+	}
+	if code := create("d", "", true, []string{"1"}); code != http.StatusBadRequest {
+		t.Errorf("expected public with tracks to be rejected, got %d", code)
+	}
+	if code := create("", "", false, nil); code != http.StatusBadRequest {
+		t.Errorf("expected missing name to be rejected, got %d", code)
+	}
+	if len(mp.created) != 2 || mp.created[0] != "a" || mp.created[1] != "b" {
+		t.Errorf("unexpected created playlists %v", mp.created)
+	}
+}
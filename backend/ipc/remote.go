@@ -0,0 +1,411 @@
+package ipc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io/fs"
+	"math"
+	"math/big"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// the number of wrong pairing codes, from any address, accepted before the code
+// is changed and pairing is locked for everyone for pairingLockout
+const maxFailedPairings = 5
+
+// the number of wrong pairing codes accepted from one address before it is locked out
+const maxFailedPairingsPerAddr = 3
+
+// how long pairing is locked after too many failures. Each further
+// lockout of the same address doubles it, up to maxPairingLockout.
+const (
+	pairingLockout    = 30 * time.Second
+	maxPairingLockout = time.Hour
+)
+
+// the number of failing addresses remembered before forgetting those no longer locked out
+const maxPairingAddrs = 1000
+
+//go:embed webremote
+var webRemoteFS embed.FS
+
+// remotePaths are the IPC API endpoints served to paired devices.
+// The window endpoints and OpenLinkPath stay local to this machine.
+var remotePaths = []string{
+	PingPath, PlayPath, PlayAlbumPath, PlayPlaylistPath, PlayTrackPath,
+	SearchAlbumPath, SearchPlaylistPath, SearchTrackPath, PlayPausePath,
+	PausePath, StopPath, PauseAfterCurrentPath, PreviousPath, NextPath,
+	PreviousChapterPath, NextChapterPath, TimePosPath, SeekByPath,
+	VolumePath, VolumeAdjustPath, CurrentTrackPath, RateCurrentTrackPath,
+	EventsPath, StatusPath, QueuePath, QueueEnqueuePath, QueueRemovePath,
+	QueueMovePath, QueueClearPath, QueueShufflePath, QueueLoopModePath,
+	LibraryArtistsPath, LibraryArtistPath, LibraryAlbumPath, LibraryGenresPath,
+	LibraryFavoritesPath, LibraryRandomTracksPath, LibraryRadioStationsPath,
+	LibraryFavoritePath, PlaylistCreatePath, PlaylistAddTracksPath,
+	PlaylistRemoveTracksPath,
+}
+
+// PairedDevice is a device that has been paired with the RemoteServer.
+// Only a hash of its token is kept.
+type PairedDevice struct {
+	ID        string
+	Name      string
+	TokenHash string
+	Paired    time.Time
+}
+
+type pairingFailures struct {
+	count       int
+	lockouts    int
+	lockedUntil time.Time
+}
+
+// RemoteServer serves the IPC API, along with a web remote control UI,
+// to other devices on the network. Each paired device authenticates with its
+// own token, sent in the Authorization header, which a browser can obtain by
+// entering the pairing code shown in the app.
+type RemoteServer struct {
+	server           *http.Server
+	api              http.Handler
+	coverFn          func(coverID string) (image.Image, error)
+	onDevicesChanged func([]PairedDevice)
+	now              func() time.Time
+
+	mutex          sync.Mutex
+	devices        []PairedDevice
+	conns          map[string]map[*http.Request]context.CancelFunc // by device ID
+	pairingCode    string
+	failedPairings int
+	lockedUntil    time.Time
+	addrFailures   map[string]*pairingFailures
+}
+
+// NewRemoteServer creates a RemoteServer for the API of the given IPC server,
+// accepting the given previously paired devices. onDevicesChanged, if non-nil,
+// is called with the new list when a device is paired or revoked.
+// coverFn is used to serve cover art to the web UI.
+func NewRemoteServer(ipcServer IPCServer, devices []PairedDevice, onDevicesChanged func([]PairedDevice), coverFn func(coverID string) (image.Image, error)) *RemoteServer {
+	r := &RemoteServer{
+		api:              ipcServer.Handler(),
+		coverFn:          coverFn,
+		onDevicesChanged: onDevicesChanged,
+		now:              time.Now,
+		devices:          slices.Clone(devices),
+		conns:            make(map[string]map[*http.Request]context.CancelFunc),
+		pairingCode:      newPairingCode(),
+		addrFailures:     make(map[string]*pairingFailures),
+	}
+	r.server = &http.Server{Handler: r.createHandler()}
+	return r
+}
+
+// Serve accepts remote connections on the listener, using TLS if cert is non-nil.
+func (r *RemoteServer) Serve(listener net.Listener, cert *tls.Certificate) error {
+	if cert != nil {
+		listener = tls.NewListener(listener, &tls.Config{
+			Certificates: []tls.Certificate{*cert},
+			MinVersion:   tls.VersionTLS12,
+		})
+	}
+	return r.server.Serve(listener)
+}
+
+// Shutdown stops the server. The event streams of remote clients
+// must have been ended by shutting down the IPC server first.
+func (r *RemoteServer) Shutdown(ctx context.Context) error {
+	return r.server.Shutdown(ctx)
+}
+
+// PairingCode returns the code to enter in the web UI to pair a new device.
+func (r *RemoteServer) PairingCode() string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.pairingCode
+}
+
+// Devices returns the currently paired devices.
+func (r *RemoteServer) Devices() []PairedDevice {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return slices.Clone(r.devices)
+}
+
+// RevokeDevice unpairs the device with the given ID and
+// closes any of its requests in progress, such as event streams.
+func (r *RemoteServer) RevokeDevice(id string) {
+	r.mutex.Lock()
+	i := slices.IndexFunc(r.devices, func(d PairedDevice) bool { return d.ID == id })
+	if i < 0 {
+		r.mutex.Unlock()
+		return
+	}
+	r.devices = slices.Delete(r.devices, i, i+1)
+	for _, cancel := range r.conns[id] {
+		cancel()
+	}
+	delete(r.conns, id)
+	devices := slices.Clone(r.devices)
+	r.mutex.Unlock()
+
+	if r.onDevicesChanged != nil {
+		r.onDevicesChanged(devices)
+	}
+}
+
+func (r *RemoteServer) createHandler() http.Handler {
+	m := http.NewServeMux()
+	ui, _ := fs.Sub(webRemoteFS, "webremote")
+	m.Handle(RemoteUIPath, http.StripPrefix(RemoteUIPath, http.FileServer(http.FS(ui))))
+	m.HandleFunc(RemotePairPath, r.handlePair)
+	m.Handle(CoverArtPath, r.requireAuth(http.HandlerFunc(r.handleCoverArt)))
+	for _, path := range remotePaths {
+		m.Handle(path, r.requireAuth(r.api))
+	}
+	m.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/" {
+			http.Redirect(w, req, RemoteUIPath, http.StatusFound)
+			return
+		}
+		http.NotFound(w, req)
+	})
+	return m
+}
+
+func (r *RemoteServer) requireAuth(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		id, ok := r.authorizedDevice(req)
+		if !ok {
+			writeRemoteErr(w, http.StatusUnauthorized, errors.New("not authorized"))
+			return
+		}
+		// track the request so revoking the device can end it
+		ctx, cancel := context.WithCancel(req.Context())
+		defer cancel()
+		r.mutex.Lock()
+		if r.conns[id] == nil {
+			r.conns[id] = make(map[*http.Request]context.CancelFunc)
+		}
+		r.conns[id][req] = cancel
+		r.mutex.Unlock()
+		defer func() {
+			r.mutex.Lock()
+			delete(r.conns[id], req)
+			if len(r.conns[id]) == 0 {
+				delete(r.conns, id)
+			}
+			r.mutex.Unlock()
+		}()
+		h.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
+
+// authorizedDevice returns the ID of the paired device whose token
+// is given in the request's Authorization header.
+func (r *RemoteServer) authorizedDevice(req *http.Request) (string, bool) {
+	token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return "", false
+	}
+	hash := []byte(hashToken(token))
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, d := range r.devices {
+		if subtle.ConstantTimeCompare(hash, []byte(d.TokenHash)) == 1 {
+			return d.ID, true
+		}
+	}
+	return "", false
+}
+
+func (r *RemoteServer) handlePair(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		writeRemoteErr(w, http.StatusMethodNotAllowed, errors.New("pairing requires POST"))
+		return
+	}
+	code := strings.TrimSpace(req.FormValue("code"))
+	addr := remoteAddr(req)
+
+	r.mutex.Lock()
+	now := r.now()
+	if wait := r.pairingLockedFor(addr, now); wait > 0 {
+		r.mutex.Unlock()
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		writeRemoteErr(w, http.StatusTooManyRequests, errors.New("too many failed pairing attempts, try again later"))
+		return
+	}
+	ok := code != "" && subtle.ConstantTimeCompare([]byte(code), []byte(r.pairingCode)) == 1
+	if !ok {
+		r.recordFailedPairing(addr, now)
+		r.mutex.Unlock()
+		writeRemoteErr(w, http.StatusUnauthorized, errors.New("invalid pairing code"))
+		return
+	}
+	// each code can only pair one device
+	r.pairingCode = newPairingCode()
+	r.failedPairings = 0
+	delete(r.addrFailures, addr)
+
+	token := NewRemoteToken()
+	r.devices = append(r.devices, PairedDevice{
+		ID:        newDeviceID(),
+		Name:      deviceName(req),
+		TokenHash: hashToken(token),
+		Paired:    now,
+	})
+	devices := slices.Clone(r.devices)
+	r.mutex.Unlock()
+
+	if r.onDevicesChanged != nil {
+		r.onDevicesChanged(devices)
+	}
+	writeRemoteJSON(w, PairResponse{Token: token})
+}
+
+// pairingLockedFor returns how long pairing from addr is locked out.
+// Must be called with the mutex held.
+func (r *RemoteServer) pairingLockedFor(addr string, now time.Time) time.Duration {
+	until := r.lockedUntil
+	if f := r.addrFailures[addr]; f != nil && f.lockedUntil.After(until) {
+		until = f.lockedUntil
+	}
+	return until.Sub(now)
+}
+
+// recordFailedPairing counts a wrong pairing code from addr and locks out
+// the address, or all pairing, when there have been too many.
+// Must be called with the mutex held.
+func (r *RemoteServer) recordFailedPairing(addr string, now time.Time) {
+	if r.failedPairings++; r.failedPairings >= maxFailedPairings {
+		// stop guessing by requiring the user to look up the new code
+		r.pairingCode = newPairingCode()
+		r.failedPairings = 0
+		r.lockedUntil = now.Add(pairingLockout)
+	}
+
+	f := r.addrFailures[addr]
+	if f == nil {
+		if len(r.addrFailures) >= maxPairingAddrs {
+			for a, f := range r.addrFailures {
+				if !f.lockedUntil.After(now) {
+					delete(r.addrFailures, a)
+				}
+			}
+		}
+		f = &pairingFailures{}
+		r.addrFailures[addr] = f
+	}
+	if f.count++; f.count >= maxFailedPairingsPerAddr {
+		lockout := min(pairingLockout<<f.lockouts, maxPairingLockout)
+		f.lockedUntil = now.Add(lockout)
+		f.count = 0
+		f.lockouts++
+	}
+}
+
+func (r *RemoteServer) handleCoverArt(w http.ResponseWriter, req *http.Request) {
+	id := req.URL.Query().Get("id")
+	if id == "" {
+		writeRemoteErr(w, http.StatusBadRequest, errors.New("missing parameter \"id\""))
+		return
+	}
+	img, err := r.coverFn(id)
+	if err != nil {
+		writeRemoteErr(w, http.StatusNotFound, err)
+		return
+	}
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	jpeg.Encode(w, img, &jpeg.Options{Quality: 85})
+}
+
+func writeRemoteJSON(w http.ResponseWriter, data any) {
+	b, _ := json.Marshal(data)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Response{Data: b})
+}
+
+func writeRemoteErr(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Response{Error: err.Error()})
+}
+
+// NewRemoteToken generates a new random token for authenticating remote clients.
+func NewRemoteToken() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func hashToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
+
+func newDeviceID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// deviceName returns the name given by the client when pairing,
+// falling back to its user agent.
+func deviceName(req *http.Request) string {
+	name := strings.TrimSpace(req.FormValue("name"))
+	if name == "" {
+		name = req.UserAgent()
+	}
+	if name == "" {
+		name = "Unknown device"
+	}
+	if r := []rune(name); len(r) > 64 {
+		name = string(r[:64])
+	}
+	return name
+}
+
+func remoteAddr(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
+func newPairingCode() string {
+	n, _ := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	return fmt.Sprintf("%06d", n.Int64())
+}
+
+// LocalAddresses returns the non-loopback IP addresses of this machine,
+// on which remote clients may be able to reach the RemoteServer.
+func LocalAddresses() []string {
+	var addrs []string
+	ifaceAddrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil
+	}
+	for _, a := range ifaceAddrs {
+		if ipNet, ok := a.(*net.IPNet); ok && !ipNet.IP.IsLoopback() && !ipNet.IP.IsLinkLocalUnicast() {
+			addrs = append(addrs, ipNet.IP.String())
+		}
+	}
+	return addrs
+}
@@ -0,0 +1,137 @@
+package ipc
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeIPCServer struct {
+	handler http.Handler
+}
+
+func (f *fakeIPCServer) Serve(net.Listener) error       { return nil }
+func (f *fakeIPCServer) Shutdown(context.Context) error { return nil }
+func (f *fakeIPCServer) PublishEvent(string, any)       {}
+func (f *fakeIPCServer) Handler() http.Handler          { return f.handler }
+
+func newTestRemoteServer(t *testing.T) (*RemoteServer, *time.Time) {
+	t.Helper()
+	api := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := NewRemoteServer(&fakeIPCServer{handler: api}, nil, nil, nil)
+	r.now = func() time.Time { return now }
+	return r, &now
+}
+
+func pair(r *RemoteServer, addr, code string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, RemotePairPath,
+		strings.NewReader(url.Values{"code": {code}, "name": {"Phone"}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.RemoteAddr = addr + ":1234"
+	w := httptest.NewRecorder()
+	r.server.Handler.ServeHTTP(w, req)
+	return w
+}
+
+func get(r *RemoteServer, path, token string) int {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.server.Handler.ServeHTTP(w, req)
+	return w.Code
+}
+
+func pairedToken(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var resp Response
+	var pr PairResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(resp.Data, &pr); err != nil {
+		t.Fatal(err)
+	}
+	return pr.Token
+}
+
+func TestRemotePairingAndRevocation(t *testing.T) {
+	r, _ := newTestRemoteServer(t)
+
+	w := pair(r, "10.0.0.2", r.PairingCode())
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected pairing to succeed, got %d", w.Code)
+	}
+	token := pairedToken(t, w)
+	devices := r.Devices()
+	if len(devices) != 1 || devices[0].Name != "Phone" || devices[0].TokenHash == token {
+		t.Fatalf("unexpected paired devices %+v", devices)
+	}
+
+	if code := get(r, StatusPath, token); code != http.StatusOK {
+		t.Errorf("expected paired device to be authorized, got %d", code)
+	}
+	if code := get(r, StatusPath+"?token="+token, ""); code != http.StatusUnauthorized {
+		t.Errorf("expected token in query not to be accepted, got %d", code)
+	}
+	for _, path := range []string{QuitPath, ShowPath, ReloadThemePath, OpenLinkPath} {
+		if code := get(r, path, token); code != http.StatusNotFound {
+			t.Errorf("expected %s not to be served remotely, got %d", path, code)
+		}
+	}
+
+	r.RevokeDevice(devices[0].ID)
+	if code := get(r, StatusPath, token); code != http.StatusUnauthorized {
+		t.Errorf("expected revoked device not to be authorized, got %d", code)
+	}
+}
+
+func TestRemotePairingLockout(t *testing.T) {
+	r, now := newTestRemoteServer(t)
+
+	for i := 0; i < maxFailedPairingsPerAddr; i++ {
+		if w := pair(r, "10.0.0.2", "wrong"); w.Code != http.StatusUnauthorized {
+			t.Fatalf("expected wrong code to be rejected, got %d", w.Code)
+		}
+	}
+	w := pair(r, "10.0.0.2", r.PairingCode())
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "30" {
+		t.Fatalf("expected address to be locked out for 30s, got %d %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	// other addresses can still pair
+	if w := pair(r, "10.0.0.3", r.PairingCode()); w.Code != http.StatusOK {
+		t.Fatalf("expected other address to pair, got %d", w.Code)
+	}
+
+	// the lockout doubles each time
+	*now = now.Add(pairingLockout)
+	for i := 0; i < maxFailedPairingsPerAddr; i++ {
+		pair(r, "10.0.0.2", "wrong")
+	}
+	if w := pair(r, "10.0.0.2", r.PairingCode()); w.Header().Get("Retry-After") != "60" {
+		t.Errorf("expected second lockout of 60s, got %q", w.Header().Get("Retry-After"))
+	}
+
+	// failures from many addresses change the code and lock out everyone
+	*now = now.Add(2 * pairingLockout)
+	code := r.PairingCode()
+	for i := 0; i < maxFailedPairings; i++ {
+		pair(r, "10.0.1."+string(rune('0'+i)), "wrong")
+	}
+	if r.PairingCode() == code {
+		t.Error("expected pairing code to change")
+	}
+	if w := pair(r, "10.0.0.4", r.PairingCode()); w.Code != http.StatusTooManyRequests {
+		t.Errorf("expected pairing to be locked for everyone, got %d", w.Code)
+	}
+}
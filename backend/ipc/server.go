@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -21,6 +23,8 @@ type PlaybackHandler interface {
 	Continue()
 	SeekBackOrPrevious()
 	SeekNext()
+	SeekPreviousChapter()
+	SeekNextChapter()
 	SetPauseAfterCurrent(bool)
 	SeekSeconds(float64)
 	SeekBySeconds(float64)
@@ -30,11 +34,32 @@ type PlaybackHandler interface {
 	PlayPlaylist(string, int, bool) error
 	PlayTrack(string) error
 	NowPlaying() mediaprovider.MediaItem
+	OnTrackFavoriteStatusChanged(id string, fav bool)
+	Status() StatusResponse
+}
+
+// QueueHandler manipulates the play queue. Indexes refer to the
+// active (shuffled or unshuffled) queue.
+type QueueHandler interface {
+	Queue() QueueResponse
+	Enqueue(itemType, id string, next bool) error
+	RemoveFromQueue(idxs []int)
+	MoveInQueue(idxs []int, insertIdx int) error
+	ClearQueue()
+	SetShuffle(bool)
+	IsShuffle() bool
+	SetLoopMode(mode string) error
 }
 
 type IPCServer interface {
 	Serve(net.Listener) error
 	Shutdown(context.Context) error
+	// PublishEvent sends an event of one of the Event* types to all
+	// clients listening on the EventsPath stream. Data is marshaled to JSON.
+	PublishEvent(eventType string, data any)
+	// Handler returns the HTTP handler serving the IPC API,
+	// so that it can also be served by a RemoteServer.
+	Handler() http.Handler
 }
 
 type ServerManager interface {
@@ -44,20 +69,25 @@ type ServerManager interface {
 type serverImpl struct {
 	server        *http.Server
 	pbHandler     PlaybackHandler
+	queueHandler  QueueHandler
 	rateFn        func(int)
 	sm            ServerManager
 	showFn        func()
 	quitFn        func()
 	reloadThemeFn func()
+	openLinkFn    func(string) error
+	events        *eventBroker
 }
 
 func NewServer(
 	pbHandler PlaybackHandler,
+	queueHandler QueueHandler,
 	rateFn func(int),
 	sm ServerManager,
 	showFn, quitFn, reloadThemeFn func(),
+	openLinkFn func(string) error,
 ) IPCServer {
-	s := &serverImpl{pbHandler: pbHandler, rateFn: rateFn, sm: sm, showFn: showFn, quitFn: quitFn, reloadThemeFn: reloadThemeFn}
+	s := &serverImpl{pbHandler: pbHandler, queueHandler: queueHandler, rateFn: rateFn, sm: sm, showFn: showFn, quitFn: quitFn, reloadThemeFn: reloadThemeFn, openLinkFn: openLinkFn, events: newEventBroker()}
 	s.server = &http.Server{
 		Handler: s.createHandler(),
 	}
@@ -69,11 +99,21 @@ func (s *serverImpl) Serve(listener net.Listener) error {
 }
 
 func (s *serverImpl) Shutdown(ctx context.Context) error {
+	// event streams never go idle, so they must be ended first
+	s.events.close()
 	err := s.server.Shutdown(ctx)
 	DestroyConn()
 	return err
 }
 
+func (s *serverImpl) PublishEvent(eventType string, data any) {
+	s.events.publish(eventType, data)
+}
+
+func (s *serverImpl) Handler() http.Handler {
+	return s.server.Handler
+}
+
 func (s *serverImpl) createHandler() http.Handler {
 	m := http.NewServeMux()
 	m.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -88,6 +128,13 @@ func (s *serverImpl) createHandler() http.Handler {
 	m.HandleFunc(QuitPath, s.makeSimpleEndpointHandler(func() {
 		s.quitFn()
 	}))
+	m.HandleFunc(OpenLinkPath, func(w http.ResponseWriter, r *http.Request) {
+		if err := s.openLinkFn(r.URL.Query().Get("url")); err != nil {
+			s.writeErr(w, err)
+			return
+		}
+		s.writeOK(w)
+	})
 	m.HandleFunc(PlayPath, s.makeSimpleEndpointHandler(s.pbHandler.Continue))
 	m.HandleFunc(PausePath, s.makeSimpleEndpointHandler(s.pbHandler.Pause))
 	m.HandleFunc(PlayPausePath, s.makeSimpleEndpointHandler(s.pbHandler.PlayPause))
@@ -97,6 +144,8 @@ func (s *serverImpl) createHandler() http.Handler {
 	}))
 	m.HandleFunc(PreviousPath, s.makeSimpleEndpointHandler(s.pbHandler.SeekBackOrPrevious))
 	m.HandleFunc(NextPath, s.makeSimpleEndpointHandler(s.pbHandler.SeekNext))
+	m.HandleFunc(PreviousChapterPath, s.makeSimpleEndpointHandler(s.pbHandler.SeekPreviousChapter))
+	m.HandleFunc(NextChapterPath, s.makeSimpleEndpointHandler(s.pbHandler.SeekNextChapter))
 	m.HandleFunc(TimePosPath, s.makeFloatEndpointHandler("s", s.pbHandler.SeekSeconds))
 	m.HandleFunc(SeekByPath, s.makeFloatEndpointHandler("s", s.pbHandler.SeekBySeconds))
 	m.HandleFunc(VolumePath, func(w http.ResponseWriter, r *http.Request) {
@@ -188,6 +237,70 @@ func (s *serverImpl) createHandler() http.Handler {
 		}
 		return track.Metadata(), nil
 	}))
+	m.HandleFunc(EventsPath, s.handleEvents)
+	m.HandleFunc(StatusPath, s.makeStatusEndpointHandler(func() (any, error) {
+		return s.pbHandler.Status(), nil
+	}))
+	m.HandleFunc(QueuePath, s.makeStatusEndpointHandler(func() (any, error) {
+		return s.queueHandler.Queue(), nil
+	}))
+	m.HandleFunc(QueueEnqueuePath, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		next, _ := strconv.ParseBool(query.Get("next"))
+		if err := s.queueHandler.Enqueue(query.Get("type"), query.Get("id"), next); err != nil {
+			s.writeErr(w, err)
+			return
+		}
+		s.writeOK(w)
+	})
+	m.HandleFunc(QueueRemovePath, func(w http.ResponseWriter, r *http.Request) {
+		idxs, err := parseIndexes(r.URL.Query().Get("idx"), len(s.queueHandler.Queue().Items))
+		if err != nil {
+			s.writeErr(w, err)
+			return
+		}
+		s.queueHandler.RemoveFromQueue(idxs)
+		s.writeOK(w)
+	})
+	m.HandleFunc(QueueMovePath, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		idxs, err := parseIndexes(query.Get("idx"), len(s.queueHandler.Queue().Items))
+		if err != nil {
+			s.writeErr(w, err)
+			return
+		}
+		to, err := strconv.Atoi(query.Get("to"))
+		if err != nil {
+			s.writeErr(w, err)
+			return
+		}
+		if err := s.queueHandler.MoveInQueue(idxs, to); err != nil {
+			s.writeErr(w, err)
+			return
+		}
+		s.writeOK(w)
+	})
+	m.HandleFunc(QueueClearPath, s.makeSimpleEndpointHandler(s.queueHandler.ClearQueue))
+	m.HandleFunc(QueueShufflePath, func(w http.ResponseWriter, r *http.Request) {
+		shuffle := !s.queueHandler.IsShuffle()
+		if v := r.URL.Query().Get("s"); v != "" {
+			var err error
+			if shuffle, err = strconv.ParseBool(v); err != nil {
+				s.writeErr(w, err)
+				return
+			}
+		}
+		s.queueHandler.SetShuffle(shuffle)
+		s.writeOK(w)
+	})
+	m.HandleFunc(QueueLoopModePath, func(w http.ResponseWriter, r *http.Request) {
+		if err := s.queueHandler.SetLoopMode(r.URL.Query().Get("m")); err != nil {
+			s.writeErr(w, err)
+			return
+		}
+		s.writeOK(w)
+	})
+	s.addLibraryHandlers(m)
 	m.HandleFunc(RateCurrentTrackPath, func(w http.ResponseWriter, r *http.Request) {
 		v := r.URL.Query().Get("r")
 		if rating, err := strconv.Atoi(v); err == nil {
@@ -206,6 +319,27 @@ func (s *serverImpl) createHandler() http.Handler {
 	return m
 }
 
+// parseIndexes parses a comma-separated list of unique indexes in the range [0, length)
+func parseIndexes(str string, length int) ([]int, error) {
+	if str == "" {
+		return nil, errors.New("no indexes given")
+	}
+	var idxs []int
+	for _, s := range strings.Split(str, ",") {
+		idx, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			return nil, err
+		}
+		if idx < 0 || idx >= length {
+			return nil, fmt.Errorf("index %d out of range", idx)
+		}
+		if !slices.Contains(idxs, idx) {
+			idxs = append(idxs, idx)
+		}
+	}
+	return idxs, nil
+}
+
 func (s *serverImpl) makeSimpleEndpointHandler(f func()) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		f()
@@ -294,12 +428,36 @@ func (s *serverImpl) writeData(w http.ResponseWriter, data []byte) (int, error)
 	return w.Write(b)
 }
 
+func (s *serverImpl) writeJSON(w http.ResponseWriter, data any) (int, error) {
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		return s.writeErr(w, err)
+	}
+	return s.writeData(w, bytes)
+}
+
 func (s *serverImpl) writeErr(w http.ResponseWriter, err error) (int, error) {
 	r := Response{Error: err.Error()}
-	b, err := json.Marshal(&r)
-	if err != nil {
-		return 0, err
+	b, mErr := json.Marshal(&r)
+	if mErr != nil {
+		return 0, mErr
 	}
-	w.WriteHeader(http.StatusInternalServerError)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(errorStatusCode(err))
 	return w.Write(b)
 }
+
+// errorStatusCode returns the HTTP status code to respond with for the given error
+func errorStatusCode(err error) int {
+	var badReq *badRequestError
+	switch {
+	case errors.As(err, &badReq):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrNoServerConnection):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, ErrNotSupported):
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,135 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dweymouth/supersonic/backend/ipc"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/player"
+	"github.com/dweymouth/supersonic/sharedutil"
+)
+
+var (
+	_ ipc.PlaybackHandler = (*ipcPlaybackHandler)(nil)
+	_ ipc.QueueHandler    = (*ipcQueueHandler)(nil)
+)
+
+// ipcPlaybackHandler adds the IPC status report to the PlaybackManager
+type ipcPlaybackHandler struct {
+	*PlaybackManager
+}
+
+func (p ipcPlaybackHandler) Status() ipc.StatusResponse {
+	stat := p.PlaybackStatus()
+	resp := ipc.StatusResponse{
+		State:    ipc.StateStopped,
+		TimePos:  stat.TimePos,
+		Duration: stat.Duration,
+		Volume:   p.Volume(),
+	}
+	switch stat.State {
+	case player.Playing:
+		resp.State = ipc.StatePlaying
+	case player.Paused:
+		resp.State = ipc.StatePaused
+	}
+	if item := p.NowPlaying(); item != nil {
+		meta := item.Metadata()
+		resp.NowPlaying = &meta
+	}
+	return resp
+}
+
+// ipcQueueHandler implements ipc.QueueHandler on top of the PlaybackManager
+type ipcQueueHandler struct {
+	pm *PlaybackManager
+	sm *ServerManager
+}
+
+func (q *ipcQueueHandler) Queue() ipc.QueueResponse {
+	items := q.pm.GetActivePlayQueue()
+	return ipc.QueueResponse{
+		Items: sharedutil.MapSlice(items, func(item mediaprovider.MediaItem) mediaprovider.MediaItemMetadata {
+			return item.Metadata()
+		}),
+		NowPlayingIndex: q.pm.NowPlayingIndex(),
+		Shuffle:         q.pm.IsShuffle(),
+		LoopMode:        loopModeToIPC(q.pm.GetLoopMode()),
+	}
+}
+
+func (q *ipcQueueHandler) Enqueue(itemType, id string, next bool) error {
+	if q.sm.Server == nil {
+		return ipc.ErrNoServerConnection
+	}
+	mode := Append
+	if next {
+		mode = InsertNext
+	}
+	switch itemType {
+	case ipc.EnqueueTypeTrack:
+		tr, err := q.sm.Server.GetTrack(id)
+		if err != nil {
+			return err
+		}
+		q.pm.LoadTracks([]*mediaprovider.Track{tr}, mode, false)
+		return nil
+	case ipc.EnqueueTypeAlbum:
+		return q.pm.LoadAlbum(id, mode, false)
+	case ipc.EnqueueTypePlaylist:
+		return q.pm.LoadPlaylist(id, mode, false)
+	default:
+		return fmt.Errorf("unknown item type %q", itemType)
+	}
+}
+
+func (q *ipcQueueHandler) RemoveFromQueue(idxs []int) {
+	q.pm.RemoveTracksFromQueue(idxs)
+}
+
+func (q *ipcQueueHandler) MoveInQueue(idxs []int, insertIdx int) error {
+	items := q.pm.GetActivePlayQueue()
+	if insertIdx < 0 || insertIdx > len(items) {
+		return errors.New("move destination out of range")
+	}
+	q.pm.UpdatePlayQueue(sharedutil.ReorderItems(items, idxs, insertIdx))
+	return nil
+}
+
+func (q *ipcQueueHandler) ClearQueue() {
+	q.pm.StopAndClearPlayQueue()
+}
+
+func (q *ipcQueueHandler) SetShuffle(shuffle bool) {
+	q.pm.SetShuffle(shuffle)
+}
+
+func (q *ipcQueueHandler) IsShuffle() bool {
+	return q.pm.IsShuffle()
+}
+
+func (q *ipcQueueHandler) SetLoopMode(mode string) error {
+	switch mode {
+	case ipc.LoopModeNone:
+		q.pm.SetLoopMode(LoopNone)
+	case ipc.LoopModeAll:
+		q.pm.SetLoopMode(LoopAll)
+	case ipc.LoopModeOne:
+		q.pm.SetLoopMode(LoopOne)
+	default:
+		return fmt.Errorf("unknown loop mode %q", mode)
+	}
+	return nil
+}
+
+func loopModeToIPC(mode LoopMode) string {
+	switch mode {
+	case LoopAll:
+		return ipc.LoopModeAll
+	case LoopOne:
+		return ipc.LoopModeOne
+	default:
+		return ipc.LoopModeNone
+	}
+}
@@ -0,0 +1,431 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-audio/audio"
+	"github.com/go-audio/wav"
+)
+
+const (
+	loudnessFile = "loudness.json"
+
+	// tracks are resampled for analysis, so the
+	// K-weighting filters only depend on one sample rate
+	loudnessAnalysisSampleRate = 44100
+
+	// how long to wait for a track to finish downloading to the audio cache
+	loudnessDownloadTimeout = 5 * time.Minute
+
+	// oversampling factor and filter length per phase for measuring true peaks
+	truePeakOversample = 4
+	truePeakTaps       = 12
+)
+
+var errNotCached = errors.New("track is not in the audio cache")
+
+// LoudnessAnalyzer measures the integrated loudness (EBU R128) and true peak
+// of tracks in the audio cache, so that tracks without ReplayGain tags can be
+// normalized. The results are persisted per server and track ID.
+type LoudnessAnalyzer struct {
+	ctx      context.Context
+	cache    *AudioCache
+	filePath string
+
+	mutex   sync.Mutex
+	results map[string]loudnessResult // by server/track key
+	pending []string                  // keys of tracks waiting to be analyzed
+	trigger chan struct{}
+}
+
+func NewLoudnessAnalyzer(ctx context.Context, cache *AudioCache, configDir string) *LoudnessAnalyzer {
+	l := &LoudnessAnalyzer{
+		ctx:      ctx,
+		cache:    cache,
+		filePath: filepath.Join(configDir, loudnessFile),
+		results:  make(map[string]loudnessResult),
+		trigger:  make(chan struct{}, 1),
+	}
+	if b, err := os.ReadFile(l.filePath); err == nil {
+		if err := json.Unmarshal(b, &l.results); err != nil {
+			log.Printf("error reading loudness data: %v", err)
+		}
+	}
+	go l.run()
+	return l
+}
+
+type loudnessResult struct {
+	LUFS float64
+	Peak float64 // true peak, linear; 0 if not measured
+}
+
+// UnmarshalJSON also accepts a bare LUFS number,
+// as saved before true peaks were measured.
+func (r *loudnessResult) UnmarshalJSON(b []byte) error {
+	var lufs float64
+	if err := json.Unmarshal(b, &lufs); err == nil {
+		*r = loudnessResult{LUFS: lufs}
+		return nil
+	}
+	type result loudnessResult
+	return json.Unmarshal(b, (*result)(r))
+}
+
+func loudnessKey(serverID, trackID string) string {
+	return serverID + "/" + trackID
+}
+
+// Loudness returns the integrated loudness in LUFS and the linear
+// true peak of the track, if it has been analyzed.
+func (l *LoudnessAnalyzer) Loudness(serverID, trackID string) (lufs, peak float64, ok bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	r, ok := l.results[loudnessKey(serverID, trackID)]
+	if !ok || r.Peak <= 0 {
+		return 0, 0, false
+	}
+	return r.LUFS, r.Peak, true
+}
+
+// Analyze queues the track for analysis once it has been downloaded
+// to the audio cache, unless it has been analyzed already.
+func (l *LoudnessAnalyzer) Analyze(serverID, trackID string) {
+	key := loudnessKey(serverID, trackID)
+	l.mutex.Lock()
+	// results without a peak are from an older version and measured again
+	analyzed := l.results[key].Peak > 0
+	if !analyzed && !slices.Contains(l.pending, key) {
+		l.pending = append(l.pending, key)
+	}
+	l.mutex.Unlock()
+
+	select {
+	case l.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// analyzes queued tracks one at a time
+func (l *LoudnessAnalyzer) run() {
+	for {
+		select {
+		case <-l.ctx.Done():
+			return
+		case <-l.trigger:
+		}
+		for {
+			l.mutex.Lock()
+			if len(l.pending) == 0 {
+				l.mutex.Unlock()
+				break
+			}
+			key := l.pending[0]
+			l.pending = l.pending[1:]
+			l.mutex.Unlock()
+
+			_, trackID, _ := strings.Cut(key, "/")
+			result, err := l.analyzeTrack(trackID)
+			if err != nil {
+				if l.ctx.Err() == nil && err != errNotCached {
+					log.Printf("error analyzing loudness of track %s: %v", trackID, err)
+				}
+				continue
+			}
+			l.mutex.Lock()
+			l.results[key] = result
+			b, _ := json.Marshal(l.results)
+			l.mutex.Unlock()
+			if err := os.WriteFile(l.filePath, b, 0644); err != nil {
+				log.Printf("error saving loudness data: %v", err)
+			}
+		}
+	}
+}
+
+func (l *LoudnessAnalyzer) analyzeTrack(trackID string) (loudnessResult, error) {
+	ctx, cancel := context.WithTimeout(l.ctx, loudnessDownloadTimeout)
+	defer cancel()
+
+	path := l.cache.ObtainReferenceToFile(trackID)
+	if path == "" {
+		// removed from the cache before it could be analyzed
+		return loudnessResult{}, errNotCached
+	}
+	defer l.cache.ReleaseReferenceToFile(trackID)
+	for !l.cache.IsFullyDownloaded(trackID) {
+		select {
+		case <-ctx.Done():
+			return loudnessResult{}, ctx.Err()
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+
+	wavFile := path + "_loudness.wav"
+	defer os.Remove(wavFile)
+	if err := convertToWav(l.ctx, path, wavFile, loudnessAnalysisSampleRate, "stereo"); err != nil {
+		return loudnessResult{}, err
+	}
+	f, err := os.Open(wavFile)
+	if err != nil {
+		return loudnessResult{}, err
+	}
+	defer f.Close()
+	return measureWavLoudness(f)
+}
+
+// measureWavLoudness returns the integrated loudness and true peak of a 16 bit WAV file.
+func measureWavLoudness(r io.ReadSeeker) (loudnessResult, error) {
+	decoder := wav.NewDecoder(r)
+	if !decoder.IsValidFile() {
+		return loudnessResult{}, errors.New("invalid wav file")
+	}
+	if err := decoder.FwdToPCM(); err != nil {
+		return loudnessResult{}, err
+	}
+	format := decoder.Format()
+	meter := newLoudnessMeter(format.NumChannels, format.SampleRate)
+	peak := newTruePeakMeter(format.NumChannels)
+
+	buf := audioBufferPool.Get().(*audio.IntBuffer)
+	defer audioBufferPool.Put(buf)
+	buf.Data = buf.Data[:cap(buf.Data)]
+	samples := make([]float64, len(buf.Data))
+	for {
+		n, err := decoder.PCMBuffer(buf)
+		if err != nil && err != io.EOF {
+			return loudnessResult{}, err
+		}
+		if n == 0 {
+			break
+		}
+		for i := range n {
+			samples[i] = float64(buf.Data[i]) / float64(1<<15)
+		}
+		meter.Add(samples[:n])
+		peak.Add(samples[:n])
+	}
+	lufs := meter.Integrated()
+	if math.IsInf(lufs, -1) {
+		return loudnessResult{}, fmt.Errorf("track is silent")
+	}
+	return loudnessResult{LUFS: lufs, Peak: peak.Peak()}, nil
+}
+
+// truePeakMeter estimates the true (inter-sample) peak level of
+// a signal by oversampling it, as in ITU-R BS.1770-4 annex 2.
+type truePeakMeter struct {
+	channels int
+	history  [][]float64 // last truePeakTaps samples of each channel, newest first
+	peak     float64
+}
+
+// truePeakFilter is the windowed sinc interpolation filter,
+// split into the phases of each oversampled point.
+var truePeakFilter = func() [truePeakOversample][truePeakTaps]float64 {
+	var phases [truePeakOversample][truePeakTaps]float64
+	const n = truePeakOversample * truePeakTaps
+	for ph := range truePeakOversample {
+		var sum float64
+		for k := range truePeakTaps {
+			i := k*truePeakOversample + ph
+			x := (float64(i) - (n-1)/2.0) / truePeakOversample
+			h := 1.0
+			if x != 0 {
+				h = math.Sin(math.Pi*x) / (math.Pi * x)
+			}
+			// Hann window
+			h *= 0.5 - 0.5*math.Cos(2*math.Pi*(float64(i)+0.5)/n)
+			phases[ph][k] = h
+			sum += h
+		}
+		// unity gain at DC for every phase
+		for k := range truePeakTaps {
+			phases[ph][k] /= sum
+		}
+	}
+	return phases
+}()
+
+func newTruePeakMeter(channels int) *truePeakMeter {
+	m := &truePeakMeter{channels: channels, history: make([][]float64, channels)}
+	for i := range m.history {
+		m.history[i] = make([]float64, truePeakTaps)
+	}
+	return m
+}
+
+// Add processes interleaved samples in the range [-1, 1].
+func (m *truePeakMeter) Add(samples []float64) {
+	for i, s := range samples {
+		h := m.history[i%m.channels]
+		copy(h[1:], h)
+		h[0] = s
+		for _, phase := range truePeakFilter {
+			var y float64
+			for k, c := range phase {
+				y += c * h[k]
+			}
+			m.peak = max(m.peak, math.Abs(y))
+		}
+	}
+}
+
+// Peak returns the highest true peak level seen, linear.
+func (m *truePeakMeter) Peak() float64 {
+	return m.peak
+}
+
+// loudnessMeter computes the integrated loudness
+// of an audio signal as specified by ITU-R BS.1770-4.
+type loudnessMeter struct {
+	channels int
+	filters  []kWeightingFilter // per channel
+
+	subBlockLen    int       // samples per channel in a 100ms sub-block
+	subBlockPos    int       // samples of the current sub-block processed
+	subBlockEnergy float64   // sum of squared filtered samples of the current sub-block
+	subBlocks      []float64 // mean square of the last 3 sub-blocks
+	blocks         []float64 // mean square of each 400ms gating block
+}
+
+func newLoudnessMeter(channels, sampleRate int) *loudnessMeter {
+	m := &loudnessMeter{
+		channels:    channels,
+		filters:     make([]kWeightingFilter, channels),
+		subBlockLen: sampleRate / 10,
+	}
+	for i := range m.filters {
+		m.filters[i] = newKWeightingFilter(float64(sampleRate))
+	}
+	return m
+}
+
+// Add processes interleaved samples in the range [-1, 1].
+func (m *loudnessMeter) Add(samples []float64) {
+	for i, s := range samples {
+		ch := i % m.channels
+		f := m.filters[ch].process(s)
+		// the channel weights of the surround channels don't apply to mono and stereo
+		m.subBlockEnergy += f * f
+		if ch < m.channels-1 {
+			continue
+		}
+		m.subBlockPos++
+		if m.subBlockPos == m.subBlockLen {
+			m.endSubBlock()
+		}
+	}
+}
+
+func (m *loudnessMeter) endSubBlock() {
+	ms := m.subBlockEnergy / float64(m.subBlockLen)
+	m.subBlockEnergy, m.subBlockPos = 0, 0
+	if len(m.subBlocks) == 3 {
+		// gating blocks are 400ms long and overlap by 75%
+		m.blocks = append(m.blocks, (m.subBlocks[0]+m.subBlocks[1]+m.subBlocks[2]+ms)/4)
+		m.subBlocks = m.subBlocks[1:]
+	}
+	m.subBlocks = append(m.subBlocks, ms)
+}
+
+// Integrated returns the gated integrated loudness in LUFS,
+// or -Inf if the signal is silent or shorter than 400ms.
+func (m *loudnessMeter) Integrated() float64 {
+	const absoluteGate = -70 // LUFS
+	const relativeGate = -10 // LU
+
+	gatedMean := func(threshold float64) float64 {
+		var sum float64
+		var n int
+		for _, b := range m.blocks {
+			if blockLoudness(b) > threshold {
+				sum += b
+				n++
+			}
+		}
+		if n == 0 {
+			return 0
+		}
+		return sum / float64(n)
+	}
+	mean := gatedMean(absoluteGate)
+	if mean == 0 {
+		return math.Inf(-1)
+	}
+	return blockLoudness(gatedMean(blockLoudness(mean) + relativeGate))
+}
+
+func blockLoudness(meanSquare float64) float64 {
+	return -0.691 + 10*math.Log10(meanSquare)
+}
+
+// kWeightingFilter is the BS.1770 "K" frequency weighting:
+// a high shelf modeling the acoustic effect of the head,
+// followed by a high pass (the "RLB" weighting curve).
+type kWeightingFilter struct {
+	shelf, highPass biquad
+}
+
+// newKWeightingFilter computes the filter coefficients for the sample rate,
+// which BS.1770 only specifies for 48 kHz (as in libebur128).
+func newKWeightingFilter(sampleRate float64) kWeightingFilter {
+	var k kWeightingFilter
+
+	f0 := 1681.974450955533
+	G := 3.999843853973347
+	Q := 0.7071752369554196
+	K := math.Tan(math.Pi * f0 / sampleRate)
+	Vh := math.Pow(10, G/20)
+	Vb := math.Pow(Vh, 0.4996667741545416)
+	a0 := 1 + K/Q + K*K
+	k.shelf = biquad{
+		b0: (Vh + Vb*K/Q + K*K) / a0,
+		b1: 2 * (K*K - Vh) / a0,
+		b2: (Vh - Vb*K/Q + K*K) / a0,
+		a1: 2 * (K*K - 1) / a0,
+		a2: (1 - K/Q + K*K) / a0,
+	}
+
+	f0 = 38.13547087602444
+	Q = 0.5003270373238773
+	K = math.Tan(math.Pi * f0 / sampleRate)
+	a0 = 1 + K/Q + K*K
+	k.highPass = biquad{
+		b0: 1,
+		b1: -2,
+		b2: 1,
+		a1: 2 * (K*K - 1) / a0,
+		a2: (1 - K/Q + K*K) / a0,
+	}
+	return k
+}
+
+func (k *kWeightingFilter) process(x float64) float64 {
+	return k.highPass.process(k.shelf.process(x))
+}
+
+// biquad is a second order IIR filter (direct form II transposed)
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	z1, z2             float64
+}
+
+func (b *biquad) process(x float64) float64 {
+	y := b.b0*x + b.z1
+	b.z1 = b.b1*x - b.a1*y + b.z2
+	b.z2 = b.b2*x - b.a2*y
+	return y
+}
@@ -0,0 +1,57 @@
+package backend
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestLoudnessMeter(t *testing.T) {
+	const sampleRate = 44100
+
+	// a full scale 1 kHz sine in one channel measures -3.01 LUFS, so one
+	// at amplitude 0.1 in both channels should measure about -20 LUFS
+	m := newLoudnessMeter(2, sampleRate)
+	samples := make([]float64, 0, 2*sampleRate*5)
+	for i := range sampleRate * 5 {
+		s := 0.1 * math.Sin(2*math.Pi*1000*float64(i)/sampleRate)
+		samples = append(samples, s, s)
+	}
+	m.Add(samples)
+	if l := m.Integrated(); math.Abs(l-(-20)) > 0.2 {
+		t.Errorf("got %.2f LUFS, want -20", l)
+	}
+
+	silent := newLoudnessMeter(2, sampleRate)
+	silent.Add(make([]float64, 2*sampleRate*5))
+	if l := silent.Integrated(); !math.IsInf(l, -1) {
+		t.Errorf("got %.2f LUFS for silence, want -Inf", l)
+	}
+}
+
+func TestTruePeakMeter(t *testing.T) {
+	// a full scale sine at a quarter of the sample rate, sampled 45° off its
+	// peaks, has sample peaks of only 0.707 but a true peak of 1
+	m := newTruePeakMeter(1)
+	samples := make([]float64, 4000)
+	for i := range samples {
+		samples[i] = math.Sin(math.Pi/2*float64(i) + math.Pi/4)
+	}
+	m.Add(samples)
+	if p := m.Peak(); math.Abs(p-1) > 0.05 {
+		t.Errorf("got true peak %.3f, want 1", p)
+	}
+}
+
+func TestLoudnessResultUnmarshal(t *testing.T) {
+	var results map[string]loudnessResult
+	if err := json.Unmarshal([]byte(`{"a":-12.5,"b":{"LUFS":-9,"Peak":0.9}}`), &results); err != nil {
+		t.Fatal(err)
+	}
+	if r := results["a"]; r.LUFS != -12.5 || r.Peak != 0 {
+		t.Errorf("got %+v for result saved without peak", r)
+	}
+	if r := results["b"]; r.LUFS != -9 || r.Peak != 0.9 {
+		t.Errorf("got %+v, want LUFS -9 and peak 0.9", r)
+	}
+}
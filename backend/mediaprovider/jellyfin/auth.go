@@ -0,0 +1,69 @@
+package jellyfin
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// sessionAuth holds the credentials of the logged in session, which
+// go-jellyfin keeps private, for calling APIs the client doesn't wrap.
+type sessionAuth struct {
+	mutex    sync.Mutex
+	token    string
+	userID   string
+	deviceID string
+}
+
+// Get returns the access token, user ID and device ID of the session.
+func (s *sessionAuth) Get() (token, userID, deviceID string) {
+	if s == nil {
+		return "", "", ""
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.token, s.userID, s.deviceID
+}
+
+// authRecorder is an http.RoundTripper that records
+// the session credentials from the login response.
+type authRecorder struct {
+	base http.RoundTripper
+	auth *sessionAuth
+}
+
+func (a *authRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := a.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK ||
+		!strings.HasSuffix(strings.ToLower(req.URL.Path), "/users/authenticatebyname") {
+		return resp, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	var login struct {
+		AccessToken string `json:"AccessToken"`
+		User        struct {
+			ID string `json:"Id"`
+		} `json:"User"`
+		SessionInfo struct {
+			DeviceID string `json:"DeviceId"`
+		} `json:"SessionInfo"`
+	}
+	if json.Unmarshal(body, &login) == nil {
+		a.auth.mutex.Lock()
+		a.auth.token = login.AccessToken
+		a.auth.userID = login.User.ID
+		a.auth.deviceID = login.SessionInfo.DeviceID
+		a.auth.mutex.Unlock()
+	}
+	return resp, nil
+}
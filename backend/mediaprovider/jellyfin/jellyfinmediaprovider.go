@@ -5,6 +5,8 @@ import (
 	"io"
 	"math"
 	"net/http"
+	"net/url"
+	"strconv"
 	"sync"
 	"time"
 
@@ -21,12 +23,22 @@ const (
 
 type JellyfinServer struct {
 	jellyfin.Client
+
+	auth *sessionAuth
 }
 
 func (j *JellyfinServer) Login(user, pass string) mediaprovider.LoginResponse {
 	if _, err := j.Ping(); err != nil {
 		return mediaprovider.LoginResponse{Error: err}
 	}
+	if j.auth == nil {
+		j.auth = &sessionAuth{}
+		base := j.HTTPClient.Transport
+		if base == nil {
+			base = http.DefaultTransport
+		}
+		j.HTTPClient.Transport = &authRecorder{base: base, auth: j.auth}
+	}
 	err := j.Client.Login(user, pass)
 	return mediaprovider.LoginResponse{
 		Error:       err,
@@ -35,13 +47,14 @@ func (j *JellyfinServer) Login(user, pass string) mediaprovider.LoginResponse {
 }
 
 func (j *JellyfinServer) MediaProvider() mediaprovider.MediaProvider {
-	return newJellyfinMediaProvider(&j.Client)
+	return newJellyfinMediaProvider(&j.Client, j.auth)
 }
 
 var _ mediaprovider.MediaProvider = (*JellyfinMediaProvider)(nil)
 
 type JellyfinMediaProvider struct {
 	client          *jellyfin.Client
+	auth            *sessionAuth
 	prefetchCoverCB func(coverArtID string)
 
 	currentLibraryID string
@@ -50,9 +63,10 @@ type JellyfinMediaProvider struct {
 	genresCachedAt int64 // unix
 }
 
-func newJellyfinMediaProvider(cli *jellyfin.Client) mediaprovider.MediaProvider {
+func newJellyfinMediaProvider(cli *jellyfin.Client, auth *sessionAuth) mediaprovider.MediaProvider {
 	return &JellyfinMediaProvider{
 		client:       cli,
+		auth:         auth,
 		genresCached: make([]*mediaprovider.Genre, 0),
 	}
 }
@@ -240,6 +254,14 @@ func (j *JellyfinMediaProvider) GetCoverArt(id string, size int) (image.Image, e
 	return j.client.GetItemImage(id, "Primary", size, 92)
 }
 
+// PublicCoverArtURL returns the URL of the item's primary image.
+// Jellyfin serves images without authentication.
+func (j *JellyfinMediaProvider) PublicCoverArtURL(id string, size int) string {
+	u := j.client.BaseURL().JoinPath("Items", id, "Images", "Primary")
+	u.RawQuery = url.Values{"width": {strconv.Itoa(size)}}.Encode()
+	return u.String()
+}
+
 func (j *JellyfinMediaProvider) GetFavorites() (mediaprovider.Favorites, error) {
 	var wg sync.WaitGroup
 	var favorites mediaprovider.Favorites
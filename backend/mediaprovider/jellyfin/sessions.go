@@ -0,0 +1,138 @@
+package jellyfin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/dweymouth/go-jellyfin"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+// only consider sessions of other devices active within this time for handoff
+const remoteSessionMaxAgeSeconds = 24 * 60 * 60
+
+// the max number of queue entries to load from a remote session
+const maxRemoteQueueLength = 500
+
+var (
+	_ mediaprovider.CanReportPlayback     = (*JellyfinMediaProvider)(nil)
+	_ mediaprovider.CanGetRemotePlayQueue = (*JellyfinMediaProvider)(nil)
+)
+
+type sessionInfo struct {
+	UserID           string    `json:"UserId"`
+	Client           string    `json:"Client"`
+	DeviceName       string    `json:"DeviceName"`
+	DeviceID         string    `json:"DeviceId"`
+	LastActivityDate time.Time `json:"LastActivityDate"`
+	NowPlayingItem   *struct {
+		ID string `json:"Id"`
+	} `json:"NowPlayingItem"`
+	PlayState struct {
+		PositionTicks int64 `json:"PositionTicks"`
+	} `json:"PlayState"`
+	NowPlayingQueue []struct {
+		ID string `json:"Id"`
+	} `json:"NowPlayingQueue"`
+}
+
+// ReportPlayback reports the playback position and state of the now playing
+// track to the server, so it can be seen (and handed off) by the user's other devices.
+func (j *JellyfinMediaProvider) ReportPlayback(trackID string, positionMs int64, state string) error {
+	var event jellyfin.PlayEvent
+	switch state {
+	case "playing":
+		event = jellyfin.Unpause
+	case "paused":
+		event = jellyfin.Pause
+	default:
+		// stop is reported by TrackEndedPlayback
+		return nil
+	}
+	return j.client.UpdatePlayStatus(trackID, event, positionMs*runTimeTicksPerMicrosecond*1000)
+}
+
+// GetRemotePlayQueue returns the now playing queue of the most recently active
+// session of the current user on another device, or nil if there is none.
+func (j *JellyfinMediaProvider) GetRemotePlayQueue() (*mediaprovider.SavedPlayQueue, error) {
+	_, userID, deviceID := j.auth.Get()
+	sessions, err := j.getSessions()
+	if err != nil {
+		return nil, err
+	}
+	sessions = slices.DeleteFunc(sessions, func(s sessionInfo) bool {
+		return s.UserID != userID || s.DeviceID == deviceID || s.NowPlayingItem == nil
+	})
+	if len(sessions) == 0 {
+		return nil, nil
+	}
+	latest := slices.MaxFunc(sessions, func(a, b sessionInfo) int {
+		return a.LastActivityDate.Compare(b.LastActivityDate)
+	})
+
+	ids := []string{latest.NowPlayingItem.ID}
+	if len(latest.NowPlayingQueue) > 0 {
+		ids = ids[:0]
+		for _, q := range latest.NowPlayingQueue[:min(len(latest.NowPlayingQueue), maxRemoteQueueLength)] {
+			ids = append(ids, q.ID)
+		}
+	}
+	queue := &mediaprovider.SavedPlayQueue{
+		TrackPos:  -1,
+		TimePos:   int(latest.PlayState.PositionTicks / runTimeTicksPerMicrosecond / 1_000_000),
+		Changed:   latest.LastActivityDate,
+		ChangedBy: latest.DeviceName,
+	}
+	for _, id := range ids {
+		tr, err := j.GetTrack(id)
+		if err != nil {
+			continue
+		}
+		if id == latest.NowPlayingItem.ID && queue.TrackPos < 0 {
+			queue.TrackPos = len(queue.Tracks)
+		}
+		queue.Tracks = append(queue.Tracks, tr)
+	}
+	if len(queue.Tracks) == 0 {
+		return nil, nil
+	}
+	return queue, nil
+}
+
+// getSessions fetches the server's active sessions that can be controlled
+// by the current user. go-jellyfin doesn't wrap the sessions API.
+func (j *JellyfinMediaProvider) getSessions() ([]sessionInfo, error) {
+	token, userID, _ := j.auth.Get()
+	if token == "" {
+		return nil, errors.New("not logged in")
+	}
+
+	u := j.client.BaseURL().JoinPath("Sessions")
+	u.RawQuery = url.Values{
+		"ControllableByUserId": {userID},
+		"ActiveWithinSeconds":  {fmt.Sprint(remoteSessionMaxAgeSeconds)},
+	}.Encode()
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Emby-Token", token)
+	resp, err := j.client.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error getting sessions: %s", resp.Status)
+	}
+	var sessions []sessionInfo
+	if err := json.NewDecoder(resp.Body).Decode(&sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
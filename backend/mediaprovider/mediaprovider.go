@@ -5,6 +5,7 @@ import (
 	"io"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/deluan/sanitize"
 )
@@ -297,11 +298,31 @@ type SupportsSharing interface {
 	CanShareArtists() bool
 }
 
+// SupportsPublicCoverArtURL is implemented by providers whose cover art
+// can be fetched by URL without including the user's credentials.
+type SupportsPublicCoverArtURL interface {
+	PublicCoverArtURL(id string, size int) string
+}
+
+// CanSubmitPastPlays is implemented by servers that can record a play
+// at the time it happened, e.g. when importing listening history.
+type CanSubmitPastPlays interface {
+	SubmitPlayAt(trackID string, playedAt time.Time) error
+}
+
 type CanSavePlayQueue interface {
 	SavePlayQueue(trackIDs []string, currentTrackPos int, timeSeconds int) error
 	GetPlayQueue() (*SavedPlayQueue, error)
 }
 
+// CanGetRemotePlayQueue is implemented by servers that can report the play queue
+// of another of the user's devices, allowing playback to be handed off between devices.
+type CanGetRemotePlayQueue interface {
+	// GetRemotePlayQueue returns the latest play queue saved or played by another device,
+	// or nil if it is unchanged from what this client last saved or loaded.
+	GetRemotePlayQueue() (*SavedPlayQueue, error)
+}
+
 type CanReportPlayback interface {
 	ReportPlayback(trackID string, positionMs int64, state string) error
 }
@@ -162,6 +162,7 @@ type Track struct {
 	Extension        string
 	Channels         int
 	DateAdded        time.Time
+	MusicBrainzID    string
 }
 
 type ReplayGainInfo struct {
@@ -203,6 +204,11 @@ type SavedPlayQueue struct {
 	Tracks   []*Track
 	TrackPos int
 	TimePos  int // seconds
+
+	// Time the queue was last saved and the name of the
+	// client or device that saved it, if reported by the server
+	Changed   time.Time
+	ChangedBy string
 }
 
 type RadioStation struct {
@@ -2,6 +2,7 @@ package subsonic
 
 import (
 	"errors"
+	"fmt"
 	"image"
 	"io"
 	"math"
@@ -40,6 +41,11 @@ type subsonicMediaProvider struct {
 
 	playbackReportOnce      sync.Once
 	playbackReportSupported bool
+
+	// identifies the play queue last saved to or loaded from the server by this client,
+	// to tell whether the queue on the server was since changed by another device
+	playQueueMutex     sync.Mutex
+	lastSyncedQueueKey string
 }
 
 func SubsonicMediaProvider(subsonicClient *subsonic.Client) mediaprovider.MediaProvider {
@@ -336,6 +342,13 @@ func (s *subsonicMediaProvider) TrackEndedPlayback(trackID string, _ int, submis
 	})
 }
 
+func (s *subsonicMediaProvider) SubmitPlayAt(trackID string, playedAt time.Time) error {
+	return s.client.Scrobble(trackID, map[string]string{
+		"time":       strconv.FormatInt(playedAt.UnixMilli(), 10),
+		"submission": "true",
+	})
+}
+
 func (s *subsonicMediaProvider) SetFavorite(params mediaprovider.RatingFavoriteParameters, favorite bool) error {
 	subParams := subsonic.StarParameters{
 		AlbumIDs:  params.AlbumIDs,
@@ -491,29 +504,72 @@ func (s *subsonicMediaProvider) SavePlayQueue(trackIDs []string, currentTrackIdx
 		return nil // don't save an empty queue
 	}
 	params := make(map[string]string)
+	var current string
 	if currentTrackIdx >= 0 {
+		current = trackIDs[currentTrackIdx]
 		params["position"] = strconv.Itoa(timeSeconds * 1000)
-		params["current"] = trackIDs[currentTrackIdx]
+		params["current"] = current
+	}
+	if err := s.client.SavePlayQueue(trackIDs, params); err != nil {
+		return err
 	}
-	return s.client.SavePlayQueue(trackIDs, params)
+	s.playQueueMutex.Lock()
+	s.lastSyncedQueueKey = playQueueKey(trackIDs, current, timeSeconds)
+	s.playQueueMutex.Unlock()
+	return nil
 }
 
 func (s *subsonicMediaProvider) GetPlayQueue() (*mediaprovider.SavedPlayQueue, error) {
-	pq, err := s.client.GetPlayQueue()
+	savedQueue, key, err := s.getPlayQueue()
 	if err != nil {
 		return nil, err
 	}
+	s.playQueueMutex.Lock()
+	s.lastSyncedQueueKey = key
+	s.playQueueMutex.Unlock()
+	return savedQueue, nil
+}
+
+func (s *subsonicMediaProvider) GetRemotePlayQueue() (*mediaprovider.SavedPlayQueue, error) {
+	savedQueue, key, err := s.getPlayQueue()
+	if err != nil {
+		return nil, err
+	}
+	s.playQueueMutex.Lock()
+	defer s.playQueueMutex.Unlock()
+	if len(savedQueue.Tracks) == 0 || key == s.lastSyncedQueueKey {
+		return nil, nil
+	}
+	return savedQueue, nil
+}
+
+func (s *subsonicMediaProvider) getPlayQueue() (*mediaprovider.SavedPlayQueue, string, error) {
+	pq, err := s.client.GetPlayQueue()
+	if err != nil {
+		return nil, "", err
+	}
 
 	savedQueue := &mediaprovider.SavedPlayQueue{}
 	if pq == nil {
-		return savedQueue, nil
+		return savedQueue, "", nil
 	}
 	savedQueue.Tracks = sharedutil.MapSlice(pq.Entries, toTrack)
 	savedQueue.TrackPos = slices.IndexFunc(pq.Entries, func(e *subsonic.Child) bool {
 		return e.ID == pq.Current
 	})
 	savedQueue.TimePos = int(pq.Position / 1000)
-	return savedQueue, nil
+	savedQueue.Changed = pq.Changed
+	// other instances of this app report the same client name,
+	// which isn't useful to identify the device
+	if pq.ChangedBy != s.client.ClientName {
+		savedQueue.ChangedBy = pq.ChangedBy
+	}
+	trackIDs := sharedutil.MapSlice(pq.Entries, func(e *subsonic.Child) string { return e.ID })
+	return savedQueue, playQueueKey(trackIDs, pq.Current, savedQueue.TimePos), nil
+}
+
+func playQueueKey(trackIDs []string, current string, timeSeconds int) string {
+	return fmt.Sprintf("%s|%s|%d", strings.Join(trackIDs, ","), current, timeSeconds)
 }
 
 // RadioProvider interface
@@ -640,6 +696,7 @@ func toTrack(ch *subsonic.Child) *mediaprovider.Track {
 		SampleRate:       ch.SamplingRate,
 		BitDepth:         ch.BitDepth,
 		Channels:         ch.ChannelCount,
+		MusicBrainzID:    ch.MusicBrainzID,
 	}
 }
 
@@ -0,0 +1,178 @@
+package mpd
+
+import (
+	"strings"
+)
+
+// Comparison operators of filter conditions
+const (
+	OpEqual    = "=="
+	OpNotEqual = "!="
+	OpContains = "contains"
+)
+
+// Condition matches a song tag against a value.
+// Tag names are lowercase, e.g. "artist", "album" or "any".
+type Condition struct {
+	Tag   string
+	Op    string
+	Value string
+}
+
+// Filter is a set of conditions which must all match.
+type Filter []Condition
+
+// ParseFilter parses the filter arguments of the find, search and list commands,
+// which are either a single filter expression like `((artist == "X") AND (album == "Y"))`
+// or old-style tag/value pairs. Old-style pairs match exactly if exact is true,
+// and otherwise by case-insensitive substring. "group" arguments are ignored.
+func ParseFilter(args []string, exact bool) (Filter, error) {
+	for i, a := range args {
+		if strings.EqualFold(a, "group") {
+			args = args[:i]
+			break
+		}
+	}
+	if len(args) == 1 && strings.HasPrefix(strings.TrimSpace(args[0]), "(") {
+		p := &exprParser{s: args[0]}
+		f, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if p.skipSpace(); p.pos < len(p.s) {
+			return nil, Errorf(ErrArg, "unexpected %q in filter expression", p.s[p.pos:])
+		}
+		return f, nil
+	}
+
+	if len(args)%2 != 0 {
+		return nil, Errorf(ErrArg, "incorrect number of filter arguments")
+	}
+	op := OpContains
+	if exact {
+		op = OpEqual
+	}
+	var f Filter
+	for i := 0; i < len(args); i += 2 {
+		f = append(f, Condition{Tag: strings.ToLower(args[i]), Op: op, Value: args[i+1]})
+	}
+	return f, nil
+}
+
+// Matches returns true if all conditions match, given a function
+// returning the values of a song's tag.
+func (f Filter) Matches(tagValues func(tag string) []string) bool {
+	for _, c := range f {
+		if !c.matches(tagValues(c.Tag)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c Condition) matches(values []string) bool {
+	match := false
+	for _, v := range values {
+		switch c.Op {
+		case OpContains:
+			match = strings.Contains(strings.ToLower(v), strings.ToLower(c.Value))
+		default:
+			match = strings.EqualFold(v, c.Value)
+		}
+		if match {
+			break
+		}
+	}
+	if c.Op == OpNotEqual {
+		return !match
+	}
+	return match
+}
+
+type exprParser struct {
+	s   string
+	pos int
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *exprParser) expect(tok string) error {
+	p.skipSpace()
+	if !strings.HasPrefix(p.s[p.pos:], tok) {
+		return Errorf(ErrArg, "expected %q in filter expression", tok)
+	}
+	p.pos += len(tok)
+	return nil
+}
+
+// parseExpr parses `(tag op 'value')` or `(EXPR AND EXPR ...)`
+func (p *exprParser) parseExpr() (Filter, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if strings.HasPrefix(p.s[p.pos:], "(") {
+		var f Filter
+		for {
+			sub, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			f = append(f, sub...)
+			p.skipSpace()
+			if strings.HasPrefix(p.s[p.pos:], "AND") {
+				p.pos += len("AND")
+				continue
+			}
+			return f, p.expect(")")
+		}
+	}
+
+	tag := p.word()
+	op := p.word()
+	if op != OpEqual && op != OpNotEqual && op != OpContains {
+		return nil, Errorf(ErrArg, "unsupported filter operator %q", op)
+	}
+	value, err := p.quoted()
+	if err != nil {
+		return nil, err
+	}
+	return Filter{{Tag: strings.ToLower(tag), Op: op, Value: value}}, p.expect(")")
+}
+
+func (p *exprParser) word() string {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.s) && p.s[p.pos] != ' ' && p.s[p.pos] != '\t' && p.s[p.pos] != ')' {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+func (p *exprParser) quoted() (string, error) {
+	p.skipSpace()
+	if p.pos >= len(p.s) || (p.s[p.pos] != '\'' && p.s[p.pos] != '"') {
+		return "", Errorf(ErrArg, "expected quoted value in filter expression")
+	}
+	quote := p.s[p.pos]
+	p.pos++
+	var sb strings.Builder
+	for p.pos < len(p.s) {
+		ch := p.s[p.pos]
+		p.pos++
+		switch {
+		case ch == '\\' && p.pos < len(p.s):
+			sb.WriteByte(p.s[p.pos])
+			p.pos++
+		case ch == quote:
+			return sb.String(), nil
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+	return "", Errorf(ErrArg, "missing closing quote in filter expression")
+}
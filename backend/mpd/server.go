@@ -0,0 +1,410 @@
+// Package mpd implements the network side of the Music Player Daemon protocol,
+// so that MPD clients can control Supersonic. Commands are executed by a Handler.
+package mpd
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// the protocol version reported to clients
+const protocolVersion = "0.23.5"
+
+// the maximum length of a command line sent by a client
+const maxLineLength = 64 * 1024
+
+// Subsystems reported by the idle command
+const (
+	SubsystemDatabase       = "database"
+	SubsystemStoredPlaylist = "stored_playlist"
+	SubsystemPlaylist       = "playlist"
+	SubsystemPlayer         = "player"
+	SubsystemMixer          = "mixer"
+	SubsystemOutput         = "output"
+	SubsystemOptions        = "options"
+)
+
+// MPD ACK error codes
+const (
+	ErrNotList    = 1
+	ErrArg        = 2
+	ErrPassword   = 3
+	ErrPermission = 4
+	ErrUnknown    = 5
+	ErrNoExist    = 50
+	ErrSystem     = 52
+)
+
+// Error is returned by a Handler to send an ACK response with a specific error code.
+// Any other error is sent with ErrSystem.
+type Error struct {
+	Code int
+	Msg  string
+}
+
+func (e *Error) Error() string { return e.Msg }
+
+// Errorf creates an Error with the given code and formatted message.
+func Errorf(code int, format string, args ...any) error {
+	return &Error{Code: code, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Handler executes MPD commands. It is called concurrently from each client connection.
+type Handler interface {
+	// Execute runs a single command, writing its response to w.
+	Execute(cmd string, args []string, w *ResponseWriter) error
+	// Commands returns the names of the commands supported by Execute.
+	Commands() []string
+}
+
+// ResponseWriter buffers the response to a single command.
+type ResponseWriter struct {
+	sb strings.Builder
+}
+
+// Field writes a "key: value" response line.
+func (w *ResponseWriter) Field(key string, value any) {
+	fmt.Fprintf(&w.sb, "%s: %v\n", key, value)
+}
+
+// Server accepts MPD client connections.
+type Server struct {
+	handler  Handler
+	password string
+
+	mutex    sync.Mutex
+	listener net.Listener
+	conns    map[*conn]struct{}
+	closed   bool
+}
+
+// NewServer creates a server which executes commands with the given handler.
+// If password is non-empty, clients must send it with the password command
+// before any other command.
+func NewServer(handler Handler, password string) *Server {
+	return &Server{
+		handler:  handler,
+		password: password,
+		conns:    make(map[*conn]struct{}),
+	}
+}
+
+// Serve accepts connections on the listener until Close is called.
+func (s *Server) Serve(listener net.Listener) error {
+	s.mutex.Lock()
+	s.listener = listener
+	s.mutex.Unlock()
+	for {
+		nc, err := listener.Accept()
+		if err != nil {
+			s.mutex.Lock()
+			closed := s.closed
+			s.mutex.Unlock()
+			if closed {
+				return nil
+			}
+			return err
+		}
+		c := &conn{
+			server:     s,
+			nc:         nc,
+			authorized: s.password == "",
+			changed:    make(map[string]bool),
+			wake:       make(chan struct{}, 1),
+		}
+		s.mutex.Lock()
+		s.conns[c] = struct{}{}
+		s.mutex.Unlock()
+		go c.serve()
+	}
+}
+
+// Close stops accepting connections and disconnects all clients.
+func (s *Server) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.closed = true
+	for c := range s.conns {
+		c.nc.Close()
+	}
+	if s.listener != nil {
+		return s.listener.Close()
+	}
+	return nil
+}
+
+// Notify records a change to the given subsystems,
+// waking up clients that are waiting in the idle command.
+func (s *Server) Notify(subsystems ...string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for c := range s.conns {
+		c.notify(subsystems)
+	}
+}
+
+func (s *Server) removeConn(c *conn) {
+	s.mutex.Lock()
+	delete(s.conns, c)
+	s.mutex.Unlock()
+}
+
+type conn struct {
+	server     *Server
+	nc         net.Conn
+	authorized bool
+
+	mutex sync.Mutex
+	// subsystems changed since the client last returned from idle
+	changed map[string]bool
+	wake    chan struct{}
+}
+
+func (c *conn) notify(subsystems []string) {
+	c.mutex.Lock()
+	for _, sub := range subsystems {
+		c.changed[sub] = true
+	}
+	c.mutex.Unlock()
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// takeChanged returns and clears the changed subsystems which are
+// included in filter, or all changed subsystems if filter is empty.
+func (c *conn) takeChanged(filter []string) []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var changed []string
+	for sub := range c.changed {
+		if len(filter) == 0 || slices.Contains(filter, sub) {
+			changed = append(changed, sub)
+			delete(c.changed, sub)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
+
+func (c *conn) serve() {
+	defer c.server.removeConn(c)
+	defer c.nc.Close()
+
+	// lines are read in the background so that noidle can be received while idling
+	lines := make(chan string)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(lines)
+		sc := bufio.NewScanner(c.nc)
+		sc.Buffer(make([]byte, 4096), maxLineLength)
+		for sc.Scan() {
+			select {
+			case lines <- sc.Text():
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	w := bufio.NewWriter(c.nc)
+	fmt.Fprintf(w, "OK MPD %s\n", protocolVersion)
+	w.Flush()
+
+	// a command received while idle, which ends the idle and is run next
+	var pending string
+	hasPending := false
+	next := func() (string, bool) {
+		if hasPending {
+			hasPending = false
+			return pending, true
+		}
+		line, ok := <-lines
+		return line, ok
+	}
+
+	var cmdList []string
+	inCmdList, listOK := false, false
+	for line, ok := next(); ok; line, ok = next() {
+		if inCmdList {
+			if line != "command_list_end" {
+				cmdList = append(cmdList, line)
+				continue
+			}
+			inCmdList = false
+			c.runCommandList(w, cmdList, listOK)
+			cmdList = nil
+		} else {
+			switch line {
+			case "command_list_begin", "command_list_ok_begin":
+				inCmdList, listOK = true, line == "command_list_ok_begin"
+				continue
+			case "close":
+				return
+			case "noidle":
+				// only meaningful while idle
+				continue
+			}
+			if cmd, args, err := parseCommand(line); err == nil && cmd == "idle" {
+				var open bool
+				if pending, hasPending, open = c.idle(w, args, lines); !open {
+					return
+				}
+			} else {
+				c.runCommandList(w, []string{line}, false)
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// idle waits for a change in one of the given subsystems or a command from the client.
+// noidle just ends the idle; any other command is returned to be run next.
+// Returns open = false if the connection was closed.
+func (c *conn) idle(w *bufio.Writer, subsystems []string, lines <-chan string) (next string, hasNext, open bool) {
+	if !c.authorized {
+		writeAck(w, ErrPermission, 0, "idle", "you don't have permission for \"idle\"")
+		return "", false, true
+	}
+	for {
+		if changed := c.takeChanged(subsystems); len(changed) > 0 {
+			for _, sub := range changed {
+				fmt.Fprintf(w, "changed: %s\n", sub)
+			}
+			io.WriteString(w, "OK\n")
+			return "", false, true
+		}
+		select {
+		case <-c.wake:
+		case line, ok := <-lines:
+			if !ok {
+				return "", false, false
+			}
+			io.WriteString(w, "OK\n")
+			return line, line != "noidle", true
+		}
+	}
+}
+
+func (c *conn) runCommandList(w *bufio.Writer, lines []string, listOK bool) {
+	for i, line := range lines {
+		cmd, args, err := parseCommand(line)
+		if err == nil {
+			err = c.execute(w, cmd, args)
+		}
+		if err != nil {
+			code := ErrSystem
+			var mpdErr *Error
+			if errors.As(err, &mpdErr) {
+				code = mpdErr.Code
+			}
+			writeAck(w, code, i, cmd, err.Error())
+			return
+		}
+		if listOK {
+			io.WriteString(w, "list_OK\n")
+		}
+	}
+	io.WriteString(w, "OK\n")
+}
+
+func (c *conn) execute(w io.Writer, cmd string, args []string) error {
+	switch cmd {
+	case "password":
+		if len(args) != 1 || subtle.ConstantTimeCompare([]byte(args[0]), []byte(c.server.password)) != 1 {
+			return Errorf(ErrPassword, "incorrect password")
+		}
+		c.authorized = true
+		return nil
+	case "ping":
+		return nil
+	}
+	if !c.authorized {
+		return Errorf(ErrPermission, "you don't have permission for %q", cmd)
+	}
+	switch cmd {
+	case "commands":
+		for _, name := range c.commands() {
+			fmt.Fprintf(w, "command: %s\n", name)
+		}
+		return nil
+	case "notcommands":
+		return nil
+	}
+	var rw ResponseWriter
+	if err := c.server.handler.Execute(cmd, args, &rw); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, rw.sb.String())
+	return err
+}
+
+func (c *conn) commands() []string {
+	cmds := append([]string{"close", "command_list_begin", "command_list_end", "command_list_ok_begin",
+		"commands", "idle", "noidle", "notcommands", "password", "ping"}, c.server.handler.Commands()...)
+	slices.Sort(cmds)
+	return slices.Compact(cmds)
+}
+
+func writeAck(w io.Writer, code, listIdx int, cmd, msg string) {
+	fmt.Fprintf(w, "ACK [%d@%d] {%s} %s\n", code, listIdx, cmd, msg)
+}
+
+// parseCommand splits a command line into the command name and its arguments,
+// which may be quoted with double quotes and use backslash escapes.
+func parseCommand(line string) (string, []string, error) {
+	var tokens []string
+	var cur strings.Builder
+	inToken, inQuote, escaped := false, false, false
+	for _, r := range line {
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case inQuote && r == '\\':
+			escaped = true
+		case r == '"':
+			if inQuote {
+				tokens = append(tokens, cur.String())
+				cur.Reset()
+				inQuote, inToken = false, false
+			} else if !inToken {
+				inQuote = true
+			} else {
+				cur.WriteRune(r)
+			}
+		case inQuote:
+			cur.WriteRune(r)
+		case r == ' ' || r == '\t':
+			if inToken {
+				tokens = append(tokens, cur.String())
+				cur.Reset()
+				inToken = false
+			}
+		default:
+			cur.WriteRune(r)
+			inToken = true
+		}
+	}
+	if inQuote {
+		return "", nil, Errorf(ErrArg, "missing closing '\"'")
+	}
+	if inToken {
+		tokens = append(tokens, cur.String())
+	}
+	if len(tokens) == 0 {
+		return "", nil, Errorf(ErrUnknown, "no command given")
+	}
+	return strings.ToLower(tokens[0]), tokens[1:], nil
+}
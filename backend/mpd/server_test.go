@@ -0,0 +1,142 @@
+package mpd
+
+import (
+	"bufio"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseCommand(t *testing.T) {
+	for _, tc := range []struct {
+		line string
+		cmd  string
+		args []string
+	}{
+		{line: "status", cmd: "status"},
+		{line: "Play 3", cmd: "play", args: []string{"3"}},
+		{line: `find artist "The \"Band\"" album  Foo`, cmd: "find", args: []string{"artist", `The "Band"`, "album", "Foo"}},
+		{line: `add ""`, cmd: "add", args: []string{""}},
+	} {
+		cmd, args, err := parseCommand(tc.line)
+		if err != nil {
+			t.Errorf("parseCommand(%q): %v", tc.line, err)
+			continue
+		}
+		if cmd != tc.cmd || !slices.Equal(args, tc.args) {
+			t.Errorf("parseCommand(%q) = %q %q, want %q %q", tc.line, cmd, args, tc.cmd, tc.args)
+		}
+	}
+
+	if _, _, err := parseCommand(`find artist "unterminated`); err == nil {
+		t.Error("expected error for unterminated quote")
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	f, err := ParseFilter([]string{"Artist", "Foo", "album", "Bar", "group", "date"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Filter{{Tag: "artist", Op: OpEqual, Value: "Foo"}, {Tag: "album", Op: OpEqual, Value: "Bar"}}
+	if !slices.Equal(f, want) {
+		t.Errorf("got %v, want %v", f, want)
+	}
+
+	f, err = ParseFilter([]string{`((artist == 'It\'s') AND (title contains "love"))`}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = Filter{{Tag: "artist", Op: OpEqual, Value: "It's"}, {Tag: "title", Op: OpContains, Value: "love"}}
+	if !slices.Equal(f, want) {
+		t.Errorf("got %v, want %v", f, want)
+	}
+
+	tags := map[string][]string{"artist": {"It's"}, "title": {"Lovely Day"}}
+	if !f.Matches(func(tag string) []string { return tags[tag] }) {
+		t.Error("expected filter to match")
+	}
+	tags["title"] = []string{"Sunny Day"}
+	if f.Matches(func(tag string) []string { return tags[tag] }) {
+		t.Error("expected filter not to match")
+	}
+
+	if _, err := ParseFilter([]string{"(artist =~ 'x')"}, true); err == nil {
+		t.Error("expected error for unsupported operator")
+	}
+}
+
+type echoHandler struct{}
+
+func (echoHandler) Execute(cmd string, args []string, w *ResponseWriter) error {
+	if cmd != "echo" {
+		return Errorf(ErrUnknown, "unknown command %q", cmd)
+	}
+	for _, a := range args {
+		w.Field("arg", a)
+	}
+	return nil
+}
+
+func (echoHandler) Commands() []string { return []string{"echo"} }
+
+func TestServerProtocol(t *testing.T) {
+	s := NewServer(echoHandler{}, "secret")
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Serve(l)
+	defer s.Close()
+
+	nc, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nc.Close()
+	r := bufio.NewReader(nc)
+	send := func(lines ...string) {
+		nc.Write([]byte(strings.Join(lines, "\n") + "\n"))
+	}
+	expect := func(want ...string) {
+		t.Helper()
+		for _, w := range want {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				t.Fatal(err)
+			}
+			if line = strings.TrimSuffix(line, "\n"); line != w {
+				t.Fatalf("got %q, want %q", line, w)
+			}
+		}
+	}
+
+	expect("OK MPD " + protocolVersion)
+	send("echo a")
+	expect(`ACK [4@0] {echo} you don't have permission for "echo"`)
+	send("password secret")
+	expect("OK")
+
+	send("command_list_ok_begin", `echo "a b"`, "echo", "command_list_end")
+	expect("arg: a b", "list_OK", "list_OK", "OK")
+	send("command_list_begin", "echo x", "bogus", "echo y", "command_list_end")
+	expect("arg: x", `ACK [5@1] {bogus} unknown command "bogus"`)
+
+	// changes before idle are reported immediately
+	s.Notify(SubsystemPlayer)
+	send("idle")
+	expect("changed: player", "OK")
+
+	send("idle playlist")
+	send("noidle")
+	expect("OK")
+
+	// other commands end the idle and are then run
+	send("idle")
+	send("echo z")
+	expect("OK", "arg: z", "OK")
+
+	send("password wrong")
+	expect(`ACK [3@0] {password} incorrect password`)
+}
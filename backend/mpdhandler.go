@@ -0,0 +1,1065 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/mpd"
+	"github.com/dweymouth/supersonic/backend/player"
+	"github.com/dweymouth/supersonic/sharedutil"
+	"github.com/zalando/go-keyring"
+)
+
+// URI prefixes identifying items to MPD clients
+const (
+	mpdTrackURIPrefix    = "track/"
+	mpdAlbumURIPrefix    = "album/"
+	mpdPlaylistURIPrefix = "playlist/"
+	mpdRadioURIPrefix    = "radio/"
+)
+
+const (
+	// max number of tracks returned from find and search
+	mpdMaxSearchResults = 1000
+	// max number of albums scanned for a search only filtered by genre
+	mpdMaxGenreSearchAlbums = 50
+
+	mpdKeyringUser = "mpd-server"
+)
+
+var mpdTagTypes = []string{"Artist", "AlbumArtist", "Album", "Title", "Track", "Disc", "Genre", "Date", "Composer"}
+
+// mpdHandler implements the commands of the MPD protocol server on top of the
+// PlaybackManager and current server.
+type mpdHandler struct {
+	pm *PlaybackManager
+	sm *ServerManager
+
+	notify          func(subsystems ...string)
+	playlistVersion atomic.Uint32
+	songIDs         mpdSongIDs
+	startTime       time.Time
+}
+
+// mpdSongIDs gives the songs in the queue IDs that stay the same while they
+// remain in the queue, as the queue itself doesn't track stable IDs.
+// Songs are matched by their type and ID, so repeats of the same song
+// are told apart by their order in the queue.
+type mpdSongIDs struct {
+	mutex    sync.Mutex
+	lastID   int
+	ids      map[string][]int
+	reserved map[string][]int // for songs still being added to the queue
+}
+
+// forQueue returns the ID of each song in the queue.
+func (s *mpdSongIDs) forQueue(queue []mediaprovider.MediaItem) []int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ids := make([]int, len(queue))
+	newIDs := make(map[string][]int, len(queue))
+	for i, item := range queue {
+		key := mpdSongKey(item)
+		if n := len(newIDs[key]); n < len(s.ids[key]) {
+			ids[i] = s.ids[key][n]
+		} else if r := s.reserved[key]; len(r) > 0 {
+			ids[i], s.reserved[key] = r[0], r[1:]
+			if len(s.reserved[key]) == 0 {
+				delete(s.reserved, key)
+			}
+		} else {
+			s.lastID++
+			ids[i] = s.lastID
+		}
+		newIDs[key] = append(newIDs[key], ids[i])
+	}
+	s.ids = newIDs
+	return ids
+}
+
+// reserve returns the IDs that the items will have once they are added
+// to the queue, since the queue is updated asynchronously.
+func (s *mpdSongIDs) reserve(items []mediaprovider.MediaItem) []int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.reserved == nil {
+		s.reserved = make(map[string][]int)
+	}
+	ids := make([]int, len(items))
+	for i, item := range items {
+		key := mpdSongKey(item)
+		s.lastID++
+		ids[i] = s.lastID
+		s.reserved[key] = append(s.reserved[key], ids[i])
+	}
+	return ids
+}
+
+// clearReserved forgets reserved IDs, for when the queue is cleared.
+func (s *mpdSongIDs) clearReserved() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.reserved = nil
+}
+
+func mpdSongKey(item mediaprovider.MediaItem) string {
+	meta := item.Metadata()
+	return fmt.Sprintf("%d/%s", meta.Type, meta.ID)
+}
+
+var _ mpd.Handler = (*mpdHandler)(nil)
+
+func (a *App) startMPDServer() {
+	h := &mpdHandler{pm: a.PlaybackManager, sm: a.ServerManager, startTime: time.Now()}
+	h.playlistVersion.Store(1)
+	a.mpdServer = mpd.NewServer(h, a.mpdPassword())
+	h.notify = a.mpdServer.Notify
+
+	pm := a.PlaybackManager
+	notifyPlayer := func() { h.notify(mpd.SubsystemPlayer) }
+	pm.OnSongChange(func(mediaprovider.MediaItem, *mediaprovider.Track) { notifyPlayer() })
+	pm.OnPlaying(notifyPlayer)
+	pm.OnPaused(notifyPlayer)
+	pm.OnStopped(notifyPlayer)
+	pm.OnSeek(notifyPlayer)
+	pm.OnVolumeChange(func(int) { h.notify(mpd.SubsystemMixer) })
+	pm.OnLoopModeChange(func(LoopMode) { h.notify(mpd.SubsystemOptions) })
+	pm.OnShuffleChange(func(bool) {
+		// the order of the active queue changes with shuffle
+		h.playlistVersion.Add(1)
+		h.notify(mpd.SubsystemOptions, mpd.SubsystemPlaylist)
+	})
+	pm.OnQueueChange(func() {
+		h.playlistVersion.Add(1)
+		h.notify(mpd.SubsystemPlaylist)
+	})
+
+	listener, err := net.Listen("tcp", a.Config.MPDServer.ListenAddress)
+	if err != nil {
+		log.Printf("error starting MPD server: %v", err)
+		a.mpdServer = nil
+		return
+	}
+	log.Printf("MPD server listening on %s", listener.Addr())
+	go a.mpdServer.Serve(listener)
+}
+
+// mpdPassword returns the password required from MPD clients from the keyring,
+// first moving a password set in the config file into the keyring if there is one.
+func (a *App) mpdPassword() string {
+	cfg := &a.Config.MPDServer
+	if !a.ServerManager.useKeyring {
+		return cfg.Password
+	}
+	if pass := cfg.Password; pass != "" {
+		if err := keyring.Set(a.appName, mpdKeyringUser, pass); err != nil {
+			log.Printf("error storing MPD password in keyring: %v", err)
+			return pass
+		}
+		cfg.Password = ""
+		return pass
+	}
+	pass, err := keyring.Get(a.appName, mpdKeyringUser)
+	if err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		log.Printf("error reading MPD password from keyring: %v", err)
+	}
+	return pass
+}
+
+func (h *mpdHandler) Commands() []string {
+	return []string{
+		"add", "addid", "clear", "consume", "crossfade", "currentsong", "decoders", "delete", "deleteid",
+		"disableoutput", "enableoutput", "find", "findadd", "getvol", "list", "listplaylist", "listplaylistinfo",
+		"listplaylists", "load", "lsinfo", "move", "moveid", "next", "outputs", "pause", "play", "playid",
+		"playlistadd", "playlistdelete", "playlistid", "playlistinfo", "plchanges", "plchangesposid", "previous",
+		"random", "repeat", "replay_gain_status", "rescan", "rm", "save", "search", "searchadd", "seek", "seekcur",
+		"seekid", "setvol", "shuffle", "single", "stats", "status", "stop", "tagtypes", "update", "urlhandlers", "volume",
+	}
+}
+
+func (h *mpdHandler) Execute(cmd string, args []string, w *mpd.ResponseWriter) error {
+	switch cmd {
+	// status and queue inspection
+	case "status":
+		h.writeStatus(w)
+	case "currentsong":
+		if idx := h.pm.NowPlayingIndex(); idx >= 0 {
+			if queue := h.pm.GetActivePlayQueue(); idx < len(queue) {
+				writeMPDSong(w, queue[idx], idx, h.songIDs.forQueue(queue)[idx])
+			}
+		}
+	case "stats":
+		w.Field("uptime", int(time.Since(h.startTime).Seconds()))
+		w.Field("playtime", 0)
+		w.Field("db_playtime", 0)
+	case "playlistinfo":
+		queue := h.pm.GetActivePlayQueue()
+		ids := h.songIDs.forQueue(queue)
+		start, end := 0, len(queue)
+		if len(args) > 0 {
+			var err error
+			if start, end, err = parseMPDRange(args[0], len(queue)); err != nil {
+				return err
+			}
+		}
+		for i := start; i < end; i++ {
+			writeMPDSong(w, queue[i], i, ids[i])
+		}
+	case "playlistid":
+		queue := h.pm.GetActivePlayQueue()
+		ids := h.songIDs.forQueue(queue)
+		if len(args) == 0 {
+			for i, item := range queue {
+				writeMPDSong(w, item, i, ids[i])
+			}
+			return nil
+		}
+		pos, err := parseMPDSongID(args[0], ids)
+		if err != nil {
+			return err
+		}
+		writeMPDSong(w, queue[pos], pos, ids[pos])
+	case "plchanges", "plchangesposid":
+		if len(args) == 0 {
+			return mpd.Errorf(mpd.ErrArg, "missing playlist version")
+		}
+		if v, err := strconv.ParseUint(args[0], 10, 32); err == nil && uint32(v) == h.playlistVersion.Load() {
+			return nil
+		}
+		// changes aren't tracked, so report the whole queue
+		queue := h.pm.GetActivePlayQueue()
+		ids := h.songIDs.forQueue(queue)
+		for i, item := range queue {
+			if cmd == "plchanges" {
+				writeMPDSong(w, item, i, ids[i])
+			} else {
+				w.Field("cpos", i)
+				w.Field("Id", ids[i])
+			}
+		}
+
+	// playback control
+	case "play":
+		queue := h.pm.GetActivePlayQueue()
+		if len(args) > 0 {
+			pos, err := parseMPDInt(args[0])
+			if err != nil {
+				return err
+			}
+			if pos >= 0 {
+				if pos >= len(queue) {
+					return mpd.Errorf(mpd.ErrArg, "bad song index")
+				}
+				h.pm.PlayTrackAt(pos)
+				return nil
+			}
+		}
+		h.resume(len(queue))
+	case "playid":
+		queue := h.pm.GetActivePlayQueue()
+		if len(args) == 0 {
+			h.resume(len(queue))
+			return nil
+		}
+		pos, err := parseMPDSongID(args[0], h.songIDs.forQueue(queue))
+		if err != nil {
+			return err
+		}
+		h.pm.PlayTrackAt(pos)
+	case "pause":
+		if len(args) == 0 {
+			h.pm.PlayPause()
+		} else if args[0] == "1" {
+			h.pm.Pause()
+		} else {
+			h.pm.Continue()
+		}
+	case "stop":
+		h.pm.Stop()
+	case "next":
+		h.pm.SeekNext()
+	case "previous":
+		if idx := h.pm.NowPlayingIndex(); idx > 0 {
+			h.pm.PlayTrackAt(idx - 1)
+		} else {
+			h.pm.SeekSeconds(0)
+		}
+	case "seek", "seekid":
+		if len(args) != 2 {
+			return mpd.Errorf(mpd.ErrArg, "wrong number of arguments for %q", cmd)
+		}
+		queue := h.pm.GetActivePlayQueue()
+		var pos int
+		var err error
+		if cmd == "seek" {
+			if pos, err = parseMPDInt(args[0]); err == nil && (pos < 0 || pos >= len(queue)) {
+				err = mpd.Errorf(mpd.ErrArg, "bad song index")
+			}
+		} else {
+			pos, err = parseMPDSongID(args[0], h.songIDs.forQueue(queue))
+		}
+		if err != nil {
+			return err
+		}
+		secs, err := strconv.ParseFloat(args[1], 64)
+		if err != nil {
+			return mpd.Errorf(mpd.ErrArg, "invalid time %q", args[1])
+		}
+		if pos != h.pm.NowPlayingIndex() {
+			h.pm.PlayTrackAt(pos)
+		}
+		h.pm.SeekSeconds(secs)
+	case "seekcur":
+		if len(args) != 1 {
+			return mpd.Errorf(mpd.ErrArg, "missing time")
+		}
+		secs, err := strconv.ParseFloat(args[0], 64)
+		if err != nil {
+			return mpd.Errorf(mpd.ErrArg, "invalid time %q", args[0])
+		}
+		if strings.HasPrefix(args[0], "+") || strings.HasPrefix(args[0], "-") {
+			h.pm.SeekBySeconds(secs)
+		} else {
+			h.pm.SeekSeconds(secs)
+		}
+
+	// volume and playback options
+	case "setvol", "volume":
+		if len(args) != 1 {
+			return mpd.Errorf(mpd.ErrArg, "missing volume")
+		}
+		vol, err := parseMPDInt(args[0])
+		if err != nil {
+			return err
+		}
+		if cmd == "volume" {
+			vol += h.pm.Volume()
+		}
+		h.pm.SetVolume(clamp(vol, 0, 100))
+	case "getvol":
+		w.Field("volume", h.pm.Volume())
+	case "random":
+		on, err := parseMPDBool(args)
+		if err != nil {
+			return err
+		}
+		h.pm.SetShuffle(on)
+	case "repeat":
+		on, err := parseMPDBool(args)
+		if err != nil {
+			return err
+		}
+		if !on {
+			h.pm.SetLoopMode(LoopNone)
+		} else if h.pm.GetLoopMode() == LoopNone {
+			h.pm.SetLoopMode(LoopAll)
+		}
+	case "single":
+		if len(args) == 1 && args[0] == "oneshot" {
+			h.pm.SetPauseAfterCurrent(true)
+			return nil
+		}
+		on, err := parseMPDBool(args)
+		if err != nil {
+			return err
+		}
+		if on {
+			h.pm.SetLoopMode(LoopOne)
+		} else if h.pm.GetLoopMode() == LoopOne {
+			h.pm.SetLoopMode(LoopAll)
+		}
+	case "consume":
+		if on, err := parseMPDBool(args); err != nil || on {
+			return mpd.Errorf(mpd.ErrArg, "consume mode is not supported")
+		}
+	case "crossfade":
+		// not supported; accepted so clients don't report an error
+	case "replay_gain_status":
+		w.Field("replay_gain_mode", "off")
+
+	// queue editing
+	case "add", "addid":
+		if len(args) == 0 {
+			return mpd.Errorf(mpd.ErrArg, "missing URI")
+		}
+		items, err := h.resolveURI(args[0])
+		if err != nil {
+			return err
+		}
+		queueLen := len(h.pm.GetActivePlayQueue())
+		pos := queueLen
+		if len(args) > 1 {
+			if pos, err = parseMPDInt(args[1]); err != nil {
+				return err
+			}
+			if pos < 0 || pos > queueLen {
+				return mpd.Errorf(mpd.ErrArg, "bad song index")
+			}
+		}
+		if cmd == "addid" && len(items) > 0 {
+			w.Field("Id", h.songIDs.reserve(items)[0])
+		}
+		h.insertItems(items, pos)
+	case "delete", "deleteid":
+		if len(args) != 1 {
+			return mpd.Errorf(mpd.ErrArg, "missing song position")
+		}
+		queue := h.pm.GetActivePlayQueue()
+		var start, end int
+		var err error
+		if cmd == "delete" {
+			start, end, err = parseMPDRange(args[0], len(queue))
+		} else {
+			start, err = parseMPDSongID(args[0], h.songIDs.forQueue(queue))
+			end = start + 1
+		}
+		if err != nil {
+			return err
+		}
+		idxs := make([]int, 0, end-start)
+		for i := start; i < end; i++ {
+			idxs = append(idxs, i)
+		}
+		h.pm.RemoveTracksFromQueue(idxs)
+	case "move", "moveid":
+		if len(args) != 2 {
+			return mpd.Errorf(mpd.ErrArg, "wrong number of arguments for %q", cmd)
+		}
+		queue := h.pm.GetActivePlayQueue()
+		var start, end int
+		var err error
+		if cmd == "move" {
+			start, end, err = parseMPDRange(args[0], len(queue))
+		} else {
+			start, err = parseMPDSongID(args[0], h.songIDs.forQueue(queue))
+			end = start + 1
+		}
+		if err != nil {
+			return err
+		}
+		to, err := parseMPDInt(args[1])
+		if err != nil {
+			return err
+		}
+		if to < 0 || to+(end-start) > len(queue) {
+			return mpd.Errorf(mpd.ErrArg, "bad song index")
+		}
+		block := slices.Clone(queue[start:end])
+		rest := slices.Delete(slices.Clone(queue), start, end)
+		h.pm.UpdatePlayQueue(slices.Insert(rest, to, block...))
+	case "clear":
+		h.songIDs.clearReserved()
+		h.pm.StopAndClearPlayQueue()
+	case "shuffle":
+		queue := slices.Clone(h.pm.GetActivePlayQueue())
+		rand.Shuffle(len(queue), func(i, j int) { queue[i], queue[j] = queue[j], queue[i] })
+		h.pm.UpdatePlayQueue(queue)
+
+	// library
+	case "find", "search", "findadd", "searchadd":
+		filter, window, err := parseMPDFilterArgs(args, strings.HasPrefix(cmd, "find"))
+		if err != nil {
+			return err
+		}
+		tracks, err := h.searchTracks(filter)
+		if err != nil {
+			return err
+		}
+		if window != nil {
+			start, end := min(window[0], len(tracks)), min(window[1], len(tracks))
+			tracks = tracks[start:end]
+		}
+		if strings.HasSuffix(cmd, "add") {
+			h.pm.LoadTracks(tracks, Append, false)
+			return nil
+		}
+		for _, tr := range tracks {
+			writeMPDSong(w, tr, -1, 0)
+		}
+	case "list":
+		return h.list(args, w)
+	case "lsinfo":
+		if len(args) == 0 || args[0] == "" || args[0] == "/" {
+			return h.writePlaylists(w)
+		}
+		items, err := h.resolveURI(args[0])
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			writeMPDSong(w, item, -1, 0)
+		}
+	case "update", "rescan":
+		mp, err := h.server()
+		if err != nil {
+			return err
+		}
+		if err := mp.RescanLibrary(); err != nil {
+			return err
+		}
+		w.Field("updating_db", 1)
+
+	// stored playlists
+	case "listplaylists":
+		return h.writePlaylists(w)
+	case "listplaylist", "listplaylistinfo", "load":
+		pl, err := h.playlistByName(args)
+		if err != nil {
+			return err
+		}
+		switch cmd {
+		case "load":
+			h.pm.LoadTracks(pl.Tracks, Append, false)
+		case "listplaylist":
+			for _, tr := range pl.Tracks {
+				w.Field("file", mpdTrackURIPrefix+tr.ID)
+			}
+		default:
+			for _, tr := range pl.Tracks {
+				writeMPDSong(w, tr, -1, 0)
+			}
+		}
+	case "save":
+		if len(args) == 0 {
+			return mpd.Errorf(mpd.ErrArg, "missing playlist name")
+		}
+		mp, err := h.server()
+		if err != nil {
+			return err
+		}
+		var trackIDs []string
+		for _, item := range h.pm.GetActivePlayQueue() {
+			if tr, ok := item.(*mediaprovider.Track); ok {
+				trackIDs = append(trackIDs, tr.ID)
+			}
+		}
+		if err := mp.CreatePlaylistWithTracks(args[0], trackIDs); err != nil {
+			return err
+		}
+		h.notify(mpd.SubsystemStoredPlaylist)
+	case "rm", "playlistadd", "playlistdelete":
+		pl, err := h.playlistByName(args)
+		if err != nil {
+			return err
+		}
+		mp, _ := h.server()
+		switch cmd {
+		case "rm":
+			err = mp.DeletePlaylist(pl.ID)
+		case "playlistadd":
+			if len(args) < 2 {
+				return mpd.Errorf(mpd.ErrArg, "missing URI")
+			}
+			items, rErr := h.resolveURI(args[1])
+			if rErr != nil {
+				return rErr
+			}
+			var trackIDs []string
+			for _, item := range items {
+				if tr, ok := item.(*mediaprovider.Track); ok {
+					trackIDs = append(trackIDs, tr.ID)
+				}
+			}
+			err = mp.AddPlaylistTracks(pl.ID, trackIDs)
+		case "playlistdelete":
+			if len(args) < 2 {
+				return mpd.Errorf(mpd.ErrArg, "missing song position")
+			}
+			start, end, rErr := parseMPDRange(args[1], len(pl.Tracks))
+			if rErr != nil {
+				return rErr
+			}
+			idxs := make([]int, 0, end-start)
+			for i := start; i < end; i++ {
+				idxs = append(idxs, i)
+			}
+			err = mp.RemovePlaylistTracks(pl.ID, idxs)
+		}
+		if err != nil {
+			return err
+		}
+		h.notify(mpd.SubsystemStoredPlaylist)
+
+	// server capabilities
+	case "tagtypes":
+		if len(args) == 0 {
+			for _, t := range mpdTagTypes {
+				w.Field("tagtype", t)
+			}
+		}
+	case "outputs":
+		w.Field("outputid", 0)
+		w.Field("outputname", "Supersonic")
+		w.Field("plugin", "supersonic")
+		w.Field("outputenabled", 1)
+	case "enableoutput", "disableoutput", "decoders", "urlhandlers":
+		// nothing to report
+	default:
+		return mpd.Errorf(mpd.ErrUnknown, "unknown command %q", cmd)
+	}
+	return nil
+}
+
+func (h *mpdHandler) writeStatus(w *mpd.ResponseWriter) {
+	stat := h.pm.PlaybackStatus()
+	queue := h.pm.GetActivePlayQueue()
+	idx := h.pm.NowPlayingIndex()
+	loop := h.pm.GetLoopMode()
+
+	w.Field("volume", h.pm.Volume())
+	w.Field("repeat", boolToMPD(loop != LoopNone))
+	w.Field("random", boolToMPD(h.pm.IsShuffle()))
+	w.Field("single", boolToMPD(loop == LoopOne))
+	w.Field("consume", 0)
+	w.Field("playlist", h.playlistVersion.Load())
+	w.Field("playlistlength", len(queue))
+	w.Field("mixrampdb", "0.000000")
+	switch stat.State {
+	case player.Playing:
+		w.Field("state", "play")
+	case player.Paused:
+		w.Field("state", "pause")
+	default:
+		w.Field("state", "stop")
+	}
+	if idx < 0 || idx >= len(queue) {
+		return
+	}
+	ids := h.songIDs.forQueue(queue)
+	w.Field("song", idx)
+	w.Field("songid", ids[idx])
+	if stat.State != player.Stopped {
+		w.Field("time", fmt.Sprintf("%d:%d", int(stat.TimePos), int(stat.Duration)))
+		w.Field("elapsed", fmt.Sprintf("%.3f", stat.TimePos))
+		w.Field("duration", fmt.Sprintf("%.3f", stat.Duration))
+		if br := queue[idx].Metadata().BitRate; br > 0 {
+			w.Field("bitrate", br)
+		}
+	}
+	next := idx + 1
+	if next >= len(queue) && loop == LoopAll {
+		next = 0
+	}
+	if next < len(queue) {
+		w.Field("nextsong", next)
+		w.Field("nextsongid", ids[next])
+	}
+}
+
+// resume continues playback if paused, or else starts the current or first song.
+func (h *mpdHandler) resume(queueLen int) {
+	if h.pm.PlaybackStatus().State == player.Paused {
+		h.pm.Continue()
+	} else if idx := h.pm.NowPlayingIndex(); idx >= 0 {
+		h.pm.PlayTrackAt(idx)
+	} else if queueLen > 0 {
+		h.pm.PlayTrackAt(0)
+	}
+}
+
+// insertItems adds items to the queue at pos
+func (h *mpdHandler) insertItems(items []mediaprovider.MediaItem, pos int) {
+	queue := h.pm.GetActivePlayQueue()
+	h.pm.LoadItems(items, Append, false)
+	if pos < len(queue) {
+		// commands are run in order, so this applies after the items are appended
+		h.pm.UpdatePlayQueue(slices.Insert(slices.Clone(queue), pos, items...))
+	}
+}
+
+func (h *mpdHandler) server() (mediaprovider.MediaProvider, error) {
+	if mp := h.sm.Server; mp != nil {
+		return mp, nil
+	}
+	return nil, errors.New("not connected to a server")
+}
+
+// resolveURI returns the items identified by a track/, album/, playlist/ or radio/ URI.
+// A URI without a prefix is treated as a track ID.
+func (h *mpdHandler) resolveURI(uri string) ([]mediaprovider.MediaItem, error) {
+	mp, err := h.server()
+	if err != nil {
+		return nil, err
+	}
+	kind, id, ok := strings.Cut(uri, "/")
+	if !ok || id == "" {
+		kind, id = "track", uri
+	}
+	var tracks []*mediaprovider.Track
+	switch kind + "/" {
+	case mpdTrackURIPrefix:
+		tr, err := mp.GetTrack(id)
+		if err != nil {
+			return nil, mpd.Errorf(mpd.ErrNoExist, "no such song")
+		}
+		tracks = []*mediaprovider.Track{tr}
+	case mpdAlbumURIPrefix:
+		al, err := mp.GetAlbum(id)
+		if err != nil {
+			return nil, mpd.Errorf(mpd.ErrNoExist, "no such album")
+		}
+		tracks = al.Tracks
+	case mpdPlaylistURIPrefix:
+		pl, err := mp.GetPlaylist(id)
+		if err != nil {
+			return nil, mpd.Errorf(mpd.ErrNoExist, "no such playlist")
+		}
+		tracks = pl.Tracks
+	case mpdRadioURIPrefix:
+		rp, ok := mp.(mediaprovider.RadioProvider)
+		if !ok {
+			return nil, mpd.Errorf(mpd.ErrNoExist, "radio stations are not supported by this server")
+		}
+		rs, err := rp.GetRadioStation(id)
+		if err != nil {
+			return nil, mpd.Errorf(mpd.ErrNoExist, "no such radio station")
+		}
+		return []mediaprovider.MediaItem{rs}, nil
+	default:
+		return nil, mpd.Errorf(mpd.ErrNoExist, "unsupported URI %q", uri)
+	}
+	return sharedutil.MapSlice(tracks, func(tr *mediaprovider.Track) mediaprovider.MediaItem { return tr }), nil
+}
+
+func (h *mpdHandler) playlistByName(args []string) (*mediaprovider.PlaylistWithTracks, error) {
+	if len(args) == 0 {
+		return nil, mpd.Errorf(mpd.ErrArg, "missing playlist name")
+	}
+	mp, err := h.server()
+	if err != nil {
+		return nil, err
+	}
+	playlists, err := mp.GetPlaylists()
+	if err != nil {
+		return nil, err
+	}
+	idx := slices.IndexFunc(playlists, func(p *mediaprovider.Playlist) bool { return p.Name == args[0] })
+	if idx < 0 {
+		return nil, mpd.Errorf(mpd.ErrNoExist, "no such playlist")
+	}
+	return mp.GetPlaylist(playlists[idx].ID)
+}
+
+func (h *mpdHandler) writePlaylists(w *mpd.ResponseWriter) error {
+	mp, err := h.server()
+	if err != nil {
+		return err
+	}
+	playlists, err := mp.GetPlaylists()
+	if err != nil {
+		return err
+	}
+	for _, p := range playlists {
+		w.Field("playlist", p.Name)
+	}
+	return nil
+}
+
+// searchTracks finds the tracks matching the filter. The server is searched using the
+// first title, artist or album condition, and the results are then filtered locally.
+func (h *mpdHandler) searchTracks(filter mpd.Filter) ([]*mediaprovider.Track, error) {
+	mp, err := h.server()
+	if err != nil {
+		return nil, err
+	}
+	var query, genre string
+	for _, c := range filter {
+		if c.Op == mpd.OpNotEqual || c.Value == "" {
+			continue
+		}
+		switch c.Tag {
+		case "file":
+			if id, ok := strings.CutPrefix(c.Value, mpdTrackURIPrefix); ok {
+				if tr, err := mp.GetTrack(id); err == nil {
+					return []*mediaprovider.Track{tr}, nil
+				}
+				return nil, nil
+			}
+		case "any", "title", "artist", "albumartist", "album", "composer":
+			if query == "" {
+				query = c.Value
+			}
+		case "genre":
+			genre = c.Value
+		}
+	}
+
+	var tracks []*mediaprovider.Track
+	matches := func(tr *mediaprovider.Track) bool {
+		return filter.Matches(func(tag string) []string { return mpdTrackTagValues(tr, tag) })
+	}
+	switch {
+	case query != "":
+		iter := mp.IterateTracks(query)
+		for tr := iter.Next(); tr != nil && len(tracks) < mpdMaxSearchResults; tr = iter.Next() {
+			if matches(tr) {
+				tracks = append(tracks, tr)
+			}
+		}
+	case genre != "":
+		iter := mp.IterateAlbums(mediaprovider.AlbumSortArtistAZ,
+			mediaprovider.NewAlbumFilter(mediaprovider.AlbumFilterOptions{Genres: []string{genre}}))
+		for i, al := 0, iter.Next(); al != nil && i < mpdMaxGenreSearchAlbums; i, al = i+1, iter.Next() {
+			album, err := mp.GetAlbum(al.ID)
+			if err != nil {
+				continue
+			}
+			for _, tr := range album.Tracks {
+				if matches(tr) {
+					tracks = append(tracks, tr)
+				}
+			}
+		}
+	default:
+		return nil, mpd.Errorf(mpd.ErrArg, "filter must include a title, artist, album or genre")
+	}
+	return tracks, nil
+}
+
+func (h *mpdHandler) list(args []string, w *mpd.ResponseWriter) error {
+	if len(args) == 0 {
+		return mpd.Errorf(mpd.ErrArg, "missing tag type")
+	}
+	mp, err := h.server()
+	if err != nil {
+		return err
+	}
+	tag := strings.ToLower(args[0])
+	key := mpdTagKey(tag)
+	args = args[1:]
+	if tag == "album" && len(args) == 1 && !strings.HasPrefix(args[0], "(") {
+		// legacy form: list album <artist>
+		args = []string{"artist", args[0]}
+	}
+	filter, _, err := parseMPDFilterArgs(args, true)
+	if err != nil {
+		return err
+	}
+
+	var values []string
+	switch {
+	case len(filter) == 0 && (tag == "artist" || tag == "albumartist"):
+		iter := mp.IterateArtists(mediaprovider.ArtistSortNameAZ, mediaprovider.NewArtistFilter(mediaprovider.ArtistFilterOptions{}))
+		for ar := iter.Next(); ar != nil; ar = iter.Next() {
+			values = append(values, ar.Name)
+		}
+	case len(filter) == 0 && tag == "album":
+		iter := mp.IterateAlbums(mediaprovider.AlbumSortTitleAZ, mediaprovider.NewAlbumFilter(mediaprovider.AlbumFilterOptions{}))
+		for al := iter.Next(); al != nil; al = iter.Next() {
+			values = append(values, al.Name)
+		}
+	case len(filter) == 0 && tag == "genre":
+		genres, err := mp.GetGenres()
+		if err != nil {
+			return err
+		}
+		for _, g := range genres {
+			values = append(values, g.Name)
+		}
+	case len(filter) == 0:
+		// listing other tags would require scanning the whole library
+	case tag == "album" && len(filter) == 1 && filter[0].Op == mpd.OpEqual &&
+		(filter[0].Tag == "artist" || filter[0].Tag == "albumartist"):
+		iter := mp.SearchArtists(filter[0].Value, mediaprovider.NewArtistFilter(mediaprovider.ArtistFilterOptions{}))
+		for ar := iter.Next(); ar != nil; ar = iter.Next() {
+			if !strings.EqualFold(ar.Name, filter[0].Value) {
+				continue
+			}
+			artist, err := mp.GetArtist(ar.ID)
+			if err != nil {
+				return err
+			}
+			for _, al := range artist.Albums {
+				values = append(values, al.Name)
+			}
+			break
+		}
+	default:
+		tracks, err := h.searchTracks(filter)
+		if err != nil {
+			return err
+		}
+		for _, tr := range tracks {
+			values = append(values, mpdTrackTagValues(tr, tag)...)
+		}
+	}
+
+	seen := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		if _, ok := seen[v]; !ok && v != "" {
+			seen[v] = struct{}{}
+			w.Field(key, v)
+		}
+	}
+	return nil
+}
+
+// writeMPDSong writes the song fields of item. If pos >= 0, its queue position and ID are included.
+func writeMPDSong(w *mpd.ResponseWriter, item mediaprovider.MediaItem, pos, id int) {
+	switch it := item.(type) {
+	case *mediaprovider.Track:
+		w.Field("file", mpdTrackURIPrefix+it.ID)
+		for _, tag := range mpdTagTypes {
+			for _, v := range mpdTrackTagValues(it, strings.ToLower(tag)) {
+				if v != "" && v != "0" {
+					w.Field(tag, v)
+				}
+			}
+		}
+		w.Field("Time", int(it.Duration.Seconds()))
+		w.Field("duration", fmt.Sprintf("%.3f", it.Duration.Seconds()))
+	case *mediaprovider.RadioStation:
+		w.Field("file", mpdRadioURIPrefix+it.ID)
+		w.Field("Name", it.StationName)
+		w.Field("Title", it.StationName)
+	default:
+		meta := item.Metadata()
+		w.Field("file", meta.ID)
+		w.Field("Title", meta.Name)
+	}
+	if pos >= 0 {
+		w.Field("Pos", pos)
+		w.Field("Id", id)
+	}
+}
+
+// mpdTrackTagValues returns the values of the given lowercase MPD tag for the track
+func mpdTrackTagValues(tr *mediaprovider.Track, tag string) []string {
+	switch tag {
+	case "any":
+		vals := []string{tr.Title, tr.Album}
+		vals = append(vals, tr.ArtistNames...)
+		vals = append(vals, tr.AlbumArtistNames...)
+		vals = append(vals, tr.ComposerNames...)
+		return append(vals, tr.Genres...)
+	case "title":
+		return []string{tr.Title}
+	case "artist":
+		return tr.ArtistNames
+	case "albumartist":
+		if len(tr.AlbumArtistNames) == 0 {
+			return tr.ArtistNames
+		}
+		return tr.AlbumArtistNames
+	case "album":
+		return []string{tr.Album}
+	case "genre":
+		return tr.Genres
+	case "composer":
+		return tr.ComposerNames
+	case "date", "originaldate":
+		return []string{strconv.Itoa(tr.Year)}
+	case "track":
+		return []string{strconv.Itoa(tr.TrackNumber)}
+	case "disc":
+		return []string{strconv.Itoa(tr.DiscNumber)}
+	case "file":
+		return []string{mpdTrackURIPrefix + tr.ID}
+	default:
+		return nil
+	}
+}
+
+// mpdTagKey returns the response key for a lowercase tag name
+func mpdTagKey(tag string) string {
+	for _, t := range mpdTagTypes {
+		if strings.EqualFold(t, tag) {
+			return t
+		}
+	}
+	return tag
+}
+
+// parseMPDFilterArgs parses filter arguments, along with the optional
+// trailing "sort <tag>" (ignored) and "window <start:end>" arguments.
+func parseMPDFilterArgs(args []string, exact bool) (mpd.Filter, []int, error) {
+	var window []int
+	for len(args) >= 2 {
+		switch strings.ToLower(args[len(args)-2]) {
+		case "sort":
+			args = args[:len(args)-2]
+			continue
+		case "window":
+			start, end, err := parseMPDRange(args[len(args)-1], mpdMaxSearchResults)
+			if err != nil {
+				return nil, nil, err
+			}
+			window = []int{start, end}
+			args = args[:len(args)-2]
+			continue
+		}
+		break
+	}
+	filter, err := mpd.ParseFilter(args, exact)
+	return filter, window, err
+}
+
+// parseMPDRange parses a "pos" or "start:end" argument, where end may be omitted,
+// into a half-open range within [0, length).
+func parseMPDRange(arg string, length int) (int, int, error) {
+	startStr, endStr, isRange := strings.Cut(arg, ":")
+	start, err := strconv.Atoi(startStr)
+	if err != nil {
+		return 0, 0, mpd.Errorf(mpd.ErrArg, "invalid range %q", arg)
+	}
+	end := start + 1
+	if isRange {
+		end = length
+		if endStr != "" {
+			if end, err = strconv.Atoi(endStr); err != nil {
+				return 0, 0, mpd.Errorf(mpd.ErrArg, "invalid range %q", arg)
+			}
+		}
+	}
+	if start < 0 || start > end || end > length || (!isRange && start >= length) {
+		return 0, 0, mpd.Errorf(mpd.ErrArg, "bad song index")
+	}
+	return start, end, nil
+}
+
+// parseMPDSongID converts a song ID to its position in the queue with the given song IDs
+func parseMPDSongID(arg string, ids []int) (int, error) {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, mpd.Errorf(mpd.ErrNoExist, "no such song")
+	}
+	pos := slices.Index(ids, id)
+	if pos < 0 {
+		return 0, mpd.Errorf(mpd.ErrNoExist, "no such song")
+	}
+	return pos, nil
+}
+
+func parseMPDInt(arg string) (int, error) {
+	i, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, mpd.Errorf(mpd.ErrArg, "integer expected: %s", arg)
+	}
+	return i, nil
+}
+
+func parseMPDBool(args []string) (bool, error) {
+	if len(args) != 1 || (args[0] != "0" && args[0] != "1") {
+		return false, mpd.Errorf(mpd.ErrArg, "boolean (0/1) expected")
+	}
+	return args[0] == "1", nil
+}
+
+func boolToMPD(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,48 @@
+package backend
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+func TestMPDSongIDs(t *testing.T) {
+	tr := func(id string) mediaprovider.MediaItem { return &mediaprovider.Track{ID: id} }
+	var s mpdSongIDs
+
+	ids := s.forQueue([]mediaprovider.MediaItem{tr("a"), tr("b"), tr("a")})
+	if !slices.Equal(ids, []int{1, 2, 3}) {
+		t.Fatalf("unexpected initial IDs %v", ids)
+	}
+
+	// IDs follow their songs when the queue is reordered or changed
+	ids = s.forQueue([]mediaprovider.MediaItem{tr("c"), tr("b"), tr("a"), tr("a")})
+	if !slices.Equal(ids, []int{4, 2, 1, 3}) {
+		t.Errorf("unexpected IDs after reorder %v", ids)
+	}
+	ids = s.forQueue([]mediaprovider.MediaItem{tr("a"), tr("c")})
+	if !slices.Equal(ids, []int{1, 4}) {
+		t.Errorf("unexpected IDs after removal %v", ids)
+	}
+
+	// songs being added get their reserved IDs once in the queue
+	reserved := s.reserve([]mediaprovider.MediaItem{tr("d")})
+	if !slices.Equal(reserved, []int{5}) {
+		t.Errorf("unexpected reserved IDs %v", reserved)
+	}
+	if ids = s.forQueue([]mediaprovider.MediaItem{tr("a"), tr("c")}); !slices.Equal(ids, []int{1, 4}) {
+		t.Errorf("unexpected IDs before add %v", ids)
+	}
+	ids = s.forQueue([]mediaprovider.MediaItem{tr("d"), tr("a"), tr("c"), tr("e")})
+	if !slices.Equal(ids, []int{5, 1, 4, 6}) {
+		t.Errorf("unexpected IDs after add %v", ids)
+	}
+
+	if _, err := parseMPDSongID("2", ids); err == nil {
+		t.Error("expected removed song ID to be rejected")
+	}
+	if pos, err := parseMPDSongID("4", ids); err != nil || pos != 2 {
+		t.Errorf("expected song ID 4 at position 2, got %d, %v", pos, err)
+	}
+}
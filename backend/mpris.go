@@ -1,9 +1,11 @@
 package backend
 
 import (
+	_ "embed"
 	"encoding/base32"
 	"errors"
 	"strconv"
+	"sync"
 
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
 	"github.com/dweymouth/supersonic/backend/player"
@@ -14,8 +16,9 @@ import (
 )
 
 const (
-	dbusTrackIDPrefix = "/Supersonic/Track/"
-	noTrackObjectPath = "/org/mpris/MediaPlayer2/TrackList/NoTrack"
+	dbusTrackIDPrefix    = "/Supersonic/Track/"
+	dbusPlaylistIDPrefix = "/Supersonic/Playlist/"
+	noTrackObjectPath    = "/org/mpris/MediaPlayer2/TrackList/NoTrack"
 )
 
 var (
@@ -24,6 +27,12 @@ var (
 	_ types.OrgMprisMediaPlayer2PlayerAdapterLoopStatus = (*MPRISHandler)(nil)
 )
 
+// introspection data for all the MPRIS interfaces, including
+// the TrackList and Playlists interfaces we export ourselves
+//
+//go:embed mpris_introspect.xml
+var mprisIntrospectXML string
+
 var errNotSupported = errors.New("not supported")
 
 type MPRISHandler struct {
@@ -37,12 +46,20 @@ type MPRISHandler struct {
 	// Function to look up the artwork URL for a given track ID
 	ArtURLLookup func(trackID string) (string, error)
 
-	connErr      error
-	playerName   string
+	connErr    error
+	playerName string
+	pm         *PlaybackManager
+	sm         *ServerManager
+	s          *server.Server
+	evt        *events.EventHandler
+
+	// object path of the current track, updated from playback callbacks
+	curTrackLock sync.Mutex
 	curTrackPath string // empty for no track
-	pm           *PlaybackManager
-	s            *server.Server
-	evt          *events.EventHandler
+
+	// playlists as last fetched from the server, for the Playlists interface
+	playlistsLock sync.Mutex
+	playlists     []*mediaprovider.Playlist
 
 	// current radio metadata
 	radioStationName string
@@ -50,8 +67,8 @@ type MPRISHandler struct {
 	radioIcyArtist   string
 }
 
-func NewMPRISHandler(playerName string, pm *PlaybackManager) *MPRISHandler {
-	m := &MPRISHandler{playerName: playerName, pm: pm, connErr: errors.New("not started")}
+func NewMPRISHandler(playerName string, pm *PlaybackManager, sm *ServerManager) *MPRISHandler {
+	m := &MPRISHandler{playerName: playerName, pm: pm, sm: sm, connErr: errors.New("not started")}
 	m.s = server.NewServer(playerName, m, m)
 	m.evt = events.NewEventHandler(m.s)
 
@@ -63,14 +80,28 @@ func NewMPRISHandler(playerName string, pm *PlaybackManager) *MPRISHandler {
 	})
 	pm.OnSongChange(func(tr mediaprovider.MediaItem, _ *mediaprovider.Track) {
 		if tr == nil {
-			m.curTrackPath = ""
+			m.setCurTrackPath("")
 		} else {
-			m.curTrackPath = dbusTrackIDPrefix + encodeTrackId(tr.Metadata().ID)
+			m.setCurTrackPath(string(trackObjectPath(pm.NowPlayingIndex(), tr)))
 		}
 		if m.connErr == nil {
 			m.evt.Player.OnTitle()
 		}
 	})
+	pm.OnQueueChange(func() {
+		// track object paths include the queue position, so they change with the queue
+		if tr := pm.NowPlaying(); tr != nil {
+			m.setCurTrackPath(string(trackObjectPath(pm.NowPlayingIndex(), tr)))
+		}
+		if m.connErr == nil {
+			m.emitTrackListReplaced()
+		}
+	})
+	sm.OnServerConnected(func(*ServerConfig) {
+		m.playlistsLock.Lock()
+		m.playlists = nil
+		m.playlistsLock.Unlock()
+	})
 	pm.OnRadioMetadataChange(func(radioName, title, artist string) {
 		m.radioStationName = radioName
 		m.radioIcyTitle = title
@@ -101,12 +132,24 @@ func NewMPRISHandler(playerName string, pm *PlaybackManager) *MPRISHandler {
 	return m
 }
 
+func (m *MPRISHandler) setCurTrackPath(path string) {
+	m.curTrackLock.Lock()
+	defer m.curTrackLock.Unlock()
+	m.curTrackPath = path
+}
+
+func (m *MPRISHandler) currentTrackPath() string {
+	m.curTrackLock.Lock()
+	defer m.curTrackLock.Unlock()
+	return m.curTrackPath
+}
+
 // Starts listening for MPRIS events.
 func (m *MPRISHandler) Start() {
 	m.connErr = nil
 	go func() {
 		// exits early with err if unable to establish D-Bus connection
-		m.connErr = m.s.Listen()
+		m.connErr = m.listen()
 	}()
 }
 
@@ -147,7 +190,7 @@ func (m *MPRISHandler) Raise() error {
 }
 
 func (m *MPRISHandler) HasTrackList() (bool, error) {
-	return false, nil
+	return true, nil
 }
 
 func (m *MPRISHandler) SupportedUriSchemes() ([]string, error) {
@@ -204,7 +247,7 @@ func (m *MPRISHandler) Seek(offset types.Microseconds) error {
 }
 
 func (m *MPRISHandler) SetPosition(trackId string, position types.Microseconds) error {
-	if m.curTrackPath == trackId {
+	if m.currentTrackPath() == trackId {
 		m.pm.SeekSeconds(microsecondsToSeconds(position))
 	}
 	return nil
@@ -262,19 +305,38 @@ func (m *MPRISHandler) SetRate(float64) error {
 
 func (m *MPRISHandler) Metadata() (types.Metadata, error) {
 	trackObjPath := noTrackObjectPath
-	if m.curTrackPath != "" {
-		trackObjPath = m.curTrackPath
+	if path := m.currentTrackPath(); path != "" {
+		trackObjPath = path
 	}
 	status := m.pm.PlaybackStatus()
 
+	var np mediaprovider.MediaItem
+	if status.State != player.Stopped {
+		np = m.pm.NowPlaying()
+	}
+	mprisMeta := m.itemMetadata(np, dbus.ObjectPath(trackObjPath), true)
+	mprisMeta.Length = secondsToMicroseconds(status.Duration)
+
+	// if playing a radio station, override title/artist with current Icy metadata if present
+	if m.radioStationName == mprisMeta.Title && m.radioIcyTitle != "" {
+		mprisMeta.Title = m.radioIcyTitle
+		mprisMeta.Artist = []string{m.radioIcyArtist}
+		mprisMeta.Album = m.radioStationName
+	}
+	return mprisMeta, nil
+}
+
+// itemMetadata builds the MPRIS metadata for a media item, which may be nil.
+// Looking up the artwork URL caches the cover image locally, so it is optional.
+func (m *MPRISHandler) itemMetadata(item mediaprovider.MediaItem, objPath dbus.ObjectPath, withArt bool) types.Metadata {
 	var meta mediaprovider.MediaItemMetadata
 	// metadata that can come only from tracks
 	var discNumber, trackNumber, userRating, playCount, year int
 	var genres []string
 
-	if np := m.pm.NowPlaying(); np != nil && status.State != player.Stopped {
-		meta = np.Metadata()
-		if track, ok := np.(*mediaprovider.Track); ok {
+	if item != nil {
+		meta = item.Metadata()
+		if track, ok := item.(*mediaprovider.Track); ok {
 			discNumber = track.DiscNumber
 			trackNumber = track.TrackNumber
 			userRating = track.Rating
@@ -284,28 +346,18 @@ func (m *MPRISHandler) Metadata() (types.Metadata, error) {
 		}
 	}
 	var artURL string
-	if meta.ID != "" && m.ArtURLLookup != nil {
+	if withArt && meta.ID != "" && m.ArtURLLookup != nil {
 		if u, err := m.ArtURLLookup(meta.CoverArtID); err == nil {
 			artURL = u
 		}
 	}
 
-	title := meta.Name
-	artists := meta.Artists
-	album := meta.Album
-	// if playing a radio station, override title/artist with current Icy metadata if present
-	if m.radioStationName == title && m.radioIcyTitle != "" {
-		title = m.radioIcyTitle
-		artists = []string{m.radioIcyArtist}
-		album = m.radioStationName
-	}
-
 	mprisMeta := types.Metadata{
-		TrackId:     dbus.ObjectPath(trackObjPath),
-		Length:      secondsToMicroseconds(status.Duration),
-		Title:       title,
-		Album:       album,
-		Artist:      artists,
+		TrackId:     objPath,
+		Length:      types.Microseconds(meta.Duration.Microseconds()),
+		Title:       meta.Name,
+		Album:       meta.Album,
+		Artist:      meta.Artists,
 		DiscNumber:  discNumber,
 		TrackNumber: trackNumber,
 		UserRating:  float64(userRating) / 5,
@@ -316,7 +368,7 @@ func (m *MPRISHandler) Metadata() (types.Metadata, error) {
 	if year != 0 {
 		mprisMeta.ContentCreated = strconv.Itoa(year)
 	}
-	return mprisMeta, nil
+	return mprisMeta
 }
 
 func (m *MPRISHandler) Volume() (float64, error) {
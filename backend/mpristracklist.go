@@ -0,0 +1,436 @@
+package backend
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+	"github.com/quarckster/go-mpris-server/pkg/types"
+)
+
+const (
+	mprisObjectPath     = "/org/mpris/MediaPlayer2"
+	mprisRootIface      = "org.mpris.MediaPlayer2"
+	mprisPlayerIface    = "org.mpris.MediaPlayer2.Player"
+	mprisTrackListIface = "org.mpris.MediaPlayer2.TrackList"
+	mprisPlaylistsIface = "org.mpris.MediaPlayer2.Playlists"
+
+	mprisOrderAlphabetical = "Alphabetical"
+	mprisOrderUserDefined  = "UserDefined"
+)
+
+var errUnknownTrackID = errors.New("unknown track ID")
+
+// mprisProperty is a D-Bus property getter and optional setter
+type mprisProperty struct {
+	get func() (any, error)
+	set func(dbus.Variant) error
+}
+
+// listen claims the MPRIS bus name and exports the MPRIS interfaces.
+// We don't use server.Listen, since go-mpris-server only implements
+// the root and Player interfaces, and not TrackList or Playlists.
+// The exported interfaces are removed by server.Stop.
+func (m *MPRISHandler) listen() error {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return err
+	}
+	m.s.Conn = conn
+	serviceName := "org.mpris.MediaPlayer2." + m.playerName
+	reply, err := conn.RequestName(serviceName, dbus.NameFlagReplaceExisting)
+	if err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		conn.Close()
+		return errors.New("Unable to claim " + serviceName)
+	}
+	if err := m.exportInterfaces(conn); err != nil {
+		conn.ReleaseName(serviceName)
+		conn.Close()
+		return err
+	}
+	return nil
+}
+
+func (m *MPRISHandler) exportInterfaces(conn *dbus.Conn) error {
+	props := m.properties()
+	tables := []struct {
+		iface   string
+		methods map[string]any
+	}{
+		{"org.freedesktop.DBus.Introspectable", map[string]any{
+			"Introspect": introspect.Introspectable(mprisIntrospectXML).Introspect,
+		}},
+		{mprisRootIface, map[string]any{
+			"Raise": func() *dbus.Error { return dbusError(m.Raise()) },
+			"Quit":  func() *dbus.Error { return dbusError(m.Quit()) },
+		}},
+		{mprisPlayerIface, map[string]any{
+			"Next":      func() *dbus.Error { return dbusError(m.Next()) },
+			"Previous":  func() *dbus.Error { return dbusError(m.Previous()) },
+			"Pause":     func() *dbus.Error { return dbusError(m.Pause()) },
+			"PlayPause": func() *dbus.Error { return dbusError(m.PlayPause()) },
+			"Stop":      func() *dbus.Error { return dbusError(m.Stop()) },
+			"Play":      func() *dbus.Error { return dbusError(m.Play()) },
+			"Seek": func(offset int64) *dbus.Error {
+				return dbusError(m.Seek(types.Microseconds(offset)))
+			},
+			"SetPosition": func(trackID dbus.ObjectPath, pos int64) *dbus.Error {
+				return dbusError(m.SetPosition(string(trackID), types.Microseconds(pos)))
+			},
+			"OpenUri": func(uri string) *dbus.Error { return dbusError(m.OpenUri(uri)) },
+		}},
+		{mprisTrackListIface, map[string]any{
+			"GetTracksMetadata": m.getTracksMetadata,
+			"AddTrack":          m.addTrack,
+			"RemoveTrack":       m.removeTrack,
+			"GoTo":              m.goTo,
+		}},
+		{mprisPlaylistsIface, map[string]any{
+			"ActivatePlaylist": m.activatePlaylist,
+			"GetPlaylists":     m.getPlaylists,
+		}},
+		{"org.freedesktop.DBus.Properties", map[string]any{
+			"Get": func(iface, name string) (dbus.Variant, *dbus.Error) {
+				p, err := lookupProperty(props, iface, name)
+				if err != nil {
+					return dbus.Variant{}, err
+				}
+				v, e := p.get()
+				if e != nil {
+					return dbus.Variant{}, dbus.MakeFailedError(e)
+				}
+				return dbus.MakeVariant(v), nil
+			},
+			"GetAll": func(iface string) (map[string]dbus.Variant, *dbus.Error) {
+				ifaceProps, ok := props[iface]
+				if !ok {
+					return nil, prop.ErrIfaceNotFound
+				}
+				all := make(map[string]dbus.Variant, len(ifaceProps))
+				for name, p := range ifaceProps {
+					v, err := p.get()
+					if err != nil {
+						return nil, dbus.MakeFailedError(err)
+					}
+					all[name] = dbus.MakeVariant(v)
+				}
+				return all, nil
+			},
+			"Set": func(iface, name string, value dbus.Variant) *dbus.Error {
+				p, err := lookupProperty(props, iface, name)
+				if err != nil {
+					return err
+				}
+				if p.set == nil {
+					return prop.ErrReadOnly
+				}
+				if err := p.set(value); err != nil {
+					return dbus.MakeFailedError(err)
+				}
+				return dbusError(m.emitPropertiesChanged(iface, map[string]dbus.Variant{name: value}, nil))
+			},
+		}},
+	}
+	for _, t := range tables {
+		if err := conn.ExportSubtreeMethodTable(t.methods, mprisObjectPath, t.iface); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MPRISHandler) properties() map[string]map[string]mprisProperty {
+	get := func(f func() (any, error)) mprisProperty { return mprisProperty{get: f} }
+	return map[string]map[string]mprisProperty{
+		mprisRootIface: {
+			"CanQuit":             get(func() (any, error) { return m.CanQuit() }),
+			"CanRaise":            get(func() (any, error) { return m.CanRaise() }),
+			"HasTrackList":        get(func() (any, error) { return m.HasTrackList() }),
+			"Identity":            get(func() (any, error) { return m.Identity() }),
+			"SupportedUriSchemes": get(func() (any, error) { return m.SupportedUriSchemes() }),
+			"SupportedMimeTypes":  get(func() (any, error) { return m.SupportedMimeTypes() }),
+		},
+		mprisPlayerIface: {
+			"PlaybackStatus": get(func() (any, error) { return m.PlaybackStatus() }),
+			"LoopStatus": {
+				get: func() (any, error) { return m.LoopStatus() },
+				set: func(v dbus.Variant) error {
+					s, ok := v.Value().(string)
+					if !ok {
+						return errors.New("LoopStatus must be a string")
+					}
+					return m.SetLoopStatus(types.LoopStatus(s))
+				},
+			},
+			"Rate": {
+				get: func() (any, error) { return m.Rate() },
+				set: func(dbus.Variant) error { return errNotSupported },
+			},
+			"Metadata": get(func() (any, error) {
+				meta, err := m.Metadata()
+				return meta.MakeMap(), err
+			}),
+			"Volume": {
+				get: func() (any, error) { return m.Volume() },
+				set: func(v dbus.Variant) error {
+					vol, ok := v.Value().(float64)
+					if !ok {
+						return errors.New("Volume must be a double")
+					}
+					return m.SetVolume(vol)
+				},
+			},
+			"Position":      get(func() (any, error) { return m.Position() }),
+			"MinimumRate":   get(func() (any, error) { return m.MinimumRate() }),
+			"MaximumRate":   get(func() (any, error) { return m.MaximumRate() }),
+			"CanGoNext":     get(func() (any, error) { return m.CanGoNext() }),
+			"CanGoPrevious": get(func() (any, error) { return m.CanGoPrevious() }),
+			"CanPlay":       get(func() (any, error) { return m.CanPlay() }),
+			"CanPause":      get(func() (any, error) { return m.CanPause() }),
+			"CanSeek":       get(func() (any, error) { return m.CanSeek() }),
+			"CanControl":    get(func() (any, error) { return m.CanControl() }),
+		},
+		mprisTrackListIface: {
+			"Tracks":        get(func() (any, error) { return m.trackObjectPaths(), nil }),
+			"CanEditTracks": get(func() (any, error) { return true, nil }),
+		},
+		mprisPlaylistsIface: {
+			"PlaylistCount": get(func() (any, error) {
+				playlists, err := m.cachedPlaylists()
+				return uint32(len(playlists)), err
+			}),
+			"Orderings": get(func() (any, error) {
+				return []string{mprisOrderAlphabetical, mprisOrderUserDefined}, nil
+			}),
+			// The play queue isn't tied to a playlist once loaded, so there is never an active one
+			"ActivePlaylist": get(func() (any, error) {
+				return mprisMaybePlaylist{Playlist: mprisPlaylist{ID: "/"}}, nil
+			}),
+		},
+	}
+}
+
+func lookupProperty(props map[string]map[string]mprisProperty, iface, name string) (mprisProperty, *dbus.Error) {
+	ifaceProps, ok := props[iface]
+	if !ok {
+		return mprisProperty{}, prop.ErrIfaceNotFound
+	}
+	p, ok := ifaceProps[name]
+	if !ok {
+		return mprisProperty{}, prop.ErrPropNotFound
+	}
+	return p, nil
+}
+
+func (m *MPRISHandler) emitPropertiesChanged(iface string, changed map[string]dbus.Variant, invalidated []string) error {
+	if m.s.Conn == nil {
+		return errors.New("not connected")
+	}
+	if invalidated == nil {
+		invalidated = []string{}
+	}
+	return m.s.Conn.Emit(mprisObjectPath, "org.freedesktop.DBus.Properties.PropertiesChanged",
+		iface, changed, invalidated)
+}
+
+func dbusError(err error) *dbus.Error {
+	if err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
+
+// TrackList interface
+
+// trackObjectPath returns the MPRIS track ID for the item at idx in the play queue.
+// The index is included since the same track may be in the queue more than once.
+func trackObjectPath(idx int, item mediaprovider.MediaItem) dbus.ObjectPath {
+	return dbus.ObjectPath(dbusTrackIDPrefix + encodeTrackId(item.Metadata().ID) + "_" + strconv.Itoa(idx))
+}
+
+// queueIndexOf returns the play queue index of the MPRIS track ID, or -1
+func queueIndexOf(queue []mediaprovider.MediaItem, trackID dbus.ObjectPath) int {
+	i := strings.LastIndex(string(trackID), "_")
+	if !strings.HasPrefix(string(trackID), dbusTrackIDPrefix) || i < 0 {
+		return -1
+	}
+	idx, err := strconv.Atoi(string(trackID[i+1:]))
+	if err != nil || idx < 0 || idx >= len(queue) || trackObjectPath(idx, queue[idx]) != trackID {
+		return -1
+	}
+	return idx
+}
+
+func (m *MPRISHandler) trackObjectPaths() []dbus.ObjectPath {
+	queue := m.pm.GetActivePlayQueue()
+	paths := make([]dbus.ObjectPath, len(queue))
+	for i, item := range queue {
+		paths[i] = trackObjectPath(i, item)
+	}
+	return paths
+}
+
+func (m *MPRISHandler) emitTrackListReplaced() {
+	if m.s.Conn == nil {
+		return
+	}
+	current := dbus.ObjectPath(noTrackObjectPath)
+	if path := m.currentTrackPath(); path != "" {
+		current = dbus.ObjectPath(path)
+	}
+	m.s.Conn.Emit(mprisObjectPath, mprisTrackListIface+".TrackListReplaced", m.trackObjectPaths(), current)
+	m.emitPropertiesChanged(mprisTrackListIface, map[string]dbus.Variant{}, []string{"Tracks"})
+}
+
+func (m *MPRISHandler) getTracksMetadata(trackIDs []dbus.ObjectPath) ([]map[string]dbus.Variant, *dbus.Error) {
+	queue := m.pm.GetActivePlayQueue()
+	metadata := make([]map[string]dbus.Variant, 0, len(trackIDs))
+	for _, id := range trackIDs {
+		// unknown IDs are skipped, as specified by MPRIS
+		if idx := queueIndexOf(queue, id); idx >= 0 {
+			meta := m.itemMetadata(queue[idx], id, false)
+			metadata = append(metadata, meta.MakeMap())
+		}
+	}
+	return metadata, nil
+}
+
+// addTrack inserts the track with the given ID after afterTrack, or at the start
+// of the queue for the NoTrack path. The URI may be a bare server track ID or
+// have a "track/" prefix, as used by the MPD server.
+func (m *MPRISHandler) addTrack(uri string, afterTrack dbus.ObjectPath, setAsCurrent bool) *dbus.Error {
+	if m.sm.Server == nil {
+		return dbus.MakeFailedError(ErrNoServers)
+	}
+	tr, err := m.sm.Server.GetTrack(strings.TrimPrefix(uri, "track/"))
+	if err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	queue := m.pm.GetActivePlayQueue()
+	insertIdx := 0
+	if afterTrack != noTrackObjectPath {
+		idx := queueIndexOf(queue, afterTrack)
+		if idx < 0 {
+			return dbus.MakeFailedError(errUnknownTrackID)
+		}
+		insertIdx = idx + 1
+	}
+	// append to both the shuffled and unshuffled queues, then move it into place
+	// in the active one; commands are run in order, so this applies after the append
+	item := mediaprovider.MediaItem(tr)
+	m.pm.LoadItems([]mediaprovider.MediaItem{item}, Append, false)
+	if insertIdx < len(queue) {
+		m.pm.UpdatePlayQueue(slices.Insert(queue, insertIdx, item))
+	}
+	if setAsCurrent {
+		m.pm.PlayTrackAt(insertIdx)
+	}
+	return nil
+}
+
+func (m *MPRISHandler) removeTrack(trackID dbus.ObjectPath) *dbus.Error {
+	idx := queueIndexOf(m.pm.GetActivePlayQueue(), trackID)
+	if idx < 0 {
+		return dbus.MakeFailedError(errUnknownTrackID)
+	}
+	m.pm.RemoveTracksFromQueue([]int{idx})
+	return nil
+}
+
+func (m *MPRISHandler) goTo(trackID dbus.ObjectPath) *dbus.Error {
+	idx := queueIndexOf(m.pm.GetActivePlayQueue(), trackID)
+	if idx < 0 {
+		return dbus.MakeFailedError(errUnknownTrackID)
+	}
+	m.pm.PlayTrackAt(idx)
+	return nil
+}
+
+// Playlists interface
+
+// mprisPlaylist is the D-Bus (oss) playlist struct: ID, name, icon
+type mprisPlaylist struct {
+	ID   dbus.ObjectPath
+	Name string
+	Icon string
+}
+
+// mprisMaybePlaylist is the D-Bus (b(oss)) struct for the ActivePlaylist property
+type mprisMaybePlaylist struct {
+	Valid    bool
+	Playlist mprisPlaylist
+}
+
+// cachedPlaylists returns the playlists last fetched by GetPlaylists,
+// fetching them from the server if they haven't been yet.
+func (m *MPRISHandler) cachedPlaylists() ([]*mediaprovider.Playlist, error) {
+	m.playlistsLock.Lock()
+	playlists := m.playlists
+	m.playlistsLock.Unlock()
+	if playlists != nil {
+		return playlists, nil
+	}
+	return m.fetchPlaylists()
+}
+
+func (m *MPRISHandler) fetchPlaylists() ([]*mediaprovider.Playlist, error) {
+	if m.sm.Server == nil {
+		return []*mediaprovider.Playlist{}, nil
+	}
+	playlists, err := m.sm.Server.GetPlaylists()
+	if err != nil {
+		return nil, err
+	}
+	if playlists == nil {
+		playlists = []*mediaprovider.Playlist{}
+	}
+	m.playlistsLock.Lock()
+	m.playlists = playlists
+	m.playlistsLock.Unlock()
+	return playlists, nil
+}
+
+func (m *MPRISHandler) getPlaylists(index, maxCount uint32, order string, reverse bool) ([]mprisPlaylist, *dbus.Error) {
+	playlists, err := m.fetchPlaylists()
+	if err != nil {
+		return nil, dbus.MakeFailedError(err)
+	}
+	playlists = slices.Clone(playlists)
+	if order == mprisOrderAlphabetical {
+		slices.SortStableFunc(playlists, func(a, b *mediaprovider.Playlist) int {
+			return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		})
+	}
+	if reverse {
+		slices.Reverse(playlists)
+	}
+	start := min(int(index), len(playlists))
+	end := min(start+int(maxCount), len(playlists))
+
+	result := make([]mprisPlaylist, 0, end-start)
+	for _, pl := range playlists[start:end] {
+		result = append(result, mprisPlaylist{
+			ID:   dbus.ObjectPath(dbusPlaylistIDPrefix + encodeTrackId(pl.ID)),
+			Name: pl.Name,
+		})
+	}
+	return result, nil
+}
+
+func (m *MPRISHandler) activatePlaylist(playlistID dbus.ObjectPath) *dbus.Error {
+	playlists, err := m.cachedPlaylists()
+	if err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	for _, pl := range playlists {
+		if dbus.ObjectPath(dbusPlaylistIDPrefix+encodeTrackId(pl.ID)) == playlistID {
+			return dbusError(m.pm.PlayPlaylist(pl.ID, 0, false))
+		}
+	}
+	return dbus.MakeFailedError(errors.New("unknown playlist ID"))
+}
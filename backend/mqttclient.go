@@ -0,0 +1,493 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/player"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/zalando/go-keyring"
+)
+
+const (
+	// how often to publish the playback position while playing
+	mqttPositionInterval = 5 * time.Second
+	// initial connect is retried at this interval; reconnects back off up to the max
+	mqttConnectRetryInterval = 10 * time.Second
+	mqttMaxReconnectInterval = 5 * time.Minute
+	// how long to wait for in-flight messages on shutdown, in ms
+	mqttDisconnectQuiesce = 250
+
+	mqttPayloadOnline  = "online"
+	mqttPayloadOffline = "offline"
+)
+
+// Topics published under the configured prefix. All are retained.
+const (
+	mqttTopicAvailability = "availability" // online | offline
+	mqttTopicState        = "state"        // playing | paused | stopped
+	mqttTopicTrack        = "track"        // now playing metadata as JSON, or empty
+	mqttTopicPosition     = "position"     // seconds
+	mqttTopicDuration     = "duration"     // seconds
+	mqttTopicVolume       = "volume"       // 0-100
+	mqttTopicShuffle      = "shuffle"      // ON | OFF
+	mqttTopicRepeat       = "repeat"       // none | all | one
+	mqttTopicCoverURL     = "cover_url"    // public URL of the cover image on the server, if supported
+	mqttTopicCover        = "cover"        // JPEG cover image
+)
+
+// Command topics, subscribed under <prefix>/command/
+const (
+	mqttCommandPlay         = "play"
+	mqttCommandPause        = "pause"
+	mqttCommandPlayPause    = "play_pause"
+	mqttCommandStop         = "stop"
+	mqttCommandNext         = "next"
+	mqttCommandPrevious     = "previous"
+	mqttCommandVolume       = "volume"        // payload: 0-100
+	mqttCommandSeek         = "seek"          // payload: seconds
+	mqttCommandShuffle      = "shuffle"       // payload: ON | OFF
+	mqttCommandRepeat       = "repeat"        // payload: none | all | one
+	mqttCommandPlayAlbum    = "play_album"    // payload: album ID
+	mqttCommandPlayPlaylist = "play_playlist" // payload: playlist ID
+	mqttCommandPlayTrack    = "play_track"    // payload: track ID
+)
+
+const (
+	// the keyring user under which the broker password is stored
+	mqttKeyringUser = "mqtt-broker"
+	// size of the cover image requested for cover_url
+	mqttCoverSize = 500
+)
+
+var mqttInvalidIDChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+type mqttClient struct {
+	client   mqtt.Client
+	cfg      *MQTTConfig
+	pm       *PlaybackManager
+	nodeID   string
+	appName  string
+	version  string
+	stopPoll chan struct{}
+
+	// coverThumbnail fetches the cover image to publish.
+	// coverURL returns its public URL, or "" if the server has none.
+	coverThumbnail func(coverID string) (image.Image, error)
+	coverURL       func(coverID string) string
+
+	// held while publishing the track and cover, so that a cover
+	// fetched for an earlier track is never published after a later one
+	coverLock   sync.Mutex
+	lastCoverID string
+}
+
+// startMQTT connects to the configured MQTT broker to publish playback
+// state and receive commands. Connecting and reconnecting happen in the background.
+func (a *App) startMQTT() {
+	cfg := &a.Config.MQTT
+	m := &mqttClient{
+		cfg:            cfg,
+		pm:             a.PlaybackManager,
+		appName:        a.displayAppName,
+		version:        a.VersionTag(),
+		stopPoll:       make(chan struct{}),
+		coverThumbnail: a.ImageManager.GetCoverThumbnail,
+		coverURL: func(coverID string) string {
+			if mp, ok := a.ServerManager.Server.(mediaprovider.SupportsPublicCoverArtURL); ok {
+				return mp.PublicCoverArtURL(coverID, mqttCoverSize)
+			}
+			return ""
+		},
+	}
+	clientID := cfg.ClientID
+	if clientID == "" {
+		host, _ := os.Hostname()
+		clientID = a.appName + "-" + host
+	}
+	m.nodeID = mqttInvalidIDChars.ReplaceAllString(clientID, "_")
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(cfg.BrokerURL).
+		SetClientID(clientID).
+		SetUsername(cfg.Username).
+		SetPassword(a.mqttPassword()).
+		SetWill(m.topic(mqttTopicAvailability), mqttPayloadOffline, 1, true).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectRetryInterval(mqttConnectRetryInterval).
+		SetMaxReconnectInterval(mqttMaxReconnectInterval).
+		SetOrderMatters(false).
+		SetOnConnectHandler(m.onConnect).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			log.Printf("lost connection to MQTT broker: %v", err)
+		})
+	m.client = mqtt.NewClient(opts)
+	m.registerCallbacks()
+	m.client.Connect()
+	go m.pollPosition()
+	a.mqttClient = m
+}
+
+// mqttPassword returns the broker password from the keyring, first moving
+// a password set in the config file into the keyring if there is one.
+func (a *App) mqttPassword() string {
+	cfg := &a.Config.MQTT
+	if !a.ServerManager.useKeyring {
+		return cfg.Password
+	}
+	if pass := cfg.Password; pass != "" {
+		if err := keyring.Set(a.appName, mqttKeyringUser, pass); err != nil {
+			log.Printf("error storing MQTT password in keyring: %v", err)
+			return pass
+		}
+		cfg.Password = ""
+		return pass
+	}
+	pass, err := keyring.Get(a.appName, mqttKeyringUser)
+	if err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		log.Printf("error reading MQTT password from keyring: %v", err)
+	}
+	return pass
+}
+
+// MQTTPassword returns the broker password.
+func (a *App) MQTTPassword() string {
+	return a.mqttPassword()
+}
+
+// SetMQTTPassword stores the broker password in the keyring, or in the
+// config file if password storage is disabled or the keyring is unavailable.
+// Takes effect the next time the MQTT client is started.
+func (a *App) SetMQTTPassword(pass string) {
+	cfg := &a.Config.MQTT
+	if a.ServerManager.useKeyring {
+		var err error
+		if pass == "" {
+			err = keyring.Delete(a.appName, mqttKeyringUser)
+			if errors.Is(err, keyring.ErrNotFound) {
+				err = nil
+			}
+		} else {
+			err = keyring.Set(a.appName, mqttKeyringUser, pass)
+		}
+		if err == nil {
+			cfg.Password = ""
+			return
+		}
+		log.Printf("error storing MQTT password in keyring: %v", err)
+	}
+	cfg.Password = pass
+}
+
+func (m *mqttClient) Close() {
+	close(m.stopPoll)
+	if m.client.IsConnected() {
+		m.client.Publish(m.topic(mqttTopicAvailability), 1, true, mqttPayloadOffline).
+			WaitTimeout(time.Second)
+	}
+	m.client.Disconnect(mqttDisconnectQuiesce)
+}
+
+func (m *mqttClient) topic(name string) string {
+	return strings.TrimSuffix(m.cfg.TopicPrefix, "/") + "/" + name
+}
+
+func (m *mqttClient) publish(name string, payload any) {
+	if !m.client.IsConnected() {
+		return // full state is published on (re)connect
+	}
+	m.client.Publish(m.topic(name), 0, true, payload)
+}
+
+// onConnect is called on each (re)connection to the broker
+func (m *mqttClient) onConnect(c mqtt.Client) {
+	log.Printf("Connected to MQTT broker %s", m.cfg.BrokerURL)
+	c.Subscribe(m.topic("command/+"), 1, m.handleCommand)
+	if m.cfg.HomeAssistantDiscovery {
+		m.publishDiscovery()
+	}
+	m.publish(mqttTopicAvailability, mqttPayloadOnline)
+	m.coverLock.Lock()
+	m.lastCoverID = ""
+	m.coverLock.Unlock()
+	m.publishTrack()
+	m.publishState()
+	m.publishPosition()
+	m.publish(mqttTopicVolume, strconv.Itoa(m.pm.Volume()))
+	m.publishShuffle(m.pm.IsShuffle())
+	m.publishRepeat(m.pm.GetLoopMode())
+}
+
+func (m *mqttClient) registerCallbacks() {
+	pm := m.pm
+	pm.OnSongChange(func(mediaprovider.MediaItem, *mediaprovider.Track) {
+		m.publishTrack()
+		m.publishPosition()
+	})
+	pm.OnPlaying(m.publishState)
+	pm.OnPaused(m.publishState)
+	pm.OnStopped(func() {
+		m.publishState()
+		m.publishPosition()
+	})
+	pm.OnSeek(m.publishPosition)
+	pm.OnVolumeChange(func(vol int) { m.publish(mqttTopicVolume, strconv.Itoa(vol)) })
+	pm.OnShuffleChange(m.publishShuffle)
+	pm.OnLoopModeChange(m.publishRepeat)
+}
+
+func (m *mqttClient) pollPosition() {
+	t := time.NewTicker(mqttPositionInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-m.stopPoll:
+			return
+		case <-t.C:
+			if m.pm.PlaybackStatus().State == player.Playing {
+				m.publishPosition()
+			}
+		}
+	}
+}
+
+func (m *mqttClient) publishState() {
+	state := "stopped"
+	switch m.pm.PlaybackStatus().State {
+	case player.Playing:
+		state = "playing"
+	case player.Paused:
+		state = "paused"
+	}
+	m.publish(mqttTopicState, state)
+}
+
+func (m *mqttClient) publishPosition() {
+	status := m.pm.PlaybackStatus()
+	m.publish(mqttTopicPosition, strconv.Itoa(int(status.TimePos)))
+	m.publish(mqttTopicDuration, strconv.Itoa(int(status.Duration)))
+}
+
+func (m *mqttClient) publishShuffle(shuffle bool) {
+	m.publish(mqttTopicShuffle, onOff(shuffle))
+}
+
+func (m *mqttClient) publishRepeat(mode LoopMode) {
+	m.publish(mqttTopicRepeat, loopModeToIPC(mode))
+}
+
+func (m *mqttClient) publishTrack() {
+	m.coverLock.Lock()
+	defer m.coverLock.Unlock()
+	item := m.pm.NowPlaying()
+	if item == nil {
+		m.publish(mqttTopicTrack, "")
+		m.publish(mqttTopicCoverURL, "")
+		m.publish(mqttTopicCover, []byte{})
+		m.lastCoverID = ""
+		return
+	}
+	meta := item.Metadata()
+	if b, err := json.Marshal(meta); err == nil {
+		m.publish(mqttTopicTrack, b)
+	}
+	if meta.CoverArtID != m.lastCoverID {
+		m.lastCoverID = meta.CoverArtID
+		go m.publishCover(meta.CoverArtID)
+	}
+}
+
+func (m *mqttClient) publishCover(coverID string) {
+	img, err := m.coverThumbnail(coverID)
+	if err != nil {
+		log.Printf("error fetching cover for MQTT: %v", err)
+		return
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+		return
+	}
+	url := m.coverURL(coverID)
+
+	m.coverLock.Lock()
+	defer m.coverLock.Unlock()
+	if m.lastCoverID != coverID {
+		return // the track changed while fetching
+	}
+	m.publish(mqttTopicCoverURL, url)
+	m.publish(mqttTopicCover, buf.Bytes())
+}
+
+func (m *mqttClient) handleCommand(_ mqtt.Client, msg mqtt.Message) {
+	cmd := msg.Topic()[strings.LastIndex(msg.Topic(), "/")+1:]
+	arg := strings.TrimSpace(string(msg.Payload()))
+	var err error
+	switch cmd {
+	case mqttCommandPlay:
+		switch m.pm.PlaybackStatus().State {
+		case player.Paused:
+			m.pm.Continue()
+		case player.Stopped:
+			m.pm.PlayFromBeginning()
+		}
+	case mqttCommandPause:
+		m.pm.Pause()
+	case mqttCommandPlayPause:
+		m.pm.PlayPause()
+	case mqttCommandStop:
+		m.pm.Stop()
+	case mqttCommandNext:
+		m.pm.SeekNext()
+	case mqttCommandPrevious:
+		m.pm.SeekBackOrPrevious()
+	case mqttCommandVolume:
+		var vol float64
+		if vol, err = strconv.ParseFloat(arg, 64); err == nil {
+			m.pm.SetVolume(clamp(int(vol), 0, 100))
+		}
+	case mqttCommandSeek:
+		var secs float64
+		if secs, err = strconv.ParseFloat(arg, 64); err == nil {
+			m.pm.SeekSeconds(secs)
+		}
+	case mqttCommandShuffle:
+		var shuffle bool
+		if shuffle, err = parseOnOff(arg); err == nil {
+			m.pm.SetShuffle(shuffle)
+		}
+	case mqttCommandRepeat:
+		switch strings.ToLower(arg) {
+		case "none", "off":
+			m.pm.SetLoopMode(LoopNone)
+		case "all":
+			m.pm.SetLoopMode(LoopAll)
+		case "one":
+			m.pm.SetLoopMode(LoopOne)
+		default:
+			err = fmt.Errorf("invalid repeat mode %q", arg)
+		}
+	case mqttCommandPlayAlbum:
+		err = m.pm.PlayAlbum(arg, 0, false)
+	case mqttCommandPlayPlaylist:
+		err = m.pm.PlayPlaylist(arg, 0, false)
+	case mqttCommandPlayTrack:
+		err = m.pm.PlayTrack(arg)
+	default:
+		err = fmt.Errorf("unknown command")
+	}
+	if err != nil {
+		log.Printf("error handling MQTT command %q %q: %v", cmd, arg, err)
+	}
+}
+
+// publishDiscovery publishes Home Assistant MQTT discovery configs,
+// creating a device with entities for the playback state and controls.
+func (m *mqttClient) publishDiscovery() {
+	device := map[string]any{
+		"identifiers":  []string{m.nodeID},
+		"name":         m.appName,
+		"manufacturer": m.appName,
+		"model":        m.appName + " music player",
+		"sw_version":   m.version,
+	}
+	entity := func(component, objectID, name string, cfg map[string]any) {
+		cfg["name"] = name
+		cfg["unique_id"] = m.nodeID + "_" + objectID
+		cfg["object_id"] = m.nodeID + "_" + objectID
+		cfg["device"] = device
+		cfg["availability_topic"] = m.topic(mqttTopicAvailability)
+		b, _ := json.Marshal(cfg)
+		topic := fmt.Sprintf("%s/%s/%s/%s/config",
+			strings.TrimSuffix(m.cfg.DiscoveryPrefix, "/"), component, m.nodeID, objectID)
+		m.client.Publish(topic, 1, true, b)
+	}
+	command := func(name string) string { return m.topic("command/" + name) }
+
+	entity("sensor", "state", "State", map[string]any{
+		"state_topic": m.topic(mqttTopicState),
+		"icon":        "mdi:play-pause",
+	})
+	entity("sensor", "title", "Title", map[string]any{
+		"state_topic":    m.topic(mqttTopicTrack),
+		"value_template": "{{ value_json.Name if value else '' }}",
+		"icon":           "mdi:music",
+	})
+	entity("sensor", "artist", "Artist", map[string]any{
+		"state_topic":    m.topic(mqttTopicTrack),
+		"value_template": "{{ value_json.Artists | join(', ') if value else '' }}",
+		"icon":           "mdi:account-music",
+	})
+	entity("sensor", "album", "Album", map[string]any{
+		"state_topic":    m.topic(mqttTopicTrack),
+		"value_template": "{{ value_json.Album if value else '' }}",
+		"icon":           "mdi:album",
+	})
+	entity("sensor", "position", "Position", map[string]any{
+		"state_topic":         m.topic(mqttTopicPosition),
+		"unit_of_measurement": "s",
+		"icon":                "mdi:timer-outline",
+	})
+	entity("number", "volume", "Volume", map[string]any{
+		"state_topic":   m.topic(mqttTopicVolume),
+		"command_topic": command(mqttCommandVolume),
+		"min":           0,
+		"max":           100,
+		"icon":          "mdi:volume-high",
+	})
+	entity("switch", "shuffle", "Shuffle", map[string]any{
+		"state_topic":   m.topic(mqttTopicShuffle),
+		"command_topic": command(mqttCommandShuffle),
+		"icon":          "mdi:shuffle",
+	})
+	entity("select", "repeat", "Repeat", map[string]any{
+		"state_topic":   m.topic(mqttTopicRepeat),
+		"command_topic": command(mqttCommandRepeat),
+		"options":       []string{"none", "all", "one"},
+		"icon":          "mdi:repeat",
+	})
+	entity("image", "cover", "Cover", map[string]any{
+		"image_topic":  m.topic(mqttTopicCover),
+		"content_type": "image/jpeg",
+	})
+	for _, b := range []struct{ cmd, name, icon string }{
+		{mqttCommandPlayPause, "Play/Pause", "mdi:play-pause"},
+		{mqttCommandNext, "Next", "mdi:skip-next"},
+		{mqttCommandPrevious, "Previous", "mdi:skip-previous"},
+		{mqttCommandStop, "Stop", "mdi:stop"},
+	} {
+		entity("button", b.cmd, b.name, map[string]any{
+			"command_topic": command(b.cmd),
+			"icon":          b.icon,
+		})
+	}
+}
+
+func onOff(b bool) string {
+	if b {
+		return "ON"
+	}
+	return "OFF"
+}
+
+func parseOnOff(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "on", "true", "1":
+		return true, nil
+	case "off", "false", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid value %q", s)
+}
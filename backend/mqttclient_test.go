@@ -0,0 +1,103 @@
+package backend
+
+import (
+	"errors"
+	"image"
+	"sync"
+	"testing"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+type fakeMQTTMessage struct {
+	topic   string
+	payload any
+}
+
+// fakeMQTTClient records the messages published through it
+type fakeMQTTClient struct {
+	mqtt.Client
+
+	mutex     sync.Mutex
+	published []fakeMQTTMessage
+}
+
+func (f *fakeMQTTClient) IsConnected() bool { return true }
+
+func (f *fakeMQTTClient) Publish(topic string, _ byte, _ bool, payload any) mqtt.Token {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.published = append(f.published, fakeMQTTMessage{topic: topic, payload: payload})
+	return &mqtt.DummyToken{}
+}
+
+func (f *fakeMQTTClient) messages(topic string) []any {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	var payloads []any
+	for _, msg := range f.published {
+		if msg.topic == topic {
+			payloads = append(payloads, msg.payload)
+		}
+	}
+	return payloads
+}
+
+func TestMQTTPublishCover(t *testing.T) {
+	fc := &fakeMQTTClient{}
+	release := make(chan struct{})
+	m := &mqttClient{
+		client: fc,
+		cfg:    &MQTTConfig{TopicPrefix: "supersonic/"},
+		coverThumbnail: func(coverID string) (image.Image, error) {
+			switch coverID {
+			case "slow":
+				<-release
+			case "missing":
+				return nil, errors.New("not found")
+			}
+			return image.NewRGBA(image.Rect(0, 0, 4, 4)), nil
+		},
+		coverURL: func(coverID string) string {
+			if coverID == "private" {
+				return ""
+			}
+			return "https://music.example.com/cover/" + coverID
+		},
+	}
+
+	// a cover that finishes fetching after the track changed isn't published
+	m.lastCoverID = "slow"
+	done := make(chan struct{})
+	go func() {
+		m.publishCover("slow")
+		close(done)
+	}()
+	m.coverLock.Lock()
+	m.lastCoverID = "fast"
+	m.coverLock.Unlock()
+	m.publishCover("fast")
+	close(release)
+	<-done
+
+	urls := fc.messages("supersonic/cover_url")
+	if len(urls) != 1 || urls[0] != "https://music.example.com/cover/fast" {
+		t.Errorf("expected only the current cover URL to be published, got %v", urls)
+	}
+	if covers := fc.messages("supersonic/cover"); len(covers) != 1 || len(covers[0].([]byte)) == 0 {
+		t.Errorf("expected one cover image to be published, got %d", len(covers))
+	}
+
+	// servers without public cover URLs publish an empty URL, never a local path
+	m.lastCoverID = "private"
+	m.publishCover("private")
+	if urls := fc.messages("supersonic/cover_url"); len(urls) != 2 || urls[1] != "" {
+		t.Errorf("expected empty cover URL, got %v", urls)
+	}
+
+	m.lastCoverID = "missing"
+	m.publishCover("missing")
+	if urls := fc.messages("supersonic/cover_url"); len(urls) != 2 {
+		t.Errorf("expected nothing published for a missing cover, got %v", urls)
+	}
+}
@@ -16,6 +16,7 @@ const (
 	cmdPlayTrackAt  // arg: int
 	cmdSeekSeconds  // arg: float64
 	cmdSeekFwdBackN // arg: int
+	cmdSeekChapter  // arg: int
 	cmdVolume       // arg: int
 	cmdLoopMode     // arg: LoopMode
 	cmdStopAndClearPlayQueue
@@ -126,6 +127,16 @@ func (c *playbackCommandQueue) SeekBackOrPrevious() {
 	c.seekBackOrFwd(-1)
 }
 
+func (c *playbackCommandQueue) SeekChapter(delta int) {
+	c.mutex.Lock()
+	c.queue = append(c.queue, playbackCommand{
+		Type: cmdSeekChapter,
+		Arg:  delta,
+	})
+	c.mutex.Unlock()
+	c.cmdAvailable.Signal()
+}
+
 func (c *playbackCommandQueue) UpdatePlayQueue(items []mediaprovider.MediaItem) {
 	c.filterCommandsAndAdd([]playbackCommandType{cmdUpdatePlayQueue},
 		playbackCommand{Type: cmdUpdatePlayQueue, Arg: items})
@@ -176,11 +187,11 @@ func (c *playbackCommandQueue) LoadItemsAndPlayAtIdx(items []mediaprovider.Media
 	c.cmdAvailable.Signal()
 }
 
-func (c *playbackCommandQueue) SetQueueState(tracks []*mediaprovider.Track, queueType QueueType) {
+func (c *playbackCommandQueue) SetQueueState(items []mediaprovider.MediaItem, queueType QueueType) {
 	c.mutex.Lock()
 	c.queue = append(c.queue, playbackCommand{
 		Type: cmdSetQueueState,
-		Arg:  tracks,
+		Arg:  items,
 		Arg2: queueType,
 	})
 	c.mutex.Unlock()
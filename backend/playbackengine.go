@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"math"
 	"math/rand"
 	"slices"
 	"strings"
@@ -110,6 +111,11 @@ type playbackEngine struct {
 	transcodeCfg  *TranscodingConfig
 	replayGainCfg ReplayGainConfig
 
+	loudness       *LoudnessAnalyzer       // nil if loudness analysis is disabled
+	waveforms      *WaveformImageGenerator // nil if the audio cache is disabled
+	replayGainMode player.ReplayGainMode
+	fallbackGain   float64 // gain applied if the now playing track has no ReplayGain tags
+
 	// registered callbacks
 	onBeforeSongChange []func(next mediaprovider.MediaItem)
 	onSongChange       []func(nowPlaying mediaprovider.MediaItem, justScrobbledIfAny *mediaprovider.Track)
@@ -122,8 +128,11 @@ type playbackEngine struct {
 	onStopped          []func()
 	onPlaying          []func()
 	onQueueChange      []func()
+	onChapterChange    []func()
 
 	onRadioMetadataChange []func(radioName, title, artist string)
+	onFavoriteChange      []func(trackID string, favorite bool)
+	onRatingChange        []func(trackID string, rating int)
 }
 
 func NewPlaybackEngine(
@@ -189,6 +198,11 @@ func (p *playbackEngine) registerPlayerCallbacks(pl player.BasePlayer) {
 		p.invokeNoArgCallbacks(p.onPlaying)
 		p.reportPlayback("playing")
 	})
+	if cp, ok := pl.(player.ChapterPlayer); ok {
+		cp.OnChapterChange(func() {
+			p.invokeNoArgCallbacks(p.onChapterChange)
+		})
+	}
 }
 
 func (p *playbackEngine) unregisterPlayerCallbacks(pl player.BasePlayer) {
@@ -197,6 +211,9 @@ func (p *playbackEngine) unregisterPlayerCallbacks(pl player.BasePlayer) {
 	pl.OnStopped(nil)
 	pl.OnSeek(nil)
 	pl.OnTrackChange(nil)
+	if cp, ok := pl.(player.ChapterPlayer); ok {
+		cp.OnChapterChange(nil)
+	}
 }
 
 func (p *playbackEngine) SetPlayer(pl player.BasePlayer) error {
@@ -518,6 +535,15 @@ func (p *playbackEngine) SeekSeconds(sec float64) error {
 	return p.player.SeekSeconds(sec)
 }
 
+// SeekChapter seeks forward or back by the given number of chapters,
+// if the current player supports chapters.
+func (p *playbackEngine) SeekChapter(delta int) error {
+	if cp, ok := p.player.(player.ChapterPlayer); ok && !p.isRadio {
+		return cp.SeekChapter(delta)
+	}
+	return nil
+}
+
 func (p *playbackEngine) IsSeeking() bool {
 	return p.player.IsSeeking()
 }
@@ -562,8 +588,8 @@ func (p *playbackEngine) Continue() error {
 
 // Load items into the specified queue(s). This overrides the queue. For standard inserts or replaces use p.LoadItems
 // This is used on program startup to populate both the playQueue and shuffledPlayQueue with the previously saved client state.
-func (p *playbackEngine) SetQueueState(tracks []*mediaprovider.Track, queueType QueueType) error {
-	newTracks := sharedutil.CopyTrackSliceToMediaItemSlice(tracks)
+func (p *playbackEngine) SetQueueState(items []mediaprovider.MediaItem, queueType QueueType) error {
+	newTracks := deepCopyMediaItemSlice(items)
 	switch queueType {
 	case PlayQueue:
 		p.setPlayQueue(newTracks)
@@ -690,6 +716,11 @@ func (p *playbackEngine) OnTrackFavoriteStatusChanged(id string, fav bool) {
 			tr.Favorite = fav
 		}
 	}
+	if !p.callbacksDisabled {
+		for _, cb := range p.onFavoriteChange {
+			cb(id, fav)
+		}
+	}
 }
 
 // Any time the user changes the rating of a track elsewhere in the app,
@@ -700,6 +731,11 @@ func (p *playbackEngine) OnTrackRatingChanged(id string, rating int) {
 			tr.Rating = rating
 		}
 	}
+	if !p.callbacksDisabled {
+		for _, cb := range p.onRatingChange {
+			cb(id, rating)
+		}
+	}
 }
 
 // Replaces the play queue with the given set of tracks.
@@ -822,43 +858,117 @@ func (p *playbackEngine) GetTrackIdsFromIdx(idx []int) []string {
 }
 
 func (p *playbackEngine) SetReplayGainOptions(config ReplayGainConfig) {
-	rGainPlayer, ok := p.player.(player.ReplayGainPlayer)
-	if !ok {
-		log.Println("Error: player doesn't support ReplayGain")
-		return
-	}
-
 	p.replayGainCfg = config
 	mode := player.ReplayGainNone
 	switch config.Mode {
 	case ReplayGainAuto:
 		mode = player.ReplayGainTrack
+		if p.nowPlayingIdx >= 0 && p.nowPlayingIdx < p.getPlayQueueLength() {
+			mode = autoReplayGainMode(p.getActivePlayQueue(), p.nowPlayingIdx)
+		}
 	case ReplayGainTrack:
 		mode = player.ReplayGainTrack
 	case ReplayGainAlbum:
 		mode = player.ReplayGainAlbum
 	}
-
-	rGainPlayer.SetReplayGainOptions(player.ReplayGainOptions{
-		Mode:            mode,
-		PreventClipping: config.PreventClipping,
-		PreampGain:      config.PreampGainDB,
-	})
+	p.replayGainMode = mode
+	if np := p.NowPlaying(); np != nil {
+		p.updateLoudnessNormalization(np)
+	}
+	p.applyReplayGainOptions()
+	// the analyzed gain of the next track is set when it is loaded
+	p.needToSetNextTrack = true
 }
 
 func (p *playbackEngine) SetReplayGainMode(mode player.ReplayGainMode) {
+	p.replayGainMode = mode
+	p.applyReplayGainOptions()
+}
+
+// autoReplayGainMode returns the ReplayGain mode for the item at idx in the
+// ReplayGainAuto mode: album gain if it is part of an in-order run of tracks
+// from the same album in the queue, and track gain otherwise.
+func autoReplayGainMode(queue []mediaprovider.MediaItem, idx int) player.ReplayGainMode {
+	tr, ok := queue[idx].(*mediaprovider.Track)
+	if !ok || tr.AlbumID == "" {
+		return player.ReplayGainTrack
+	}
+	if idx > 0 {
+		if prev, ok := queue[idx-1].(*mediaprovider.Track); ok && followsInAlbum(prev, tr) {
+			return player.ReplayGainAlbum
+		}
+	}
+	if idx < len(queue)-1 {
+		if next, ok := queue[idx+1].(*mediaprovider.Track); ok && followsInAlbum(tr, next) {
+			return player.ReplayGainAlbum
+		}
+	}
+	return player.ReplayGainTrack
+}
+
+// followsInAlbum returns true if b comes after a in the same album.
+func followsInAlbum(a, b *mediaprovider.Track) bool {
+	if a.AlbumID != b.AlbumID || a.ID == b.ID {
+		return false
+	}
+	if a.DiscNumber != b.DiscNumber {
+		return a.DiscNumber < b.DiscNumber
+	}
+	// tracks without track numbers can't be out of order
+	return a.TrackNumber <= b.TrackNumber
+}
+
+func (p *playbackEngine) applyReplayGainOptions() {
 	rGainPlayer, ok := p.player.(player.ReplayGainPlayer)
 	if !ok {
 		log.Println("Error: player doesn't support ReplayGain")
 		return
 	}
 	rGainPlayer.SetReplayGainOptions(player.ReplayGainOptions{
+		Mode:            p.replayGainMode,
 		PreventClipping: p.replayGainCfg.PreventClipping,
 		PreampGain:      p.replayGainCfg.PreampGainDB,
-		Mode:            mode,
+		FallbackGain:    p.fallbackGain,
 	})
 }
 
+// updateLoudnessNormalization records the analyzed gain of the now playing
+// track if it has no ReplayGain tags, and the dynamic normalizer for radio.
+func (p *playbackEngine) updateLoudnessNormalization(nowPlaying mediaprovider.MediaItem) {
+	if mpvP, ok := p.player.(*mpv.Player); ok {
+		mpvP.SetDynamicNormalizer(p.isRadio && p.replayGainCfg.NormalizeRadio && p.replayGainMode != player.ReplayGainNone)
+	}
+	p.fallbackGain = p.loudnessGain(nowPlaying)
+}
+
+// loudnessGain returns the gain in dB to normalize the item by, if it is a track without
+// ReplayGain tags whose loudness has been analyzed. Since mpv applies the fallback gain
+// as is, it includes the preamp and is limited by the true peak if clipping is prevented.
+func (p *playbackEngine) loudnessGain(item mediaprovider.MediaItem) float64 {
+	cfg := p.replayGainCfg
+	tr, ok := item.(*mediaprovider.Track)
+	if !ok || p.loudness == nil || !cfg.AnalyzeLoudness || cfg.Mode == ReplayGainNone ||
+		tr.ReplayGain != (mediaprovider.ReplayGainInfo{}) {
+		return 0
+	}
+	lufs, peak, ok := p.loudness.Loudness(p.sm.ServerID.String(), tr.ID)
+	if !ok {
+		return 0
+	}
+	gain := cfg.TargetLUFS - lufs + cfg.PreampGainDB
+	if headroom := -20 * math.Log10(peak); cfg.PreventClipping && gain > 0 && gain > headroom {
+		gain = max(0, headroom)
+	}
+	return gain
+}
+
+// analyzeLoudness queues the track for loudness analysis if it has no ReplayGain tags
+func (p *playbackEngine) analyzeLoudness(tr *mediaprovider.Track) {
+	if p.loudness != nil && p.replayGainCfg.AnalyzeLoudness && tr.ReplayGain == (mediaprovider.ReplayGainInfo{}) {
+		p.loudness.Analyze(p.sm.ServerID.String(), tr.ID)
+	}
+}
+
 func (p *playbackEngine) cacheNextTracks() {
 	if p.audiocache != nil {
 		// fetch up to the 2 next tracks in the queue to the cache
@@ -882,6 +992,19 @@ func (p *playbackEngine) cacheNextTracks() {
 			id = np.Metadata().ID
 		}
 		p.audiocache.CacheOnly(id, fetch)
+
+		// analyze the tracks once cached, including the now playing track
+		// (which is cached by setTrack) for when it is played again
+		for _, idx := range [3]int{npI, npI + 1, npI + 2} {
+			if idx < p.getPlayQueueLength() {
+				if tr, ok := p.getPlayQueueItemAt(idx).(*mediaprovider.Track); ok {
+					p.analyzeLoudness(tr)
+					if p.waveforms != nil && (p.playbackCfg.UseWaveformSeekbar || p.playbackCfg.SkipSilence) {
+						p.waveforms.GenerateInBackground(tr)
+					}
+				}
+			}
+		}
 	}
 }
 
@@ -906,6 +1029,13 @@ func (p *playbackEngine) handleOnTrackChange() {
 	nowPlaying := p.getPlayQueueItemAt(p.nowPlayingIdx)
 	_, isRadio := nowPlaying.(*mediaprovider.RadioStation)
 	p.isRadio = isRadio
+	// the analyzed gain was already set for the file when it was loaded
+	p.updateLoudnessNormalization(nowPlaying)
+	if p.replayGainCfg.Mode == ReplayGainAuto {
+		if mode := autoReplayGainMode(p.getActivePlayQueue(), p.nowPlayingIdx); mode != p.replayGainMode {
+			p.SetReplayGainMode(mode)
+		}
+	}
 
 	// reset flags
 	p.wasStopped = false
@@ -1011,6 +1141,9 @@ func (p *playbackEngine) setTrack(idx int, next bool, startTime float64) error {
 				return errors.New("no stream URL")
 			}
 		}
+		if mpvP, ok := p.player.(*mpv.Player); ok {
+			mpvP.SetFileFallbackGain(p.loudnessGain(item))
+		}
 		if next {
 			return urlP.SetNextFile(url, meta)
 		}
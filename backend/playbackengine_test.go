@@ -0,0 +1,41 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/player"
+)
+
+func TestAutoReplayGainMode(t *testing.T) {
+	track := func(id, albumID string, disc, num int) *mediaprovider.Track {
+		return &mediaprovider.Track{ID: id, AlbumID: albumID, DiscNumber: disc, TrackNumber: num}
+	}
+	queue := []mediaprovider.MediaItem{
+		track("1", "a", 1, 1),
+		track("2", "a", 1, 2),
+		track("3", "a", 2, 1),
+		track("4", "b", 1, 5),
+		track("5", "c", 1, 3),
+		track("6", "c", 1, 2),
+		&mediaprovider.RadioStation{ID: "r"},
+		track("7", "d", 1, 1),
+		track("8", "d", 1, 2),
+	}
+	want := []player.ReplayGainMode{
+		player.ReplayGainAlbum, // start of album run
+		player.ReplayGainAlbum,
+		player.ReplayGainAlbum, // next disc of the same album
+		player.ReplayGainTrack, // single track
+		player.ReplayGainTrack, // same album but out of order
+		player.ReplayGainTrack,
+		player.ReplayGainTrack, // radio
+		player.ReplayGainAlbum, // album added after a radio station
+		player.ReplayGainAlbum,
+	}
+	for i, w := range want {
+		if got := autoReplayGainMode(queue, i); got != w {
+			t.Errorf("item %d: got %v, want %v", i, got, w)
+		}
+	}
+}
@@ -50,6 +50,12 @@ type PlaybackManager struct {
 	wfmUpdateImageCancel context.CancelFunc
 	wfmImageJobs         [3]*WaveformImageJob
 
+	// skipping of silence at the start and end of the now playing track
+	silenceSkipCancel context.CancelFunc
+	silenceSkipLock   sync.Mutex
+	silenceSkipEnd    float64 // end of the audible part, or 0 if nothing to skip
+	silenceSkipAlbum  string  // album of the track silenceSkipEnd belongs to
+
 	// whether autoplay tracks are currently being fetched/enqueued
 	pendingAutoplay    bool
 	wasLoadTrackPaused bool
@@ -88,9 +94,11 @@ func NewPlaybackManager(
 		cache:       c,
 	}
 	if c != nil {
-		pm.wfmGen = NewWaveformImageGenerator(c)
+		pm.wfmGen = NewWaveformImageGenerator(c, playbackCfg.WaveformMode)
+		e.waveforms = pm.wfmGen
 	}
 	pm.addOnTrackChangeHook()
+	pm.addSilenceSkipHooks()
 	go pm.runCmdQueue(ctx)
 	return pm
 }
@@ -362,6 +370,16 @@ func (p *PlaybackManager) OnRadioMetadataChange(cb func(radioName, title, artist
 	p.engine.onRadioMetadataChange = append(p.engine.onRadioMetadataChange, cb)
 }
 
+// Registers a callback that is notified whenever the favorite status of a track is changed.
+func (p *PlaybackManager) OnFavoriteChange(cb func(trackID string, favorite bool)) {
+	p.engine.onFavoriteChange = append(p.engine.onFavoriteChange, cb)
+}
+
+// Registers a callback that is notified whenever the rating of a track is changed.
+func (p *PlaybackManager) OnRatingChange(cb func(trackID string, rating int)) {
+	p.engine.onRatingChange = append(p.engine.onRatingChange, cb)
+}
+
 // Registers a callback that is notified whenever the play time should be updated.
 func (p *PlaybackManager) OnPlayTimeUpdate(cb func(curTime float64, totalTime float64, seeked bool)) {
 	p.engine.onPlayTimeUpdate = append(p.engine.onPlayTimeUpdate, cb)
@@ -387,6 +405,12 @@ func (p *PlaybackManager) OnQueueChange(cb func()) {
 	p.engine.onQueueChange = append(p.engine.onQueueChange, cb)
 }
 
+// Registers a callback that is notified whenever the chapters
+// of the playing file or the current chapter change.
+func (p *PlaybackManager) OnChapterChange(cb func()) {
+	p.engine.onChapterChange = append(p.engine.onChapterChange, cb)
+}
+
 // Registers a callback that is notified whenever the player has been seeked.
 func (p *PlaybackManager) OnSeek(cb func()) {
 	p.engine.onSeek = append(p.engine.onSeek, cb)
@@ -451,11 +475,11 @@ func (p *PlaybackManager) LoadItems(items []mediaprovider.MediaItem, insertQueue
 	p.cmdQueue.LoadItems(items, insertQueueMode, shuffle)
 }
 
-// Replaces the specified queue (PlayQueue/ShuffledPlayQueue) with the given tracks.
+// Replaces the specified queue (PlayQueue/ShuffledPlayQueue) with the given items.
 // This is used when starting supersonic to load the queue state and directly overrides any previous data.
-// For replacing the queue while supersonic is running, use LoadTracks
-func (p *PlaybackManager) SetQueueState(tracks []*mediaprovider.Track, queueType QueueType) {
-	p.cmdQueue.SetQueueState(tracks, queueType)
+// For replacing the queue while supersonic is running, use LoadItems
+func (p *PlaybackManager) SetQueueState(items []mediaprovider.MediaItem, queueType QueueType) {
+	p.cmdQueue.SetQueueState(items, queueType)
 }
 
 // Replaces the play queue with the given set of tracks.
@@ -469,9 +493,6 @@ func (p *PlaybackManager) PlayAlbum(albumID string, firstTrack int, shuffle bool
 	if err := p.LoadAlbum(albumID, Replace, shuffle); err != nil {
 		return err
 	}
-	if p.engine.replayGainCfg.Mode == ReplayGainAuto {
-		p.SetReplayGainMode(player.ReplayGainAlbum)
-	}
 	p.PlayTrackAt(firstTrack)
 	return nil
 }
@@ -480,9 +501,6 @@ func (p *PlaybackManager) PlayPlaylist(playlistID string, firstTrack int, shuffl
 	if err := p.LoadPlaylist(playlistID, Replace, shuffle); err != nil {
 		return err
 	}
-	if p.engine.replayGainCfg.Mode == ReplayGainAuto {
-		p.SetReplayGainMode(player.ReplayGainTrack)
-	}
 	p.PlayTrackAt(firstTrack)
 	return nil
 }
@@ -493,9 +511,6 @@ func (p *PlaybackManager) PlayTrack(trackID string) error {
 		return err
 	}
 	p.LoadTracks([]*mediaprovider.Track{tr}, Replace, false)
-	if p.engine.replayGainCfg.Mode == ReplayGainAuto {
-		p.SetReplayGainMode(player.ReplayGainTrack)
-	}
 	p.PlayFromBeginning()
 	return nil
 }
@@ -518,9 +533,6 @@ func (p *PlaybackManager) ShuffleArtistAlbums(artistID string) {
 		p.LoadAlbum(al.ID, Append, false)
 	}
 
-	if p.engine.replayGainCfg.Mode == ReplayGainAuto {
-		p.SetReplayGainMode(player.ReplayGainAlbum)
-	}
 	p.PlayFromBeginning()
 }
 
@@ -531,13 +543,6 @@ func (p *PlaybackManager) PlayArtistDiscography(artistID string, shuffleTracks b
 		return
 	}
 	p.LoadTracks(tr, Replace, shuffleTracks)
-	if p.engine.replayGainCfg.Mode == ReplayGainAuto {
-		if shuffleTracks {
-			p.SetReplayGainMode(player.ReplayGainTrack)
-		} else {
-			p.SetReplayGainMode(player.ReplayGainAlbum)
-		}
-	}
 	p.PlayFromBeginning()
 }
 
@@ -588,10 +593,6 @@ func (p *PlaybackManager) PlayRandomAlbums(genreName string) error {
 		return errors.New("logged out")
 	}
 
-	if p.engine.replayGainCfg.Mode == ReplayGainAuto {
-		p.SetReplayGainMode(player.ReplayGainAlbum)
-	}
-
 	var options mediaprovider.AlbumFilterOptions
 	if genreName != "" {
 		options = mediaprovider.AlbumFilterOptions{
@@ -631,9 +632,6 @@ func (p *PlaybackManager) fetchAndPlayTracks(fetchFn func() ([]*mediaprovider.Tr
 		return err
 	} else {
 		p.LoadTracks(songs, Replace, false)
-		if p.engine.replayGainCfg.Mode == ReplayGainAuto {
-			p.SetReplayGainMode(player.ReplayGainTrack)
-		}
 		p.PlayFromBeginning()
 		return nil
 	}
@@ -672,12 +670,22 @@ func (p *PlaybackManager) StopAndClearPlayQueue() {
 	p.cmdQueue.StopAndClearPlayQueue()
 }
 
-func (p *PlaybackManager) SetReplayGainOptions(config ReplayGainConfig) {
-	p.engine.SetReplayGainOptions(config)
+// SetLoudnessAnalyzer sets the analyzer used to normalize
+// tracks without ReplayGain tags, if enabled in the ReplayGain config.
+func (p *PlaybackManager) SetLoudnessAnalyzer(l *LoudnessAnalyzer) {
+	p.engine.loudness = l
 }
 
-func (p *PlaybackManager) SetReplayGainMode(mode player.ReplayGainMode) {
-	p.engine.SetReplayGainMode(mode)
+// SetWaveformCache sets the cache used to persist the
+// waveforms of tracks, if the waveform seekbar is enabled.
+func (p *PlaybackManager) SetWaveformCache(c *WaveformCache) {
+	if p.wfmGen != nil {
+		p.wfmGen.SetWaveformCache(c)
+	}
+}
+
+func (p *PlaybackManager) SetReplayGainOptions(config ReplayGainConfig) {
+	p.engine.SetReplayGainOptions(config)
 }
 
 // Changes the loop mode of the player to the next one.
@@ -737,6 +745,33 @@ func (p *PlaybackManager) SeekBackOrPrevious() {
 	p.cmdQueue.SeekBackOrPrevious()
 }
 
+func (p *PlaybackManager) SeekNextChapter() {
+	p.cmdQueue.SeekChapter(1)
+}
+
+// Seeks to the start of the current chapter, or the previous
+// chapter if within the first seconds of the current one.
+func (p *PlaybackManager) SeekPreviousChapter() {
+	p.cmdQueue.SeekChapter(-1)
+}
+
+// Chapters returns the chapters of the now playing track,
+// if the current player supports chapters.
+func (p *PlaybackManager) Chapters() []player.Chapter {
+	if cp, ok := p.CurrentPlayer().(player.ChapterPlayer); ok {
+		return cp.GetChapters()
+	}
+	return nil
+}
+
+// CurrentChapter returns the index of the current chapter, or -1 if none.
+func (p *PlaybackManager) CurrentChapter() int {
+	if cp, ok := p.CurrentPlayer().(player.ChapterPlayer); ok {
+		return cp.GetChapter()
+	}
+	return -1
+}
+
 // Seek to given absolute position in the current track by seconds.
 func (p *PlaybackManager) SeekSeconds(sec float64) {
 	p.cmdQueue.SeekSeconds(sec)
@@ -913,6 +948,8 @@ func (p *PlaybackManager) runCmdQueue(ctx context.Context) {
 			case cmdSeekFwdBackN:
 				action := fmt.Sprintf("SeekFwdBack[%d]", c.Arg.(int))
 				logIfErr(action, p.engine.SeekFwdBackN(c.Arg.(int)))
+			case cmdSeekChapter:
+				logIfErr("SeekChapter", p.engine.SeekChapter(c.Arg.(int)))
 			case cmdVolume:
 				logIfErr("Volume", p.engine.SetVolume(c.Arg.(int)))
 			case cmdLoopMode:
@@ -939,7 +976,7 @@ func (p *PlaybackManager) runCmdQueue(ctx context.Context) {
 				logIfErr("LoadItemsAndPlayAtIdx", err)
 			case cmdSetQueueState:
 				err := p.engine.SetQueueState(
-					c.Arg.([]*mediaprovider.Track),
+					c.Arg.([]mediaprovider.MediaItem),
 					c.Arg2.(QueueType),
 				)
 				logIfErr("SetQueueState", err)
@@ -0,0 +1,100 @@
+package mpv
+
+import (
+	"github.com/dweymouth/supersonic/backend/player"
+	"github.com/supersonic-app/go-mpv"
+)
+
+var _ player.ChapterPlayer = (*Player)(nil)
+
+// seeking back within this many seconds of the start of a chapter goes
+// to the previous chapter, like mpv's default chapter-seek-threshold
+const chapterRestartThreshold = 5
+
+// Returns the chapters of the playing file.
+func (p *Player) GetChapters() []player.Chapter {
+	if !p.initialized {
+		return nil
+	}
+	n, err := p.mpv.GetProperty("chapter-list", mpv.FORMAT_NODE)
+	if err != nil {
+		return nil
+	}
+	return parseChapterList(n.(*mpv.Node))
+}
+
+// parseChapterList converts mpv's chapter-list property to chapters.
+func parseChapterList(n *mpv.Node) []player.Chapter {
+	nodeArr, _ := n.Data.([]*mpv.Node)
+	chapters := make([]player.Chapter, 0, len(nodeArr))
+	for _, node := range nodeArr {
+		ch, ok := node.Data.(map[string]*mpv.Node)
+		if !ok {
+			continue
+		}
+		var c player.Chapter
+		if title, ok := ch["title"]; ok {
+			c.Title, _ = title.Data.(string)
+		}
+		if time, ok := ch["time"]; ok {
+			c.Time, _ = time.Data.(float64)
+		}
+		chapters = append(chapters, c)
+	}
+	return chapters
+}
+
+// Returns the index of the current chapter, or -1 if
+// the file has no chapters or playback is before the first.
+func (p *Player) GetChapter() int {
+	if !p.initialized {
+		return -1
+	}
+	ch, err := p.getInt64Property("chapter")
+	if err != nil {
+		return -1
+	}
+	return int(ch)
+}
+
+// Seeks forward or back by the given number of chapters.
+// Seeking back within the first seconds of a chapter goes to the
+// previous chapter, otherwise to the start of the current one.
+// Seeking past the last chapter does nothing, rather than ending the file.
+func (p *Player) SeekChapter(delta int) error {
+	if !p.initialized {
+		return ErrUnitialized
+	}
+	target, ok := chapterSeekTarget(p.GetChapters(), p.GetChapter(), p.GetStatus().TimePos, delta)
+	if !ok {
+		return nil
+	}
+	return p.SeekSeconds(target)
+}
+
+// chapterSeekTarget returns the time to seek to in order to move delta
+// chapters from the current one at timePos, or false if there is no such
+// chapter. Seeking back from before the first chapter goes to the start.
+func chapterSeekTarget(chapters []player.Chapter, current int, timePos float64, delta int) (float64, bool) {
+	if len(chapters) == 0 || delta == 0 {
+		return 0, false
+	}
+	if delta < 0 && current >= 0 && current < len(chapters) &&
+		timePos-chapters[current].Time > chapterRestartThreshold {
+		delta++ // restart the current chapter first
+	}
+	idx := current + delta
+	if idx >= len(chapters) {
+		return 0, false
+	}
+	if idx < 0 {
+		return 0, true
+	}
+	return chapters[idx].Time, true
+}
+
+// Registers a callback which is invoked when the chapters of the playing file
+// or the current chapter change. The callback is invoked on the mpv event goroutine.
+func (p *Player) OnChapterChange(cb func()) {
+	p.chapterCb = cb
+}
@@ -0,0 +1,59 @@
+package mpv
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/dweymouth/supersonic/backend/player"
+	"github.com/supersonic-app/go-mpv"
+)
+
+func TestParseChapterList(t *testing.T) {
+	chapter := func(title string, time float64) *mpv.Node {
+		ch := map[string]*mpv.Node{"time": {Data: time, Format: mpv.FORMAT_DOUBLE}}
+		if title != "" {
+			ch["title"] = &mpv.Node{Data: title, Format: mpv.FORMAT_STRING}
+		}
+		return &mpv.Node{Data: ch, Format: mpv.FORMAT_NODE_MAP}
+	}
+	list := &mpv.Node{Format: mpv.FORMAT_NODE_ARRAY, Data: []*mpv.Node{
+		chapter("Intro", 0),
+		{Data: "bogus", Format: mpv.FORMAT_STRING},
+		chapter("", 61.5),
+	}}
+	want := []player.Chapter{{Title: "Intro", Time: 0}, {Time: 61.5}}
+	if got := parseChapterList(list); !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := parseChapterList(&mpv.Node{Format: mpv.FORMAT_NONE}); len(got) != 0 {
+		t.Errorf("got %v for a file without chapters", got)
+	}
+}
+
+func TestChapterSeekTarget(t *testing.T) {
+	chapters := []player.Chapter{{Time: 10}, {Time: 100}, {Time: 200}}
+	for _, tt := range []struct {
+		name    string
+		current int
+		pos     float64
+		delta   int
+		want    float64
+		wantOK  bool
+	}{
+		{"next", 0, 50, 1, 100, true},
+		{"next before first chapter", -1, 5, 1, 10, true},
+		{"next on last chapter", 2, 250, 1, 0, false},
+		{"restart current chapter", 1, 150, -1, 100, true},
+		{"previous near chapter start", 1, 102, -1, 10, true},
+		{"previous near first chapter start", 0, 12, -1, 0, true},
+		{"previous before first chapter", -1, 5, -1, 0, true},
+	} {
+		got, ok := chapterSeekTarget(chapters, tt.current, tt.pos, tt.delta)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("%s: got %v, %v, want %v, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+	if _, ok := chapterSeekTarget(nil, -1, 5, 1); ok {
+		t.Error("expected no seek in a file without chapters")
+	}
+}
@@ -0,0 +1,127 @@
+package mpv
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// DSPStageType identifies a stage of the DSP chain.
+type DSPStageType string
+
+const (
+	DSPStageBalance     DSPStageType = "balance"
+	DSPStageMono        DSPStageType = "mono"
+	DSPStageCrossfeed   DSPStageType = "crossfeed"
+	DSPStageStereoWiden DSPStageType = "stereowiden"
+	DSPStageCompressor  DSPStageType = "compressor"
+	DSPStageLavfi       DSPStageType = "lavfi"
+	DSPStageLimiter     DSPStageType = "limiter"
+)
+
+// DSPStage is a stage of the DSP chain applied after the equalizer,
+// implemented with an ffmpeg audio filter. Which of the parameters
+// are used depends on the stage type.
+type DSPStage struct {
+	Type    DSPStageType
+	Enabled bool
+
+	// Crossfeed (bs2b) profile: "default", "cmoy" or "jmeier"
+	CrossfeedProfile string
+
+	// Compressor
+	ThresholdDB float64
+	Ratio       float64
+	AttackMs    float64
+	ReleaseMs   float64
+	MakeupDB    float64
+
+	// Limiter ceiling (max output level)
+	CeilingDB float64
+
+	// Channel balance, from -1 (left only) to 1 (right only)
+	Balance float64
+
+	// Stereo width multiplier; 1 leaves the signal unchanged
+	Width float64
+
+	// Raw lavfi filter graph, e.g. "aecho=0.8:0.9:40:0.3"
+	LavfiGraph string
+}
+
+var CrossfeedProfiles = []string{"default", "cmoy", "jmeier"}
+
+// DefaultDSPChain returns all DSP stages, disabled, in their default order.
+func DefaultDSPChain() []DSPStage {
+	return []DSPStage{
+		{Type: DSPStageBalance},
+		{Type: DSPStageMono},
+		{Type: DSPStageCrossfeed, CrossfeedProfile: "default"},
+		{Type: DSPStageStereoWiden, Width: 1.5},
+		// gentle "night mode" compression
+		{Type: DSPStageCompressor, ThresholdDB: -24, Ratio: 4, AttackMs: 20, ReleaseMs: 250, MakeupDB: 6},
+		{Type: DSPStageLavfi},
+		{Type: DSPStageLimiter, CeilingDB: -1},
+	}
+}
+
+// NormalizeDSPChain returns the chain with unknown and duplicate stages
+// removed and any missing stages appended, disabled, with default settings.
+func NormalizeDSPChain(chain []DSPStage) []DSPStage {
+	defaults := DefaultDSPChain()
+	isKnown := func(t DSPStageType) bool {
+		return slices.ContainsFunc(defaults, func(s DSPStage) bool { return s.Type == t })
+	}
+	var normalized []DSPStage
+	for _, s := range chain {
+		if isKnown(s.Type) && !slices.ContainsFunc(normalized, func(n DSPStage) bool { return n.Type == s.Type }) {
+			normalized = append(normalized, s)
+		}
+	}
+	for _, d := range defaults {
+		if !slices.ContainsFunc(normalized, func(n DSPStage) bool { return n.Type == d.Type }) {
+			normalized = append(normalized, d)
+		}
+	}
+	return normalized
+}
+
+// Filter returns the mpv audio filter string for the stage,
+// or "" if the stage is disabled or would have no effect.
+func (s DSPStage) Filter() string {
+	if !s.Enabled {
+		return ""
+	}
+	switch s.Type {
+	case DSPStageBalance:
+		if math.Abs(s.Balance) < 0.01 {
+			return ""
+		}
+		return fmt.Sprintf("lavfi=[stereotools=balance_out=%0.2f]", max(-1, min(1, s.Balance)))
+	case DSPStageMono:
+		return "lavfi=[pan=stereo|c0=0.5*c0+0.5*c1|c1=0.5*c0+0.5*c1]"
+	case DSPStageCrossfeed:
+		profile := s.CrossfeedProfile
+		if !slices.Contains(CrossfeedProfiles, profile) {
+			profile = "default"
+		}
+		return "lavfi=[bs2b=profile=" + profile + "]"
+	case DSPStageStereoWiden:
+		if math.Abs(s.Width-1) < 0.01 {
+			return ""
+		}
+		return fmt.Sprintf("lavfi=[extrastereo=m=%0.2f]", max(0, s.Width))
+	case DSPStageCompressor:
+		return fmt.Sprintf("lavfi=[acompressor=threshold=%0.1fdB:ratio=%0.1f:attack=%0.1f:release=%0.1f:makeup=%0.1fdB]",
+			s.ThresholdDB, max(1, s.Ratio), max(0.01, s.AttackMs), max(0.01, s.ReleaseMs), max(0, s.MakeupDB))
+	case DSPStageLavfi:
+		if graph := strings.TrimSpace(s.LavfiGraph); graph != "" {
+			return "lavfi=[" + graph + "]"
+		}
+	case DSPStageLimiter:
+		// level=0 disables alimiter's auto gain
+		return fmt.Sprintf("lavfi=[alimiter=limit=%0.4f:level=0]", math.Pow(10, min(0, s.CeilingDB)/20))
+	}
+	return ""
+}
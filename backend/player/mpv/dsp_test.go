@@ -0,0 +1,66 @@
+package mpv
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDSPStageFilter(t *testing.T) {
+	for _, tt := range []struct {
+		stage DSPStage
+		want  string
+	}{
+		{DSPStage{Type: DSPStageBalance, Balance: 0.5}, ""}, // disabled
+		{DSPStage{Type: DSPStageBalance, Enabled: true, Balance: 0.005}, ""},
+		{DSPStage{Type: DSPStageBalance, Enabled: true, Balance: -2}, "lavfi=[stereotools=balance_out=-1.00]"},
+		{DSPStage{Type: DSPStageMono, Enabled: true}, "lavfi=[pan=stereo|c0=0.5*c0+0.5*c1|c1=0.5*c0+0.5*c1]"},
+		{DSPStage{Type: DSPStageCrossfeed, Enabled: true, CrossfeedProfile: "cmoy"}, "lavfi=[bs2b=profile=cmoy]"},
+		{DSPStage{Type: DSPStageCrossfeed, Enabled: true, CrossfeedProfile: "bogus"}, "lavfi=[bs2b=profile=default]"},
+		{DSPStage{Type: DSPStageStereoWiden, Enabled: true, Width: 1}, ""},
+		{DSPStage{Type: DSPStageStereoWiden, Enabled: true, Width: 1.5}, "lavfi=[extrastereo=m=1.50]"},
+		{DSPStage{Type: DSPStageCompressor, Enabled: true, ThresholdDB: -24, Ratio: 0, AttackMs: 20, ReleaseMs: 250, MakeupDB: 6},
+			"lavfi=[acompressor=threshold=-24.0dB:ratio=1.0:attack=20.0:release=250.0:makeup=6.0dB]"},
+		{DSPStage{Type: DSPStageLavfi, Enabled: true, LavfiGraph: "  "}, ""},
+		{DSPStage{Type: DSPStageLavfi, Enabled: true, LavfiGraph: " aecho=0.8:0.9:40:0.3 "}, "lavfi=[aecho=0.8:0.9:40:0.3]"},
+		{DSPStage{Type: DSPStageLimiter, Enabled: true, CeilingDB: -6}, "lavfi=[alimiter=limit=0.5012:level=0]"},
+		{DSPStage{Type: DSPStageLimiter, Enabled: true, CeilingDB: 3}, "lavfi=[alimiter=limit=1.0000:level=0]"},
+		{DSPStage{Type: "unknown", Enabled: true}, ""},
+	} {
+		if got := tt.stage.Filter(); got != tt.want {
+			t.Errorf("%+v: got %q, want %q", tt.stage, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeDSPChain(t *testing.T) {
+	types := func(chain []DSPStage) []DSPStageType {
+		var t []DSPStageType
+		for _, s := range chain {
+			t = append(t, s.Type)
+		}
+		return t
+	}
+	defaultTypes := types(DefaultDSPChain())
+
+	if got := types(NormalizeDSPChain(nil)); !slices.Equal(got, defaultTypes) {
+		t.Errorf("expected default chain, got %v", got)
+	}
+
+	chain := NormalizeDSPChain([]DSPStage{
+		{Type: DSPStageLimiter, Enabled: true, CeilingDB: -3},
+		{Type: "unknown"},
+		{Type: DSPStageMono, Enabled: true},
+		{Type: DSPStageLimiter, CeilingDB: -9},
+	})
+	want := []DSPStageType{DSPStageLimiter, DSPStageMono, DSPStageBalance, DSPStageCrossfeed,
+		DSPStageStereoWiden, DSPStageCompressor, DSPStageLavfi}
+	if got := types(chain); !slices.Equal(got, want) {
+		t.Fatalf("got order %v, want %v", got, want)
+	}
+	if !chain[0].Enabled || chain[0].CeilingDB != -3 {
+		t.Errorf("expected the first of duplicate stages to be kept, got %+v", chain[0])
+	}
+	if chain[2].Enabled {
+		t.Error("expected missing stages to be added disabled")
+	}
+}
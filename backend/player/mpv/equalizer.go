@@ -74,6 +74,7 @@ const (
 )
 
 type EqualizerBand struct {
+	Filter    FilterType
 	Frequency int
 	Gain      float64
 	Width     float64
@@ -101,8 +102,8 @@ func (e EqualizerBand) String() string {
 	if math.Abs(e.Gain) < 0.02 {
 		return ""
 	}
-	return fmt.Sprintf("equalizer=f=%d:g=%0.2f:t=%s:w=%0.2f",
-		e.Frequency, e.Gain, e.WidthType.String(), e.Width)
+	return fmt.Sprintf("%s=f=%d:g=%0.2f:t=%s:w=%0.2f",
+		e.Filter.ffmpegFilter(), e.Frequency, e.Gain, e.WidthType.String(), e.Width)
 }
 
 func (w WidthType) String() string {
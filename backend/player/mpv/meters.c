@@ -0,0 +1,40 @@
+#include <mpv/client.h>
+#include <stdlib.h>
+#include <string.h>
+
+int mpv_get_loudness(mpv_handle* handle, double* momentary, double* shortTerm, double* integrated, double* lra) {
+    mpv_node result;
+    int ret = mpv_get_property(handle, "af-metadata/ebur128", MPV_FORMAT_NODE, &result);
+    if (ret != MPV_ERROR_SUCCESS) {
+        return ret;
+    }
+    if (result.format != MPV_FORMAT_NODE_MAP) {
+        mpv_free_node_contents(&result);
+        return MPV_ERROR_PROPERTY_FORMAT;
+    }
+
+    for (int i = 0; i < result.u.list->num; i++) {
+        const char* key = result.u.list->keys[i];
+        double* dest = NULL;
+        if (strcmp("lavfi.r128.M", key) == 0) {
+            dest = momentary;
+        } else if (strcmp("lavfi.r128.S", key) == 0) {
+            dest = shortTerm;
+        } else if (strcmp("lavfi.r128.I", key) == 0) {
+            dest = integrated;
+        } else if (strcmp("lavfi.r128.LRA", key) == 0) {
+            dest = lra;
+        }
+        if (dest == NULL) {
+            continue;
+        }
+        if (result.u.list->values[i].format != MPV_FORMAT_STRING) {
+            ret = MPV_ERROR_PROPERTY_FORMAT;
+            break;
+        }
+        *dest = atof(result.u.list->values[i].u.string);
+    }
+
+    mpv_free_node_contents(&result);
+    return ret;
+}
@@ -0,0 +1,150 @@
+package mpv
+
+// #include <mpv/client.h>
+// int mpv_get_loudness(mpv_handle* handle, double* momentary, double* shortTerm, double* integrated, double* lra);
+import "C"
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/dweymouth/supersonic/backend/player"
+	"github.com/supersonic-app/go-mpv"
+)
+
+const (
+	// number of bands measured by the spectrum analyzer
+	SpectrumBands = 24
+
+	spectrumMinFreq = 40
+	spectrumMaxFreq = 16000
+
+	// astats channel of the first band; channels are numbered from 1
+	// and the two channels of the stereo signal come first
+	spectrumFirstBandChannel = 3
+)
+
+// Loudness is an EBU R128 loudness measurement.
+type Loudness struct {
+	Momentary  float64 // LUFS, over the last 400ms
+	ShortTerm  float64 // LUFS, over the last 3s
+	Integrated float64 // LUFS, since the meter was enabled
+	Range      float64 // LU
+}
+
+// SpectrumBandFrequencies returns the center frequencies
+// of the bands measured by the spectrum analyzer.
+func SpectrumBandFrequencies() []float64 {
+	freqs := make([]float64, SpectrumBands)
+	for i := range freqs {
+		freqs[i] = spectrumMinFreq * math.Pow(spectrumMaxFreq/spectrumMinFreq, float64(i)/(SpectrumBands-1))
+	}
+	return freqs
+}
+
+// spectrumFilter returns a filter which measures the RMS level of each band
+// of the downmixed signal by merging the bands as extra channels for astats.
+// The metadata can only reach mpv on the output frames, so the bands are
+// dropped again afterwards. The input is pinned to stereo first, so that the
+// output layout and the band channels are the same for any source layout.
+func spectrumFilter() string {
+	widthOctaves := math.Log2(spectrumMaxFreq/spectrumMinFreq) / (SpectrumBands - 1)
+	var sb strings.Builder
+	sb.WriteString("@spectrum:lavfi=[aformat=channel_layouts=stereo,asplit=2[main][mono];[mono]pan=mono|c0=0.5*c0+0.5*c1")
+	fmt.Fprintf(&sb, ",asplit=%d", SpectrumBands)
+	for i := range SpectrumBands {
+		fmt.Fprintf(&sb, "[b%d]", i)
+	}
+	for i, freq := range SpectrumBandFrequencies() {
+		fmt.Fprintf(&sb, ";[b%d]bandpass=f=%0.1f:width_type=o:w=%0.3f[s%d]", i, freq, widthOctaves, i)
+	}
+	sb.WriteString(";[main]")
+	for i := range SpectrumBands {
+		fmt.Fprintf(&sb, "[s%d]", i)
+	}
+	fmt.Fprintf(&sb, "amerge=inputs=%d,astats=metadata=1:reset=1:measure_perchannel=RMS_level:measure_overall=none", SpectrumBands+1)
+	sb.WriteString(",pan=stereo|c0=c0|c1=c1]")
+	return sb.String()
+}
+
+const loudnessFilter = "@ebur128:lavfi=[ebur128=metadata=1]"
+
+// Enables or disables the EBU R128 loudness meter.
+func (p *Player) SetLoudnessMeterEnabled(enabled bool) error {
+	if p.loudnessEnabled == enabled {
+		return nil
+	}
+	p.loudnessEnabled = enabled
+	return p.setAF()
+}
+
+// Returns the current loudness measurement, if the loudness meter is enabled.
+func (p *Player) GetLoudness() (Loudness, error) {
+	nInf := math.Inf(-1)
+	l := Loudness{Momentary: nInf, ShortTerm: nInf, Integrated: nInf}
+	if p.status.State != player.Playing {
+		return l, nil
+	}
+	var m, s, i, lra C.double = C.double(nInf), C.double(nInf), C.double(nInf), 0
+	ret := int(C.mpv_get_loudness((*C.mpv_handle)(p.mpv.MPVHandle()), &m, &s, &i, &lra))
+	if err := mpv.NewError(ret); err != nil {
+		return l, err
+	}
+	return Loudness{Momentary: float64(m), ShortTerm: float64(s), Integrated: float64(i), Range: float64(lra)}, nil
+}
+
+// Enables or disables the spectrum analyzer.
+func (p *Player) SetSpectrumEnabled(enabled bool) error {
+	if p.spectrumEnabled == enabled {
+		return nil
+	}
+	p.spectrumEnabled = enabled
+	return p.setAF()
+}
+
+// Fills bands with the current level in dBFS of each of the
+// SpectrumBands bands, if the spectrum analyzer is enabled.
+func (p *Player) GetSpectrum(bands []float64) error {
+	for i := range bands {
+		bands[i] = math.Inf(-1)
+	}
+	if p.status.State != player.Playing || len(bands) == 0 {
+		return nil
+	}
+	node, err := p.mpv.GetProperty("af-metadata/spectrum", mpv.FORMAT_NODE)
+	if err != nil {
+		return err
+	}
+	metadata, ok := node.(*mpv.Node).Data.(map[string]*mpv.Node)
+	if !ok {
+		return mpv.ERROR_PROPERTY_FORMAT
+	}
+	for key, val := range metadata {
+		if level, ok := val.Data.(string); ok {
+			setSpectrumBand(bands, key, level)
+		}
+	}
+	return nil
+}
+
+// setSpectrumBand stores the level of an astats metadata entry
+// of the spectrum filter in bands, if it is the level of a band.
+func setSpectrumBand(bands []float64, key, level string) {
+	ch, ok := strings.CutPrefix(key, "lavfi.astats.")
+	if !ok {
+		return
+	}
+	ch, ok = strings.CutSuffix(ch, ".RMS_level")
+	if !ok {
+		return
+	}
+	i, err := strconv.Atoi(ch)
+	if i -= spectrumFirstBandChannel; err != nil || i < 0 || i >= len(bands) {
+		return
+	}
+	if l, err := strconv.ParseFloat(level, 64); err == nil {
+		bands[i] = l
+	}
+}
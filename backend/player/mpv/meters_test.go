@@ -0,0 +1,54 @@
+package mpv
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestSpectrumFilter(t *testing.T) {
+	f := spectrumFilter()
+	// the input layout is pinned before the signal is split
+	if !strings.HasPrefix(f, "@spectrum:lavfi=[aformat=channel_layouts=stereo,asplit=2[main][mono];[mono]pan=mono|c0=0.5*c0+0.5*c1,") {
+		t.Errorf("unexpected start of filter: %s", f)
+	}
+	if n := strings.Count(f, "bandpass="); n != SpectrumBands {
+		t.Errorf("got %d bandpass filters, want %d", n, SpectrumBands)
+	}
+	if !strings.Contains(f, ";[b0]bandpass=f=40.0:width_type=o:w=0.376[s0];") {
+		t.Errorf("unexpected first band in filter: %s", f)
+	}
+	// the bands are merged after the two stereo channels and dropped again
+	if !strings.Contains(f, ";[main][s0][s1]") ||
+		!strings.HasSuffix(f, "amerge=inputs=25,astats=metadata=1:reset=1:measure_perchannel=RMS_level:measure_overall=none,pan=stereo|c0=c0|c1=c1]") {
+		t.Errorf("unexpected end of filter: %s", f)
+	}
+}
+
+func TestSetSpectrumBand(t *testing.T) {
+	bands := make([]float64, SpectrumBands)
+	for i := range bands {
+		bands[i] = math.Inf(-1)
+	}
+	for key, level := range map[string]string{
+		"lavfi.astats.1.RMS_level":  "-3.0", // stereo channels
+		"lavfi.astats.2.RMS_level":  "-4.0",
+		"lavfi.astats.3.RMS_level":  "-20.5",
+		"lavfi.astats.26.RMS_level": "-40.25",
+		"lavfi.astats.27.RMS_level": "-1.0", // out of range
+		"lavfi.astats.4.Peak_level": "-1.0",
+		"lavfi.astats.5.RMS_level":  "-inf",
+		"lavfi.astats.x.RMS_level":  "-1.0",
+		"lavfi.r128.M":              "-1.0",
+	} {
+		setSpectrumBand(bands, key, level)
+	}
+	if bands[0] != -20.5 || bands[SpectrumBands-1] != -40.25 {
+		t.Errorf("got first and last bands %v and %v, want -20.5 and -40.25", bands[0], bands[SpectrumBands-1])
+	}
+	for i := 1; i < SpectrumBands-1; i++ {
+		if !math.IsInf(bands[i], -1) {
+			t.Errorf("band %d got level %v, want -Inf", i, bands[i])
+		}
+	}
+}
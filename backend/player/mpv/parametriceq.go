@@ -0,0 +1,181 @@
+package mpv
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"strconv"
+)
+
+// ParametricEqualizer is an equalizer of arbitrary peaking and shelving
+// filters, such as the ParametricEQ profiles published by AutoEQ.
+type ParametricEqualizer struct {
+	Disabled bool
+	EQPreamp float64
+	Filters  []ParametricFilter
+}
+
+type ParametricFilter struct {
+	Type      FilterType
+	Frequency float64 // center or corner frequency in Hz
+	Gain      float64 // dB
+	Q         float64
+}
+
+// sample rate assumed when computing the frequency response
+const responseSampleRate = 48000
+
+var _ Equalizer = (*ParametricEqualizer)(nil)
+
+func (p *ParametricEqualizer) IsEnabled() bool {
+	return !p.Disabled
+}
+
+func (p *ParametricEqualizer) Preamp() float64 {
+	return p.EQPreamp
+}
+
+func (p *ParametricEqualizer) Curve() EqualizerCurve {
+	curve := make([]EqualizerBand, 0, len(p.Filters))
+	for _, f := range p.Filters {
+		if f.Frequency <= 0 || f.Q <= 0 {
+			continue
+		}
+		curve = append(curve, EqualizerBand{
+			Filter:    f.Type,
+			Frequency: int(math.Round(f.Frequency)),
+			Gain:      f.Gain,
+			Width:     f.Q,
+			WidthType: WidthTypeQ,
+		})
+	}
+	return curve
+}
+
+func (p *ParametricEqualizer) BandFrequencies() []string {
+	ret := make([]string, len(p.Filters))
+	for i, f := range p.Filters {
+		ret[i] = FormatFrequency(f.Frequency)
+	}
+	return ret
+}
+
+func (*ParametricEqualizer) Type() string {
+	return "Parametric"
+}
+
+// Response returns the gain in dB of all filters combined
+// at the given frequency, not including the preamp.
+func (p *ParametricEqualizer) Response(freq float64) float64 {
+	var gain float64
+	for _, f := range p.Filters {
+		gain += f.Response(freq)
+	}
+	return gain
+}
+
+// Response returns the gain in dB of the filter at the given frequency.
+// The filter coefficients are those of the RBJ Audio EQ Cookbook,
+// which ffmpeg's equalizer, lowshelf and highshelf filters also use.
+func (f ParametricFilter) Response(freq float64) float64 {
+	if f.Frequency <= 0 || f.Q <= 0 || f.Frequency >= responseSampleRate/2 {
+		return 0
+	}
+	A := math.Pow(10, f.Gain/40)
+	w0 := 2 * math.Pi * f.Frequency / responseSampleRate
+	cosW0 := math.Cos(w0)
+	alpha := math.Sin(w0) / (2 * f.Q)
+	sqrtA2Alpha := 2 * math.Sqrt(A) * alpha
+
+	var b0, b1, b2, a0, a1, a2 float64
+	switch f.Type {
+	case FilterTypeLowShelf:
+		b0 = A * ((A + 1) - (A-1)*cosW0 + sqrtA2Alpha)
+		b1 = 2 * A * ((A - 1) - (A+1)*cosW0)
+		b2 = A * ((A + 1) - (A-1)*cosW0 - sqrtA2Alpha)
+		a0 = (A + 1) + (A-1)*cosW0 + sqrtA2Alpha
+		a1 = -2 * ((A - 1) + (A+1)*cosW0)
+		a2 = (A + 1) + (A-1)*cosW0 - sqrtA2Alpha
+	case FilterTypeHighShelf:
+		b0 = A * ((A + 1) + (A-1)*cosW0 + sqrtA2Alpha)
+		b1 = -2 * A * ((A - 1) + (A+1)*cosW0)
+		b2 = A * ((A + 1) + (A-1)*cosW0 - sqrtA2Alpha)
+		a0 = (A + 1) - (A-1)*cosW0 + sqrtA2Alpha
+		a1 = 2 * ((A - 1) - (A+1)*cosW0)
+		a2 = (A + 1) - (A-1)*cosW0 - sqrtA2Alpha
+	default:
+		b0 = 1 + alpha*A
+		b1 = -2 * cosW0
+		b2 = 1 - alpha*A
+		a0 = 1 + alpha/A
+		a1 = -2 * cosW0
+		a2 = 1 - alpha/A
+	}
+
+	z := cmplx.Exp(complex(0, -2*math.Pi*freq/responseSampleRate))
+	h := (complex(b0, 0) + complex(b1, 0)*z + complex(b2, 0)*z*z) /
+		(complex(a0, 0) + complex(a1, 0)*z + complex(a2, 0)*z*z)
+	return 20 * math.Log10(cmplx.Abs(h))
+}
+
+// FilterType is the type of an equalizer band's filter.
+// It is marshaled as the abbreviations used by AutoEQ and Equalizer APO.
+type FilterType int
+
+const (
+	FilterTypePeaking FilterType = iota
+	FilterTypeLowShelf
+	FilterTypeHighShelf
+)
+
+func (f FilterType) String() string {
+	switch f {
+	case FilterTypeLowShelf:
+		return "LSC"
+	case FilterTypeHighShelf:
+		return "HSC"
+	}
+	return "PK"
+}
+
+// ParseFilterType parses a filter type abbreviation such as "PK" or "LSC".
+func ParseFilterType(s string) (FilterType, error) {
+	switch s {
+	case "PK", "PEQ":
+		return FilterTypePeaking, nil
+	case "LSC", "LS":
+		return FilterTypeLowShelf, nil
+	case "HSC", "HS":
+		return FilterTypeHighShelf, nil
+	}
+	return FilterTypePeaking, fmt.Errorf("unknown filter type %q", s)
+}
+
+func (f FilterType) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+func (f *FilterType) UnmarshalText(b []byte) error {
+	t, err := ParseFilterType(string(b))
+	*f = t
+	return err
+}
+
+// name of the ffmpeg filter
+func (f FilterType) ffmpegFilter() string {
+	switch f {
+	case FilterTypeLowShelf:
+		return "lowshelf"
+	case FilterTypeHighShelf:
+		return "highshelf"
+	}
+	return "equalizer"
+}
+
+// FormatFrequency formats a frequency in Hz for display, e.g. "63" or "1.6k".
+func FormatFrequency(freq float64) string {
+	if freq >= 1000 {
+		return strconv.FormatFloat(math.Round(freq/100)/10, 'f', -1, 64) + "k"
+	}
+	return strconv.FormatFloat(math.Round(freq), 'f', -1, 64)
+}
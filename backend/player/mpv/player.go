@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
@@ -18,6 +19,8 @@ import (
 // Error returned by many Player functions if called before the player has not been initialized.
 var ErrUnitialized error = errors.New("mpv player uninitialized")
 
+var mpvVersionRegex = regexp.MustCompile(`(\d+)\.(\d+)`)
+
 // Information about a specific audio device.
 // Returned by ListAudioDevices.
 type AudioDevice struct {
@@ -69,10 +72,23 @@ type Player struct {
 	prePausedState player.State
 	clientName     string
 	equalizer      Equalizer
+	dspChain       []DSPStage
+	dynamicNorm    bool
 	peaksEnabled   bool
 	pauseFade      bool
 
-	icyTitleCb func(string)
+	// ReplayGain fallback gain of the file loaded next, see SetFileFallbackGain
+	nextFileGain float64
+	// whether loadfile takes an index before the per-file options (mpv >= 0.38)
+	loadfileHasIndex bool
+
+	// meters measured after the equalizer and DSP chain
+	loudnessEnabled bool
+	spectrumEnabled bool
+
+	icyTitleCb     func(string)
+	audioDevicesCb func()
+	chapterCb      func()
 
 	fileLoadedLock sync.Mutex
 	fileLoadedSig  *sync.Cond
@@ -133,10 +149,13 @@ func (p *Player) Init(maxCacheMB int) error {
 		}
 
 		m.ObserveProperty(0, "metadata", mpv.FORMAT_NODE)
+		m.ObserveProperty(3, "chapter", mpv.FORMAT_INT64)
+		m.ObserveProperty(3, "chapter-list/count", mpv.FORMAT_INT64)
 
 		if err := m.Initialize(); err != nil {
 			return fmt.Errorf("error initializing mpv: %s", err.Error())
 		}
+		p.loadfileHasIndex = mpvVersionAtLeast(m.GetPropertyString("mpv-version"), 0, 38)
 
 		p.mpv = m
 	}
@@ -152,7 +171,7 @@ func (p *Player) PlayFile(url string, _ mediaprovider.MediaItemMetadata, startTi
 	if !p.initialized {
 		return ErrUnitialized
 	}
-	err := p.mpv.Command([]string{"loadfile", url, "replace"})
+	err := p.mpv.Command(p.loadfileCommand(url, "replace"))
 	if err != nil {
 		return err
 	}
@@ -204,13 +223,45 @@ func (p *Player) SetNextFile(url string, _ mediaprovider.MediaItemMetadata) erro
 		return nil
 	}
 
-	err := p.mpv.Command([]string{"loadfile", url, "append"})
+	err := p.mpv.Command(p.loadfileCommand(url, "append"))
 	if err == nil {
 		p.lenPlaylist++
 	}
 	return err
 }
 
+// SetFileFallbackGain sets the ReplayGain fallback gain, in dB, for the file loaded
+// by the next call to PlayFile or SetNextFile. It is set as a per-file option, since
+// changing the replaygain-fallback property would also affect the playing file,
+// and the gain of a file played gaplessly must be set before it starts.
+func (p *Player) SetFileFallbackGain(gain float64) {
+	p.nextFileGain = gain
+}
+
+func (p *Player) loadfileCommand(url, flags string) []string {
+	return loadfileArgs(url, flags, p.nextFileGain, p.loadfileHasIndex)
+}
+
+func loadfileArgs(url, flags string, fallbackGain float64, hasIndex bool) []string {
+	args := []string{"loadfile", url, flags}
+	if hasIndex {
+		args = append(args, "-1")
+	}
+	return append(args, fmt.Sprintf("replaygain-fallback=%0.2f", fallbackGain))
+}
+
+// mpvVersionAtLeast parses an mpv-version string such as "mpv 0.38.0"
+// or "mpv v0.37.0-git-1234" and compares it to major.minor.
+func mpvVersionAtLeast(version string, major, minor int) bool {
+	m := mpvVersionRegex.FindStringSubmatch(version)
+	if m == nil {
+		return false
+	}
+	gotMajor, _ := strconv.Atoi(m[1])
+	gotMinor, _ := strconv.Atoi(m[2])
+	return gotMajor > major || (gotMajor == major && gotMinor >= minor)
+}
+
 // Seeks within the currently playing track.
 // See MPV seek command documentation for more details.
 func (p *Player) SeekSeconds(secs float64) error {
@@ -271,6 +322,9 @@ func (p *Player) SetReplayGainOptions(options player.ReplayGainOptions) error {
 		if err := p.mpv.SetPropertyString("replaygain-clip", clip); err != nil {
 			return err
 		}
+		if err := p.mpv.SetProperty("replaygain-fallback", mpv.FORMAT_DOUBLE, options.FallbackGain); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -414,6 +468,18 @@ func (p *Player) SetAudioDevice(deviceName string) error {
 	return p.mpv.SetPropertyString("audio-device", deviceName)
 }
 
+// Returns the name of the selected audio device, which may be "auto".
+func (p *Player) GetAudioDevice() string {
+	return p.mpv.GetPropertyString("audio-device")
+}
+
+// Registers a callback which is invoked when audio devices are added or removed.
+// The callback is invoked on the mpv event goroutine and should not block.
+func (p *Player) OnAudioDevicesChanged(cb func()) {
+	p.audioDevicesCb = cb
+	p.mpv.ObserveProperty(2, "audio-device-list", mpv.FORMAT_NODE)
+}
+
 func (p *Player) SetEqualizer(eq Equalizer) error {
 	p.equalizer = eq
 	return p.setAF()
@@ -423,6 +489,29 @@ func (p *Player) Equalizer() Equalizer {
 	return p.equalizer
 }
 
+// SetDynamicNormalizer enables or disables dynamic loudness normalization,
+// for sources such as radio streams which can't be normalized by ReplayGain.
+func (p *Player) SetDynamicNormalizer(enabled bool) error {
+	if p.dynamicNorm == enabled {
+		return nil
+	}
+	p.dynamicNorm = enabled
+	return p.setAF()
+}
+
+// SetDSPChain sets the DSP stages applied, in order, after the equalizer.
+// If mpv rejects the chain, the previous one is kept.
+func (p *Player) SetDSPChain(chain []DSPStage) error {
+	prev := p.dspChain
+	p.dspChain = chain
+	if err := p.setAF(); err != nil {
+		p.dspChain = prev
+		p.setAF()
+		return err
+	}
+	return nil
+}
+
 func (p *Player) GetMediaInfo() (MediaInfo, error) {
 	var info MediaInfo
 	n, err := p.mpv.GetProperty("audio-params", mpv.FORMAT_NODE)
@@ -522,6 +611,9 @@ func (p *Player) setAF() error {
 	if p.peaksEnabled {
 		filters = append(filters, "@astats:astats=metadata=1:reset=1:measure_overall=none")
 	}
+	if p.dynamicNorm {
+		filters = append(filters, "lavfi=[dynaudnorm=f=500:g=31]")
+	}
 	if eq := p.equalizer; eq != nil && eq.IsEnabled() {
 		if math.Abs(eq.Preamp()) > 0.01 {
 			filters = append(filters, fmt.Sprintf("volume=volume=%0.1fdB", eq.Preamp()))
@@ -530,6 +622,17 @@ func (p *Player) setAF() error {
 			filters = append(filters, eqAF)
 		}
 	}
+	for _, stage := range p.dspChain {
+		if f := stage.Filter(); f != "" {
+			filters = append(filters, f)
+		}
+	}
+	if p.spectrumEnabled {
+		filters = append(filters, spectrumFilter())
+	}
+	if p.loudnessEnabled {
+		filters = append(filters, loudnessFilter)
+	}
 	return p.mpv.SetPropertyString("af", strings.Join(filters, ","))
 }
 
@@ -566,6 +669,12 @@ func (p *Player) eventHandler(ctx context.Context) {
 				if e.Reply_Userdata == 1 && p.icyTitleCb != nil {
 					p.icyTitleCb(p.mpv.GetPropertyString("metadata/icy-title"))
 				}
+				if e.Reply_Userdata == 2 && p.audioDevicesCb != nil {
+					p.audioDevicesCb()
+				}
+				if e.Reply_Userdata == 3 && p.chapterCb != nil {
+					p.chapterCb()
+				}
 
 			}
 		}
@@ -0,0 +1,35 @@
+package mpv
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestLoadfileArgs(t *testing.T) {
+	got := loadfileArgs("/tmp/a.flac", "append", -3.456, false)
+	if want := []string{"loadfile", "/tmp/a.flac", "append", "replaygain-fallback=-3.46"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	got = loadfileArgs("/tmp/a.flac", "replace", 0, true)
+	if want := []string{"loadfile", "/tmp/a.flac", "replace", "-1", "replaygain-fallback=0.00"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestMPVVersionAtLeast(t *testing.T) {
+	for _, tt := range []struct {
+		version string
+		want    bool
+	}{
+		{"mpv 0.38.0", true},
+		{"mpv v0.39.0-dirty", true},
+		{"mpv 1.0.0", true},
+		{"mpv 0.37.0", false},
+		{"mpv v0.37.0-git-1234", false},
+		{"", false},
+	} {
+		if got := mpvVersionAtLeast(tt.version, 0, 38); got != tt.want {
+			t.Errorf("mpvVersionAtLeast(%q) = %v, want %v", tt.version, got, tt.want)
+		}
+	}
+}
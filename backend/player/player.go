@@ -43,6 +43,25 @@ type ReplayGainPlayer interface {
 	SetReplayGainOptions(ReplayGainOptions) error
 }
 
+// ChapterPlayer is implemented by players which can
+// read the embedded chapters of the playing file.
+type ChapterPlayer interface {
+	GetChapters() []Chapter
+	// Returns the index of the current chapter, or -1 if none.
+	GetChapter() int
+	// Seeks forward or back by the given number of chapters.
+	SeekChapter(delta int) error
+	// Registers a callback which is invoked when the
+	// chapters of the playing file or the current chapter change.
+	OnChapterChange(func())
+}
+
+// A chapter of the playing file.
+type Chapter struct {
+	Title string
+	Time  float64 // start time in seconds
+}
+
 // The playback state (Stopped, Paused, or Playing).
 type State int
 
@@ -73,7 +92,8 @@ type ReplayGainOptions struct {
 	Mode            ReplayGainMode
 	PreampGain      float64
 	PreventClipping bool
-	// Fallback gain intentionally omitted
+	// Gain in dB applied to files without ReplayGain tags
+	FallbackGain float64
 }
 
 func (r ReplayGainMode) String() string {
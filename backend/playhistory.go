@@ -0,0 +1,497 @@
+package backend
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deluan/sanitize"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+const playHistoryFile = "play_history.jsonl"
+
+const (
+	PlayHistorySourceLocal        = "local"
+	PlayHistorySourceListenBrainz = "listenbrainz"
+	PlayHistorySourceLastFM       = "lastfm"
+)
+
+// PlayHistoryEntry is a single play of a track, either recorded
+// locally when the track was scrobbled or imported from an export file.
+type PlayHistoryEntry struct {
+	Time          time.Time `json:"time"`
+	ServerID      string    `json:"serverID,omitempty"`
+	TrackID       string    `json:"trackID,omitempty"`
+	Title         string    `json:"title"`
+	Artists       []string  `json:"artists,omitempty"`
+	Album         string    `json:"album,omitempty"`
+	AlbumID       string    `json:"albumID,omitempty"`
+	DurationSecs  int       `json:"durationSecs,omitempty"`
+	MusicBrainzID string    `json:"musicBrainzID,omitempty"`
+	Source        string    `json:"source,omitempty"`
+}
+
+// PlayHistory is the local, append-only record of played tracks,
+// stored as one JSON object per line in the config dir.
+type PlayHistory struct {
+	mutex    sync.Mutex
+	filePath string
+}
+
+func NewPlayHistory(configDir string) *PlayHistory {
+	return &PlayHistory{filePath: filepath.Join(configDir, playHistoryFile)}
+}
+
+// Record appends a play of the given track to the history.
+func (h *PlayHistory) Record(serverID string, tr *mediaprovider.Track, playedAt time.Time) error {
+	return h.Append([]PlayHistoryEntry{{
+		Time:          playedAt.UTC(),
+		ServerID:      serverID,
+		TrackID:       tr.ID,
+		Title:         tr.Title,
+		Artists:       tr.ArtistNames,
+		Album:         tr.Album,
+		AlbumID:       tr.AlbumID,
+		DurationSecs:  int(tr.Duration.Seconds()),
+		MusicBrainzID: tr.MusicBrainzID,
+		Source:        PlayHistorySourceLocal,
+	}})
+}
+
+// Append adds the given entries to the end of the history file.
+func (h *PlayHistory) Append(entries []PlayHistoryEntry) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	f, err := os.OpenFile(h.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// Entries returns all entries in the history, oldest first.
+func (h *PlayHistory) Entries() ([]PlayHistoryEntry, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	f, err := os.Open(h.filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []PlayHistoryEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e PlayHistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue // skip corrupted lines
+		}
+		entries = append(entries, e)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+	return entries, scanner.Err()
+}
+
+// Export writes the full history to w, in CSV format if csvFormat
+// is true and JSONL (one JSON object per line) otherwise.
+func (h *PlayHistory) Export(w io.Writer, csvFormat bool) error {
+	entries, err := h.Entries()
+	if err != nil {
+		return err
+	}
+	if !csvFormat {
+		enc := json.NewEncoder(w)
+		for _, e := range entries {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"time", "title", "artists", "album", "duration_secs",
+		"musicbrainz_id", "track_id", "album_id", "server_id", "source"})
+	for _, e := range entries {
+		cw.Write([]string{
+			e.Time.Format(time.RFC3339),
+			e.Title,
+			strings.Join(e.Artists, "; "),
+			e.Album,
+			strconv.Itoa(e.DurationSecs),
+			e.MusicBrainzID,
+			e.TrackID,
+			e.AlbumID,
+			e.ServerID,
+			e.Source,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadPlayHistoryImportFile parses a listening history export file.
+// CSV files are read as Last.fm exports, and .json, .jsonl and .zip
+// files as ListenBrainz exports.
+func ReadPlayHistoryImportFile(path string) ([]PlayHistoryEntry, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return ParseLastFMExport(f)
+	case ".zip":
+		return readListenBrainzZip(path)
+	default:
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return ParseListenBrainzExport(f)
+	}
+}
+
+type listenBrainzListen struct {
+	ListenedAt    json.RawMessage `json:"listened_at"`
+	TrackMetadata struct {
+		ArtistName     string `json:"artist_name"`
+		TrackName      string `json:"track_name"`
+		ReleaseName    string `json:"release_name"`
+		AdditionalInfo struct {
+			RecordingMBID string `json:"recording_mbid"`
+			DurationMs    int    `json:"duration_ms"`
+			Duration      int    `json:"duration"`
+		} `json:"additional_info"`
+		MBIDMapping struct {
+			RecordingMBID string `json:"recording_mbid"`
+		} `json:"mbid_mapping"`
+	} `json:"track_metadata"`
+}
+
+// ParseListenBrainzExport parses a ListenBrainz listens export,
+// either as a single JSON array or as JSON lines.
+func ParseListenBrainzExport(r io.Reader) ([]PlayHistoryEntry, error) {
+	br := bufio.NewReader(r)
+	var listens []listenBrainzListen
+	if isJSONArray(br) {
+		if err := json.NewDecoder(br).Decode(&listens); err != nil {
+			return nil, err
+		}
+	} else {
+		dec := json.NewDecoder(br)
+		for {
+			var l listenBrainzListen
+			if err := dec.Decode(&l); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, err
+			}
+			listens = append(listens, l)
+		}
+	}
+
+	entries := make([]PlayHistoryEntry, 0, len(listens))
+	for _, l := range listens {
+		md := l.TrackMetadata
+		if md.TrackName == "" {
+			continue
+		}
+		t, err := parseListenBrainzTime(l.ListenedAt)
+		if err != nil {
+			continue
+		}
+		mbid := md.AdditionalInfo.RecordingMBID
+		if mbid == "" {
+			mbid = md.MBIDMapping.RecordingMBID
+		}
+		dur := md.AdditionalInfo.Duration
+		if md.AdditionalInfo.DurationMs > 0 {
+			dur = md.AdditionalInfo.DurationMs / 1000
+		}
+		e := PlayHistoryEntry{
+			Time:          t,
+			Title:         md.TrackName,
+			Album:         md.ReleaseName,
+			DurationSecs:  dur,
+			MusicBrainzID: mbid,
+			Source:        PlayHistorySourceListenBrainz,
+		}
+		if md.ArtistName != "" {
+			e.Artists = []string{md.ArtistName}
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// newer ListenBrainz exports are zip archives with
+// one JSONL file per month under listens/
+func readListenBrainzZip(path string) ([]PlayHistoryEntry, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	var entries []PlayHistoryEntry
+	for _, f := range zr.File {
+		if ext := filepath.Ext(f.Name); ext != ".jsonl" && ext != ".json" {
+			continue
+		}
+		if !strings.Contains(f.Name, "listens") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		e, err := ParseListenBrainzExport(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		entries = append(entries, e...)
+	}
+	return entries, nil
+}
+
+func isJSONArray(br *bufio.Reader) bool {
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return false
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			br.ReadByte()
+		case 0xEF: // UTF-8 BOM
+			br.Discard(3)
+		default:
+			return b[0] == '['
+		}
+	}
+}
+
+func parseListenBrainzTime(raw json.RawMessage) (time.Time, error) {
+	var secs int64
+	if err := json.Unmarshal(raw, &secs); err == nil {
+		return time.Unix(secs, 0).UTC(), nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+var lastFMDateFormats = []string{
+	"02 Jan 2006 15:04",
+	"2 Jan 2006 15:04",
+	"02 Jan 2006, 15:04",
+	"2 Jan 2006, 15:04",
+	"2006-01-02 15:04:05",
+	time.RFC3339,
+}
+
+// ParseLastFMExport parses a Last.fm scrobbles CSV export. Files with a header row
+// (uts, utc_time, artist, album, track, track_mbid) are read by column name;
+// headerless files are read as artist, album, track, date.
+func ParseLastFMExport(r io.Reader) ([]PlayHistoryEntry, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	cr := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(b, []byte("\xEF\xBB\xBF"))))
+	cr.FieldsPerRecord = -1
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	cols := map[string]int{"artist": 0, "album": 1, "track": 2, "date": 3}
+	if hdr := rows[0]; containsFold(hdr, "track") || containsFold(hdr, "uts") {
+		cols = make(map[string]int)
+		for i, name := range hdr {
+			name = strings.ToLower(strings.TrimSpace(name))
+			switch name {
+			case "title":
+				name = "track"
+			case "utc_time", "timestamp":
+				name = "date"
+			case "mbid":
+				name = "track_mbid"
+			}
+			cols[name] = i
+		}
+		rows = rows[1:]
+	}
+	get := func(row []string, col string) string {
+		if i, ok := cols[col]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	entries := make([]PlayHistoryEntry, 0, len(rows))
+	for _, row := range rows {
+		title := get(row, "track")
+		if title == "" {
+			continue
+		}
+		var t time.Time
+		if uts, err := strconv.ParseInt(get(row, "uts"), 10, 64); err == nil {
+			t = time.Unix(uts, 0).UTC()
+		} else {
+			t = parseLastFMDate(get(row, "date"))
+		}
+		if t.IsZero() {
+			continue
+		}
+		e := PlayHistoryEntry{
+			Time:          t,
+			Title:         title,
+			Album:         get(row, "album"),
+			MusicBrainzID: get(row, "track_mbid"),
+			Source:        PlayHistorySourceLastFM,
+		}
+		if a := get(row, "artist"); a != "" {
+			e.Artists = []string{a}
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func parseLastFMDate(s string) time.Time {
+	for _, f := range lastFMDateFormats {
+		if t, err := time.Parse(f, s); err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Time{}
+}
+
+func containsFold(s []string, v string) bool {
+	for _, x := range s {
+		if strings.EqualFold(strings.TrimSpace(x), v) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchPlayHistoryEntries looks up library tracks for the given imported entries,
+// by MusicBrainz recording ID when available and by title, artist and album otherwise.
+// Matched entries are updated in place with the library track and album IDs.
+// Returns the number of entries that were matched.
+func MatchPlayHistoryEntries(mp mediaprovider.MediaProvider, serverID string, entries []PlayHistoryEntry) int {
+	type matchKey struct{ mbid, title, artist, album string }
+	cache := make(map[matchKey]*mediaprovider.Track)
+
+	matched := 0
+	for i := range entries {
+		e := &entries[i]
+		var artist string
+		if len(e.Artists) > 0 {
+			artist = normalizeForMatch(e.Artists[0])
+		}
+		key := matchKey{e.MusicBrainzID, normalizeForMatch(e.Title), artist, normalizeForMatch(e.Album)}
+		tr, ok := cache[key]
+		if !ok {
+			tr = findMatchingTrack(mp, key.mbid, key.title, key.artist, key.album)
+			cache[key] = tr
+		}
+		if tr == nil {
+			continue
+		}
+		e.ServerID = serverID
+		e.TrackID = tr.ID
+		e.AlbumID = tr.AlbumID
+		if e.DurationSecs == 0 {
+			e.DurationSecs = int(tr.Duration.Seconds())
+		}
+		matched++
+	}
+	return matched
+}
+
+func findMatchingTrack(mp mediaprovider.MediaProvider, mbid, title, artist, album string) *mediaprovider.Track {
+	if title == "" {
+		return nil
+	}
+	var best *mediaprovider.Track
+	iter := mp.IterateTracks(title)
+	for n := 0; n < 50; n++ {
+		tr := iter.Next()
+		if tr == nil {
+			break
+		}
+		if mbid != "" && strings.EqualFold(tr.MusicBrainzID, mbid) {
+			return tr
+		}
+		if normalizeForMatch(tr.Title) != title {
+			continue
+		}
+		if artist != "" && !artistMatches(tr, artist) {
+			continue
+		}
+		if album != "" && normalizeForMatch(tr.Album) == album {
+			if mbid == "" {
+				return tr
+			}
+			best = tr // keep looking for an exact MBID match
+		} else if best == nil {
+			best = tr
+		}
+	}
+	return best
+}
+
+func artistMatches(tr *mediaprovider.Track, artist string) bool {
+	for _, names := range [][]string{tr.ArtistNames, tr.AlbumArtistNames} {
+		for _, a := range names {
+			if n := normalizeForMatch(a); n == artist || (n != "" && strings.Contains(artist, n)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func normalizeForMatch(s string) string {
+	return strings.ToLower(sanitize.Accents(strings.TrimSpace(s)))
+}
@@ -0,0 +1,64 @@
+package backend
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseListenBrainzExport(t *testing.T) {
+	jsonl := `{"listened_at": 1700000000, "track_metadata": {"artist_name": "Artist", "track_name": "Song", "release_name": "Album", "additional_info": {"recording_mbid": "abc", "duration_ms": 200000}}}
+{"listened_at": "2023-11-14T22:13:20Z", "track_metadata": {"artist_name": "Artist", "track_name": "Other", "mbid_mapping": {"recording_mbid": "def"}}}
+{"listened_at": 1700000001, "track_metadata": {"artist_name": "Artist"}}
+`
+	array := "[" + strings.Join(strings.Split(strings.TrimSpace(jsonl), "\n"), ",") + "]"
+
+	for name, input := range map[string]string{"jsonl": jsonl, "array": array} {
+		entries, err := ParseListenBrainzExport(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if len(entries) != 2 {
+			t.Fatalf("%s: got %d entries, want 2", name, len(entries))
+		}
+		e := entries[0]
+		if e.Title != "Song" || e.Album != "Album" || e.MusicBrainzID != "abc" || e.DurationSecs != 200 {
+			t.Errorf("%s: unexpected first entry: %+v", name, e)
+		}
+		if !e.Time.Equal(time.Unix(1700000000, 0)) {
+			t.Errorf("%s: got time %v", name, e.Time)
+		}
+		if e := entries[1]; e.MusicBrainzID != "def" || !e.Time.Equal(time.Unix(1700000000, 0)) {
+			t.Errorf("%s: unexpected second entry: %+v", name, e)
+		}
+	}
+}
+
+func TestParseLastFMExport(t *testing.T) {
+	withHeader := "\xEF\xBB\xBFuts,utc_time,artist,artist_mbid,album,album_mbid,track,track_mbid\n" +
+		"1700000000,\"14 Nov 2023, 22:13\",Artist,,Album,,Song,abc\n"
+	headerless := "Artist,Album,Song,14 Nov 2023 22:13\n"
+
+	entries, err := ParseLastFMExport(strings.NewReader(withHeader))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	if e := entries[0]; e.Title != "Song" || e.Album != "Album" || e.MusicBrainzID != "abc" ||
+		len(e.Artists) != 1 || e.Artists[0] != "Artist" || !e.Time.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+
+	entries, err = ParseLastFMExport(strings.NewReader(headerless))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	if e := entries[0]; e.Title != "Song" || e.Album != "Album" || !e.Time.Equal(time.Date(2023, 11, 14, 22, 13, 0, 0, time.UTC)) {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+}
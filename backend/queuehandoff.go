@@ -0,0 +1,135 @@
+package backend
+
+import (
+	"log"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/player"
+	"github.com/dweymouth/supersonic/sharedutil"
+)
+
+// how often to save the play queue to the server while playing,
+// and check for a newer queue from another device while not playing
+const queueHandoffInterval = 30 * time.Second
+
+type queueHandoffState struct {
+	start sync.Once
+	mutex sync.Mutex
+	// identifies the remote queue last offered to the user,
+	// so a dismissed queue isn't offered again until its tracks change
+	lastOffered string
+	// when the local play queue was last played, so remote queues,
+	// including this client's own stale server queue, that are older
+	// than the local queue aren't offered
+	localPlayed time.Time
+}
+
+func (s *queueHandoffState) markLocalPlayed(t time.Time) {
+	s.mutex.Lock()
+	if t.After(s.localPlayed) {
+		s.localPlayed = t
+	}
+	s.mutex.Unlock()
+}
+
+func (a *App) startQueueHandoff() {
+	a.ServerManager.OnLogout(func() {
+		a.queueHandoff.mutex.Lock()
+		a.queueHandoff.lastOffered = ""
+		a.queueHandoff.mutex.Unlock()
+	})
+	// the saved queue file was last written when the queue was played
+	if stat, err := os.Stat(path.Join(a.configDir, savedQueueFile)); err == nil {
+		a.queueHandoff.markLocalPlayed(stat.ModTime())
+	}
+	a.PlaybackManager.OnPlaying(func() { a.queueHandoff.markLocalPlayed(time.Now()) })
+	go a.runQueueHandoffLoop()
+}
+
+func (a *App) runQueueHandoffLoop() {
+	t := time.NewTicker(queueHandoffInterval)
+	for {
+		select {
+		case <-a.bgrndCtx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+			if a.ServerManager.Server == nil {
+				continue
+			}
+			if a.PlaybackManager.PlaybackStatus().State == player.Playing {
+				a.queueHandoff.markLocalPlayed(time.Now())
+				if a.Config.Application.SaveQueueToServer {
+					a.SavePlayQueueIfEnabled()
+				}
+			} else if a.Config.Application.EnableQueueHandoff {
+				a.checkRemotePlayQueue()
+			}
+		}
+	}
+}
+
+func (a *App) checkRemotePlayQueue() {
+	rq, ok := a.ServerManager.Server.(mediaprovider.CanGetRemotePlayQueue)
+	if !ok {
+		return
+	}
+	queue, err := rq.GetRemotePlayQueue()
+	if err != nil {
+		log.Printf("error checking remote play queue: %v", err)
+		return
+	}
+	if queue == nil || len(queue.Tracks) == 0 {
+		return
+	}
+	if a.queueHandoff.shouldOffer(queue) && a.OnRemotePlayQueue != nil {
+		a.OnRemotePlayQueue(queue)
+	}
+}
+
+// shouldOffer returns true if the remote queue is newer than the local queue
+// and hasn't been offered already, and records it as offered.
+func (s *queueHandoffState) shouldOffer(queue *mediaprovider.SavedPlayQueue) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !isNewerRemoteQueue(queue, s.localPlayed) {
+		return false
+	}
+	key := remoteQueueKey(queue)
+	if key == s.lastOffered {
+		return false
+	}
+	s.lastOffered = key
+	return true
+}
+
+// remoteQueueKey identifies a remote queue by the device
+// that saved it and its tracks, but not its position
+func remoteQueueKey(queue *mediaprovider.SavedPlayQueue) string {
+	return queue.ChangedBy + "|" + strings.Join(sharedutil.MapSlice(queue.Tracks,
+		func(t *mediaprovider.Track) string { return t.ID }), ",")
+}
+
+// isNewerRemoteQueue returns true if the remote queue changed after the local
+// queue was last played. Queues without a timestamp are assumed to be newer.
+func isNewerRemoteQueue(queue *mediaprovider.SavedPlayQueue, localPlayed time.Time) bool {
+	return queue.Changed.IsZero() || queue.Changed.After(localPlayed)
+}
+
+// ContinueFromRemotePlayQueue replaces the play queue with one
+// played on another device and resumes playback from its position.
+func (a *App) ContinueFromRemotePlayQueue(queue *mediaprovider.SavedPlayQueue) {
+	// the remote queue is already in its playing order
+	a.PlaybackManager.SetShuffle(false)
+	a.PlaybackManager.LoadTracks(queue.Tracks, Replace, false)
+	idx := max(queue.TrackPos, 0)
+	if idx < len(queue.Tracks) {
+		a.PlaybackManager.LoadTrackPaused(idx, float64(queue.TimePos))
+		a.PlaybackManager.Continue()
+	}
+}
@@ -0,0 +1,73 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+// remoteQueueTestProvider serves a fixed remote play queue
+type remoteQueueTestProvider struct {
+	mediaprovider.MediaProvider
+	queue *mediaprovider.SavedPlayQueue
+}
+
+func (p *remoteQueueTestProvider) GetRemotePlayQueue() (*mediaprovider.SavedPlayQueue, error) {
+	return p.queue, nil
+}
+
+func TestCheckRemotePlayQueue(t *testing.T) {
+	localPlayed := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tracks := func(ids ...string) []*mediaprovider.Track {
+		res := make([]*mediaprovider.Track, len(ids))
+		for i, id := range ids {
+			res[i] = &mediaprovider.Track{ID: id}
+		}
+		return res
+	}
+
+	provider := &remoteQueueTestProvider{}
+	a := &App{ServerManager: &ServerManager{Server: provider}}
+	a.queueHandoff.markLocalPlayed(localPlayed)
+	var offered *mediaprovider.SavedPlayQueue
+	a.OnRemotePlayQueue = func(q *mediaprovider.SavedPlayQueue) { offered = q }
+	check := func(queue *mediaprovider.SavedPlayQueue) bool {
+		offered = nil
+		provider.queue = queue
+		a.checkRemotePlayQueue()
+		return offered == queue && queue != nil
+	}
+
+	if check(nil) || check(&mediaprovider.SavedPlayQueue{ChangedBy: "phone"}) {
+		t.Error("expected missing or empty remote queue not to be offered")
+	}
+	if check(&mediaprovider.SavedPlayQueue{Tracks: tracks("1", "2"), ChangedBy: "phone",
+		Changed: localPlayed.Add(-time.Minute)}) {
+		t.Error("expected queue older than the local queue not to be offered")
+	}
+
+	queue := &mediaprovider.SavedPlayQueue{Tracks: tracks("1", "2"), ChangedBy: "phone",
+		Changed: localPlayed.Add(time.Minute)}
+	if !check(queue) {
+		t.Error("expected newer remote queue to be offered")
+	}
+	moved := *queue
+	moved.TrackPos, moved.TimePos = 1, 30
+	if check(&moved) {
+		t.Error("expected queue not to be offered again when only its position changed")
+	}
+	if !check(&mediaprovider.SavedPlayQueue{Tracks: tracks("1", "2"), ChangedBy: "laptop"}) {
+		t.Error("expected queue from another device, without a timestamp, to be offered")
+	}
+	if !check(&mediaprovider.SavedPlayQueue{Tracks: tracks("1", "3"), ChangedBy: "laptop"}) {
+		t.Error("expected queue to be offered again when its tracks changed")
+	}
+
+	// playing locally makes the remote queue stale
+	a.queueHandoff.markLocalPlayed(localPlayed.Add(time.Hour))
+	if check(&mediaprovider.SavedPlayQueue{Tracks: tracks("4"), ChangedBy: "phone",
+		Changed: localPlayed.Add(time.Minute)}) {
+		t.Error("expected queue older than the last local playback not to be offered")
+	}
+}
@@ -0,0 +1,112 @@
+package backend
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"path/filepath"
+	"slices"
+
+	"github.com/dweymouth/supersonic/backend/ipc"
+)
+
+const (
+	remoteControlCertFile = "remote-cert.pem"
+	remoteControlKeyFile  = "remote-key.pem"
+)
+
+type remoteControl struct {
+	server          *ipc.RemoteServer
+	port            int
+	scheme          string
+	certFingerprint string
+}
+
+// RemoteControlInfo describes how to connect a device to the remote control server.
+type RemoteControlInfo struct {
+	URLs            []string
+	PairingCode     string
+	CertFingerprint string // empty if not using TLS
+}
+
+// startRemoteControl serves the IPC API and web remote UI on the
+// configured TCP port. Must be called after the IPC server is created.
+func (a *App) startRemoteControl() {
+	cfg := &a.Config.RemoteControl
+
+	rc := &remoteControl{port: cfg.Port, scheme: "http"}
+	var cert *tls.Certificate
+	if cfg.UseTLS {
+		c, err := ipc.LoadOrCreateCertificate(
+			filepath.Join(a.configDir, remoteControlCertFile),
+			filepath.Join(a.configDir, remoteControlKeyFile))
+		if err != nil {
+			log.Printf("error loading remote control certificate: %v", err)
+			return
+		}
+		cert = &c
+		rc.scheme = "https"
+		rc.certFingerprint = ipc.CertificateFingerprint(c)
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Port))
+	if err != nil {
+		log.Printf("error starting remote control server: %v", err)
+		return
+	}
+	rc.server = ipc.NewRemoteServer(a.ipcServer, cfg.Devices,
+		a.setRemoteControlDevices, a.ImageManager.GetCoverThumbnail)
+	a.remoteControl = rc
+	go rc.server.Serve(listener, cert)
+}
+
+// RemoteControlInfo returns the connection info for the remote control
+// server, or false if it is not running.
+func (a *App) RemoteControlInfo() (RemoteControlInfo, bool) {
+	rc := a.remoteControl
+	if rc == nil {
+		return RemoteControlInfo{}, false
+	}
+	info := RemoteControlInfo{
+		PairingCode:     rc.server.PairingCode(),
+		CertFingerprint: rc.certFingerprint,
+	}
+	for _, addr := range ipc.LocalAddresses() {
+		host := net.JoinHostPort(addr, fmt.Sprint(rc.port))
+		info.URLs = append(info.URLs, fmt.Sprintf("%s://%s%s", rc.scheme, host, ipc.RemoteUIPath))
+	}
+	return info, true
+}
+
+// RemoteControlDevices returns the devices paired with the remote control server.
+func (a *App) RemoteControlDevices() []ipc.PairedDevice {
+	if a.remoteControl == nil {
+		return a.Config.RemoteControl.Devices
+	}
+	return a.remoteControl.server.Devices()
+}
+
+// RevokeRemoteControlDevice unpairs the device with the given ID.
+// Must be called on the main thread.
+func (a *App) RevokeRemoteControlDevice(id string) {
+	if a.remoteControl != nil {
+		a.remoteControl.server.RevokeDevice(id)
+		return
+	}
+	a.saveRemoteControlDevices(slices.DeleteFunc(slices.Clone(a.Config.RemoteControl.Devices),
+		func(d ipc.PairedDevice) bool { return d.ID == id }))
+}
+
+// setRemoteControlDevices is called from the remote control server's goroutines
+// when devices are paired or revoked, and saves them on the main thread.
+func (a *App) setRemoteControlDevices(devices []ipc.PairedDevice) {
+	a.runOnMainThread(func() { a.saveRemoteControlDevices(devices) })
+}
+
+// saveRemoteControlDevices stores the paired devices in the config and saves it.
+// Must be called on the main thread.
+func (a *App) saveRemoteControlDevices(devices []ipc.PairedDevice) {
+	a.Config.RemoteControl.Devices = devices
+	a.SaveConfigFile()
+}
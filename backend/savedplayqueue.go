@@ -5,81 +5,159 @@ import (
 	"errors"
 	"log"
 	"os"
+	"slices"
 
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
 )
 
 type SavedPlayQueue struct {
-	Tracks     []*mediaprovider.Track
-	TrackIndex int
-	TimePos    float64
+	Items []mediaprovider.MediaItem
+	// The shuffled ordering of Items. Only set if Shuffle is true.
+	ShuffledItems []mediaprovider.MediaItem
+	// Index of the now playing item in the active (shuffled or unshuffled) queue
+	TrackIndex        int
+	TimePos           float64
+	Shuffle           bool
+	LoopMode          LoopMode
+	PauseAfterCurrent bool
+
+	// false if loaded from the server or a file saved by an older version,
+	// in which case Shuffle, LoopMode and PauseAfterCurrent are not known
+	hasPlaybackModes bool
+}
+
+// ActiveQueue returns the queue that TrackIndex refers to.
+func (s *SavedPlayQueue) ActiveQueue() []mediaprovider.MediaItem {
+	if s.Shuffle {
+		return s.ShuffledItems
+	}
+	return s.Items
 }
 
 type serializedSavedPlayQueue struct {
-	ServerID   string   `json:"serverID"`
-	TrackIDs   []string `json:"trackIDs"`
-	TrackIndex int      `json:"trackIndex"`
-	TimePos    float64  `json:"timePos"`
+	ServerID string `json:"serverID"`
+	// IDs of the tracks in the active queue. Superseded by Items,
+	// but still written so the file can be read by older versions.
+	TrackIDs   []string                   `json:"trackIDs"`
+	Items      []serializedSavedQueueItem `json:"items,omitempty"`
+	TrackIndex int                        `json:"trackIndex"`
+	TimePos    float64                    `json:"timePos"`
+	// Indexes into Items giving the shuffled ordering
+	ShuffledOrder     []int    `json:"shuffledOrder,omitempty"`
+	Shuffle           bool     `json:"shuffle"`
+	LoopMode          LoopMode `json:"loopMode"`
+	PauseAfterCurrent bool     `json:"pauseAfterCurrent"`
+}
+
+type serializedSavedQueueItem struct {
+	Type mediaprovider.MediaItemType `json:"type"`
+	ID   string                      `json:"id"`
 }
 
-// SavePlayQueue saves the current play queue and playback position to a JSON file.
+// SavePlayQueue saves the current play queue, shuffle ordering, playback modes
+// and playback position to a JSON file.
 // If the provided CanSavePlayQueue server is non-nil, it will also save to the server.
-func SavePlayQueue(serverID string, queue []mediaprovider.MediaItem, pm *PlaybackManager, filepath string, server mediaprovider.CanSavePlayQueue) error {
+// Since servers can only store tracks, radio stations are omitted from the server-side queue.
+func SavePlayQueue(serverID string, pm *PlaybackManager, filepath string, server mediaprovider.CanSavePlayQueue) error {
 	stats := pm.PlaybackStatus()
 	trackIdx := pm.NowPlayingIndex()
+	queue := pm.GetPlayQueue()
+	shuffle := pm.IsShuffle()
 
-	trackIDs := make([]string, 0, len(queue))
+	saved := serializedSavedPlayQueue{
+		ServerID:          serverID,
+		Items:             make([]serializedSavedQueueItem, 0, len(queue)),
+		TrackIndex:        trackIdx,
+		TimePos:           stats.TimePos,
+		Shuffle:           shuffle,
+		LoopMode:          pm.GetLoopMode(),
+		PauseAfterCurrent: pm.IsPauseAfterCurrent(),
+	}
 	for _, item := range queue {
+		meta := item.Metadata()
+		saved.Items = append(saved.Items, serializedSavedQueueItem{Type: meta.Type, ID: meta.ID})
+	}
+
+	activeQueue := queue
+	if shuffle {
+		activeQueue = pm.GetShuffledPlayQueue()
+		saved.ShuffledOrder = shuffledOrder(queue, activeQueue)
+	}
+
+	// the server-side queue (and legacy TrackIDs) only holds tracks,
+	// so the now playing index must skip over any radio stations
+	trackIDs := make([]string, 0, len(activeQueue))
+	serverTrackIdx := 0
+	for i, item := range activeQueue {
 		if _, ok := item.(*mediaprovider.Track); ok {
-			// don't save radio stations in play queue
 			trackIDs = append(trackIDs, item.Metadata().ID)
+		} else if i < trackIdx {
+			serverTrackIdx--
 		}
 	}
-	if l := len(trackIDs); trackIdx >= l {
-		if l == 0 {
-			trackIdx = 0
-		} else {
-			trackIdx = l - 1
-		}
+	serverTrackIdx += trackIdx
+	if l := len(trackIDs); serverTrackIdx >= l {
+		serverTrackIdx = max(l-1, 0)
 	}
+	saved.TrackIDs = trackIDs
 
-	saved := serializedSavedPlayQueue{
-		ServerID:   serverID,
-		TrackIDs:   trackIDs,
-		TrackIndex: trackIdx,
-		TimePos:    stats.TimePos,
-	}
 	b, _ := json.Marshal(saved)
 	err := os.WriteFile(filepath, b, 0o644)
 
 	if server != nil {
 		// save to server
-		err = server.SavePlayQueue(trackIDs, trackIdx, int(stats.TimePos))
+		err = server.SavePlayQueue(trackIDs, serverTrackIdx, int(stats.TimePos))
 	}
 	return err
 }
 
 // Loads the saved play queue from the given filepath using the current server.
 // If loadFromServer is true and the current server supports saving the play queue,
-// the queue will attempt to load from the server and only use the local file as a fallback.
+// the queue will attempt to load from the server. The local file is still used
+// if it contains the same tracks as the server queue, since it additionally stores
+// radio stations, the shuffle ordering and playback modes. Otherwise, the local file is a fallback.
+// Items that can no longer be found on the server are dropped from the restored queue.
 // Returns an error if the queue could not be loaded for any reason, including the
 // currently logged in server being different than the server from which the queue was saved.
 func LoadPlayQueue(filepath string, sm *ServerManager, loadFromServer bool) (*SavedPlayQueue, error) {
+	var serverQueue *mediaprovider.SavedPlayQueue
 	if pq, ok := sm.Server.(mediaprovider.CanSavePlayQueue); loadFromServer && ok && pq != nil {
 		// load queue from server
 		queue, err := pq.GetPlayQueue()
 		if err == nil {
-			return &SavedPlayQueue{
-				Tracks:     queue.Tracks,
-				TrackIndex: queue.TrackPos,
-				TimePos:    float64(queue.TimePos),
-			}, nil
+			serverQueue = queue
 		} else {
 			log.Printf("error loading queue from server: %v", err.Error())
 		}
 	}
 
 	// load queue from local file
+	localQueue, err := loadLocalPlayQueue(filepath, sm)
+	if serverQueue == nil {
+		return localQueue, err
+	}
+	if err == nil && sameTracks(localQueue.ActiveQueue(), serverQueue.Tracks) {
+		// local state matches the server - use it, but take
+		// the position from the server as it may have been updated
+		// by another client since this one last saved
+		if idx := trackIdxToItemIdx(localQueue.ActiveQueue(), serverQueue.TrackPos); idx >= 0 {
+			localQueue.TrackIndex = idx
+			localQueue.TimePos = float64(serverQueue.TimePos)
+		}
+		return localQueue, nil
+	}
+	items := make([]mediaprovider.MediaItem, 0, len(serverQueue.Tracks))
+	for _, tr := range serverQueue.Tracks {
+		items = append(items, tr)
+	}
+	return &SavedPlayQueue{
+		Items:      items,
+		TrackIndex: serverQueue.TrackPos,
+		TimePos:    float64(serverQueue.TimePos),
+	}, nil
+}
+
+func loadLocalPlayQueue(filepath string, sm *ServerManager) (*SavedPlayQueue, error) {
 	b, err := os.ReadFile(filepath)
 	if err != nil {
 		return nil, err
@@ -94,23 +172,211 @@ func LoadPlayQueue(filepath string, sm *ServerManager, loadFromServer bool) (*Sa
 		return nil, errors.New("saved play queue was from a different server")
 	}
 
-	tracks := make([]*mediaprovider.Track, 0, len(savedData.TrackIDs))
-	mp := sm.Server
-	for i, id := range savedData.TrackIDs {
-		if tr, err := mp.GetTrack(id); err != nil || tr == nil {
-			// ignore/skip individual track failures
-			if i < savedData.TrackIndex {
-				savedData.TrackIndex--
-			}
+	if savedData.Items == nil {
+		// saved by an older version - only track IDs of the active queue
+		savedData.Items = make([]serializedSavedQueueItem, len(savedData.TrackIDs))
+		for i, id := range savedData.TrackIDs {
+			savedData.Items[i] = serializedSavedQueueItem{Type: mediaprovider.MediaItemTypeTrack, ID: id}
+		}
+		return restoreSavedPlayQueue(sm.Server, &savedData, false), nil
+	}
+	return restoreSavedPlayQueue(sm.Server, &savedData, true), nil
+}
+
+// migrateLegacyShuffledQueue converts the saved queue file of an older version, which
+// saved the unshuffled and shuffled queues of a shuffled play queue in two more files,
+// to a queue file with the shuffle ordering. The queue file is only converted if shuffle
+// was on and it still holds the shuffled queue. The legacy files are removed afterwards.
+func migrateLegacyShuffledQueue(queueFile, unshuffledFile, shuffledFile string, shuffle bool, loopMode LoopMode) error {
+	if err := convertLegacyShuffledQueue(queueFile, unshuffledFile, shuffledFile, shuffle, loopMode); err != nil {
+		return err
+	}
+	os.Remove(unshuffledFile)
+	os.Remove(shuffledFile)
+	return nil
+}
+
+func convertLegacyShuffledQueue(queueFile, unshuffledFile, shuffledFile string, shuffle bool, loopMode LoopMode) error {
+	read := func(filepath string) *serializedSavedPlayQueue {
+		b, err := os.ReadFile(filepath)
+		if err != nil {
+			return nil
+		}
+		var savedData serializedSavedPlayQueue
+		if json.Unmarshal(b, &savedData) != nil {
+			return nil
+		}
+		return &savedData
+	}
+
+	saved := read(queueFile)
+	if saved == nil || saved.Items != nil || !shuffle {
+		// nothing to convert, or already saved by a newer version
+		return nil
+	}
+	unshuffled, shuffled := read(unshuffledFile), read(shuffledFile)
+	if unshuffled == nil || shuffled == nil ||
+		unshuffled.ServerID != saved.ServerID || shuffled.ServerID != saved.ServerID ||
+		!slices.Equal(shuffled.TrackIDs, saved.TrackIDs) {
+		// the queue was changed, e.g. by another client, after shuffling
+		return nil
+	}
+
+	asTracks := func(ids []string) []mediaprovider.MediaItem {
+		items := make([]mediaprovider.MediaItem, len(ids))
+		for i, id := range ids {
+			items[i] = &mediaprovider.Track{ID: id}
+		}
+		return items
+	}
+	order := shuffledOrder(asTracks(unshuffled.TrackIDs), asTracks(saved.TrackIDs))
+	if order == nil {
+		return nil
+	}
+	saved.Items = make([]serializedSavedQueueItem, len(unshuffled.TrackIDs))
+	for i, id := range unshuffled.TrackIDs {
+		saved.Items[i] = serializedSavedQueueItem{Type: mediaprovider.MediaItemTypeTrack, ID: id}
+	}
+	saved.ShuffledOrder = order
+	saved.Shuffle = true
+	saved.LoopMode = loopMode
+
+	b, _ := json.Marshal(saved)
+	return os.WriteFile(queueFile, b, 0o644)
+}
+
+func restoreSavedPlayQueue(mp mediaprovider.MediaProvider, savedData *serializedSavedPlayQueue, hasPlaybackModes bool) *SavedPlayQueue {
+	// newIdxs maps from index in savedData.Items to index in the restored
+	// queue, or -1 for items that no longer exist on the server
+	newIdxs := make([]int, len(savedData.Items))
+	items := make([]mediaprovider.MediaItem, 0, len(savedData.Items))
+	for i, it := range savedData.Items {
+		if item := fetchSavedQueueItem(mp, it); item != nil {
+			newIdxs[i] = len(items)
+			items = append(items, item)
 		} else {
-			tracks = append(tracks, tr)
+			// ignore/skip individual item failures
+			log.Printf("skipping saved queue item %s: not found on server", it.ID)
+			newIdxs[i] = -1
 		}
 	}
 
 	savedQueue := &SavedPlayQueue{
-		Tracks:     tracks,
-		TrackIndex: savedData.TrackIndex,
-		TimePos:    savedData.TimePos,
+		Items:             items,
+		TrackIndex:        savedData.TrackIndex,
+		TimePos:           savedData.TimePos,
+		LoopMode:          savedData.LoopMode,
+		PauseAfterCurrent: savedData.PauseAfterCurrent,
+		hasPlaybackModes:  hasPlaybackModes,
+	}
+
+	// the active queue order, as indexes into savedData.Items
+	activeOrder := savedData.ShuffledOrder
+	if savedData.Shuffle && len(activeOrder) == len(savedData.Items) {
+		savedQueue.Shuffle = true
+		for _, idx := range activeOrder {
+			if idx >= 0 && idx < len(newIdxs) && newIdxs[idx] >= 0 {
+				savedQueue.ShuffledItems = append(savedQueue.ShuffledItems, items[newIdxs[idx]])
+			}
+		}
+	} else {
+		if savedData.Shuffle {
+			// shuffle ordering is missing or corrupt, so TrackIndex
+			// can't be mapped to the unshuffled queue
+			savedData.TrackIndex = 0
+			savedQueue.TrackIndex = 0
+			savedQueue.TimePos = 0
+		}
+		activeOrder = make([]int, len(savedData.Items))
+		for i := range activeOrder {
+			activeOrder[i] = i
+		}
+	}
+
+	// shift the now playing index back by the number of dropped items before it.
+	// If the now playing item itself was dropped, resume from the start of the next one.
+	for i, idx := range activeOrder {
+		if i > savedData.TrackIndex {
+			break
+		}
+		if idx < 0 || idx >= len(newIdxs) || newIdxs[idx] < 0 {
+			if i < savedData.TrackIndex {
+				savedQueue.TrackIndex--
+			} else {
+				savedQueue.TimePos = 0
+			}
+		}
+	}
+	if l := len(savedQueue.ActiveQueue()); savedQueue.TrackIndex < 0 || savedQueue.TrackIndex >= l {
+		// out of range, e.g. the last items were dropped or the file is corrupt
+		savedQueue.TrackIndex = 0
+		savedQueue.TimePos = 0
+	}
+	return savedQueue
+}
+
+func fetchSavedQueueItem(mp mediaprovider.MediaProvider, item serializedSavedQueueItem) mediaprovider.MediaItem {
+	switch item.Type {
+	case mediaprovider.MediaItemTypeRadioStation:
+		if rp, ok := mp.(mediaprovider.RadioProvider); ok {
+			if rs, err := rp.GetRadioStation(item.ID); err == nil && rs != nil {
+				return rs
+			}
+		}
+	default:
+		if tr, err := mp.GetTrack(item.ID); err == nil && tr != nil {
+			return tr
+		}
+	}
+	return nil
+}
+
+// shuffledOrder returns the index into queue of each item in shuffled.
+// Duplicate items in the queue are matched up in order.
+func shuffledOrder(queue, shuffled []mediaprovider.MediaItem) []int {
+	idxsByID := make(map[string][]int, len(queue))
+	for i, item := range queue {
+		id := item.Metadata().ID
+		idxsByID[id] = append(idxsByID[id], i)
+	}
+	order := make([]int, 0, len(shuffled))
+	for _, item := range shuffled {
+		id := item.Metadata().ID
+		if idxs := idxsByID[id]; len(idxs) > 0 {
+			order = append(order, idxs[0])
+			idxsByID[id] = idxs[1:]
+		}
+	}
+	if len(order) != len(queue) {
+		// inconsistent queue state; don't save shuffle ordering
+		return nil
+	}
+	return order
+}
+
+// sameTracks returns true if the tracks in items, ignoring any
+// radio stations, are the same as the given tracks in the same order.
+func sameTracks(items []mediaprovider.MediaItem, tracks []*mediaprovider.Track) bool {
+	itemTracks := slices.DeleteFunc(slices.Clone(items), func(item mediaprovider.MediaItem) bool {
+		_, ok := item.(*mediaprovider.Track)
+		return !ok
+	})
+	return slices.EqualFunc(itemTracks, tracks, func(a mediaprovider.MediaItem, b *mediaprovider.Track) bool {
+		return a.Metadata().ID == b.ID
+	})
+}
+
+// trackIdxToItemIdx converts the index of the trackIdx'th track in items,
+// skipping radio stations, to its index in items. Returns -1 if out of range.
+func trackIdxToItemIdx(items []mediaprovider.MediaItem, trackIdx int) int {
+	for i, item := range items {
+		if _, ok := item.(*mediaprovider.Track); !ok {
+			continue
+		}
+		if trackIdx == 0 {
+			return i
+		}
+		trackIdx--
 	}
-	return savedQueue, nil
+	return -1
 }
@@ -0,0 +1,212 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path"
+	"slices"
+	"testing"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/sharedutil"
+)
+
+// savedQueueTestProvider serves the tracks and radio stations with the given IDs
+type savedQueueTestProvider struct {
+	mediaprovider.MediaProvider
+	ids []string
+}
+
+func (p *savedQueueTestProvider) GetTrack(id string) (*mediaprovider.Track, error) {
+	if slices.Contains(p.ids, id) {
+		return &mediaprovider.Track{ID: id}, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func (p *savedQueueTestProvider) GetRadioStation(id string) (*mediaprovider.RadioStation, error) {
+	if slices.Contains(p.ids, id) {
+		return &mediaprovider.RadioStation{ID: id}, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func (p *savedQueueTestProvider) GetRadioStations() ([]*mediaprovider.RadioStation, error) {
+	return nil, nil
+}
+
+func TestRestoreSavedPlayQueue(t *testing.T) {
+	items := func(ids ...string) []serializedSavedQueueItem {
+		res := make([]serializedSavedQueueItem, len(ids))
+		for i, id := range ids {
+			res[i] = serializedSavedQueueItem{Type: mediaprovider.MediaItemTypeTrack, ID: id}
+		}
+		return res
+	}
+	ids := func(items []mediaprovider.MediaItem) []string {
+		res := make([]string, len(items))
+		for i, item := range items {
+			res[i] = item.Metadata().ID
+		}
+		return res
+	}
+
+	tests := []struct {
+		name         string
+		available    []string
+		saved        serializedSavedPlayQueue
+		wantItems    []string
+		wantShuffled []string
+		wantShuffle  bool
+		wantTrackIdx int
+		wantTimePos  float64
+	}{
+		{
+			name:      "unshuffled",
+			available: []string{"a", "b", "c"},
+			saved:     serializedSavedPlayQueue{Items: items("a", "b", "c"), TrackIndex: 1, TimePos: 10},
+			wantItems: []string{"a", "b", "c"}, wantTrackIdx: 1, wantTimePos: 10,
+		},
+		{
+			name:      "unshuffled with missing tracks before and after now playing",
+			available: []string{"b", "c"},
+			saved:     serializedSavedPlayQueue{Items: items("a", "b", "c", "d"), TrackIndex: 2, TimePos: 10},
+			wantItems: []string{"b", "c"}, wantTrackIdx: 1, wantTimePos: 10,
+		},
+		{
+			name:      "now playing track missing",
+			available: []string{"a", "c"},
+			saved:     serializedSavedPlayQueue{Items: items("a", "b", "c"), TrackIndex: 1, TimePos: 10},
+			wantItems: []string{"a", "c"}, wantTrackIdx: 1, wantTimePos: 0,
+		},
+		{
+			name:      "shuffled",
+			available: []string{"a", "b", "c"},
+			saved: serializedSavedPlayQueue{Items: items("a", "b", "c"), ShuffledOrder: []int{2, 0, 1},
+				Shuffle: true, TrackIndex: 1, TimePos: 10},
+			wantItems: []string{"a", "b", "c"}, wantShuffled: []string{"c", "a", "b"},
+			wantShuffle: true, wantTrackIdx: 1, wantTimePos: 10,
+		},
+		{
+			name:      "shuffled with missing tracks",
+			available: []string{"a", "b"},
+			saved: serializedSavedPlayQueue{Items: items("a", "b", "c"), ShuffledOrder: []int{2, 0, 1},
+				Shuffle: true, TrackIndex: 2, TimePos: 10},
+			wantItems: []string{"a", "b"}, wantShuffled: []string{"a", "b"},
+			wantShuffle: true, wantTrackIdx: 1, wantTimePos: 10,
+		},
+		{
+			name:      "shuffled with corrupt ordering",
+			available: []string{"a", "b", "c"},
+			saved: serializedSavedPlayQueue{Items: items("a", "b", "c"), ShuffledOrder: []int{2, 0},
+				Shuffle: true, TrackIndex: 1, TimePos: 10},
+			wantItems: []string{"a", "b", "c"}, wantTrackIdx: 0, wantTimePos: 0,
+		},
+		{
+			name:      "index out of range",
+			available: []string{"a", "b"},
+			saved:     serializedSavedPlayQueue{Items: items("a", "b"), TrackIndex: 5, TimePos: 10},
+			wantItems: []string{"a", "b"}, wantTrackIdx: 0, wantTimePos: 0,
+		},
+		{
+			name:      "index out of range after dropping trailing tracks",
+			available: []string{"a"},
+			saved:     serializedSavedPlayQueue{Items: items("a", "b"), TrackIndex: 1, TimePos: 10},
+			wantItems: []string{"a"}, wantTrackIdx: 0, wantTimePos: 0,
+		},
+		{
+			name:      "negative index",
+			available: []string{"a"},
+			saved:     serializedSavedPlayQueue{Items: items("a"), TrackIndex: -1, TimePos: 10},
+			wantItems: []string{"a"}, wantTrackIdx: 0, wantTimePos: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mp := &savedQueueTestProvider{ids: tt.available}
+			got := restoreSavedPlayQueue(mp, &tt.saved, true)
+			if g := ids(got.Items); !slices.Equal(g, tt.wantItems) {
+				t.Errorf("items: got %v, want %v", g, tt.wantItems)
+			}
+			if g := ids(got.ShuffledItems); !slices.Equal(g, tt.wantShuffled) {
+				t.Errorf("shuffled items: got %v, want %v", g, tt.wantShuffled)
+			}
+			if got.Shuffle != tt.wantShuffle {
+				t.Errorf("shuffle: got %v, want %v", got.Shuffle, tt.wantShuffle)
+			}
+			if got.TrackIndex != tt.wantTrackIdx {
+				t.Errorf("track index: got %d, want %d", got.TrackIndex, tt.wantTrackIdx)
+			}
+			if got.TimePos != tt.wantTimePos {
+				t.Errorf("time pos: got %v, want %v", got.TimePos, tt.wantTimePos)
+			}
+		})
+	}
+}
+
+func TestMigrateLegacyShuffledQueue(t *testing.T) {
+	write := func(filepath string, saved serializedSavedPlayQueue) {
+		b, _ := json.Marshal(saved)
+		if err := os.WriteFile(filepath, b, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	read := func(filepath string) serializedSavedPlayQueue {
+		var saved serializedSavedPlayQueue
+		b, _ := os.ReadFile(filepath)
+		json.Unmarshal(b, &saved)
+		return saved
+	}
+	exists := func(filepath string) bool {
+		_, err := os.Stat(filepath)
+		return err == nil
+	}
+
+	dir := t.TempDir()
+	queueFile := path.Join(dir, "saved_queue.json")
+	unshuffledFile := path.Join(dir, "saved_unshuffled_queue.json")
+	shuffledFile := path.Join(dir, "saved_shuffled_queue.json")
+	writeLegacy := func(active, shuffled []string) {
+		write(queueFile, serializedSavedPlayQueue{ServerID: "s", TrackIDs: active, TrackIndex: 2, TimePos: 10})
+		write(unshuffledFile, serializedSavedPlayQueue{ServerID: "s", TrackIDs: []string{"a", "b", "c"}})
+		write(shuffledFile, serializedSavedPlayQueue{ServerID: "s", TrackIDs: shuffled})
+	}
+
+	writeLegacy([]string{"c", "a", "b"}, []string{"c", "a", "b"})
+	if err := migrateLegacyShuffledQueue(queueFile, unshuffledFile, shuffledFile, true, LoopAll); err != nil {
+		t.Fatal(err)
+	}
+	if exists(unshuffledFile) || exists(shuffledFile) {
+		t.Error("expected legacy files to be removed")
+	}
+	saved := read(queueFile)
+	got := restoreSavedPlayQueue(&savedQueueTestProvider{ids: []string{"a", "b", "c"}}, &saved, true)
+	if !got.Shuffle || got.LoopMode != LoopAll {
+		t.Errorf("expected shuffle and loop mode to be migrated, got %v, %v", got.Shuffle, got.LoopMode)
+	}
+	gotIDs := func(items []mediaprovider.MediaItem) []string {
+		return sharedutil.MapSlice(items, func(i mediaprovider.MediaItem) string { return i.Metadata().ID })
+	}
+	if g := gotIDs(got.Items); !slices.Equal(g, []string{"a", "b", "c"}) {
+		t.Errorf("items: got %v", g)
+	}
+	if g := gotIDs(got.ShuffledItems); !slices.Equal(g, []string{"c", "a", "b"}) {
+		t.Errorf("shuffled items: got %v", g)
+	}
+	if got.TrackIndex != 2 || got.TimePos != 10 {
+		t.Errorf("expected position to be kept, got %d, %v", got.TrackIndex, got.TimePos)
+	}
+
+	// queue changed after shuffling; restored unshuffled as before
+	writeLegacy([]string{"d", "e"}, []string{"c", "a", "b"})
+	if err := migrateLegacyShuffledQueue(queueFile, unshuffledFile, shuffledFile, true, LoopNone); err != nil {
+		t.Fatal(err)
+	}
+	if saved := read(queueFile); saved.Items != nil || !slices.Equal(saved.TrackIDs, []string{"d", "e"}) {
+		t.Errorf("expected changed queue file to be left alone, got %+v", saved)
+	}
+	if exists(unshuffledFile) || exists(shuffledFile) {
+		t.Error("expected legacy files to be removed")
+	}
+}
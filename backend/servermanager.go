@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/dweymouth/go-jellyfin"
@@ -32,6 +34,11 @@ type ServerManager struct {
 	config            *Config
 	onServerConnected []func(*ServerConfig)
 	onLogout          []func()
+
+	// guards ContinuousAlbumIDs of the server configs, which is read
+	// from playback goroutines; the slice is replaced, never modified
+	continuousLock          sync.RWMutex
+	onAlbumContinuousChange []func(albumID string, continuous bool)
 }
 
 var ErrUnreachable = errors.New("server is unreachable")
@@ -111,6 +118,53 @@ func (s *ServerManager) SetDefaultServer(serverID uuid.UUID) {
 	}
 }
 
+// IsAlbumContinuous returns whether the album of the connected server is
+// flagged as continuous, so silence between its tracks is never skipped.
+func (s *ServerManager) IsAlbumContinuous(albumID string) bool {
+	if conf := s.connectedServer(); conf != nil {
+		s.continuousLock.RLock()
+		defer s.continuousLock.RUnlock()
+		return slices.Contains(conf.ContinuousAlbumIDs, albumID)
+	}
+	return false
+}
+
+// SetAlbumContinuous flags or unflags the album of the connected server as continuous.
+func (s *ServerManager) SetAlbumContinuous(albumID string, continuous bool) {
+	conf := s.connectedServer()
+	if conf == nil {
+		return
+	}
+	s.continuousLock.Lock()
+	ids := make([]string, 0, len(conf.ContinuousAlbumIDs)+1)
+	for _, id := range conf.ContinuousAlbumIDs {
+		if id != albumID {
+			ids = append(ids, id)
+		}
+	}
+	if continuous {
+		ids = append(ids, albumID)
+	}
+	conf.ContinuousAlbumIDs = ids
+	s.continuousLock.Unlock()
+
+	for _, cb := range s.onAlbumContinuousChange {
+		cb(albumID, continuous)
+	}
+}
+
+func (s *ServerManager) connectedServer() *ServerConfig {
+	if s.Server == nil {
+		return nil
+	}
+	for _, conf := range s.config.Servers {
+		if conf.ID == s.ServerID {
+			return conf
+		}
+	}
+	return nil
+}
+
 func (s *ServerManager) AddServer(nickname string, connection ServerConnection) *ServerConfig {
 	sc := &ServerConfig{
 		ID:               uuid.New(),
@@ -162,6 +216,11 @@ func (s *ServerManager) OnLogout(cb func()) {
 	s.onLogout = append(s.onLogout, cb)
 }
 
+// Sets a callback that is invoked when an album is flagged or unflagged as continuous.
+func (s *ServerManager) OnAlbumContinuousChange(cb func(albumID string, continuous bool)) {
+	s.onAlbumContinuousChange = append(s.onAlbumContinuousChange, cb)
+}
+
 func (s *ServerManager) GetServerPassword(serverID uuid.UUID) (string, error) {
 	if s.useKeyring {
 		return keyring.Get(s.appName, serverID.String())
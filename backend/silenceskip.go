@@ -0,0 +1,147 @@
+package backend
+
+import (
+	"context"
+	"log"
+	"math"
+	"time"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+)
+
+// MinSkipSilenceThresholdDB is the lowest silence threshold that can be told
+// apart in the analyzed waveform: peaks are stored with 8 bits, so the quietest
+// non-zero peak is 1/255, or about -48 dB, and any lower threshold would only
+// treat digital silence as silent.
+const MinSkipSilenceThresholdDB = -48
+
+// silenceBounds returns the start and end times, in seconds, of the audible part
+// of a track with the given duration, according to its analyzed waveform data.
+// Leading or trailing silence shorter than minSilence seconds is not trimmed.
+func silenceBounds(data *waveformData, duration, thresholdDB, minSilence float64) (start, end float64) {
+	start, end = 0, duration
+	if data == nil || data.progress == 0 || duration <= 0 {
+		return start, end
+	}
+	threshold := math.Pow(10, max(thresholdDB, MinSkipSilenceThresholdDB)/20)
+	audible := func(i int) bool {
+		return float64(data.Peak[i])/255 > threshold
+	}
+
+	first := 0
+	for first < data.progress && !audible(first) {
+		first++
+	}
+	if first == data.progress {
+		return start, end // entirely silent; leave it alone
+	}
+	last := data.progress - 1
+	for !audible(last) {
+		last--
+	}
+
+	// each analyzed column covers an equal slice of the track
+	colSecs := duration / float64(len(data.Peak))
+	if s := float64(first) * colSecs; s >= minSilence {
+		start = s
+	}
+	if e := float64(last+1) * colSecs; duration-e >= minSilence {
+		end = e
+	}
+	return start, end
+}
+
+func (p *PlaybackManager) addSilenceSkipHooks() {
+	p.OnSongChange(func(item mediaprovider.MediaItem, _ *mediaprovider.Track) {
+		p.handleSilenceSkipSongChange(item)
+	})
+
+	// an album flagged as continuous while playing keeps its trailing silence
+	p.engine.sm.OnAlbumContinuousChange(func(albumID string, continuous bool) {
+		p.silenceSkipLock.Lock()
+		defer p.silenceSkipLock.Unlock()
+		if continuous && p.silenceSkipAlbum == albumID {
+			p.silenceSkipEnd = 0
+		}
+	})
+
+	p.OnPlayTimeUpdate(func(curTime, _ float64, _ bool) {
+		p.silenceSkipLock.Lock()
+		end, album := p.silenceSkipEnd, p.silenceSkipAlbum
+		skip := end > 0 && curTime >= end
+		if skip {
+			p.silenceSkipEnd = 0
+		}
+		p.silenceSkipLock.Unlock()
+		if !skip || p.engine.sm.IsAlbumContinuous(album) {
+			return
+		}
+		log.Printf("skipping trailing silence from %0.1fs", end)
+		if idx := p.engine.nextPlayingIndex(); idx >= 0 {
+			p.cmdQueue.PlayTrackAt(idx)
+		} else {
+			p.cmdQueue.Stop()
+		}
+	})
+}
+
+// handleSilenceSkipSongChange waits for the waveform analysis of the new track,
+// then skips its leading silence and arms the skip of its trailing silence.
+func (p *PlaybackManager) handleSilenceSkipSongChange(item mediaprovider.MediaItem) {
+	if p.silenceSkipCancel != nil {
+		p.silenceSkipCancel()
+		p.silenceSkipCancel = nil
+	}
+	p.silenceSkipLock.Lock()
+	p.silenceSkipEnd = 0
+	p.silenceSkipAlbum = ""
+	p.silenceSkipLock.Unlock()
+
+	tr, ok := item.(*mediaprovider.Track)
+	if !ok || p.wfmGen == nil || !p.cfg.SkipSilence || p.engine.sm.IsAlbumContinuous(tr.AlbumID) {
+		return
+	}
+
+	// the waveform seekbar shares the analysis job of the now playing
+	// track and cancels it when the track changes; otherwise we do
+	ownsJob := !p.cfg.UseWaveformSeekbar
+	job := p.wfmGen.StartWaveformGeneration(tr)
+	ctx, cancel := context.WithCancel(p.cache.rootCtx)
+	p.silenceSkipCancel = cancel
+	go func() {
+		for !job.Done() {
+			select {
+			case <-ctx.Done():
+				if ownsJob {
+					job.Cancel()
+				}
+				return
+			case <-time.After(250 * time.Millisecond):
+			}
+		}
+		data := job.analysis()
+		if data == nil {
+			return
+		}
+		start, end := silenceBounds(data, tr.Duration.Seconds(),
+			p.cfg.SkipSilenceThresholdDB, p.cfg.SkipSilenceMinSeconds)
+
+		p.silenceSkipLock.Lock()
+		if ctx.Err() != nil || p.engine.sm.IsAlbumContinuous(tr.AlbumID) {
+			// the track has changed or its album was flagged in the meantime
+			p.silenceSkipLock.Unlock()
+			return
+		}
+		if end < tr.Duration.Seconds() {
+			p.silenceSkipEnd = end
+			p.silenceSkipAlbum = tr.AlbumID
+		}
+		p.silenceSkipLock.Unlock()
+
+		// only skip the lead-in if playback hasn't already moved past it
+		if start > 0 && p.PlaybackStatus().TimePos < start {
+			log.Printf("skipping leading silence to %0.1fs", start)
+			p.cmdQueue.SeekSeconds(start)
+		}
+	}()
+}
@@ -0,0 +1,107 @@
+package backend
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/google/uuid"
+)
+
+func TestSilenceBounds(t *testing.T) {
+	// 1024 columns over 102.4 seconds, so each column is 0.1 seconds
+	data := &waveformData{progress: 1024}
+	for i := 50; i < 900; i++ {
+		data.Peak[i] = 128
+	}
+	start, end := silenceBounds(data, 102.4, -40, 6)
+	if start != 0 {
+		t.Errorf("expected short lead-in to be kept, got start %v", start)
+	}
+	if end < 89.99 || end > 90.01 {
+		t.Errorf("expected lead-out to be trimmed at 90s, got end %v", end)
+	}
+
+	start, _ = silenceBounds(data, 102.4, -40, 3)
+	if start < 4.99 || start > 5.01 {
+		t.Errorf("expected lead-in to be trimmed at 5s, got start %v", start)
+	}
+
+	// quiet audio above the threshold is not silence
+	for i := 900; i < 1024; i++ {
+		data.Peak[i] = 5
+	}
+	if _, end := silenceBounds(data, 102.4, -40, 3); end != 102.4 {
+		t.Errorf("expected quiet lead-out to be kept, got end %v", end)
+	}
+	if _, end := silenceBounds(data, 102.4, -20, 3); end < 89.99 || end > 90.01 {
+		t.Errorf("expected quiet lead-out below threshold to be trimmed, got end %v", end)
+	}
+
+	if start, end := silenceBounds(&waveformData{progress: 1024}, 102.4, -40, 3); start != 0 || end != 102.4 {
+		t.Error("expected silent track not to be trimmed")
+	}
+}
+
+func TestSilenceSkipContinuousAlbum(t *testing.T) {
+	conf := &ServerConfig{ID: uuid.New()}
+	sm := &ServerManager{
+		ServerID: conf.ID,
+		Server:   struct{ mediaprovider.MediaProvider }{},
+		config:   &Config{Servers: []*ServerConfig{conf}},
+	}
+	// a bare command queue, with nothing consuming its commands
+	q := &playbackCommandQueue{}
+	q.cmdAvailable = sync.NewCond(&q.mutex)
+	p := &PlaybackManager{engine: &playbackEngine{sm: sm}, cmdQueue: q}
+	p.addSilenceSkipHooks()
+	playTimeUpdate := func(curTime float64) {
+		for _, cb := range p.engine.onPlayTimeUpdate {
+			cb(curTime, 100, false)
+		}
+	}
+
+	p.silenceSkipEnd, p.silenceSkipAlbum = 90, "al-1"
+	sm.SetAlbumContinuous("al-2", true)
+	if p.silenceSkipEnd != 90 {
+		t.Error("expected skip to stay armed when another album is flagged")
+	}
+	sm.SetAlbumContinuous("al-1", true)
+	if p.silenceSkipEnd != 0 {
+		t.Error("expected skip to be disarmed when the album is flagged")
+	}
+
+	// the flag is checked again before skipping
+	p.silenceSkipEnd = 90
+	playTimeUpdate(95)
+	if len(q.queue) != 0 {
+		t.Errorf("expected no skip for a continuous album, got %d commands", len(q.queue))
+	}
+
+	sm.SetAlbumContinuous("al-1", false)
+	p.silenceSkipEnd = 90
+	playTimeUpdate(85)
+	if len(q.queue) != 0 {
+		t.Error("expected no skip before the trailing silence")
+	}
+	playTimeUpdate(95)
+	if len(q.queue) != 1 || q.queue[0].Type != cmdStop {
+		t.Error("expected the last track in the queue to be stopped at its trailing silence")
+	}
+}
+
+func TestSilenceBoundsThresholdFloor(t *testing.T) {
+	// a lead-in at the quietest non-zero peak is silent at and below the lowest threshold
+	data := &waveformData{progress: 1024}
+	for i := 0; i < 1024; i++ {
+		data.Peak[i] = 128
+	}
+	for i := 0; i < 50; i++ {
+		data.Peak[i] = 1
+	}
+	for _, db := range []float64{MinSkipSilenceThresholdDB, -60} {
+		if start, _ := silenceBounds(data, 102.4, db, 3); start < 4.99 || start > 5.01 {
+			t.Errorf("expected lead-in to be trimmed at 5s at %v dB, got start %v", db, start)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package backend
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/20after4/configdir"
+)
+
+const waveformFileExt = ".wfm"
+
+// WaveformCache persists the analyzed peak and RMS data of waveform images
+// on disk, per server and track ID, so waveforms of previously played
+// tracks don't need to be downloaded and analyzed again.
+type WaveformCache struct {
+	baseCacheDir string
+	maxSizeBytes int64
+
+	mutex sync.Mutex
+}
+
+func NewWaveformCache(baseCacheDir string, maxSizeBytes int64) *WaveformCache {
+	return &WaveformCache{baseCacheDir: baseCacheDir, maxSizeBytes: maxSizeBytes}
+}
+
+func (w *WaveformCache) filePath(serverID, trackID string) string {
+	return filepath.Join(w.baseCacheDir, serverID, sanitizeFileName(trackID)+waveformFileExt)
+}
+
+// Get returns the cached waveform data of the track, if any.
+func (w *WaveformCache) Get(serverID, trackID string) (*waveformData, bool) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	path := w.filePath(serverID, trackID)
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	data, err := decodeWaveformData(b)
+	if err != nil {
+		os.Remove(path)
+		return nil, false
+	}
+	// modTime is used as the last access time when pruning
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return data, true
+}
+
+// Has returns true if the waveform data of the track is cached.
+func (w *WaveformCache) Has(serverID, trackID string) bool {
+	_, err := os.Stat(w.filePath(serverID, trackID))
+	return err == nil
+}
+
+// Put saves the waveform data of the track, evicting the least
+// recently used waveforms if the cache exceeds its maximum size.
+func (w *WaveformCache) Put(serverID, trackID string, data *waveformData) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	path := w.filePath(serverID, trackID)
+	if err := configdir.MakePath(filepath.Dir(path)); err != nil {
+		return err
+	}
+	// write to a temp file first so a partially written file is never read
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, encodeWaveformData(data), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	w.prune()
+	return nil
+}
+
+// Clear deletes all cached waveforms.
+func (w *WaveformCache) Clear() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return os.RemoveAll(w.baseCacheDir)
+}
+
+func (w *WaveformCache) prune() {
+	type fileInfo struct {
+		path    string
+		size    int64
+		modTime int64
+	}
+	var files []fileInfo
+	var totalSize int64
+	filepath.WalkDir(w.baseCacheDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, waveformFileExt) {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			files = append(files, fileInfo{path: path, size: info.Size(), modTime: info.ModTime().UnixMilli()})
+			totalSize += info.Size()
+		}
+		return nil
+	})
+
+	if totalSize > w.maxSizeBytes {
+		sort.Slice(files, func(i, j int) bool {
+			return files[i].modTime < files[j].modTime
+		})
+		for i := 0; i < len(files) && totalSize > w.maxSizeBytes; i++ {
+			if err := os.Remove(files[i].path); err == nil {
+				totalSize -= files[i].size
+			}
+		}
+	}
+}
+
+// version of the file format, which is a version byte followed by
+// the valid bytes of each waveformData array, in the order of waveformArrays
+const waveformFileVersion = 2
+
+func waveformArrays(data *waveformData) [][]byte {
+	return [][]byte{data.Peak[:], data.RMS[:], data.PeakL[:], data.RMSL[:], data.PeakR[:], data.RMSR[:], data.Centroid[:]}
+}
+
+func encodeWaveformData(data *waveformData) []byte {
+	arrays := waveformArrays(data)
+	b := make([]byte, 0, 1+len(arrays)*data.progress)
+	b = append(b, waveformFileVersion)
+	for _, a := range arrays {
+		b = append(b, a[:data.progress]...)
+	}
+	return b
+}
+
+func decodeWaveformData(b []byte) (*waveformData, error) {
+	data := &waveformData{done: true}
+	arrays := waveformArrays(data)
+	if len(b) < 1 || b[0] != waveformFileVersion || (len(b)-1)%len(arrays) != 0 {
+		return nil, errors.New("invalid waveform data")
+	}
+	b = b[1:]
+	n := len(b) / len(arrays)
+	if n == 0 || n > len(data.Peak) {
+		return nil, errors.New("invalid waveform data")
+	}
+	for i, a := range arrays {
+		copy(a, b[i*n:(i+1)*n])
+	}
+	data.progress = n
+	return data, nil
+}
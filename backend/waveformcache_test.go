@@ -0,0 +1,51 @@
+package backend
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestWaveformCache(t *testing.T) {
+	// room for two full waveforms of 7169 bytes each
+	c := NewWaveformCache(t.TempDir(), 17000)
+
+	data := &waveformData{progress: 1000}
+	for i := range data.progress {
+		data.Peak[i] = byte(i)
+		data.RMS[i] = byte(i / 2)
+		data.Centroid[i] = byte(i / 3)
+	}
+	if err := c.Put("server", "track/1", data); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := c.Get("server", "track/1")
+	if !ok {
+		t.Fatal("expected cached waveform")
+	}
+	if got.progress != data.progress || got.Peak != data.Peak || got.RMS != data.RMS || got.Centroid != data.Centroid || !got.done {
+		t.Error("cached waveform does not match")
+	}
+	if _, ok := c.Get("other", "track/1"); ok {
+		t.Error("expected waveforms to be cached per server")
+	}
+
+	// the least recently used waveform is evicted
+	full := &waveformData{progress: 1024}
+	c.Put("server", "2", full)
+	old := time.Now().Add(-time.Hour)
+	_ = os.Chtimes(c.filePath("server", "track/1"), old, old)
+	_ = os.Chtimes(c.filePath("server", "2"), old.Add(time.Minute), old.Add(time.Minute))
+	c.Get("server", "track/1")
+	c.Put("server", "3", full)
+	if !c.Has("server", "track/1") || c.Has("server", "2") || !c.Has("server", "3") {
+		t.Error("expected least recently used waveform to be evicted")
+	}
+
+	if err := c.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if c.Has("server", "3") {
+		t.Error("expected cache to be cleared")
+	}
+}
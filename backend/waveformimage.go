@@ -11,6 +11,7 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
 	"time"
 
@@ -21,8 +22,22 @@ import (
 	"github.com/supersonic-app/go-mpv"
 )
 
+// Waveform seekbar rendering modes
+const (
+	WaveformModeMono      = "Mono"
+	WaveformModeStereo    = "Stereo"    // left channel above the center line, right below
+	WaveformModeFrequency = "Frequency" // colored by spectral centroid, from red (bass) to blue (treble)
+)
+
 type WaveformImageGenerator struct {
 	audioCache *AudioCache
+	cache      *WaveformCache
+	mode       string
+
+	mutex   sync.Mutex
+	running map[string]*WaveformImageJob // jobs analyzing a track, by track ID
+	pending []*mediaprovider.Track       // tracks queued for background generation
+	trigger chan struct{}
 }
 
 // Buffer pool for waveform analysis to reduce allocations
@@ -51,6 +66,7 @@ type WaveformImageJob struct {
 	ItemID   string
 	lock     sync.Mutex
 	img      *WaveformImage
+	data     *waveformData // analysis data, set when done
 	err      error
 	progress int // first invalid pixel in X direction
 	done     bool
@@ -83,6 +99,15 @@ func (w *WaveformImageJob) Err() error {
 	return w.err
 }
 
+// analysis returns the analyzed waveform data of the track
+// if the job completed successfully, or nil otherwise.
+func (w *WaveformImageJob) analysis() *waveformData {
+	if w.Done() && w.Err() == nil {
+		return w.data
+	}
+	return nil
+}
+
 func (w *WaveformImageJob) Get() *WaveformImage {
 	if w.Done() {
 		return w.img
@@ -102,17 +127,50 @@ func (w *WaveformImageJob) Get() *WaveformImage {
 	return result
 }
 
-func NewWaveformImageGenerator(cache *AudioCache) *WaveformImageGenerator {
-	return &WaveformImageGenerator{audioCache: cache}
+func NewWaveformImageGenerator(cache *AudioCache, mode string) *WaveformImageGenerator {
+	w := &WaveformImageGenerator{
+		audioCache: cache,
+		mode:       mode,
+		running:    make(map[string]*WaveformImageJob),
+		trigger:    make(chan struct{}, 1),
+	}
+	go w.runBackgroundGeneration()
+	return w
 }
 
+// SetWaveformCache sets the cache used to persist analyzed waveforms.
+func (w *WaveformImageGenerator) SetWaveformCache(cache *WaveformCache) {
+	w.cache = cache
+}
+
+// StartWaveformGeneration returns a job generating the waveform image of the track.
+// If the waveform is cached, the returned job is already done. If the track is
+// already being analyzed, e.g. in the background, the running job is returned.
 func (w *WaveformImageGenerator) StartWaveformGeneration(item *mediaprovider.Track) *WaveformImageJob {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if job, ok := w.running[item.ID]; ok && !job.Canceled() {
+		return job
+	}
+
+	serverID := w.audioCache.s.ServerID.String()
+	if w.cache != nil {
+		if data, ok := w.cache.Get(serverID, item.ID); ok {
+			job := &WaveformImageJob{img: NewWaveformImage(), ItemID: item.ID}
+			generateWaveformImage(context.Background(), data, job, w.mode)
+			job.data = data
+			job.done = true
+			return job
+		}
+	}
+
 	ctx, cancel := context.WithCancel(w.audioCache.rootCtx)
 	job := &WaveformImageJob{
 		img:    NewWaveformImage(),
 		ItemID: item.ID,
 		cancel: cancel,
 	}
+	w.running[item.ID] = job
 
 	// Set up a pipeline of concurrent tasks that need to complete to generate
 	// a waveform image:
@@ -121,6 +179,7 @@ func (w *WaveformImageGenerator) StartWaveformGeneration(item *mediaprovider.Tra
 	// 3. Begin analyzing the resulting WAV file
 	// 4. Begin generating the image from the analysis data
 	go func() {
+		defer w.removeRunningJob(job)
 		path := w.audioCache.ObtainReferenceToFile(job.ItemID)
 		// wait for file to begin downloading if not already
 		for path == "" {
@@ -179,7 +238,10 @@ func (w *WaveformImageGenerator) StartWaveformGeneration(item *mediaprovider.Tra
 		// Start converting the file to WAV for analysis
 		var wavConvertDone bool
 		go func() {
-			err := w.convertToWav(ctx, job.ItemID, path, transcodeFile)
+			// no need to preserve full sample resolution just for waveform image
+			// let's make less data to process and smaller on-disk file
+			err := convertToWav(ctx, path, transcodeFile, 22050, "stereo")
+			w.audioCache.ReleaseReferenceToFile(job.ItemID)
 			wavConvertDone = true
 			if err != nil {
 				job.setError(err)
@@ -214,25 +276,108 @@ func (w *WaveformImageGenerator) StartWaveformGeneration(item *mediaprovider.Tra
 			close(data.notify)
 		}()
 
-		// Start generating the waveform image
-		go func() {
-			generateWaveformImage(ctx, data, job)
-			job.done = true
-		}()
+		// Generate the waveform image, and persist the analysis
+		// data if the whole track was analyzed successfully
+		generateWaveformImage(ctx, data, job, w.mode)
+		if w.cache != nil && ctx.Err() == nil && job.Err() == nil && data.progress > 0 {
+			if err := w.cache.Put(serverID, item.ID, data); err != nil {
+				log.Printf("failed to cache waveform: %s", err.Error())
+			}
+		}
+		job.data = data
+		job.done = true
 	}()
 	return job
 }
 
+func (w *WaveformImageGenerator) removeRunningJob(job *WaveformImageJob) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.running[job.ItemID] == job {
+		delete(w.running, job.ItemID)
+	}
+}
+
+// GenerateInBackground queues the waveform of the track to be generated
+// and cached once it has been downloaded to the audio cache, if not cached already.
+func (w *WaveformImageGenerator) GenerateInBackground(item *mediaprovider.Track) {
+	if w.cache == nil || w.cache.Has(w.audioCache.s.ServerID.String(), item.ID) {
+		return
+	}
+	w.mutex.Lock()
+	if !slices.ContainsFunc(w.pending, func(t *mediaprovider.Track) bool { return t.ID == item.ID }) {
+		w.pending = append(w.pending, item)
+	}
+	w.mutex.Unlock()
+
+	select {
+	case w.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// generates the waveforms of queued tracks one at a time
+func (w *WaveformImageGenerator) runBackgroundGeneration() {
+	ctx := w.audioCache.rootCtx
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.trigger:
+		}
+		for {
+			w.mutex.Lock()
+			if len(w.pending) == 0 {
+				w.mutex.Unlock()
+				break
+			}
+			item := w.pending[0]
+			w.pending = w.pending[1:]
+			w.mutex.Unlock()
+
+			if w.cache.Has(w.audioCache.s.ServerID.String(), item.ID) {
+				continue
+			}
+			// only generate waveforms of tracks the audio cache is fetching,
+			// otherwise the job would wait for the download indefinitely
+			if w.audioCache.pathForCachedOrDownloadingFile(item.ID, false) == "" {
+				continue
+			}
+
+			job := w.StartWaveformGeneration(item)
+			for !job.Done() {
+				select {
+				case <-ctx.Done():
+					job.Cancel()
+					return
+				case <-time.After(250 * time.Millisecond):
+				}
+			}
+		}
+	}
+}
+
 type waveformData struct {
+	// levels of the signal downmixed to mono
 	Peak [1024]byte
 	RMS  [1024]byte
 
+	// levels of the left and right channels
+	PeakL [1024]byte
+	RMSL  [1024]byte
+	PeakR [1024]byte
+	RMSR  [1024]byte
+
+	// spectral centroid, scaled logarithmically between
+	// the center frequencies of the lowest and highest bands
+	Centroid [1024]byte
+
 	progress int // first invalid index for Peak/RMS data
 	done     bool
 	notify   chan struct{} // signals when new data is available
 }
 
-func generateWaveformImage(ctx context.Context, data *waveformData, job *WaveformImageJob) {
+func generateWaveformImage(ctx context.Context, data *waveformData, job *WaveformImageJob, mode string) {
 	centerY := job.img.Rect.Dy() / 2 // 24
 	top := centerY - 1               // 23
 	bottom := centerY                // 24
@@ -255,29 +400,51 @@ func generateWaveformImage(ctx context.Context, data *waveformData, job *Wavefor
 			return // done but data not available for this x
 		}
 
-		rmsPixels := int(data.RMS[x]) * centerY / 255
-		peakPixels := int(data.Peak[x]) * centerY / 255
+		rmsTop, peakTop := data.RMS[x], data.Peak[x]
+		rmsBottom, peakBottom := rmsTop, peakTop
+		switch mode {
+		case WaveformModeStereo:
+			rmsTop, peakTop = data.RMSL[x], data.PeakL[x]
+			rmsBottom, peakBottom = data.RMSR[x], data.PeakR[x]
+		case WaveformModeFrequency:
+			opaqueColor = centroidColor(data.Centroid[x])
+			translucentColor = opaqueColor
+			translucentColor.A = 128
+		}
 
 		// Always draw at least 2 center pixels
 		setPixel(job.img, x, top, opaqueColor)
 		setPixel(job.img, x, bottom, opaqueColor)
 
-		// Draw RMS pixels (solid)
-		for i := 1; i < rmsPixels; i++ {
-			setPixel(job.img, x, top-i, opaqueColor)
-			setPixel(job.img, x, bottom+i, opaqueColor)
-		}
-
-		// Draw Peak extension (translucent)
-		for i := max(1, rmsPixels); i < peakPixels; i++ {
-			setPixel(job.img, x, top-i, translucentColor)
-			setPixel(job.img, x, bottom+i, translucentColor)
-		}
+		drawWaveformColumn(job.img, x, top, -1, int(rmsTop)*centerY/255, int(peakTop)*centerY/255, opaqueColor, translucentColor)
+		drawWaveformColumn(job.img, x, bottom, 1, int(rmsBottom)*centerY/255, int(peakBottom)*centerY/255, opaqueColor, translucentColor)
 		job.progress = x + 1
 	}
 }
 
-// assumes mono, 16 bit
+// drawWaveformColumn draws the RMS pixels (solid) and the peak extension (translucent)
+// of one half of a column, going from the center line y in direction dir.
+func drawWaveformColumn(img *WaveformImage, x, y, dir, rmsPixels, peakPixels int, opaque, translucent color.NRGBA) {
+	for i := 1; i < rmsPixels; i++ {
+		setPixel(img, x, y+dir*i, opaque)
+	}
+	for i := max(1, rmsPixels); i < peakPixels; i++ {
+		setPixel(img, x, y+dir*i, translucent)
+	}
+}
+
+// centroidColor maps a spectral centroid value to a fully saturated hue,
+// from red for bass-heavy sections through green to blue for treble.
+func centroidColor(centroid byte) color.NRGBA {
+	hue := float64(centroid) / 255 * 240
+	f := func(n float64) byte {
+		k := math.Mod(n+hue/60, 6)
+		return float64ToByte(1 - math.Max(0, math.Min(1, math.Min(k, 4-k))))
+	}
+	return color.NRGBA{R: f(5), G: f(3), B: f(1), A: 255}
+}
+
+// assumes 16 bit, mono or stereo
 func analyzeWavFile(ctx context.Context, transcodeFile string, data *waveformData, millisecs int64, fileDone func() bool) error {
 	f, err := os.Open(transcodeFile)
 	if err != nil {
@@ -304,9 +471,10 @@ func analyzeWavFile(ctx context.Context, transcodeFile string, data *waveformDat
 	buf := audioBufferPool.Get().(*audio.IntBuffer)
 	defer audioBufferPool.Put(buf)
 
+	channels := format.NumChannels
 	curChunk := 0
-	chunkSamples := make([]float64, 0, samplesPerChunk)
-	bytesPerSample := int64(2 * format.NumChannels) // 16-bit = 2 bytes per channel
+	chunk := newWaveformChunk(float64(format.SampleRate))
+	bytesPerSample := int64(2 * channels) // 16-bit = 2 bytes per channel
 
 	// file read loop
 	doneReading := false
@@ -341,11 +509,11 @@ func analyzeWavFile(ctx context.Context, transcodeFile string, data *waveformDat
 			}
 
 			// Resize buffer to fit only what’s safe
-			safeSamples := min(maxSamples, cap(buf.Data))
-			buf.Data = buf.Data[:safeSamples]
+			safeSamples := min(maxSamples*channels, cap(buf.Data))
+			buf.Data = buf.Data[:safeSamples-safeSamples%channels]
 		} else {
 			// File is done being written, resize read buf to the max
-			buf.Data = buf.Data[:cap(buf.Data)]
+			buf.Data = buf.Data[:cap(buf.Data)-cap(buf.Data)%channels]
 		}
 
 		n, err := decoder.PCMBuffer(buf)
@@ -362,15 +530,15 @@ func analyzeWavFile(ctx context.Context, transcodeFile string, data *waveformDat
 		}
 
 		// Process samples
-		for i := range n {
-			sample := float64(buf.Data[i]) / float64(1<<15) // Normalize to [-1, 1]
-			chunkSamples = append(chunkSamples, sample)
+		for i := 0; i+channels <= n; i += channels {
+			// Normalize to [-1, 1]
+			l := float64(buf.Data[i]) / float64(1<<15)
+			r := float64(buf.Data[i+channels-1]) / float64(1<<15)
+			chunk.add(l, r)
 
-			if len(chunkSamples) >= samplesPerChunk {
+			if chunk.n >= samplesPerChunk {
 				if curChunk < 1024 {
-					peak, rms := computePeakAndRMS(chunkSamples)
-					data.Peak[curChunk] = float64ToByte(peak)
-					data.RMS[curChunk] = float64ToByte(rms)
+					chunk.store(data, curChunk)
 				}
 				curChunk++
 				data.progress = curChunk
@@ -379,7 +547,7 @@ func analyzeWavFile(ctx context.Context, transcodeFile string, data *waveformDat
 				case data.notify <- struct{}{}:
 				default:
 				}
-				chunkSamples = chunkSamples[:0]
+				chunk.reset()
 				if curChunk >= 1024 {
 					break
 				}
@@ -388,10 +556,8 @@ func analyzeWavFile(ctx context.Context, transcodeFile string, data *waveformDat
 	}
 
 	// analyze the last chunk if it's partially filled with samples
-	if curChunk < 1024 && len(chunkSamples) > 0 {
-		peak, rms := computePeakAndRMS(chunkSamples)
-		data.Peak[curChunk] = float64ToByte(peak)
-		data.RMS[curChunk] = float64ToByte(rms)
+	if curChunk < 1024 && chunk.n > 0 {
+		chunk.store(data, curChunk)
 		data.progress = curChunk + 1
 		// Notify that final data is available (non-blocking)
 		select {
@@ -403,6 +569,76 @@ func analyzeWavFile(ctx context.Context, transcodeFile string, data *waveformDat
 	return nil
 }
 
+// center frequencies of the low, mid and high bands
+// the spectral centroid is computed from
+var waveformBandFreqs = [3]float64{100, 800, 5000}
+
+// waveformChunk accumulates the levels and band energies
+// of the samples making up one column of the waveform.
+type waveformChunk struct {
+	n                     int
+	peak, peakL, peakR    float64
+	sumSq, sumSqL, sumSqR float64
+	bandEnergy            [3]float64
+
+	// one-pole lowpass filters splitting the bands at 250 Hz and 2.5 kHz.
+	// the filter state is kept across chunks
+	lowCoef, midCoef float64
+	low, mid         float64
+}
+
+func newWaveformChunk(sampleRate float64) *waveformChunk {
+	return &waveformChunk{
+		lowCoef: 1 - math.Exp(-2*math.Pi*250/sampleRate),
+		midCoef: 1 - math.Exp(-2*math.Pi*2500/sampleRate),
+	}
+}
+
+func (c *waveformChunk) add(l, r float64) {
+	m := (l + r) / 2
+	c.n++
+	c.peak = math.Max(c.peak, math.Abs(m))
+	c.peakL = math.Max(c.peakL, math.Abs(l))
+	c.peakR = math.Max(c.peakR, math.Abs(r))
+	c.sumSq += m * m
+	c.sumSqL += l * l
+	c.sumSqR += r * r
+
+	c.low += c.lowCoef * (m - c.low)
+	c.mid += c.midCoef * (m - c.mid)
+	bands := [3]float64{c.low, c.mid - c.low, m - c.mid}
+	for i, b := range bands {
+		c.bandEnergy[i] += b * b
+	}
+}
+
+func (c *waveformChunk) store(data *waveformData, x int) {
+	n := float64(c.n)
+	data.Peak[x] = float64ToByte(c.peak)
+	data.RMS[x] = float64ToByte(math.Sqrt(c.sumSq / n))
+	data.PeakL[x] = float64ToByte(c.peakL)
+	data.RMSL[x] = float64ToByte(math.Sqrt(c.sumSqL / n))
+	data.PeakR[x] = float64ToByte(c.peakR)
+	data.RMSR[x] = float64ToByte(math.Sqrt(c.sumSqR / n))
+
+	var weighted, total float64
+	for i, e := range c.bandEnergy {
+		weighted += e * waveformBandFreqs[i]
+		total += e
+	}
+	if total > 0 {
+		lo, hi := math.Log(waveformBandFreqs[0]), math.Log(waveformBandFreqs[2])
+		data.Centroid[x] = float64ToByte((math.Log(weighted/total) - lo) / (hi - lo))
+	}
+}
+
+func (c *waveformChunk) reset() {
+	c.n = 0
+	c.peak, c.peakL, c.peakR = 0, 0, 0
+	c.sumSq, c.sumSqL, c.sumSqR = 0, 0, 0
+	c.bandEnergy = [3]float64{}
+}
+
 func (j *WaveformImageJob) setError(err error) {
 	j.lock.Lock()
 	defer j.lock.Unlock()
@@ -410,21 +646,6 @@ func (j *WaveformImageJob) setError(err error) {
 	j.err = err
 }
 
-func computePeakAndRMS(chunk []float64) (peak float64, rms float64) {
-	var sumSquares float64
-	peak = 0.0
-	for _, v := range chunk {
-		if v > peak {
-			peak = v
-		} else if v < -peak {
-			peak = -v
-		}
-		sumSquares += float64(v * v)
-	}
-	rms = math.Sqrt(sumSquares / float64(len(chunk)))
-	return peak, rms
-}
-
 func float64ToByte(val float64) byte {
 	if val > 1.0 {
 		val = 1.0
@@ -435,7 +656,9 @@ func float64ToByte(val float64) byte {
 	return byte(val * 255)
 }
 
-func (w *WaveformImageGenerator) convertToWav(ctx context.Context, id, inPath, outPath string) error {
+// convertToWav decodes the audio file at inPath to a 16 bit WAV file with the
+// given sample rate and channel layout (e.g. "mono" or "stereo"), using mpv.
+func convertToWav(ctx context.Context, inPath, outPath string, sampleRate int, channels string) error {
 	m := mpv.Create()
 	m.SetOptionString("video", "no")
 	m.SetOptionString("audio-display", "no")
@@ -444,10 +667,8 @@ func (w *WaveformImageGenerator) convertToWav(ctx context.Context, id, inPath, o
 	m.SetOptionString("ao-pcm-file", outPath)
 	m.SetOptionString("ao", "pcm")
 	m.SetOption("volume", mpv.FORMAT_INT64, 100)
-	// no need to preserve full sample resolution just for waveform image
-	// let's make less data to process and smaller on-disk file
-	m.SetOption("audio-samplerate", mpv.FORMAT_INT64, 22050)
-	m.SetOptionString("audio-channels", "mono")
+	m.SetOption("audio-samplerate", mpv.FORMAT_INT64, sampleRate)
+	m.SetOptionString("audio-channels", channels)
 	m.SetOptionString("audio-format", "s16")
 	if err := m.Initialize(); err != nil {
 		return err
@@ -456,7 +677,6 @@ func (w *WaveformImageGenerator) convertToWav(ctx context.Context, id, inPath, o
 	defer m.TerminateDestroy()
 
 	m.Command([]string{"loadfile", inPath, "replace"})
-	defer w.audioCache.ReleaseReferenceToFile(id)
 
 	// Wait for MPV idle or ctx expiry
 	for {
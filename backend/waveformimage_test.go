@@ -0,0 +1,40 @@
+package backend
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWaveformChunk(t *testing.T) {
+	const sampleRate = 22050
+	analyze := func(freq, ampL, ampR float64) *waveformData {
+		data := &waveformData{}
+		c := newWaveformChunk(sampleRate)
+		for i := range sampleRate {
+			s := math.Sin(2 * math.Pi * freq * float64(i) / sampleRate)
+			c.add(ampL*s, ampR*s)
+		}
+		c.store(data, 0)
+		return data
+	}
+
+	bass := analyze(60, 0.5, 0.5)
+	treble := analyze(8000, 0.5, 0.5)
+	if bass.Centroid[0] > 64 {
+		t.Errorf("expected low centroid for bass, got %d", bass.Centroid[0])
+	}
+	if treble.Centroid[0] < 192 {
+		t.Errorf("expected high centroid for treble, got %d", treble.Centroid[0])
+	}
+
+	stereo := analyze(440, 1, 0.25)
+	if stereo.PeakL[0] != 254 && stereo.PeakL[0] != 255 {
+		t.Errorf("expected full scale left peak, got %d", stereo.PeakL[0])
+	}
+	if stereo.PeakR[0] < 62 || stereo.PeakR[0] > 64 {
+		t.Errorf("expected quarter scale right peak, got %d", stereo.PeakR[0])
+	}
+	if stereo.RMSL[0] <= stereo.RMSR[0] || stereo.RMS[0] <= stereo.RMSR[0] || stereo.RMS[0] >= stereo.RMSL[0] {
+		t.Error("expected mono RMS between right and left channel RMS")
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/dweymouth/supersonic/backend/windows"
 	"github.com/dweymouth/supersonic/res"
 	"github.com/dweymouth/supersonic/res/wintaskbarthumbs"
+	"github.com/dweymouth/supersonic/tui"
 	"github.com/dweymouth/supersonic/ui"
 	"github.com/dweymouth/supersonic/ui/controller"
 	myTheme "github.com/dweymouth/supersonic/ui/theme"
@@ -57,6 +58,16 @@ func main() {
 		return
 	}
 
+	if *backend.FlagHeadless {
+		err := myApp.RunHeadless()
+		log.Println("Running shutdown tasks...")
+		myApp.Shutdown()
+		if err != nil {
+			log.Fatalf("fatal headless startup error: %v", err.Error())
+		}
+		return
+	}
+
 	if myApp.Config.Application.UIScaleSize == "Smaller" {
 		os.Setenv("FYNE_SCALE", "0.85")
 	} else if myApp.Config.Application.UIScaleSize == "Larger" {
@@ -91,6 +102,16 @@ func main() {
 		}
 	}
 
+	if *backend.FlagTUI {
+		err := tui.Run(myApp)
+		log.Println("Running shutdown tasks...")
+		myApp.Shutdown()
+		if err != nil {
+			log.Fatalf("fatal terminal UI error: %v", err.Error())
+		}
+		return
+	}
+
 	if runtime.GOOS == "windows" {
 		if err := initWindowsTaskbarIcons(); err != nil {
 			log.Printf("Error initializing taskbar thumbnail icons: %s", err.Error())
@@ -107,12 +128,21 @@ func main() {
 
 	fyneApp := app.New()
 	fyneApp.SetIcon(res.ResAppicon256Png)
+	myApp.SetMainThreadRunner(fyne.Do)
 
 	mainWindow := ui.NewMainWindow(fyneApp, res.AppName, res.DisplayName, res.AppVersion, myApp)
 	mainWindow.Window.SetMaster()
 	myApp.OnReactivate = util.FyneDoFunc(mainWindow.Show)
 	myApp.OnExit = util.FyneDoFunc(mainWindow.Quit)
 	myApp.OnReloadTheme = util.FyneDoFunc(mainWindow.ReloadTheme)
+	myApp.OnOpenDeepLink = func(l *backend.DeepLink) {
+		fyne.Do(func() { mainWindow.Controller.OpenDeepLink(l) })
+	}
+	registerURLHandler(func(link string) {
+		if err := myApp.OpenDeepLink(link); err != nil {
+			log.Printf("error opening link: %s", err.Error())
+		}
+	})
 
 	if runtime.GOOS == "windows" {
 		windowStartupTasks := sync.OnceFunc(func() {
@@ -139,6 +169,12 @@ func main() {
 				controller.SetWindowThemeMode(mainWindow.Window, mode)
 			}
 			defaultServer := myApp.ServerManager.GetDefaultServer()
+			if l := myApp.PendingDeepLink; l != nil {
+				// connect to the server of the link the app was opened with
+				if s := myApp.FindDeepLinkServer(l); s != nil {
+					defaultServer = s
+				}
+			}
 			if defaultServer == nil {
 				mainWindow.Controller.PromptForFirstServer()
 			} else if !*backend.FlagStartMinimized { // If the minimized start flag was passed, the connection is already established.
@@ -10,6 +10,9 @@ const (
 	LatestReleaseURL = GithubURL + "/releases/latest"
 	KofiURL          = "https://ko-fi.com/dweymouth"
 	Copyright        = "Copyright © 2022–2026 Drew Weymouth and contributors"
+
+	// Discord application shown as the activity name by the Discord Rich Presence integration
+	DiscordApplicationID = ""
 )
 
 var (
@@ -0,0 +1,163 @@
+package tui
+
+import (
+	"fyne.io/fyne/v2/lang"
+)
+
+// view is a page of the terminal UI
+type view interface {
+	title() string
+	// render returns exactly height lines of the given width
+	render(width, height int) []string
+	// handleKey returns true if the key was consumed
+	handleKey(k key) bool
+}
+
+type listItem struct {
+	text  string
+	right string
+
+	// called when the item is selected with Enter
+	onSelect func()
+	// called with 'a' (next == false) or 'A' (next == true)
+	onEnqueue func(next bool)
+}
+
+// listView is a scrollable list of items, which can be loaded
+// incrementally from an iterator as the user scrolls.
+type listView struct {
+	name     string
+	items    []listItem
+	selected int
+	offset   int
+	loading  bool
+	// if set, called to load more items when scrolling near the end
+	loadMore func(onLoaded func(items []listItem, done bool))
+	// if set, called with the selected index on 'd'
+	onDelete func(idx int)
+	// the index of an item to highlight, or -1
+	highlight int
+}
+
+func newListView(name string, items []listItem) *listView {
+	return &listView{name: name, items: items, highlight: -1}
+}
+
+// newIteratorListView creates a listView which loads items from an iterator's
+// next function in the background, posting updates with post.
+func newIteratorListView[T any](name string, post func(func()), next func() *T, toItem func(*T) listItem) *listView {
+	l := newListView(name, nil)
+	l.loadMore = func(onLoaded func([]listItem, bool)) {
+		go func() {
+			var items []listItem
+			done := false
+			for len(items) < iteratorPageSize {
+				t := next()
+				if t == nil {
+					done = true
+					break
+				}
+				items = append(items, toItem(t))
+			}
+			post(func() { onLoaded(items, done) })
+		}()
+	}
+	l.maybeLoadMore(0)
+	return l
+}
+
+const iteratorPageSize = 100
+
+func (l *listView) title() string { return l.name }
+
+func (l *listView) setItems(items []listItem) {
+	l.items = items
+	l.selected = min(l.selected, max(len(items)-1, 0))
+}
+
+func (l *listView) maybeLoadMore(height int) {
+	if l.loadMore == nil || l.loading || l.offset+2*height < len(l.items) {
+		return
+	}
+	l.loading = true
+	l.loadMore(func(items []listItem, done bool) {
+		l.items = append(l.items, items...)
+		l.loading = false
+		if done {
+			l.loadMore = nil
+		}
+	})
+}
+
+func (l *listView) render(width, height int) []string {
+	l.maybeLoadMore(height)
+	if l.selected < l.offset {
+		l.offset = l.selected
+	} else if l.selected >= l.offset+height {
+		l.offset = l.selected - height + 1
+	}
+	lines := make([]string, height)
+	for i := range lines {
+		idx := l.offset + i
+		switch {
+		case idx < len(l.items):
+			item := l.items[idx]
+			prefix := "  "
+			if idx == l.highlight {
+				prefix = "▶ "
+			}
+			line := columns(prefix+item.text, item.right, width)
+			if idx == l.selected {
+				line = styleReverse + line + styleReset
+			} else if idx == l.highlight {
+				line = styleBold + line + styleReset
+			}
+			lines[i] = line
+		case idx == len(l.items) && (l.loading || l.loadMore != nil):
+			lines[i] = styleDim + "  " + lang.L("Loading") + "…" + styleReset
+		case idx == 0 && len(l.items) == 0:
+			lines[i] = styleDim + "  " + lang.L("No items") + styleReset
+		}
+	}
+	return lines
+}
+
+func (l *listView) handleKey(k key) bool {
+	page := 10
+	switch {
+	case k.code == keyUp || k == runeKey('k'):
+		l.selected = max(l.selected-1, 0)
+	case k.code == keyDown || k == runeKey('j'):
+		l.selected = min(l.selected+1, max(len(l.items)-1, 0))
+	case k.code == keyPgUp:
+		l.selected = max(l.selected-page, 0)
+	case k.code == keyPgDown:
+		l.selected = min(l.selected+page, max(len(l.items)-1, 0))
+	case k.code == keyHome || k == runeKey('g'):
+		l.selected = 0
+	case k.code == keyEnd || k == runeKey('G'):
+		l.selected = max(len(l.items)-1, 0)
+	case k.code == keyEnter || k == runeKey('l'):
+		if item := l.selectedItem(); item != nil && item.onSelect != nil {
+			item.onSelect()
+		}
+	case k == runeKey('a') || k == runeKey('A'):
+		if item := l.selectedItem(); item != nil && item.onEnqueue != nil {
+			item.onEnqueue(k.r == 'A')
+		}
+	case k == runeKey('d'):
+		if l.onDelete != nil && l.selectedItem() != nil {
+			l.onDelete(l.selected)
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+func (l *listView) selectedItem() *listItem {
+	if l.selected < len(l.items) {
+		return &l.items[l.selected]
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package tui
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2/lang"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/ui/util"
+	"github.com/mattn/go-runewidth"
+)
+
+// nowPlayingView shows the current track's metadata and playback
+// progress, and its lyrics, following along if they are synced.
+type nowPlayingView struct {
+	u      *ui
+	item   mediaprovider.MediaItem
+	lyrics *mediaprovider.Lyrics
+	// set while lyrics for the current track are being fetched
+	fetchingLyrics bool
+	// scroll offset for unsynced lyrics
+	scroll int
+}
+
+func newNowPlayingView(u *ui) *nowPlayingView {
+	return &nowPlayingView{u: u}
+}
+
+func (n *nowPlayingView) title() string { return lang.L("Now Playing") }
+
+func (n *nowPlayingView) setItem(item mediaprovider.MediaItem) {
+	n.item = item
+	n.lyrics = nil
+	n.scroll = 0
+	tr, ok := item.(*mediaprovider.Track)
+	if !ok || tr == nil {
+		n.fetchingLyrics = false
+		return
+	}
+	n.fetchingLyrics = true
+	n.u.app.LyricsManager.FetchLyricsAsync(tr, func(id string, lyrics *mediaprovider.Lyrics) {
+		n.u.post(func() {
+			if n.item == nil || n.item.Metadata().ID != id {
+				return // track changed since
+			}
+			n.fetchingLyrics = false
+			n.lyrics = lyrics
+		})
+	})
+}
+
+func (n *nowPlayingView) handleKey(k key) bool {
+	switch {
+	case k.code == keyUp || k == runeKey('k'):
+		n.scroll = max(n.scroll-1, 0)
+	case k.code == keyDown || k == runeKey('j'):
+		n.scroll++
+	default:
+		return false
+	}
+	return true
+}
+
+func (n *nowPlayingView) render(width, height int) []string {
+	lines := make([]string, 0, height)
+	if n.item == nil {
+		lines = append(lines, "", styleDim+"  "+lang.L("Nothing playing")+styleReset)
+		return padLines(lines, height)
+	}
+
+	meta := n.item.Metadata()
+	status := n.u.pm.PlaybackStatus()
+	lines = append(lines,
+		"",
+		styleBold+fit("  "+meta.Name, width)+styleReset,
+		fit("  "+strings.Join(meta.Artists, ", "), width),
+		styleDim+fit("  "+meta.Album, width)+styleReset,
+		"",
+		"  "+progressBar(status.TimePos, status.Duration, width-4),
+		"",
+		styleBold+"  "+lang.L("Lyrics")+styleReset,
+	)
+
+	lyricsHeight := height - len(lines)
+	switch {
+	case n.fetchingLyrics:
+		lines = append(lines, styleDim+"  "+lang.L("Loading")+"…"+styleReset)
+	case n.lyrics == nil || len(n.lyrics.Lines) == 0:
+		lines = append(lines, styleDim+"  "+lang.L("Lyrics not available")+styleReset)
+	default:
+		lines = append(lines, n.renderLyrics(status.TimePos, width, lyricsHeight)...)
+	}
+	return padLines(lines, height)
+}
+
+// renderLyrics shows synced lyrics with the current line highlighted and
+// centered, or unsynced lyrics from the user's scroll position
+func (n *nowPlayingView) renderLyrics(timePos float64, width, height int) []string {
+	if height <= 0 {
+		return nil
+	}
+	current := -1
+	start := min(n.scroll, max(len(n.lyrics.Lines)-height, 0))
+	if n.lyrics.Synced {
+		for i, l := range n.lyrics.Lines {
+			if l.Start > timePos {
+				break
+			}
+			current = i
+		}
+		start = max(current-height/2, 0)
+	}
+	n.scroll = start
+
+	end := min(start+height, len(n.lyrics.Lines))
+	lines := make([]string, 0, end-start)
+	for i := start; i < end; i++ {
+		line := fit("  "+n.lyrics.Lines[i].Text, width)
+		if i == current {
+			line = styleBold + line + styleReset
+		} else if n.lyrics.Synced {
+			line = styleDim + line + styleReset
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func progressBar(pos, dur float64, width int) string {
+	timeText := " " + util.SecondsToMMSS(pos) + " / " + util.SecondsToMMSS(dur)
+	barWidth := width - runewidth.StringWidth(timeText)
+	if barWidth <= 0 {
+		return timeText
+	}
+	filled := 0
+	if dur > 0 {
+		filled = min(int(pos/dur*float64(barWidth)), barWidth)
+	}
+	return strings.Repeat("━", filled) + styleDim + strings.Repeat("─", barWidth-filled) + styleReset + timeText
+}
+
+func padLines(lines []string, height int) []string {
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+	return lines[:height]
+}
@@ -0,0 +1,186 @@
+package tui
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+	"golang.org/x/term"
+)
+
+type keyCode int
+
+const (
+	keyRune keyCode = iota
+	keyEnter
+	keyEsc
+	keyBackspace
+	keyTab
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyPgUp
+	keyPgDown
+	keyHome
+	keyEnd
+	keyCtrlC
+)
+
+type key struct {
+	code keyCode
+	r    rune // set for keyRune
+}
+
+func runeKey(r rune) key { return key{code: keyRune, r: r} }
+
+// terminal puts the terminal in raw mode on the alternate screen, and
+// decodes key presses from standard input.
+type terminal struct {
+	in       *os.File
+	out      *bufio.Writer
+	oldState *term.State
+}
+
+func openTerminal() (*terminal, error) {
+	t := &terminal{in: os.Stdin, out: bufio.NewWriter(os.Stdout)}
+	state, err := term.MakeRaw(int(t.in.Fd()))
+	if err != nil {
+		return nil, err
+	}
+	t.oldState = state
+	// alternate screen, hide cursor
+	t.out.WriteString("\x1b[?1049h\x1b[?25l")
+	t.out.Flush()
+	return t, nil
+}
+
+func (t *terminal) close() {
+	t.out.WriteString("\x1b[?25h\x1b[?1049l")
+	t.out.Flush()
+	term.Restore(int(t.in.Fd()), t.oldState)
+}
+
+func (t *terminal) size() (int, int) {
+	w, h, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || w <= 0 || h <= 0 {
+		return 80, 24
+	}
+	return w, h
+}
+
+// draw replaces the screen contents with the given lines
+func (t *terminal) draw(lines []string) {
+	t.out.WriteString("\x1b[H")
+	for i, line := range lines {
+		if i > 0 {
+			t.out.WriteString("\r\n")
+		}
+		t.out.WriteString(line)
+		t.out.WriteString("\x1b[0m\x1b[K")
+	}
+	t.out.WriteString("\x1b[J")
+	t.out.Flush()
+}
+
+// readKeys sends decoded key presses on the returned channel until stdin is closed.
+func (t *terminal) readKeys() <-chan key {
+	keys := make(chan key)
+	go func() {
+		defer close(keys)
+		buf := make([]byte, 256)
+		for {
+			n, err := t.in.Read(buf)
+			if err != nil {
+				return
+			}
+			for _, k := range decodeKeys(buf[:n]) {
+				keys <- k
+			}
+		}
+	}()
+	return keys
+}
+
+var escapeSequences = map[string]keyCode{
+	"[A": keyUp, "[B": keyDown, "[C": keyRight, "[D": keyLeft,
+	"OA": keyUp, "OB": keyDown, "OC": keyRight, "OD": keyLeft,
+	"[5~": keyPgUp, "[6~": keyPgDown,
+	"[H": keyHome, "[F": keyEnd, "OH": keyHome, "OF": keyEnd,
+	"[1~": keyHome, "[4~": keyEnd,
+}
+
+func decodeKeys(b []byte) []key {
+	var keys []key
+	for len(b) > 0 {
+		switch c := b[0]; {
+		case c == 0x1b:
+			if len(b) == 1 {
+				keys = append(keys, key{code: keyEsc})
+				return keys
+			}
+			matched := false
+			for seq, code := range escapeSequences {
+				if strings.HasPrefix(string(b[1:]), seq) {
+					keys = append(keys, key{code: code})
+					b = b[1+len(seq):]
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				// unknown sequence - treat as escape and drop the rest
+				keys = append(keys, key{code: keyEsc})
+				return keys
+			}
+		case c == '\r' || c == '\n':
+			keys = append(keys, key{code: keyEnter})
+			b = b[1:]
+		case c == 0x7f || c == 0x08:
+			keys = append(keys, key{code: keyBackspace})
+			b = b[1:]
+		case c == '\t':
+			keys = append(keys, key{code: keyTab})
+			b = b[1:]
+		case c == 0x03:
+			keys = append(keys, key{code: keyCtrlC})
+			b = b[1:]
+		case c < 0x20:
+			b = b[1:]
+		default:
+			r, size := utf8.DecodeRune(b)
+			keys = append(keys, runeKey(r))
+			b = b[size:]
+		}
+	}
+	return keys
+}
+
+// fit truncates or pads s to exactly width terminal cells
+func fit(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if runewidth.StringWidth(s) > width {
+		return runewidth.Truncate(s, width, "…")
+	}
+	return runewidth.FillRight(s, width)
+}
+
+// columns lays out left and right aligned text within width cells
+func columns(left, right string, width int) string {
+	rw := runewidth.StringWidth(right)
+	if rw >= width {
+		return fit(left, width)
+	}
+	return fit(left, width-rw-1) + " " + right
+}
+
+const (
+	styleReverse = "\x1b[7m"
+	styleBold    = "\x1b[1m"
+	styleDim     = "\x1b[2m"
+	styleReset   = "\x1b[0m"
+)
@@ -0,0 +1,328 @@
+// Package tui implements a terminal user interface for Supersonic,
+// as an alternative frontend to the Fyne UI for use over SSH or in a console.
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"fyne.io/fyne/v2/lang"
+	"github.com/dweymouth/supersonic/backend"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/player"
+	"github.com/dweymouth/supersonic/sharedutil"
+	"github.com/dweymouth/supersonic/ui/util"
+	"golang.org/x/term"
+)
+
+// how often to redraw the screen to update the playback position
+const refreshInterval = 250 * time.Millisecond
+
+// how long transient status messages are shown
+const messageDuration = 4 * time.Second
+
+// a tab holds a stack of views, the last of which is shown
+type tab struct {
+	name  string
+	stack []view
+	// creates the root view the first time the tab is shown
+	makeRoot func() view
+}
+
+func (t *tab) current() view {
+	if len(t.stack) == 0 {
+		t.stack = []view{t.makeRoot()}
+	}
+	return t.stack[len(t.stack)-1]
+}
+
+type ui struct {
+	app    *backend.App
+	pm     *backend.PlaybackManager
+	term   *terminal
+	ctx    context.Context
+	posted chan func()
+	quit   bool
+
+	tabs       []*tab
+	currentTab int
+	nowPlaying *nowPlayingView
+	queue      *listView
+
+	message     string
+	messageTime time.Time
+}
+
+// Run connects to the configured server and runs the terminal UI until
+// the user quits, the app is asked to quit over IPC, or SIGTERM is received.
+// The caller should call Shutdown on the app after it returns.
+func Run(app *backend.App) error {
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return errors.New("the terminal UI must be run in a terminal")
+	}
+
+	serverCfg, err := app.ConfiguredServer()
+	if err != nil {
+		return err
+	}
+	pass, err := app.StoredServerPassword(serverCfg)
+	if err != nil {
+		fmt.Printf("%s (%s@%s): ", lang.L("Password"), serverCfg.Username, serverCfg.Hostname)
+		p, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Println()
+		if err != nil {
+			return err
+		}
+		pass = string(p)
+	}
+	app.ServerManager.OnServerConnected(app.RestoreServerSession)
+	fmt.Printf("Connecting to %s...\n", serverCfg.Nickname)
+	if err := app.ServerManager.ConnectToServer(serverCfg, pass); err != nil {
+		return fmt.Errorf("error connecting to server %q: %v", serverCfg.Nickname, err)
+	}
+
+	ctx, stop := signal.NotifyContext(app.BackgroundContext(), syscall.SIGTERM, syscall.SIGHUP)
+	defer stop()
+
+	t, err := openTerminal()
+	if err != nil {
+		return err
+	}
+	defer t.close()
+
+	u := &ui{
+		app:    app,
+		pm:     app.PlaybackManager,
+		term:   t,
+		ctx:    ctx,
+		posted: make(chan func(), 16),
+	}
+	app.OnExit = func() { u.post(func() { u.quit = true }) }
+	app.OnReactivate = func() {}
+	app.OnReloadTheme = func() {}
+	u.buildTabs()
+	u.registerCallbacks()
+	u.loop()
+	return nil
+}
+
+// post runs f on the UI goroutine. Safe to call from any goroutine.
+func (u *ui) post(f func()) {
+	select {
+	case u.posted <- f:
+	case <-u.ctx.Done():
+	}
+}
+
+func (u *ui) showMessage(msg string) {
+	u.message = msg
+	u.messageTime = time.Now()
+}
+
+func (u *ui) showError(err error) {
+	u.showMessage("Error: " + err.Error())
+}
+
+func (u *ui) loop() {
+	keys := u.term.readKeys()
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+
+	u.render()
+	for !u.quit {
+		select {
+		case <-u.ctx.Done():
+			return
+		case k, ok := <-keys:
+			if !ok {
+				return
+			}
+			u.handleKey(k)
+		case f := <-u.posted:
+			f()
+		case <-ticker.C:
+		}
+		u.render()
+	}
+}
+
+func (u *ui) buildTabs() {
+	u.nowPlaying = newNowPlayingView(u)
+	u.tabs = []*tab{
+		{name: lang.L("Albums"), makeRoot: u.albumsView},
+		{name: lang.L("Artists"), makeRoot: u.artistsView},
+		{name: lang.L("Playlists"), makeRoot: u.playlistsView},
+		{name: lang.L("Search"), makeRoot: func() view { return newSearchView(u) }},
+		{name: lang.L("Play Queue"), makeRoot: func() view { return u.queue }},
+		{name: lang.L("Now Playing"), makeRoot: func() view { return u.nowPlaying }},
+	}
+	u.queue = newListView(lang.L("Play Queue"), nil)
+	u.queue.onDelete = func(idx int) { u.pm.RemoveTracksFromQueue([]int{idx}) }
+	u.refreshQueue()
+}
+
+func (u *ui) registerCallbacks() {
+	u.pm.OnQueueChange(func() { u.post(u.refreshQueue) })
+	u.pm.OnSongChange(func(item mediaprovider.MediaItem, _ *mediaprovider.Track) {
+		u.post(func() {
+			u.refreshQueue()
+			u.nowPlaying.setItem(item)
+		})
+	})
+	u.nowPlaying.setItem(u.pm.NowPlaying())
+}
+
+func (u *ui) refreshQueue() {
+	queue := u.pm.GetActivePlayQueue()
+	items := make([]listItem, len(queue))
+	for i, item := range queue {
+		meta := item.Metadata()
+		idx := i
+		items[i] = listItem{
+			text:     fmt.Sprintf("%3d. %s – %s", i+1, meta.Name, strings.Join(meta.Artists, ", ")),
+			right:    util.SecondsToMMSS(meta.Duration.Seconds()),
+			onSelect: func() { u.pm.PlayTrackAt(idx) },
+		}
+	}
+	u.queue.setItems(items)
+	u.queue.highlight = u.pm.NowPlayingIndex()
+}
+
+// push shows v on top of the current tab's view stack
+func (u *ui) push(v view) {
+	t := u.tabs[u.currentTab]
+	t.current()
+	t.stack = append(t.stack, v)
+}
+
+func (u *ui) back() {
+	if t := u.tabs[u.currentTab]; len(t.stack) > 1 {
+		t.stack = t.stack[:len(t.stack)-1]
+	}
+}
+
+func (u *ui) showTab(i int) {
+	u.currentTab = (i + len(u.tabs)) % len(u.tabs)
+}
+
+func (u *ui) handleKey(k key) {
+	if k.code == keyCtrlC {
+		u.quit = true
+		return
+	}
+	if u.tabs[u.currentTab].current().handleKey(k) {
+		return
+	}
+
+	switch k.code {
+	case keyTab:
+		u.showTab(u.currentTab + 1)
+	case keyEsc, keyBackspace:
+		u.back()
+	case keyLeft:
+		u.pm.SeekBySeconds(-10)
+	case keyRight:
+		u.pm.SeekBySeconds(10)
+	case keyRune:
+		switch r := k.r; {
+		case r >= '1' && r <= '0'+rune(len(u.tabs)):
+			u.showTab(int(r - '1'))
+		case r == 'h':
+			u.back()
+		case r == 'q':
+			u.quit = true
+		case r == ' ':
+			u.pm.PlayPause()
+		case r == 'n' || r == '>':
+			u.pm.SeekNext()
+		case r == 'p' || r == '<':
+			u.pm.SeekBackOrPrevious()
+		case r == '+' || r == '=':
+			u.pm.SetVolume(min(u.pm.Volume()+5, 100))
+		case r == '-':
+			u.pm.SetVolume(max(u.pm.Volume()-5, 0))
+		case r == 's':
+			u.pm.SetShuffle(!u.pm.IsShuffle())
+		case r == 'r':
+			u.pm.SetNextLoopMode()
+		case r == '/':
+			u.showTab(3)
+			if sv, ok := u.tabs[3].current().(*searchView); ok {
+				sv.startEditing()
+			}
+		}
+	}
+}
+
+func (u *ui) render() {
+	width, height := u.term.size()
+	lines := make([]string, 0, height)
+
+	// tab bar
+	var sb strings.Builder
+	for i, t := range u.tabs {
+		label := fmt.Sprintf(" %d %s ", i+1, t.name)
+		if i == u.currentTab {
+			label = styleReverse + label + styleReset
+		}
+		sb.WriteString(label)
+	}
+	lines = append(lines, sb.String())
+
+	// breadcrumb of the current tab's view stack
+	t := u.tabs[u.currentTab]
+	t.current()
+	titles := sharedutil.MapSlice(t.stack, func(v view) string { return v.title() })
+	lines = append(lines, styleBold+fit(" "+strings.Join(titles, " › "), width)+styleReset)
+
+	bodyHeight := max(height-4, 0)
+	lines = append(lines, t.current().render(width, bodyHeight)...)
+	lines = append(lines, styleReverse+u.statusLine(width)+styleReset)
+	lines = append(lines, u.helpLine(width))
+	u.term.draw(lines)
+}
+
+func (u *ui) statusLine(width int) string {
+	status := u.pm.PlaybackStatus()
+	icon := "■"
+	switch status.State {
+	case player.Playing:
+		icon = "▶"
+	case player.Paused:
+		icon = "⏸"
+	}
+	left := " " + icon + " " + lang.L("Nothing playing")
+	if item := u.pm.NowPlaying(); item != nil && status.State != player.Stopped {
+		meta := item.Metadata()
+		left = fmt.Sprintf(" %s %s – %s", icon, meta.Name, strings.Join(meta.Artists, ", "))
+	}
+
+	right := fmt.Sprintf("%s / %s  %s %d%%",
+		util.SecondsToMMSS(status.TimePos), util.SecondsToMMSS(status.Duration),
+		lang.L("Volume"), u.pm.Volume())
+	if u.pm.IsShuffle() {
+		right += "  " + lang.L("Shuffle")
+	}
+	switch u.pm.GetLoopMode() {
+	case backend.LoopAll:
+		right += "  " + lang.L("Repeat")
+	case backend.LoopOne:
+		right += "  " + lang.L("Repeat") + " 1"
+	}
+	return columns(left, right+" ", width)
+}
+
+func (u *ui) helpLine(width int) string {
+	if u.message != "" && time.Since(u.messageTime) < messageDuration {
+		return fit(" "+u.message, width)
+	}
+	help := " q:quit  space:play/pause  n/p:next/prev  ←/→:seek  +/-:volume  s:shuffle  r:repeat" +
+		"  enter:open  a/A:queue/next  bksp:back  /:search"
+	return styleDim + fit(help, width) + styleReset
+}
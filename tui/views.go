@@ -0,0 +1,304 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2/lang"
+	"github.com/dweymouth/supersonic/backend"
+	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/ui/util"
+)
+
+// number of results to request for a search
+const searchResultCount = 50
+
+func (u *ui) mp() mediaprovider.MediaProvider {
+	return u.app.ServerManager.Server
+}
+
+func queueMode(next bool) backend.InsertQueueMode {
+	if next {
+		return backend.InsertNext
+	}
+	return backend.Append
+}
+
+// asyncListView creates a listView whose items are fetched in the background
+func (u *ui) asyncListView(name string, fetch func() ([]listItem, error)) *listView {
+	l := newListView(name, nil)
+	l.loading = true
+	go func() {
+		items, err := fetch()
+		u.post(func() {
+			l.loading = false
+			if err != nil {
+				u.showError(err)
+				return
+			}
+			l.setItems(items)
+		})
+	}()
+	return l
+}
+
+func (u *ui) albumsView() view {
+	mp := u.mp()
+	sortOrder := u.app.Config.AlbumsPage.SortOrder
+	if !slices.Contains(mp.AlbumSortOrders(), sortOrder) {
+		sortOrder = mp.AlbumSortOrders()[0]
+	}
+	iter := mp.IterateAlbums(sortOrder, mediaprovider.NewAlbumFilter(mediaprovider.AlbumFilterOptions{}))
+	return newIteratorListView(lang.L("Albums"), u.post, iter.Next, u.albumItem)
+}
+
+func (u *ui) genreAlbumsView(genre string) view {
+	mp := u.mp()
+	filter := mediaprovider.NewAlbumFilter(mediaprovider.AlbumFilterOptions{Genres: []string{genre}})
+	iter := mp.IterateAlbums(mp.AlbumSortOrders()[0], filter)
+	return newIteratorListView(genre, u.post, iter.Next, u.albumItem)
+}
+
+func (u *ui) albumItem(al *mediaprovider.Album) listItem {
+	year := ""
+	if y := al.YearOrZero(); y > 0 {
+		year = strconv.Itoa(y)
+	}
+	return listItem{
+		text:     al.Name + " – " + strings.Join(al.ArtistNames, ", "),
+		right:    year,
+		onSelect: func() { u.push(u.albumView(al.ID, al.Name)) },
+		onEnqueue: func(next bool) {
+			if err := u.pm.LoadAlbum(al.ID, queueMode(next), false); err != nil {
+				u.showError(err)
+			}
+		},
+	}
+}
+
+func (u *ui) albumView(id, name string) view {
+	return u.asyncListView(name, func() ([]listItem, error) {
+		album, err := u.mp().GetAlbum(id)
+		if err != nil {
+			return nil, err
+		}
+		return u.trackItems(album.Tracks, true), nil
+	})
+}
+
+func (u *ui) artistsView() view {
+	iter := u.mp().IterateArtists(mediaprovider.ArtistSortNameAZ,
+		mediaprovider.NewArtistFilter(mediaprovider.ArtistFilterOptions{}))
+	return newIteratorListView(lang.L("Artists"), u.post, iter.Next, u.artistItem)
+}
+
+func (u *ui) artistItem(ar *mediaprovider.Artist) listItem {
+	return listItem{
+		text:     ar.Name,
+		right:    strconv.Itoa(ar.AlbumCount),
+		onSelect: func() { u.push(u.artistView(ar.ID, ar.Name)) },
+		onEnqueue: func(next bool) {
+			go func() {
+				tracks, err := u.mp().GetArtistTracks(ar.ID)
+				if err != nil {
+					u.post(func() { u.showError(err) })
+					return
+				}
+				u.pm.LoadTracks(tracks, queueMode(next), false)
+			}()
+		},
+	}
+}
+
+func (u *ui) artistView(id, name string) view {
+	return u.asyncListView(name, func() ([]listItem, error) {
+		artist, err := u.mp().GetArtist(id)
+		if err != nil {
+			return nil, err
+		}
+		items := make([]listItem, len(artist.Albums))
+		for i, al := range artist.Albums {
+			items[i] = u.albumItem(al)
+		}
+		return items, nil
+	})
+}
+
+func (u *ui) playlistsView() view {
+	return u.asyncListView(lang.L("Playlists"), func() ([]listItem, error) {
+		playlists, err := u.mp().GetPlaylists()
+		if err != nil {
+			return nil, err
+		}
+		items := make([]listItem, len(playlists))
+		for i, pl := range playlists {
+			items[i] = u.playlistItem(pl.ID, pl.Name, strconv.Itoa(pl.TrackCount))
+		}
+		return items, nil
+	})
+}
+
+func (u *ui) playlistItem(id, name, right string) listItem {
+	return listItem{
+		text:     name,
+		right:    right,
+		onSelect: func() { u.push(u.playlistView(id, name)) },
+		onEnqueue: func(next bool) {
+			if err := u.pm.LoadPlaylist(id, queueMode(next), false); err != nil {
+				u.showError(err)
+			}
+		},
+	}
+}
+
+func (u *ui) playlistView(id, name string) view {
+	return u.asyncListView(name, func() ([]listItem, error) {
+		playlist, err := u.mp().GetPlaylist(id)
+		if err != nil {
+			return nil, err
+		}
+		return u.trackItems(playlist.Tracks, false), nil
+	})
+}
+
+// trackItems creates list items for a tracklist. Selecting a track
+// replaces the play queue with the tracklist and plays from that track.
+func (u *ui) trackItems(tracks []*mediaprovider.Track, trackNumbers bool) []listItem {
+	items := make([]listItem, len(tracks))
+	for i, tr := range tracks {
+		num := i + 1
+		if trackNumbers {
+			num = tr.TrackNumber
+		}
+		idx := i
+		items[i] = listItem{
+			text:     fmt.Sprintf("%3d. %s – %s", num, tr.Title, strings.Join(tr.ArtistNames, ", ")),
+			right:    util.SecondsToMMSS(tr.Duration.Seconds()),
+			onSelect: func() { u.pm.LoadTracksAndPlayAtIdx(tracks, false, idx) },
+			onEnqueue: func(next bool) {
+				u.pm.LoadTracks([]*mediaprovider.Track{tr}, queueMode(next), false)
+			},
+		}
+	}
+	return items
+}
+
+// searchView has a query input line above a list of results
+type searchView struct {
+	u       *ui
+	query   []rune
+	editing bool
+	results *listView
+}
+
+func newSearchView(u *ui) *searchView {
+	return &searchView{u: u, editing: true, results: newListView("", nil)}
+}
+
+func (s *searchView) title() string { return lang.L("Search") }
+
+func (s *searchView) startEditing() {
+	s.editing = true
+}
+
+func (s *searchView) render(width, height int) []string {
+	prompt := " " + lang.L("Search") + ": " + string(s.query)
+	if s.editing {
+		prompt += "█"
+	} else if len(s.query) == 0 {
+		prompt += styleDim + lang.L("Search...") + styleReset
+	}
+	lines := []string{prompt, ""}
+	return padLines(append(lines, s.results.render(width, max(height-2, 0))...), height)
+}
+
+func (s *searchView) handleKey(k key) bool {
+	if !s.editing {
+		if k == runeKey('/') {
+			s.editing = true
+			return true
+		}
+		return s.results.handleKey(k)
+	}
+
+	switch k.code {
+	case keyRune:
+		s.query = append(s.query, k.r)
+	case keyBackspace:
+		if len(s.query) > 0 {
+			s.query = s.query[:len(s.query)-1]
+		}
+	case keyEnter:
+		s.editing = false
+		s.search(string(s.query))
+	case keyEsc:
+		s.editing = false
+	case keyUp, keyDown:
+		s.editing = false
+		return s.results.handleKey(k)
+	default:
+		return false
+	}
+	return true
+}
+
+func (s *searchView) search(query string) {
+	if strings.TrimSpace(query) == "" {
+		s.results.setItems(nil)
+		return
+	}
+	u := s.u
+	s.results = u.asyncListView("", func() ([]listItem, error) {
+		results, err := u.mp().SearchAll(query, searchResultCount)
+		if err != nil {
+			return nil, err
+		}
+		items := make([]listItem, 0, len(results))
+		for _, r := range results {
+			if item, ok := u.searchResultItem(r); ok {
+				items = append(items, item)
+			}
+		}
+		return items, nil
+	})
+}
+
+func (u *ui) searchResultItem(r *mediaprovider.SearchResult) (listItem, bool) {
+	switch r.Type {
+	case mediaprovider.ContentTypeAlbum:
+		return u.albumItem(&mediaprovider.Album{ID: r.ID, Name: r.Name, ArtistNames: []string{r.ArtistName}}), true
+	case mediaprovider.ContentTypeArtist:
+		return u.artistItem(&mediaprovider.Artist{ID: r.ID, Name: r.Name, AlbumCount: r.Size}), true
+	case mediaprovider.ContentTypePlaylist:
+		return u.playlistItem(r.ID, r.Name, lang.L("Playlist")), true
+	case mediaprovider.ContentTypeGenre:
+		return listItem{
+			text:     r.Name,
+			right:    lang.L("Genre"),
+			onSelect: func() { u.push(u.genreAlbumsView(r.Name)) },
+		}, true
+	case mediaprovider.ContentTypeTrack:
+		return listItem{
+			text:  r.Name + " – " + r.ArtistName,
+			right: util.SecondsToMMSS(float64(r.Size)),
+			onSelect: func() {
+				if err := u.pm.PlayTrack(r.ID); err != nil {
+					u.showError(err)
+				}
+			},
+			onEnqueue: func(next bool) {
+				go func() {
+					tr, err := u.mp().GetTrack(r.ID)
+					if err != nil {
+						u.post(func() { u.showError(err) })
+						return
+					}
+					u.pm.LoadTracks([]*mediaprovider.Track{tr}, queueMode(next), false)
+				}()
+			},
+		}, true
+	}
+	return listItem{}, false
+}
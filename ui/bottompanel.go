@@ -3,6 +3,7 @@ package ui
 import (
 	"github.com/dweymouth/supersonic/backend"
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
+	"github.com/dweymouth/supersonic/backend/player"
 	"github.com/dweymouth/supersonic/backend/player/mpv"
 	"github.com/dweymouth/supersonic/ui/controller"
 	"github.com/dweymouth/supersonic/ui/layouts"
@@ -35,6 +36,13 @@ func NewBottomPanel(pm *backend.PlaybackManager, im *backend.ImageManager, contr
 	bp.ExtendBaseWidget(bp)
 
 	pm.OnSongChange(bp.onSongChange)
+	updateChapters := func() {
+		chapters, cur := pm.Chapters(), pm.CurrentChapter()
+		dur := pm.PlaybackStatus().Duration
+		fyne.Do(func() { bp.updateChapters(chapters, cur, dur) })
+	}
+	pm.OnSongChange(func(mediaprovider.MediaItem, *mediaprovider.Track) { updateChapters() })
+	pm.OnChapterChange(updateChapters)
 	pm.OnRadioMetadataChange(bp.onRadioMetadataChange)
 	pm.OnWaveformImgUpdate(bp.updateWaveformImg)
 	pm.OnPlayTimeUpdate(func(cur, total float64, _ bool) {
@@ -92,6 +100,7 @@ func NewBottomPanel(pm *backend.PlaybackManager, im *backend.ImageManager, contr
 		}
 	}
 	bp.Controls = widgets.NewPlayerControls(cfg.Playback.UseWaveformSeekbar, pm.GetLoopMode(), pm.IsShuffle())
+	bp.Controls.SetWaveformColored(cfg.Playback.WaveformMode == backend.WaveformModeFrequency)
 	bp.Controls.OnPlayPause(func() {
 		pm.PlayPause()
 	})
@@ -153,6 +162,24 @@ func (bp *BottomPanel) onSongChange(song mediaprovider.MediaItem, _ *mediaprovid
 	})
 }
 
+func (bp *BottomPanel) updateChapters(chapters []player.Chapter, current int, duration float64) {
+	chapter := ""
+	if current >= 0 && current < len(chapters) {
+		chapter = widgets.ChapterTitle(chapters, current)
+	}
+	bp.NowPlaying.SetChapter(chapter)
+
+	var markers []float64
+	if duration > 0 {
+		for _, c := range chapters {
+			if c.Time > 0 && c.Time < duration {
+				markers = append(markers, c.Time/duration)
+			}
+		}
+	}
+	bp.Controls.SetChapterMarkers(markers)
+}
+
 func (bp *BottomPanel) onRadioMetadataChange(radioName, title, artist string) {
 	fyne.Do(func() {
 		bp.NowPlaying.Update(&mediaprovider.Track{
@@ -107,7 +107,7 @@ func newAlbumPage(
 	a.tracklist.OnVisibleColumnsChanged = func(cols []string) {
 		a.cfg.TracklistColumns = cols
 	}
-	a.contr.ConnectTracklistActionsWithReplayGainAlbum(a.tracklist)
+	a.contr.ConnectTracklistActions(a.tracklist)
 
 	a.container = container.NewBorder(
 		container.New(&layout.CustomPaddedLayout{LeftPadding: 15, RightPadding: 15, TopPadding: 15, BottomPadding: 10}, a.header),
@@ -219,6 +219,7 @@ type AlbumPageHeader struct {
 	genreLabel            *widgets.MultiHyperlink
 	miscLabel             *widget.Label
 	shareMenuItem         *fyne.MenuItem
+	continuousMenuItem    *fyne.MenuItem
 	collapseBtn           *widgets.HeaderCollapseButton
 	artistReleaseTypeLine *fyne.Container
 
@@ -292,11 +293,23 @@ func NewAlbumPageHeader(page *AlbumPage) *AlbumPageHeader {
 				a.page.contr.ShowShareDialog(a.albumID)
 			})
 			a.shareMenuItem.Icon = myTheme.ShareIcon
-			menu := fyne.NewMenu("", playNext, queue, playlist, download, info, a.shareMenuItem)
+			copyLink := fyne.NewMenuItem(lang.L("Copy link"), func() {
+				a.page.contr.CopyDeepLink(backend.DeepLinkTypeAlbum, a.albumID)
+			})
+			copyLink.Icon = theme.ContentCopyIcon()
+			a.continuousMenuItem = fyne.NewMenuItem(lang.L("Continuous album"), func() {
+				sm := a.page.contr.App.ServerManager
+				sm.SetAlbumContinuous(a.albumID, !sm.IsAlbumContinuous(a.albumID))
+			})
+			menu := fyne.NewMenu("", playNext, queue, playlist, download, info, a.shareMenuItem, copyLink,
+				fyne.NewMenuItemSeparator(), a.continuousMenuItem)
 			pop = widget.NewPopUpMenu(menu, fyne.CurrentApp().Driver().CanvasForObject(a))
 		}
 		_, canShare := page.mp.(mediaprovider.SupportsSharing)
 		a.shareMenuItem.Disabled = !canShare
+		// never skip silence between the tracks of continuous albums
+		a.continuousMenuItem.Checked = a.page.contr.App.ServerManager.IsAlbumContinuous(a.albumID)
+		a.continuousMenuItem.Disabled = !a.page.contr.App.Config.Playback.SkipSilence
 		pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(menuBtn)
 		pop.ShowAtPosition(fyne.NewPos(pos.X, pos.Y+menuBtn.Size().Height))
 	}
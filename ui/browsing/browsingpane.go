@@ -52,6 +52,10 @@ type CanShowPlayQueue interface {
 	OnPlayQueueChange()
 }
 
+type CanShowChapters interface {
+	OnChapterChange()
+}
+
 type BrowsingPane struct {
 	widget.BaseWidget
 
@@ -72,6 +76,7 @@ func NewBrowsingPane(app *backend.PlaybackManager, contr *controller.Controller,
 	b.playbackManager.OnSongChange(b.onSongChange)
 	b.playbackManager.OnPlayTimeUpdate(b.onPlayTimeUpdate)
 	b.playbackManager.OnQueueChange(b.onQueueChange)
+	b.playbackManager.OnChapterChange(b.onChapterChange)
 	bkgrnd := myTheme.NewThemedRectangle(myTheme.ColorNamePageBackground)
 	b.pageContainer = container.NewStack(bkgrnd, layout.NewSpacer())
 	return b
@@ -204,6 +209,17 @@ func (b *BrowsingPane) onQueueChange() {
 	})
 }
 
+func (b *BrowsingPane) onChapterChange() {
+	fyne.Do(func() {
+		if b.curPage == nil {
+			return
+		}
+		if p, ok := b.curPage.(CanShowChapters); ok {
+			p.OnChapterChange()
+		}
+	})
+}
+
 func (b *BrowsingPane) addPageToHistory(p Page, truncate bool) {
 	if truncate {
 		// allow garbage collection of pages that will be removed from the history
@@ -57,6 +57,8 @@ type NowPlayingPage struct {
 	queueList          *widgets.PlayQueueList
 	relatedList        *widgets.PlayQueueList
 	lyricsViewer       *widgets.LyricsViewer
+	chapterList        *widgets.ChapterList
+	visualization      *controller.VisualizationView
 	card               *widgets.LargeNowPlayingCard
 	statusLabel        *widget.Label
 	tabs               *container.AppTabs
@@ -185,6 +187,14 @@ func NewNowPlayingPage(
 		a.contr.ShowTrackInfoDialog(track)
 	}
 	a.lyricsViewer = widgets.NewLyricsViewer(a.onSeekToLyricLine)
+	a.chapterList = widgets.NewChapterList()
+	a.chapterList.OnChapterTapped = func(idx int) {
+		if chapters := a.pm.Chapters(); idx < len(chapters) {
+			a.pm.SeekSeconds(chapters[idx].Time)
+		}
+	}
+	a.chapterList.OnPreviousChapter = a.pm.SeekPreviousChapter
+	a.chapterList.OnNextChapter = a.pm.SeekNextChapter
 	a.statusLabel = widget.NewLabel(lang.L("Stopped"))
 
 	a.Reload()
@@ -205,6 +215,10 @@ func (a *NowPlayingPage) CreateRenderer() fyne.WidgetRenderer {
 			initialTab = 1
 		} else if a.conf.InitialView == "Related" {
 			initialTab = 2
+		} else if a.conf.InitialView == "Visualization" {
+			initialTab = 3
+		} else if a.conf.InitialView == "Chapters" {
+			initialTab = 4
 		}
 		_ = initialTab
 		paddedLayout := &layouts.PercentPadLayout{
@@ -213,6 +227,7 @@ func (a *NowPlayingPage) CreateRenderer() fyne.WidgetRenderer {
 		}
 		a.lyricsLoading = widgets.NewLoadingDots()
 		a.relatedLoading = widgets.NewLoadingDots()
+		a.visualization = a.contr.NewVisualizationView(a.contr.MainWindow, &a.conf.Visualization)
 		a.tabs = container.NewAppTabs(
 			container.NewTabItem(lang.L("Play Queue"),
 				container.NewBorder(layout.NewSpacer(), nil, nil, nil, a.queueList)),
@@ -222,6 +237,8 @@ func (a *NowPlayingPage) CreateRenderer() fyne.WidgetRenderer {
 			container.NewTabItem(lang.L("Related"), container.NewStack(
 				a.relatedList,
 				container.NewCenter(a.relatedLoading))),
+			container.NewTabItem(lang.L("Visualization"), a.visualization),
+			container.NewTabItem(lang.L("Chapters"), a.chapterList),
 		)
 		a.tabs.SelectIndex(initialTab)
 		a.tabs.OnSelected = func(*container.TabItem) {
@@ -232,12 +249,14 @@ func (a *NowPlayingPage) CreateRenderer() fyne.WidgetRenderer {
 			} else if idx == 2 /*related*/ {
 				a.updateRelatedList()
 			}
+			a.visualization.SetActive(idx == 3 /*visualization*/)
 		}
 		if initialTab == 1 /*lyrics*/ {
 			a.updateLyrics()
 		} else if initialTab == 2 /*related*/ {
 			a.updateRelatedList()
 		}
+		a.visualization.SetActive(initialTab == 3 /*visualization*/)
 		c := theme.Color(myTheme.ColorNamePageBackground)
 		a.backgroundGradient = canvas.NewLinearGradient(c, c, 0)
 		a.backgroundImgA = canvas.NewImageFromImage(nil)
@@ -269,6 +288,10 @@ func (a *NowPlayingPage) Save() SavedPage {
 		a.imageLoadCancel()
 	}
 	a.alreadyLoaded = false
+	if a.visualization != nil {
+		// only update the visualization while the page is shown
+		a.visualization.SetActive(false)
+	}
 	nps := a.nowPlayingPageState
 	a.pool.Release(util.WidgetTypeNowPlayingPage, a)
 	return &nps
@@ -287,6 +310,8 @@ func (a *NowPlayingPage) Scroll(delta float32) {
 	case 1: /*lyrics*/
 	case 2: /*related*/
 		a.relatedList.Scroll(delta)
+	case 4: /*chapters*/
+		a.chapterList.Scroll(delta)
 	}
 }
 
@@ -321,6 +346,7 @@ func (a *NowPlayingPage) OnSongChange(song mediaprovider.MediaItem, lastScrobble
 	} else if a.tabs != nil && a.tabs.SelectedIndex() == 2 /*related*/ {
 		a.updateRelatedList()
 	}
+	a.updateChapters()
 }
 
 func (a *NowPlayingPage) onImageLoaded(img image.Image, err error) {
@@ -469,6 +495,14 @@ func (a *NowPlayingPage) updateRelatedList() {
 	}(ctx)
 }
 
+func (a *NowPlayingPage) OnChapterChange() {
+	a.updateChapters()
+}
+
+func (a *NowPlayingPage) updateChapters() {
+	a.chapterList.SetChapters(a.pm.Chapters(), a.pm.CurrentChapter())
+}
+
 func (a *NowPlayingPage) OnPlayQueueChange() {
 	a.Reload()
 }
@@ -487,6 +521,7 @@ func (a *NowPlayingPage) Reload() {
 		a.totalTime += tr.Metadata().Duration.Seconds()
 	}
 	a.formatStatusLine()
+	a.updateChapters()
 
 	if a.tabs == nil {
 		return
@@ -497,6 +532,8 @@ func (a *NowPlayingPage) Reload() {
 		a.updateLyrics()
 	case 2: /*related*/
 		a.updateRelatedList()
+	case 3: /*visualization*/
+		a.visualization.SetActive(true)
 	}
 }
 
@@ -568,6 +605,10 @@ func (a *NowPlayingPage) saveSelectedTab(tabNum int) {
 		tabName = "Lyrics"
 	case 2:
 		tabName = "Related"
+	case 3:
+		tabName = "Visualization"
+	case 4:
+		tabName = "Chapters"
 	}
 	a.conf.InitialView = tabName
 }
@@ -430,7 +430,11 @@ func NewPlaylistPageHeader(page *PlaylistPage) *PlaylistPageHeader {
 				a.page.contr.ShowDownloadDialog(a.page.tracks, a.titleLabel.String())
 			})
 			download.Icon = theme.DownloadIcon()
-			menu := fyne.NewMenu("", playNext, queue, playlist, download)
+			copyLink := fyne.NewMenuItem(lang.L("Copy link"), func() {
+				a.page.contr.CopyDeepLink(backend.DeepLinkTypePlaylist, a.page.playlistID)
+			})
+			copyLink.Icon = theme.ContentCopyIcon()
+			menu := fyne.NewMenu("", playNext, queue, playlist, download, copyLink)
 			pop = widget.NewPopUpMenu(menu, fyne.CurrentApp().Driver().CanvasForObject(a))
 		}
 		pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(menuBtn)
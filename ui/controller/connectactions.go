@@ -5,7 +5,6 @@ import (
 
 	"github.com/dweymouth/supersonic/backend"
 	"github.com/dweymouth/supersonic/backend/mediaprovider"
-	"github.com/dweymouth/supersonic/backend/player"
 	"github.com/dweymouth/supersonic/sharedutil"
 	"github.com/dweymouth/supersonic/ui/widgets"
 
@@ -14,15 +13,7 @@ import (
 	"fyne.io/fyne/v2/widget"
 )
 
-func (m *Controller) ConnectTracklistActionsWithReplayGainAlbum(tracklist *widgets.Tracklist) {
-	m.connectTracklistActionsWithReplayGainMode(tracklist, player.ReplayGainAlbum)
-}
-
 func (m *Controller) ConnectTracklistActions(tracklist *widgets.Tracklist) {
-	m.connectTracklistActionsWithReplayGainMode(tracklist, player.ReplayGainTrack)
-}
-
-func (m *Controller) connectTracklistActionsWithReplayGainMode(tracklist *widgets.Tracklist, mode player.ReplayGainMode) {
 	tracklist.OnAddToPlaylist = m.DoAddTracksToPlaylistWorkflow
 	tracklist.OnPlaySelectionNext = func(tracks []*mediaprovider.Track) {
 		m.App.PlaybackManager.LoadTracks(tracks, backend.InsertNext, false)
@@ -32,15 +23,9 @@ func (m *Controller) connectTracklistActionsWithReplayGainMode(tracklist *widget
 	}
 	tracklist.OnPlayTrackAt = func(idx int) {
 		m.App.PlaybackManager.LoadTracksAndPlayAtIdx(tracklist.GetTracks(), false, idx)
-		if m.App.Config.ReplayGain.Mode == backend.ReplayGainAuto {
-			m.App.PlaybackManager.SetReplayGainMode(mode)
-		}
 	}
 	tracklist.OnPlaySelection = func(tracks []*mediaprovider.Track, shuffle bool) {
 		m.App.PlaybackManager.LoadTracks(tracks, backend.Replace, shuffle)
-		if m.App.Config.ReplayGain.Mode == backend.ReplayGainAuto {
-			m.App.PlaybackManager.SetReplayGainMode(mode)
-		}
 		m.App.PlaybackManager.PlayFromBeginning()
 	}
 	tracklist.OnSetFavorite = m.SetTrackFavorites
@@ -61,6 +46,9 @@ func (m *Controller) connectTracklistActionsWithReplayGainMode(tracklist *widget
 	tracklist.OnShare = func(trackID string) {
 		m.ShowShareDialog(trackID)
 	}
+	tracklist.OnCopyLink = func(trackID string) {
+		m.CopyDeepLink(backend.DeepLinkTypeTrack, trackID)
+	}
 	tracklist.OnShowTrackInfo = m.ShowTrackInfoDialog
 	tracklist.OnPlaySongRadio = func(track *mediaprovider.Track) {
 		go func() {
@@ -70,9 +58,6 @@ func (m *Controller) connectTracklistActionsWithReplayGainMode(tracklist *widget
 				return
 			}
 			m.App.PlaybackManager.LoadTracks(tracks, backend.Replace, false)
-			if m.App.Config.ReplayGain.Mode == backend.ReplayGainAuto {
-				m.App.PlaybackManager.SetReplayGainMode(mode)
-			}
 			m.App.PlaybackManager.PlayFromBeginning()
 		}()
 	}
@@ -104,6 +89,9 @@ func (m *Controller) ConnectAlbumGridActions(grid *widgets.GridView) {
 	grid.OnShare = func(albumID string) {
 		m.ShowShareDialog(albumID)
 	}
+	grid.OnCopyLink = func(albumID string) {
+		m.CopyDeepLink(backend.DeepLinkTypeAlbum, albumID)
+	}
 }
 
 func (m *Controller) ConnectGroupedReleasesActions(grid *widgets.GroupedReleases) {
@@ -132,6 +120,9 @@ func (m *Controller) ConnectGroupedReleasesActions(grid *widgets.GroupedReleases
 	grid.OnShare = func(albumID string) {
 		m.ShowShareDialog(albumID)
 	}
+	grid.OnCopyLink = func(albumID string) {
+		m.CopyDeepLink(backend.DeepLinkTypeAlbum, albumID)
+	}
 }
 
 func (m *Controller) onAddAlbumToPlaylist(albumID string) {
@@ -194,6 +185,9 @@ func (m *Controller) ConnectArtistGridActions(grid *widgets.GridView) {
 	grid.OnShare = func(artistID string) {
 		m.ShowShareDialog(artistID)
 	}
+	grid.OnCopyLink = func(artistID string) {
+		m.CopyDeepLink(backend.DeepLinkTypeArtist, artistID)
+	}
 }
 
 func (c *Controller) ConnectPlayQueuelistActions(list *widgets.PlayQueueList) {
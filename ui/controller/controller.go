@@ -11,6 +11,8 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"strings"
 	"time"
 
 	fynetooltip "github.com/dweymouth/fyne-tooltip"
@@ -30,6 +32,7 @@ import (
 	"fyne.io/fyne/v2/driver"
 	"fyne.io/fyne/v2/lang"
 	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/storage"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 )
@@ -322,7 +325,7 @@ func (c *Controller) ShowSettingsDialog(themeUpdateCallbk func(), themeFiles map
 	_, isEqualizerPlayer := curPlayer.(*mpv.Player)
 	_, canSavePlayQueue := c.App.ServerManager.Server.(mediaprovider.CanSavePlayQueue)
 	isLocalPlayer := isEqualizerPlayer
-	bands := c.App.LocalPlayer.Equalizer().BandFrequencies()
+	bands := backend.NewGraphicEqualizerFromConfig(&c.App.Config.LocalPlayback).BandFrequencies()
 
 	dlg := dialogs.NewSettingsDialog(c.App.Config,
 		devs, themeFiles, bands,
@@ -343,39 +346,24 @@ func (c *Controller) ShowSettingsDialog(themeUpdateCallbk func(), themeFiles map
 		c.App.LocalPlayer.SetPauseFade(c.App.Config.LocalPlayback.PauseFade)
 	}
 	dlg.OnAudioDeviceSettingChanged = func() {
-		c.App.LocalPlayer.SetAudioDevice(c.App.Config.LocalPlayback.AudioDeviceName)
+		c.App.SetAudioDevice(c.App.Config.LocalPlayback.AudioDeviceName)
 	}
 	dlg.OnThemeSettingChanged = themeUpdateCallbk
 	dlg.OnEqualizerSettingsChanged = func() {
-		// Create the appropriate equalizer type based on config
-		var eq mpv.Equalizer
-		if c.App.Config.LocalPlayback.EqualizerType == "ISO10Band" {
-			eq10 := &mpv.ISO10BandEqualizer{
-				Disabled: !c.App.Config.LocalPlayback.EqualizerEnabled,
-				EQPreamp: c.App.Config.LocalPlayback.EqualizerPreamp,
-			}
-			// Copy up to 10 bands
-			numBands := min(len(c.App.Config.LocalPlayback.GraphicEqualizerBands), 10)
-			for i := 0; i < numBands; i++ {
-				eq10.BandGains[i] = c.App.Config.LocalPlayback.GraphicEqualizerBands[i]
-			}
-			eq = eq10
-		} else {
-			eq15 := &mpv.ISO15BandEqualizer{
-				Disabled: !c.App.Config.LocalPlayback.EqualizerEnabled,
-				EQPreamp: c.App.Config.LocalPlayback.EqualizerPreamp,
-			}
-			// Copy up to 15 bands
-			numBands := min(len(c.App.Config.LocalPlayback.GraphicEqualizerBands), 15)
-			for i := 0; i < numBands; i++ {
-				eq15.BandGains[i] = c.App.Config.LocalPlayback.GraphicEqualizerBands[i]
-			}
-			eq = eq15
-		}
-		c.App.LocalPlayer.SetEqualizer(eq)
+		c.App.LocalPlayer.SetEqualizer(backend.NewEqualizerFromConfig(&c.App.Config.LocalPlayback))
+	}
+	dlg.OnDSPSettingsChanged = func(chain []mpv.DSPStage) error {
+		return c.App.LocalPlayer.SetDSPChain(chain)
 	}
 	dlg.OnPageNeedsRefresh = c.RefreshPageFunc
 	dlg.OnClearCaches = func() { go c.App.ClearCaches() }
+	dlg.OnClearWaveformCache = func() { go c.App.ClearWaveformCache() }
+	dlg.OnExportPlayHistory = c.showExportPlayHistoryDialog
+	dlg.OnImportPlayHistory = c.showImportPlayHistoryDialog
+	dlg.OnShowRemoteControlPairing = c.showRemoteControlPairingDialog
+	dlg.OnShowDiscordPrivacy = c.showDiscordPrivacyDialog
+	dlg.SetMQTTPassword(c.App.MQTTPassword())
+	dlg.OnMQTTPasswordChanged = c.App.SetMQTTPassword
 	pop := widget.NewModalPopUp(dlg, c.MainWindow.Canvas())
 	fynetooltip.AddPopUpToolTipLayer(pop)
 	dlg.OnDismiss = func() {
@@ -389,6 +377,176 @@ func (c *Controller) ShowSettingsDialog(themeUpdateCallbk func(), themeFiles map
 	pop.Show()
 }
 
+func (c *Controller) showExportPlayHistoryDialog() {
+	dg := dialog.NewFileSave(
+		func(file fyne.URIWriteCloser, err error) {
+			if err != nil {
+				log.Println(err)
+				return
+			}
+			if file == nil {
+				return
+			}
+			file.Close()
+			path := file.URI().Path()
+			go func() {
+				if err := c.App.ExportPlayHistory(path); err != nil {
+					log.Printf("error exporting play history: %v", err)
+					fyne.Do(func() { c.ToastProvider.ShowErrorToast(lang.L("Failed to export listening history")) })
+					return
+				}
+				fyne.Do(func() { c.ToastProvider.ShowSuccessToast(lang.L("Exported listening history")) })
+			}()
+		},
+		c.MainWindow)
+	dg.SetFileName("listening_history.jsonl")
+	dg.Show()
+}
+
+func (c *Controller) showRemoteControlPairingDialog() {
+	info, ok := c.App.RemoteControlInfo()
+	if !ok {
+		dialog.ShowInformation(lang.L("Pair device"),
+			lang.L("Remote control is not running. Enable it and restart Supersonic to pair a device."),
+			c.MainWindow)
+		return
+	}
+	var sb strings.Builder
+	sb.WriteString(lang.L("Open one of these addresses on the device to pair") + ":\n\n")
+	for _, u := range info.URLs {
+		sb.WriteString(u + "\n")
+	}
+	sb.WriteString("\n" + lang.L("Pairing code") + ": " + info.PairingCode + "\n")
+	if info.CertFingerprint != "" {
+		sb.WriteString("\n" + lang.L("Certificate fingerprint") + ":\n" + info.CertFingerprint)
+	}
+	text := widget.NewLabel(sb.String())
+	text.Wrapping = fyne.TextWrapBreak
+	text.Selectable = true
+
+	devices := container.NewVBox()
+	var updateDevices func()
+	updateDevices = func() {
+		devices.RemoveAll()
+		for _, d := range c.App.RemoteControlDevices() {
+			name := widget.NewLabel(fmt.Sprintf("%s (%s)", d.Name, d.Paired.Format(time.DateOnly)))
+			name.Truncation = fyne.TextTruncateEllipsis
+			revoke := widget.NewButton(lang.L("Revoke"), func() {
+				c.App.RevokeRemoteControlDevice(d.ID)
+				updateDevices()
+			})
+			devices.Add(container.NewBorder(nil, nil, nil, revoke, name))
+		}
+		if len(devices.Objects) == 0 {
+			devices.Add(widget.NewLabel(lang.L("No paired devices")))
+		}
+	}
+	updateDevices()
+
+	content := container.NewVBox(text, widget.NewLabelWithStyle(lang.L("Paired devices"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}), devices)
+	dlg := dialog.NewCustom(lang.L("Pair device"), lang.L("Close"), content, c.MainWindow)
+	dlg.Resize(fyne.NewSize(500, 0))
+	dlg.Show()
+}
+
+// showDiscordPrivacyDialog edits what the Discord
+// activity shows while connected to the current server.
+func (c *Controller) showDiscordPrivacyDialog() {
+	presence := c.App.DiscordPresenceConfig()
+	show := widget.NewCheck(lang.L("Show on Discord while connected to this server"), nil)
+	show.Checked = !presence.Disabled
+	album := widget.NewCheck(lang.L("Show album name"), nil)
+	album.Checked = !presence.HideAlbum
+	cover := widget.NewCheck(lang.L("Show cover art"), nil)
+	cover.Checked = presence.ShowCoverArt
+	if _, ok := c.App.ServerManager.Server.(mediaprovider.SupportsPublicCoverArtURL); !ok {
+		cover.Disable()
+	}
+
+	hidden := slices.Clone(presence.HiddenLibraries)
+	libraries := container.NewVBox(widget.NewLabel(lang.L("Loading")))
+	go func() {
+		libs, err := c.App.ServerManager.Server.GetLibraries()
+		if err != nil {
+			log.Printf("error loading server libraries: %s", err.Error())
+		}
+		fyne.Do(func() {
+			libraries.RemoveAll()
+			for _, l := range libs {
+				check := widget.NewCheck(l.Name, func(hide bool) {
+					hidden = slices.DeleteFunc(hidden, func(id string) bool { return id == l.ID })
+					if hide {
+						hidden = append(hidden, l.ID)
+					}
+				})
+				check.Checked = slices.Contains(hidden, l.ID)
+				libraries.Add(check)
+			}
+			if len(libs) == 0 {
+				libraries.Add(widget.NewLabel(lang.L("No libraries")))
+			}
+		})
+	}()
+
+	content := container.NewVBox(show, album, cover,
+		widget.NewLabelWithStyle(lang.L("Hide while these libraries are selected"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		libraries)
+	dialog.ShowCustomConfirm(lang.L("Discord privacy"), lang.L("OK"), lang.L("Cancel"), content,
+		func(ok bool) {
+			if ok {
+				c.App.SetDiscordPresenceConfig(backend.DiscordPresenceServerConfig{
+					Disabled:        !show.Checked,
+					HideAlbum:       !album.Checked,
+					ShowCoverArt:    cover.Checked,
+					HiddenLibraries: hidden,
+				})
+			}
+		}, c.MainWindow)
+}
+
+func (c *Controller) showImportPlayHistoryDialog() {
+	dg := dialog.NewFileOpen(
+		func(file fyne.URIReadCloser, err error) {
+			if err != nil {
+				log.Println(err)
+				return
+			}
+			if file == nil {
+				return
+			}
+			file.Close()
+			path := file.URI().Path()
+			submit := widget.NewCheck(lang.L("Also submit matched plays to the server, at their original times"), nil)
+			if _, ok := c.App.ServerManager.Server.(mediaprovider.CanSubmitPastPlays); !ok {
+				submit.Disable()
+			}
+			content := container.NewVBox(
+				widget.NewLabel(lang.L("Plays are added to the local listening history.")),
+				submit,
+			)
+			dialog.ShowCustomConfirm(lang.L("Import listening history"), lang.L("Import"), lang.L("Cancel"), content,
+				func(ok bool) {
+					if ok {
+						go c.importPlayHistory(path, submit.Checked)
+					}
+				}, c.MainWindow)
+		},
+		c.MainWindow)
+	dg.SetFilter(&storage.ExtensionFileFilter{Extensions: []string{".csv", ".json", ".jsonl", ".zip"}})
+	dg.Show()
+}
+
+func (c *Controller) importPlayHistory(path string, submitToServer bool) {
+	imported, matched, err := c.App.ImportPlayHistory(path, submitToServer)
+	if err != nil {
+		log.Printf("error importing play history: %v", err)
+		fyne.Do(func() { c.ToastProvider.ShowErrorToast(lang.L("Failed to import listening history")) })
+		return
+	}
+	msg := fmt.Sprintf(lang.L("Imported %d plays (%d matched to library)"), imported, matched)
+	fyne.Do(func() { c.ToastProvider.ShowSuccessToast(msg) })
+}
+
 func (c *Controller) doModalClosed() {
 	c.haveModal = false
 	if c.runOnModalClosed != nil {
@@ -423,6 +581,31 @@ func (c *Controller) SetTrackRatings(trackIDs []string, rating int) {
 	}
 }
 
+// ShowContinueFromDeviceDialog offers to continue playback
+// from a play queue saved or played on another device.
+func (c *Controller) ShowContinueFromDeviceDialog(queue *mediaprovider.SavedPlayQueue) {
+	device := queue.ChangedBy
+	if device == "" {
+		device = lang.L("another device")
+	}
+	idx := max(queue.TrackPos, 0)
+	if idx >= len(queue.Tracks) {
+		return
+	}
+	tr := queue.Tracks[idx]
+	msg := fmt.Sprintf("%s – %s (%s)", tr.Title, strings.Join(tr.ArtistNames, ", "),
+		util.SecondsToMMSS(float64(queue.TimePos)))
+	dlg := dialog.NewConfirm(fmt.Sprintf(lang.L("Continue from %s?"), device), msg,
+		func(ok bool) {
+			if ok {
+				c.App.ContinueFromRemotePlayQueue(queue)
+			}
+		}, c.MainWindow)
+	dlg.SetConfirmText(lang.L("Continue"))
+	dlg.SetDismissText(lang.L("Dismiss"))
+	dlg.Show()
+}
+
 func (c *Controller) ShowShareDialog(id string) {
 	sh, ok := c.App.ServerManager.Server.(mediaprovider.SupportsSharing)
 	if !ok {
@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"log"
+
+	"github.com/dweymouth/supersonic/backend"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/lang"
+)
+
+// OpenDeepLink navigates to the item of a supersonic:// link and runs its action.
+// If no server is connected yet, the link is opened once connected.
+func (m *Controller) OpenDeepLink(link *backend.DeepLink) {
+	server := m.App.FindDeepLinkServer(link)
+	switch {
+	case server == nil:
+		m.ToastProvider.ShowErrorToast(lang.L("No configured server matches the link"))
+	case m.App.ServerManager.Server == nil:
+		m.App.PendingDeepLink = link
+	case server.ID != m.App.ServerManager.ServerID:
+		m.ToastProvider.ShowErrorToast(lang.L("The link is for another server") + ": " + server.Nickname)
+	default:
+		m.openDeepLink(link)
+	}
+}
+
+// OpenPendingDeepLink opens the link received while not connected, if any.
+func (m *Controller) OpenPendingDeepLink() {
+	if link := m.App.PendingDeepLink; link != nil {
+		m.App.PendingDeepLink = nil
+		m.OpenDeepLink(link)
+	}
+}
+
+func (m *Controller) openDeepLink(link *backend.DeepLink) {
+	switch link.Type {
+	case backend.DeepLinkTypeAlbum:
+		m.NavigateTo(AlbumRoute(link.ID))
+	case backend.DeepLinkTypeArtist:
+		m.NavigateTo(ArtistRoute(link.ID))
+	case backend.DeepLinkTypePlaylist:
+		m.NavigateTo(PlaylistRoute(link.ID))
+	}
+	go func() {
+		if link.Type == backend.DeepLinkTypeTrack {
+			// tracks have no page of their own, so show the album
+			if tr, err := m.App.ServerManager.Server.GetTrack(link.ID); err == nil && tr.AlbumID != "" {
+				fyne.Do(func() { m.NavigateTo(AlbumRoute(tr.AlbumID)) })
+			}
+		}
+		if err := m.App.RunDeepLinkAction(link); err != nil {
+			log.Printf("error opening link %s: %v", link, err)
+			fyne.Do(func() { m.ToastProvider.ShowErrorToast(lang.L("Unable to open link")) })
+		}
+	}()
+}
+
+// CopyDeepLink copies a supersonic:// link to the item to the clipboard.
+func (m *Controller) CopyDeepLink(itemType, id string) {
+	m.MainWindow.Clipboard().SetContent(m.App.DeepLinkTo(itemType, id))
+	m.ToastProvider.ShowSuccessToast(lang.L("Link copied to clipboard"))
+}
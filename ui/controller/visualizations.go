@@ -2,10 +2,14 @@ package controller
 
 import (
 	"math"
+	"slices"
 	"time"
 
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver"
 	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
 	"github.com/dweymouth/supersonic/backend/player"
 	"github.com/dweymouth/supersonic/backend/player/mpv"
 	"github.com/dweymouth/supersonic/ui/shortcuts"
@@ -14,12 +18,146 @@ import (
 	"github.com/dweymouth/supersonic/ui/visualizations"
 )
 
+// Visualization types, as saved in the config
+const (
+	VisualizationPeakMeter = "Peak Meter"
+	VisualizationLoudness  = "Loudness"
+	VisualizationSpectrum  = "Spectrum"
+)
+
+var visualizationTypes = []string{VisualizationPeakMeter, VisualizationLoudness, VisualizationSpectrum}
+
+const (
+	// the loudness meter measures every 100ms,
+	// so update it every 6th frame of the 60 Hz animation
+	loudnessUpdateInterval = 6
+	spectrumUpdateInterval = 2
+
+	// how often to check if the window of a shown visualization is minimized
+	minimizedPollInterval = 500 * time.Millisecond
+)
+
 // embedded in parent controller struct
 type visualizationData struct {
-	peakMeter    *visualizations.PeakMeter
-	peakMeterWin fyne.Window
+	visualizationWin  fyne.Window
+	visualizationView *VisualizationView
 
+	// visualizations that are currently shown and fed with data while playing
+	visualizations    []fyne.CanvasObject
+	spectrumLevels    []float64
 	visualizationAnim *fyne.Animation
+	visualizationTick uint64
+}
+
+// VisualizationView shows the visualization selected by the user, which is only
+// fed with data (and measured by the player) while active and its window isn't minimized.
+type VisualizationView struct {
+	widget.BaseWidget
+
+	c        *Controller
+	win      fyne.Window
+	kind     *string
+	current  fyne.CanvasObject
+	shown    bool // set by SetActive
+	active   bool // shown and the window isn't minimized
+	stopPoll chan struct{}
+
+	selector  *widget.Select
+	container *fyne.Container
+}
+
+// NewVisualizationView creates a view, to be shown in win, of the visualization
+// type saved in kind, which is updated when the user selects another.
+func (c *Controller) NewVisualizationView(win fyne.Window, kind *string) *VisualizationView {
+	v := &VisualizationView{c: c, win: win, kind: kind}
+	v.ExtendBaseWidget(v)
+	names := make([]string, len(visualizationTypes))
+	for i, t := range visualizationTypes {
+		names[i] = lang.L(t)
+	}
+	v.selector = widget.NewSelect(names, func(_ string) {
+		v.setKind(visualizationTypes[v.selector.SelectedIndex()])
+	})
+	v.container = container.NewBorder(container.NewHBox(v.selector), nil, nil, nil)
+	idx := slices.Index(visualizationTypes, *kind)
+	if idx < 0 {
+		idx = 0
+	}
+	v.selector.SetSelectedIndex(idx)
+	return v
+}
+
+func (v *VisualizationView) setKind(kind string) {
+	if v.current != nil {
+		if v.active {
+			v.c.removeVisualization(v.current)
+		}
+		v.container.Remove(v.current)
+	}
+	*v.kind = kind
+	switch kind {
+	case VisualizationLoudness:
+		v.current = visualizations.NewLoudnessMeter()
+	case VisualizationSpectrum:
+		v.current = visualizations.NewSpectrum(mpv.SpectrumBandFrequencies())
+	default:
+		v.current = visualizations.NewPeakMeter()
+	}
+	v.container.Add(v.current)
+	if v.active {
+		v.c.addVisualization(v.current)
+	}
+}
+
+// SetActive sets whether the view is visible to the user and should be updated.
+func (v *VisualizationView) SetActive(active bool) {
+	if active == v.shown {
+		return
+	}
+	v.shown = active
+	if active {
+		v.stopPoll = make(chan struct{})
+		go v.pauseWhileMinimized(v.stopPoll)
+	} else {
+		close(v.stopPoll)
+	}
+	v.setUpdating(active)
+}
+
+func (v *VisualizationView) setUpdating(updating bool) {
+	if updating == v.active {
+		return
+	}
+	v.active = updating
+	if updating {
+		v.c.addVisualization(v.current)
+	} else {
+		v.c.removeVisualization(v.current)
+	}
+}
+
+// pauseWhileMinimized stops updating the view while its window
+// is minimized, until stop is closed. Fyne has no event for this,
+// so the native window state is polled.
+func (v *VisualizationView) pauseWhileMinimized(stop <-chan struct{}) {
+	ticker := time.NewTicker(minimizedPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		fyne.Do(func() {
+			if v.shown {
+				v.setUpdating(!isWindowMinimized(v.win))
+			}
+		})
+	}
+}
+
+func (v *VisualizationView) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(v.container)
 }
 
 func (c *Controller) initVisualizations() {
@@ -27,62 +165,109 @@ func (c *Controller) initVisualizations() {
 	c.App.PlaybackManager.OnPaused(c.stopVisualizationAnim)
 	c.App.PlaybackManager.OnPlaying(func() {
 		if _, ok := c.App.PlaybackManager.CurrentPlayer().(*mpv.Player); ok {
-			if c.peakMeter != nil {
+			if len(c.visualizations) > 0 {
 				c.startVisualizationAnim()
 			}
 		}
 	})
 }
 
-func (c *Controller) ShowPeakMeter() {
-	if c.peakMeterWin != nil {
-		c.peakMeterWin.Show()
+func (c *Controller) ShowVisualizationWindow() {
+	if c.visualizationWin != nil {
+		c.visualizationWin.Show()
 		return
 	}
-	c.peakMeterWin = fyne.CurrentApp().NewWindow(lang.L("Peak Meter"))
+	c.visualizationWin = fyne.CurrentApp().NewWindow(lang.L("Visualizations"))
 
 	onClose := func() {
-		c.stopVisualizationAnim()
-		c.peakMeter = nil
-		util.SaveWindowSize(c.peakMeterWin,
+		c.visualizationView.SetActive(false)
+		c.visualizationView = nil
+		util.SaveWindowSize(c.visualizationWin,
 			&c.App.Config.PeakMeter.WindowWidth,
 			&c.App.Config.PeakMeter.WindowHeight)
-		c.peakMeterWin.Close()
-		c.peakMeterWin = nil
+		c.visualizationWin.Close()
+		c.visualizationWin = nil
 	}
 
-	c.peakMeterWin.SetCloseIntercept(onClose)
-	c.peakMeterWin.Canvas().AddShortcut(&shortcuts.ShortcutCloseWindow, func(_ fyne.Shortcut) {
+	c.visualizationWin.SetCloseIntercept(onClose)
+	c.visualizationWin.Canvas().AddShortcut(&shortcuts.ShortcutCloseWindow, func(_ fyne.Shortcut) {
 		onClose()
 	})
 	if c.App.Config.PeakMeter.WindowHeight > 0 {
-		c.peakMeterWin.Resize(fyne.NewSize(
+		c.visualizationWin.Resize(fyne.NewSize(
 			float32(c.App.Config.PeakMeter.WindowWidth),
 			float32(c.App.Config.PeakMeter.WindowHeight)))
 	}
-	c.peakMeter = visualizations.NewPeakMeter()
-	c.peakMeterWin.SetContent(c.peakMeter)
+	c.visualizationView = c.NewVisualizationView(c.visualizationWin, &c.App.Config.PeakMeter.Visualization)
+	c.visualizationWin.SetContent(c.visualizationView)
+	c.visualizationView.SetActive(true)
+	c.visualizationWin.Show()
+	SetWindowThemeMode(c.visualizationWin, fyne.CurrentApp().Settings().Theme().(*myTheme.MyTheme).AppearanceMode())
+}
+
+// isWindowMinimized returns whether win is minimized, if the platform can tell.
+func isWindowMinimized(win fyne.Window) bool {
+	minimized := false
+	win.(driver.NativeWindow).RunNative(func(ctx any) {
+		switch ctx := ctx.(type) {
+		case driver.WindowsWindowContext:
+			minimized = isNativeWindowMinimized(ctx.HWND)
+		case driver.MacWindowContext:
+			minimized = isNativeWindowMinimized(ctx.NSWindow)
+		case driver.X11WindowContext:
+			minimized = isNativeWindowMinimized(ctx.WindowHandle)
+		}
+	})
+	return minimized
+}
+
+func (c *Controller) addVisualization(v fyne.CanvasObject) {
+	c.visualizations = append(c.visualizations, v)
+	c.updateVisualizationFilters()
 	if c.App.LocalPlayer.GetStatus().State == player.Playing {
 		c.startVisualizationAnim()
 	} else {
 		// TODO: why is this needed?
-		c.peakMeter.Refresh()
+		v.Refresh()
+	}
+}
+
+func (c *Controller) removeVisualization(v fyne.CanvasObject) {
+	c.visualizations = slices.DeleteFunc(c.visualizations, func(o fyne.CanvasObject) bool { return o == v })
+	if len(c.visualizations) == 0 {
+		c.stopVisualizationAnim()
 	}
-	c.peakMeterWin.Show()
-	SetWindowThemeMode(c.peakMeterWin, fyne.CurrentApp().Settings().Theme().(*myTheme.MyTheme).AppearanceMode())
+	c.updateVisualizationFilters()
+}
+
+// updateVisualizationFilters enables only the measurements
+// needed by the visualizations that are currently shown.
+func (c *Controller) updateVisualizationFilters() {
+	var peaks, loudness, spectrum bool
+	for _, v := range c.visualizations {
+		switch v.(type) {
+		case *visualizations.PeakMeter:
+			peaks = true
+		case *visualizations.LoudnessMeter:
+			loudness = true
+		case *visualizations.Spectrum:
+			spectrum = true
+		}
+	}
+	c.App.LocalPlayer.SetPeaksEnabled(peaks)
+	c.App.LocalPlayer.SetLoudnessMeterEnabled(loudness)
+	c.App.LocalPlayer.SetSpectrumEnabled(spectrum)
 }
 
 func (c *Controller) stopVisualizationAnim() {
 	if c.visualizationAnim != nil {
 		c.visualizationAnim.Stop()
 		c.visualizationAnim = nil
-		c.App.LocalPlayer.SetPeaksEnabled(false)
 	}
 }
 
 func (c *Controller) startVisualizationAnim() {
 	if c.visualizationAnim == nil {
-		c.App.LocalPlayer.SetPeaksEnabled(true)
 		c.visualizationAnim = fyne.NewAnimation(
 			time.Duration(math.MaxInt64), /*until stopped*/
 			c.tickVisualizations)
@@ -91,8 +276,39 @@ func (c *Controller) startVisualizationAnim() {
 }
 
 func (c *Controller) tickVisualizations(_ float32) {
-	lP, rP, lRMS, rRMS := c.App.LocalPlayer.GetPeaks()
-	if c.visualizationData.peakMeter != nil {
-		c.visualizationData.peakMeter.UpdatePeaks(lP, rP, lRMS, rRMS)
+	c.visualizationTick++
+	var peaksRead, loudnessRead, spectrumRead bool
+	var lP, rP, lRMS, rRMS float64
+	var loudness mpv.Loudness
+	for _, v := range c.visualizations {
+		switch v := v.(type) {
+		case *visualizations.PeakMeter:
+			if !peaksRead {
+				lP, rP, lRMS, rRMS = c.App.LocalPlayer.GetPeaks()
+				peaksRead = true
+			}
+			v.UpdatePeaks(lP, rP, lRMS, rRMS)
+		case *visualizations.LoudnessMeter:
+			if c.visualizationTick%loudnessUpdateInterval != 0 {
+				continue
+			}
+			if !loudnessRead {
+				loudness, _ = c.App.LocalPlayer.GetLoudness()
+				loudnessRead = true
+			}
+			v.UpdateLoudness(loudness.Momentary, loudness.ShortTerm, loudness.Integrated, loudness.Range)
+		case *visualizations.Spectrum:
+			if c.visualizationTick%spectrumUpdateInterval != 0 {
+				continue
+			}
+			if !spectrumRead {
+				if c.spectrumLevels == nil {
+					c.spectrumLevels = make([]float64, mpv.SpectrumBands)
+				}
+				c.App.LocalPlayer.GetSpectrum(c.spectrumLevels)
+				spectrumRead = true
+			}
+			v.UpdateSpectrum(c.spectrumLevels)
+		}
 	}
 }
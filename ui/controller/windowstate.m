@@ -0,0 +1,10 @@
+//go:build darwin
+
+#import <AppKit/AppKit.h>
+
+int isWindowMiniaturized(void* windowPtr) {
+    if (windowPtr == NULL) return 0;
+
+    NSWindow* window = (__bridge NSWindow*)windowPtr;
+    return window.miniaturized ? 1 : 0;
+}
@@ -0,0 +1,13 @@
+//go:build darwin
+
+package controller
+
+/*
+int isWindowMiniaturized(void* windowPtr);
+*/
+import "C"
+import "unsafe"
+
+func isNativeWindowMinimized(nsWindowPtr uintptr) bool {
+	return C.isWindowMiniaturized(unsafe.Pointer(nsWindowPtr)) != 0
+}
@@ -0,0 +1,5 @@
+//go:build !windows && !darwin && (!linux || (wayland && !x11))
+
+package controller
+
+func isNativeWindowMinimized(ptr uintptr) bool { return false }
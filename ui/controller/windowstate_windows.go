@@ -0,0 +1,12 @@
+//go:build windows
+
+package controller
+
+import "syscall"
+
+var isIconic = syscall.NewLazyDLL("user32.dll").NewProc("IsIconic")
+
+func isNativeWindowMinimized(hwnd uintptr) bool {
+	ret, _, _ := isIconic.Call(hwnd)
+	return ret != 0
+}
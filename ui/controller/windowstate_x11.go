@@ -0,0 +1,34 @@
+//go:build linux && !(wayland && !x11)
+
+package controller
+
+/*
+#cgo LDFLAGS: -lX11
+#include <X11/Xlib.h>
+#include <X11/Xutil.h>
+
+// returns whether the window manager has iconified the window
+static int isWindowIconic(unsigned long window) {
+	static Display* dpy = NULL;
+	if (dpy == NULL && (dpy = XOpenDisplay(NULL)) == NULL) return 0;
+	Atom wmState = XInternAtom(dpy, "WM_STATE", True);
+	if (wmState == None) return 0;
+
+	Atom type;
+	int format;
+	unsigned long n, after;
+	unsigned char* data = NULL;
+	int iconic = 0;
+	if (XGetWindowProperty(dpy, (Window)window, wmState, 0, 1, False, wmState,
+			&type, &format, &n, &after, &data) == Success && data != NULL) {
+		iconic = format == 32 && n > 0 && ((long*)data)[0] == IconicState;
+		XFree(data);
+	}
+	return iconic;
+}
+*/
+import "C"
+
+func isNativeWindowMinimized(x11Window uintptr) bool {
+	return x11Window != 0 && C.isWindowIconic(C.ulong(x11Window)) != 0
+}
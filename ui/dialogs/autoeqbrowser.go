@@ -23,6 +23,9 @@ type AutoEQBrowser struct {
 	toastProvider     ToastProvider
 	allProfileResults []*mediaprovider.SearchResult
 	OnProfileSelected func(*backend.AutoEQProfile)
+
+	// If true, the parametric variant of the selected profile is fetched
+	Parametric bool
 }
 
 func NewAutoEQBrowser(manager *backend.AutoEQManager, im util.ImageFetcher, toastProvider ToastProvider) *AutoEQBrowser {
@@ -111,7 +114,11 @@ func (ab *AutoEQBrowser) SetOnProfileSelected(callback func(*backend.AutoEQProfi
 	ab.SearchDialog.OnNavigateTo = func(_ mediaprovider.ContentType, profilePath string) {
 		go func() {
 			// Fetch the full profile data
-			profile, err := ab.manager.FetchProfile(context.Background(), profilePath)
+			fetch := ab.manager.FetchProfile
+			if ab.Parametric {
+				fetch = ab.manager.FetchParametricProfile
+			}
+			profile, err := fetch(context.Background(), profilePath)
 			fyne.Do(func() {
 				if err != nil {
 					log.Printf("Error loading AutoEQ profile: %v", err)
@@ -0,0 +1,167 @@
+package dialogs
+
+import (
+	"fmt"
+	"slices"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	ttwidget "github.com/dweymouth/fyne-tooltip/widget"
+	"github.com/dweymouth/supersonic/backend/player/mpv"
+)
+
+// DSPChainEditor edits the order, enabled state and
+// parameters of the stages of the player's DSP chain.
+type DSPChainEditor struct {
+	widget.BaseWidget
+
+	OnChanged func(chain []mpv.DSPStage)
+
+	chain []mpv.DSPStage
+	rows  *fyne.Container
+}
+
+func NewDSPChainEditor(chain []mpv.DSPStage) *DSPChainEditor {
+	d := &DSPChainEditor{
+		chain: mpv.NormalizeDSPChain(chain),
+		rows:  container.NewVBox(),
+	}
+	d.ExtendBaseWidget(d)
+	d.buildRows()
+	return d
+}
+
+func (d *DSPChainEditor) onChanged() {
+	if d.OnChanged != nil {
+		d.OnChanged(slices.Clone(d.chain))
+	}
+}
+
+func (d *DSPChainEditor) buildRows() {
+	d.rows.RemoveAll()
+	for i := range d.chain {
+		stage := &d.chain[i]
+
+		up := ttwidget.NewButtonWithIcon("", theme.MoveUpIcon(), func() { d.move(i, -1) })
+		up.SetToolTip(lang.L("Move up"))
+		down := ttwidget.NewButtonWithIcon("", theme.MoveDownIcon(), func() { d.move(i, 1) })
+		down.SetToolTip(lang.L("Move down"))
+		if i == 0 {
+			up.Disable()
+		}
+		if i == len(d.chain)-1 {
+			down.Disable()
+		}
+
+		enabled := widget.NewCheck(dspStageName(stage.Type), func(b bool) {
+			stage.Enabled = b
+			d.onChanged()
+		})
+		enabled.Checked = stage.Enabled
+
+		d.rows.Add(container.NewBorder(nil, nil,
+			container.NewHBox(up, down, enabled), nil,
+			d.stageParams(stage)))
+		if i < len(d.chain)-1 {
+			d.rows.Add(widget.NewSeparator())
+		}
+	}
+	d.rows.Refresh()
+}
+
+func (d *DSPChainEditor) move(i, delta int) {
+	j := i + delta
+	if j < 0 || j >= len(d.chain) {
+		return
+	}
+	d.chain[i], d.chain[j] = d.chain[j], d.chain[i]
+	d.buildRows()
+	d.onChanged()
+}
+
+// stageParams returns the widgets to edit the stage's parameters.
+func (d *DSPChainEditor) stageParams(stage *mpv.DSPStage) fyne.CanvasObject {
+	entry := func(label string, val *float64, min, max float64) []fyne.CanvasObject {
+		e := newParametricValueEntry(*val, min, max, func(f float64) {
+			*val = f
+			d.onChanged()
+		})
+		return []fyne.CanvasObject{widget.NewLabel(label), e}
+	}
+	slider := func(val *float64, min, max, step float64) fyne.CanvasObject {
+		s := ttwidget.NewSlider(min, max)
+		s.Step = step
+		s.SetValue(*val)
+		s.SetToolTip(fmt.Sprintf("%0.2f", s.Value))
+		s.OnChanged = func(f float64) {
+			s.SetToolTip(fmt.Sprintf("%0.2f", f))
+		}
+		s.OnChangeEnded = func(f float64) {
+			*val = f
+			d.onChanged()
+		}
+		return s
+	}
+
+	switch stage.Type {
+	case mpv.DSPStageBalance:
+		return container.NewBorder(nil, nil, widget.NewLabel(lang.L("Left")), widget.NewLabel(lang.L("Right")),
+			slider(&stage.Balance, -1, 1, 0.05))
+	case mpv.DSPStageCrossfeed:
+		profile := widget.NewSelect(mpv.CrossfeedProfiles, func(s string) {
+			stage.CrossfeedProfile = s
+			d.onChanged()
+		})
+		profile.Selected = stage.CrossfeedProfile
+		return container.NewHBox(widget.NewLabel(lang.L("Profile")), profile)
+	case mpv.DSPStageStereoWiden:
+		return slider(&stage.Width, 0, 3, 0.1)
+	case mpv.DSPStageCompressor:
+		var objs []fyne.CanvasObject
+		objs = append(objs, entry(lang.L("Threshold (dB)"), &stage.ThresholdDB, -60, 0)...)
+		objs = append(objs, entry(lang.L("Ratio"), &stage.Ratio, 1, 20)...)
+		objs = append(objs, entry(lang.L("Attack (ms)"), &stage.AttackMs, 0.01, 2000)...)
+		objs = append(objs, entry(lang.L("Release (ms)"), &stage.ReleaseMs, 0.01, 9000)...)
+		objs = append(objs, entry(lang.L("Makeup (dB)"), &stage.MakeupDB, 0, 36)...)
+		return container.NewGridWithColumns(4, objs...)
+	case mpv.DSPStageLimiter:
+		return container.NewGridWithColumns(2, entry(lang.L("Ceiling (dB)"), &stage.CeilingDB, -24, 0)...)
+	case mpv.DSPStageLavfi:
+		graph := widget.NewEntry()
+		graph.SetPlaceHolder("aecho=0.8:0.9:40:0.3")
+		graph.SetText(stage.LavfiGraph)
+		graph.OnChanged = func(s string) {
+			stage.LavfiGraph = s
+			d.onChanged()
+		}
+		return graph
+	}
+	return widget.NewLabel("")
+}
+
+func dspStageName(t mpv.DSPStageType) string {
+	switch t {
+	case mpv.DSPStageBalance:
+		return lang.L("Channel balance")
+	case mpv.DSPStageMono:
+		return lang.L("Mono downmix")
+	case mpv.DSPStageCrossfeed:
+		return lang.L("Crossfeed")
+	case mpv.DSPStageStereoWiden:
+		return lang.L("Stereo widening")
+	case mpv.DSPStageCompressor:
+		return lang.L("Compressor (night mode)")
+	case mpv.DSPStageLavfi:
+		return lang.L("Custom lavfi filter")
+	case mpv.DSPStageLimiter:
+		return lang.L("Limiter")
+	}
+	return string(t)
+}
+
+func (d *DSPChainEditor) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(container.NewVScroll(d.rows))
+}
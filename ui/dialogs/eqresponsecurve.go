@@ -0,0 +1,171 @@
+package dialogs
+
+import (
+	"image/color"
+	"math"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/dweymouth/supersonic/backend/player/mpv"
+)
+
+const (
+	eqCurveMinFreq  = 20.
+	eqCurveMaxFreq  = 20000.
+	eqCurveMaxGain  = 15. // dB shown above and below 0
+	eqCurveSegments = 150
+)
+
+var (
+	eqCurveGainLines = []float64{-12, -6, 0, 6, 12}
+	eqCurveFreqLines = []float64{100, 1000, 10000}
+)
+
+// eqResponseCurve plots the frequency response of an equalizer
+// on a logarithmic frequency axis.
+type eqResponseCurve struct {
+	widget.BaseWidget
+
+	response func(freq float64) float64 // gain in dB
+}
+
+func newEQResponseCurve() *eqResponseCurve {
+	c := &eqResponseCurve{}
+	c.ExtendBaseWidget(c)
+	return c
+}
+
+// SetResponse sets the function that returns the gain in dB at a frequency.
+func (c *eqResponseCurve) SetResponse(response func(freq float64) float64) {
+	c.response = response
+	c.Refresh()
+}
+
+func (c *eqResponseCurve) MinSize() fyne.Size {
+	return fyne.NewSize(300, 150)
+}
+
+func (c *eqResponseCurve) CreateRenderer() fyne.WidgetRenderer {
+	r := &eqResponseCurveRenderer{
+		c:          c,
+		background: canvas.NewRectangle(color.Transparent),
+	}
+	for range eqCurveGainLines {
+		r.gridLines = append(r.gridLines, canvas.NewLine(color.Transparent))
+	}
+	for _, f := range eqCurveFreqLines {
+		r.gridLines = append(r.gridLines, canvas.NewLine(color.Transparent))
+		l := canvas.NewText(mpv.FormatFrequency(f), color.Transparent)
+		l.TextSize = theme.CaptionTextSize()
+		r.freqLabels = append(r.freqLabels, l)
+	}
+	for range eqCurveSegments {
+		r.segments = append(r.segments, canvas.NewLine(color.Transparent))
+	}
+	r.updateColors()
+	return r
+}
+
+type eqResponseCurveRenderer struct {
+	c          *eqResponseCurve
+	background *canvas.Rectangle
+	gridLines  []*canvas.Line
+	freqLabels []*canvas.Text
+	segments   []*canvas.Line
+}
+
+func (r *eqResponseCurveRenderer) Layout(size fyne.Size) {
+	r.background.Resize(size)
+	gainY := func(gain float64) float32 {
+		gain = math.Max(-eqCurveMaxGain, math.Min(eqCurveMaxGain, gain))
+		return float32((eqCurveMaxGain - gain) / (2 * eqCurveMaxGain) * float64(size.Height))
+	}
+
+	for i, g := range eqCurveGainLines {
+		y := gainY(g)
+		r.gridLines[i].Position1 = fyne.NewPos(0, y)
+		r.gridLines[i].Position2 = fyne.NewPos(size.Width, y)
+	}
+	for i, f := range eqCurveFreqLines {
+		x := eqCurveFreqX(f, size.Width)
+		line := r.gridLines[len(eqCurveGainLines)+i]
+		line.Position1 = fyne.NewPos(x, 0)
+		line.Position2 = fyne.NewPos(x, size.Height)
+		r.freqLabels[i].Move(fyne.NewPos(x+2, size.Height-r.freqLabels[i].MinSize().Height))
+	}
+
+	response := r.c.response
+	if response == nil {
+		response = func(float64) float64 { return 0 }
+	}
+	var prev fyne.Position
+	for i := 0; i <= eqCurveSegments; i++ {
+		x := size.Width * float32(i) / eqCurveSegments
+		p := fyne.NewPos(x, gainY(response(eqCurveXFreq(x, size.Width))))
+		if i > 0 {
+			r.segments[i-1].Position1 = prev
+			r.segments[i-1].Position2 = p
+		}
+		prev = p
+	}
+}
+
+// eqCurveFreqX returns the x position of a frequency on the log frequency axis.
+func eqCurveFreqX(freq float64, width float32) float32 {
+	return width * float32(math.Log(freq/eqCurveMinFreq)/math.Log(eqCurveMaxFreq/eqCurveMinFreq))
+}
+
+// eqCurveXFreq is the inverse of eqCurveFreqX.
+func eqCurveXFreq(x, width float32) float64 {
+	return eqCurveMinFreq * math.Pow(eqCurveMaxFreq/eqCurveMinFreq, float64(x/width))
+}
+
+func (r *eqResponseCurveRenderer) MinSize() fyne.Size {
+	return r.c.MinSize()
+}
+
+func (r *eqResponseCurveRenderer) updateColors() {
+	th := r.c.Theme()
+	v := fyne.CurrentApp().Settings().ThemeVariant()
+	r.background.FillColor = th.Color(theme.ColorNameInputBackground, v)
+	r.background.CornerRadius = th.Size(theme.SizeNameInputRadius)
+	for i, l := range r.gridLines {
+		l.StrokeColor = th.Color(theme.ColorNameSeparator, v)
+		l.StrokeWidth = 1
+		if i < len(eqCurveGainLines) && eqCurveGainLines[i] == 0 {
+			l.StrokeColor = th.Color(theme.ColorNameDisabled, v)
+		}
+	}
+	for _, l := range r.freqLabels {
+		l.Color = th.Color(theme.ColorNamePlaceHolder, v)
+	}
+	for _, s := range r.segments {
+		s.StrokeColor = th.Color(theme.ColorNamePrimary, v)
+		s.StrokeWidth = 2
+	}
+}
+
+func (r *eqResponseCurveRenderer) Refresh() {
+	r.updateColors()
+	r.Layout(r.c.Size())
+	canvas.Refresh(r.c)
+}
+
+func (r *eqResponseCurveRenderer) Objects() []fyne.CanvasObject {
+	objs := make([]fyne.CanvasObject, 0, 1+len(r.gridLines)+len(r.freqLabels)+len(r.segments))
+	objs = append(objs, r.background)
+	for _, l := range r.gridLines {
+		objs = append(objs, l)
+	}
+	for _, l := range r.freqLabels {
+		objs = append(objs, l)
+	}
+	for _, s := range r.segments {
+		objs = append(objs, s)
+	}
+	return objs
+}
+
+func (r *eqResponseCurveRenderer) Destroy() {}
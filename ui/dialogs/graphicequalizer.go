@@ -3,6 +3,7 @@ package dialogs
 import (
 	"fmt"
 	"math"
+	"slices"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
@@ -82,7 +83,10 @@ func (g *GraphicEqualizer) loadPresets() {
 		g.eqPresets = []backend.EQPreset{}
 		return
 	}
-	g.eqPresets = presets
+	// parametric presets are managed by the ParametricEqualizer
+	g.eqPresets = slices.DeleteFunc(presets, func(p backend.EQPreset) bool {
+		return p.Type == backend.EqualizerTypeParametric
+	})
 }
 
 func (g *GraphicEqualizer) buildSliders(preamp float64, bands []string, bandGains []float64) {
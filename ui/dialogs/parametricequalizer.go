@@ -0,0 +1,351 @@
+package dialogs
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	ttwidget "github.com/dweymouth/fyne-tooltip/widget"
+	"github.com/dweymouth/supersonic/backend"
+	"github.com/dweymouth/supersonic/backend/player/mpv"
+	myTheme "github.com/dweymouth/supersonic/ui/theme"
+	"github.com/dweymouth/supersonic/ui/util"
+)
+
+const parametricEQMaxGain = 30 // dB
+
+// ParametricEqualizer is an editor for the filters of the parametric
+// equalizer, which plots the resulting frequency response.
+type ParametricEqualizer struct {
+	widget.BaseWidget
+
+	// Called when the preamp or filters are changed. name is the name of
+	// the loaded preset or AutoEQ profile, or empty if edited since.
+	OnChanged           func(preamp float64, filters []mpv.ParametricFilter, name string)
+	OnLoadAutoEQProfile func()
+
+	eq       mpv.ParametricEqualizer
+	name     string
+	applying bool // true while applying a preset or profile
+
+	curve         *eqResponseCurve
+	preampEntry   *widget.Entry
+	filterRows    *fyne.Container
+	presetSelect  *widget.Select
+	nameLabel     *widget.Label
+	presets       []backend.EQPreset
+	presetManager *backend.EQPresetManager
+	parentWindow  fyne.Window
+	container     *fyne.Container
+}
+
+func NewParametricEqualizer(preamp float64, filters []mpv.ParametricFilter, name string, presetMgr *backend.EQPresetManager, parentWindow fyne.Window) *ParametricEqualizer {
+	p := &ParametricEqualizer{
+		eq:            mpv.ParametricEqualizer{EQPreamp: preamp, Filters: slices.Clone(filters)},
+		name:          name,
+		presetManager: presetMgr,
+		parentWindow:  parentWindow,
+	}
+	p.ExtendBaseWidget(p)
+
+	p.curve = newEQResponseCurve()
+	p.curve.SetResponse(p.response)
+
+	p.presetSelect = widget.NewSelect(nil, func(name string) {
+		if i := slices.IndexFunc(p.presets, func(pr backend.EQPreset) bool { return pr.Name == name }); i >= 0 {
+			p.Apply(p.presets[i].Preamp, p.presets[i].Filters, name)
+		}
+	})
+	p.presetSelect.PlaceHolder = lang.L("EQ Preset")
+	p.loadPresets()
+
+	saveAsBtn := ttwidget.NewButtonWithIcon("", myTheme.SaveAsIcon, p.showSaveAsDialog)
+	saveAsBtn.SetToolTip(lang.L("Save As"))
+	deleteBtn := ttwidget.NewButtonWithIcon("", theme.DeleteIcon(), p.showDeletePresetDialog)
+	deleteBtn.SetToolTip(lang.L("Delete"))
+	autoEQBtn := widget.NewButton(lang.L("AutoEQ"), func() {
+		if p.OnLoadAutoEQProfile != nil {
+			p.OnLoadAutoEQProfile()
+		}
+	})
+	resetBtn := widget.NewButton(lang.L("Reset"), func() {
+		p.Apply(0, nil, "")
+	})
+
+	p.preampEntry = newParametricValueEntry(preamp, -parametricEQMaxGain, parametricEQMaxGain, func(f float64) {
+		p.eq.EQPreamp = f
+		p.onEdited()
+	})
+	p.nameLabel = widget.NewLabel("")
+	p.updateNameLabel()
+
+	p.filterRows = container.NewVBox()
+	p.buildFilterRows()
+
+	addBtn := widget.NewButtonWithIcon(lang.L("Add filter"), theme.ContentAddIcon(), func() {
+		p.eq.Filters = append(p.eq.Filters, mpv.ParametricFilter{
+			Type:      mpv.FilterTypePeaking,
+			Frequency: 1000,
+			Q:         1,
+		})
+		p.buildFilterRows()
+		p.onEdited()
+	})
+
+	// align the header with the rows, which have a delete button on the right
+	deleteBtnWidth := widget.NewButtonWithIcon("", theme.DeleteIcon(), nil).MinSize().Width
+	header := container.NewBorder(nil, nil, nil, util.NewHSpace(deleteBtnWidth),
+		container.NewGridWithColumns(4,
+			widget.NewLabel(lang.L("Filter")),
+			widget.NewLabel(lang.L("Frequency (Hz)")),
+			widget.NewLabel(lang.L("Gain (dB)")),
+			widget.NewLabel("Q"),
+		))
+
+	topBar := container.NewVBox(
+		container.NewHBox(
+			widget.NewLabel(lang.L("EQ Preset:")),
+			p.presetSelect,
+			layout.NewSpacer(),
+			saveAsBtn,
+			deleteBtn,
+			resetBtn,
+			autoEQBtn,
+		),
+		container.NewHBox(
+			widget.NewLabel(lang.L("EQ Preamp")),
+			p.preampEntry,
+			widget.NewLabel("dB"),
+			layout.NewSpacer(),
+			p.nameLabel,
+		),
+	)
+	filterArea := container.NewBorder(header, container.NewHBox(addBtn), nil, nil,
+		container.NewVScroll(p.filterRows))
+	p.container = container.NewBorder(topBar, nil, nil, nil,
+		container.NewGridWithRows(2, p.curve, filterArea))
+	return p
+}
+
+// Apply replaces the preamp and filters, e.g. with those of an AutoEQ profile.
+func (p *ParametricEqualizer) Apply(preamp float64, filters []mpv.ParametricFilter, name string) {
+	p.applying = true
+	defer func() { p.applying = false }()
+
+	p.eq.EQPreamp = preamp
+	p.eq.Filters = slices.Clone(filters)
+	p.name = name
+	p.preampEntry.SetText(formatParametricValue(preamp))
+	p.buildFilterRows()
+	if !slices.ContainsFunc(p.presets, func(pr backend.EQPreset) bool { return pr.Name == name }) {
+		p.presetSelect.ClearSelected()
+	}
+	p.updateNameLabel()
+	p.curve.Refresh()
+	p.notifyChanged()
+}
+
+func (p *ParametricEqualizer) response(freq float64) float64 {
+	return p.eq.EQPreamp + p.eq.Response(freq)
+}
+
+// onEdited is called when the user edits a value
+func (p *ParametricEqualizer) onEdited() {
+	if p.applying {
+		return
+	}
+	p.name = ""
+	p.presetSelect.ClearSelected()
+	p.updateNameLabel()
+	p.curve.Refresh()
+	p.notifyChanged()
+}
+
+func (p *ParametricEqualizer) notifyChanged() {
+	if p.OnChanged != nil {
+		p.OnChanged(p.eq.EQPreamp, slices.Clone(p.eq.Filters), p.name)
+	}
+}
+
+func (p *ParametricEqualizer) updateNameLabel() {
+	if p.name == "" {
+		p.nameLabel.SetText("")
+	} else {
+		p.nameLabel.SetText(fmt.Sprintf("%s: %s", lang.L("Profile"), p.name))
+	}
+}
+
+func (p *ParametricEqualizer) buildFilterRows() {
+	filterTypes := []string{lang.L("Peak"), lang.L("Low shelf"), lang.L("High shelf")}
+
+	p.filterRows.RemoveAll()
+	for i, f := range p.eq.Filters {
+		typ := widget.NewSelect(filterTypes, nil)
+		typ.SetSelectedIndex(int(f.Type))
+		typ.OnChanged = func(string) {
+			p.eq.Filters[i].Type = mpv.FilterType(typ.SelectedIndex())
+			p.onEdited()
+		}
+		freq := newParametricValueEntry(f.Frequency, 10, 22000, func(v float64) {
+			p.eq.Filters[i].Frequency = v
+			p.onEdited()
+		})
+		gain := newParametricValueEntry(f.Gain, -parametricEQMaxGain, parametricEQMaxGain, func(v float64) {
+			p.eq.Filters[i].Gain = v
+			p.onEdited()
+		})
+		q := newParametricValueEntry(f.Q, 0.05, 20, func(v float64) {
+			p.eq.Filters[i].Q = v
+			p.onEdited()
+		})
+		remove := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+			p.eq.Filters = slices.Delete(p.eq.Filters, i, i+1)
+			p.buildFilterRows()
+			p.onEdited()
+		})
+		p.filterRows.Add(container.NewBorder(nil, nil, nil, remove,
+			container.NewGridWithColumns(4, typ, freq, gain, q)))
+	}
+	p.filterRows.Refresh()
+}
+
+// newParametricValueEntry returns an entry that calls onChanged
+// when its text is changed to a valid number within [min, max].
+func newParametricValueEntry(val, min, max float64, onChanged func(float64)) *widget.Entry {
+	parse := func(s string) (float64, error) {
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err == nil && (f < min || f > max || math.IsNaN(f)) {
+			err = fmt.Errorf("must be between %v and %v", min, max)
+		}
+		return f, err
+	}
+	e := widget.NewEntry()
+	e.SetText(formatParametricValue(val))
+	e.Validator = func(s string) error {
+		_, err := parse(s)
+		return err
+	}
+	e.OnChanged = func(s string) {
+		if f, err := parse(s); err == nil {
+			onChanged(f)
+		}
+	}
+	return e
+}
+
+func formatParametricValue(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func (p *ParametricEqualizer) loadPresets() {
+	presets, _ := p.presetManager.LoadPresets()
+	p.presets = slices.DeleteFunc(presets, func(pr backend.EQPreset) bool {
+		return pr.Type != backend.EqualizerTypeParametric
+	})
+	names := make([]string, len(p.presets))
+	for i, pr := range p.presets {
+		names[i] = pr.Name
+	}
+	p.presetSelect.Options = names
+	p.presetSelect.Refresh()
+}
+
+func (p *ParametricEqualizer) savePresetWithName(name string) {
+	preset := backend.EQPreset{
+		Name:    name,
+		Type:    backend.EqualizerTypeParametric,
+		Preamp:  p.eq.EQPreamp,
+		Bands:   []float64{},
+		Filters: slices.Clone(p.eq.Filters),
+	}
+	if err := p.presetManager.SavePreset(preset); err != nil {
+		dialog.ShowError(err, p.parentWindow)
+		return
+	}
+	p.loadPresets()
+	p.presetSelect.SetSelected(name)
+}
+
+func (p *ParametricEqualizer) showSaveAsDialog() {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder(lang.L("Preset name"))
+	nameEntry.SetText(p.name)
+
+	formDialog := dialog.NewForm(
+		lang.L("Save Preset As"),
+		lang.L("Save"),
+		lang.L("Cancel"),
+		[]*widget.FormItem{
+			widget.NewFormItem(lang.L("Name"), nameEntry),
+		},
+		func(confirmed bool) {
+			name := nameEntry.Text
+			if !confirmed || name == "" {
+				return
+			}
+			all, _ := p.presetManager.LoadPresets()
+			i := slices.IndexFunc(all, func(pr backend.EQPreset) bool { return pr.Name == name })
+			switch {
+			case i < 0:
+				p.savePresetWithName(name)
+			case all[i].IsBuiltin:
+				dialog.ShowInformation(
+					lang.L("Invalid Name"),
+					lang.L("Cannot use the name of a builtin preset"),
+					p.parentWindow,
+				)
+			default:
+				dialog.ShowConfirm(
+					lang.L("Overwrite Preset"),
+					fmt.Sprintf(lang.L("Preset '%s' already exists. Overwrite?"), name),
+					func(overwrite bool) {
+						if overwrite {
+							p.savePresetWithName(name)
+						}
+					},
+					p.parentWindow,
+				)
+			}
+		},
+		p.parentWindow,
+	)
+	formDialog.Resize(fyne.NewSize(400, 150))
+	formDialog.Show()
+}
+
+func (p *ParametricEqualizer) showDeletePresetDialog() {
+	name := p.presetSelect.Selected
+	if name == "" {
+		dialog.ShowInformation(lang.L("No Preset Selected"), lang.L("Please select a preset to delete"), p.parentWindow)
+		return
+	}
+	dialog.ShowConfirm(
+		lang.L("Delete Preset"),
+		fmt.Sprintf(lang.L("Delete preset '%s'?"), name),
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			if err := p.presetManager.DeletePreset(name); err != nil {
+				dialog.ShowError(err, p.parentWindow)
+				return
+			}
+			p.loadPresets()
+			p.presetSelect.ClearSelected()
+		},
+		p.parentWindow,
+	)
+}
+
+func (p *ParametricEqualizer) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(p.container)
+}
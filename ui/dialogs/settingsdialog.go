@@ -39,8 +39,15 @@ type SettingsDialog struct {
 	OnThemeSettingChanged          func()
 	OnDismiss                      func()
 	OnEqualizerSettingsChanged     func()
+	OnDSPSettingsChanged           func(chain []mpv.DSPStage) error
 	OnPageNeedsRefresh             func()
 	OnClearCaches                  func()
+	OnClearWaveformCache           func()
+	OnExportPlayHistory            func()
+	OnImportPlayHistory            func()
+	OnShowRemoteControlPairing     func()
+	OnShowDiscordPrivacy           func()
+	OnMQTTPasswordChanged          func(string)
 
 	config          *backend.Config
 	audioDevices    []mpv.AudioDevice
@@ -54,7 +61,14 @@ type SettingsDialog struct {
 
 	clientDecidesScrobble bool
 
+	// description of the device the EQ and volume settings belong to
+	deviceProfile binding.String
+
+	tabs    *container.AppTabs
+	eqTab   *container.TabItem
 	content fyne.CanvasObject
+
+	mqttPassword *widget.Entry
 }
 
 type ToastProvider interface {
@@ -91,16 +105,19 @@ func NewSettingsDialog(
 		toastProvider:         toastProvider,
 	}
 	s.ExtendBaseWidget(s)
+	s.deviceProfile = binding.NewString()
+	s.deviceProfile.Set(config.AudioDeviceProfileName())
 
 	// TODO: It may be a nicer UX to always create the equalizer tab,
 	// but disable it if we are not using an equalizer player
 	var tabs *container.AppTabs
 	if isEqualizerPlayer {
+		s.eqTab = s.createEqualizerTab(equalizerBands)
 		tabs = container.NewAppTabs(
 			s.createGeneralTab(canSavePlayQueue),
 			s.createAppearanceTab(window),
 			s.createPlaybackTab(isLocalPlayer, isReplayGainPlayer),
-			s.createEqualizerTab(equalizerBands),
+			s.eqTab,
 			s.createAdvancedTab(),
 		)
 	} else {
@@ -112,6 +129,7 @@ func NewSettingsDialog(
 		)
 	}
 
+	s.tabs = tabs
 	tabs.SelectIndex(s.getActiveTabNumFromConfig())
 	tabs.OnSelected = func(ti *container.TabItem) {
 		s.saveSelectedTab(tabs.SelectedIndex())
@@ -208,6 +226,8 @@ func (s *SettingsDialog) createGeneralTab(canSaveQueueToServer bool) *container.
 		saveQueueHBox.Add(saveToServer)
 	}
 
+	queueHandoff := widget.NewCheckWithData(lang.L("Offer to continue playback from other devices"),
+		binding.BindBool(&s.config.Application.EnableQueueHandoff))
 	trackNotif := widget.NewCheckWithData(lang.L("Show notification on track change"),
 		binding.BindBool(&s.config.Application.ShowTrackChangeNotification))
 	albumGridYears := widget.NewCheck(lang.L("Show year in album grid and now playing"), func(b bool) {
@@ -306,6 +326,7 @@ func (s *SettingsDialog) createGeneralTab(canSaveQueueToServer bool) *container.
 		),
 		container.NewHBox(systemTrayEnable, closeToTray),
 		saveQueueHBox,
+		queueHandoff,
 		trackNotif,
 		albumGridYears,
 		s.newSectionSeparator(),
@@ -365,13 +386,6 @@ func (s *SettingsDialog) createPlaybackTab(isLocalPlayer, isReplayGainPlayer boo
 	}
 	deviceSelect := widget.NewSelect(deviceList, nil)
 	deviceSelect.SetSelectedIndex(selIndex)
-	deviceSelect.OnChanged = func(_ string) {
-		dev := s.audioDevices[deviceSelect.SelectedIndex()]
-		s.config.LocalPlayback.AudioDeviceName = dev.Name
-		if s.OnAudioDeviceSettingChanged != nil {
-			s.OnAudioDeviceSettingChanged()
-		}
-	}
 
 	rGainOpts := []string{lang.L("None"), lang.L("Album"), lang.L("Track"), lang.L("Auto")}
 	replayGainSelect := widget.NewSelect(rGainOpts, nil)
@@ -413,13 +427,22 @@ func (s *SettingsDialog) createPlaybackTab(isLocalPlayer, isReplayGainPlayer boo
 			s.onReplayGainSettingsChanged()
 		}
 	}
-	initVal := math.Round(s.config.ReplayGain.PreampGainDB)
-	if initVal < -9 {
-		initVal = -9
-	} else if initVal > 9 {
-		initVal = 9
+	preampGainText := func() string {
+		return strconv.Itoa(int(math.Round(min(max(s.config.ReplayGain.PreampGainDB, -9), 9))))
+	}
+	preampGain.Text = preampGainText()
+
+	deviceSelect.OnChanged = func(_ string) {
+		dev := s.audioDevices[deviceSelect.SelectedIndex()]
+		s.config.LocalPlayback.AudioDeviceName = dev.Name
+		if s.OnAudioDeviceSettingChanged != nil {
+			s.OnAudioDeviceSettingChanged()
+		}
+		// the new device may have its own EQ and ReplayGain preamp settings
+		s.deviceProfile.Set(s.config.AudioDeviceProfileName())
+		preampGain.SetText(preampGainText())
+		s.refreshEqualizerTab()
 	}
-	preampGain.Text = strconv.Itoa(int(initVal))
 
 	preventClipping := widget.NewCheck("", func(checked bool) {
 		s.config.ReplayGain.PreventClipping = checked
@@ -427,6 +450,53 @@ func (s *SettingsDialog) createPlaybackTab(isLocalPlayer, isReplayGainPlayer boo
 	})
 	preventClipping.Checked = s.config.ReplayGain.PreventClipping
 
+	targetLUFS := newParametricValueEntry(s.config.ReplayGain.TargetLUFS, -40, -5, func(f float64) {
+		s.config.ReplayGain.TargetLUFS = f
+		s.onReplayGainSettingsChanged()
+	})
+	if !s.config.ReplayGain.AnalyzeLoudness {
+		targetLUFS.Disable()
+	}
+	analyzeLoudness := widget.NewCheck(lang.L("Analyze loudness of untagged tracks"), func(checked bool) {
+		s.config.ReplayGain.AnalyzeLoudness = checked
+		if checked {
+			targetLUFS.Enable()
+		} else {
+			targetLUFS.Disable()
+		}
+		s.setRestartRequired()
+	})
+	analyzeLoudness.Checked = s.config.ReplayGain.AnalyzeLoudness
+
+	normalizeRadio := widget.NewCheck(lang.L("Normalize radio streams"), func(checked bool) {
+		s.config.ReplayGain.NormalizeRadio = checked
+		s.onReplayGainSettingsChanged()
+	})
+	normalizeRadio.Checked = s.config.ReplayGain.NormalizeRadio
+
+	silenceThreshold := newParametricValueEntry(s.config.Playback.SkipSilenceThresholdDB, backend.MinSkipSilenceThresholdDB, -10, func(f float64) {
+		s.config.Playback.SkipSilenceThresholdDB = f
+	})
+	silenceMinSecs := newParametricValueEntry(s.config.Playback.SkipSilenceMinSeconds, 1, 600, func(f float64) {
+		s.config.Playback.SkipSilenceMinSeconds = f
+	})
+	if !s.config.Playback.SkipSilence {
+		silenceThreshold.Disable()
+		silenceMinSecs.Disable()
+	}
+	skipSilence := widget.NewCheck(lang.L("Skip silence at the start and end of tracks"), func(checked bool) {
+		s.config.Playback.SkipSilence = checked
+		if checked {
+			silenceThreshold.Enable()
+			silenceMinSecs.Enable()
+		} else {
+			silenceThreshold.Disable()
+			silenceMinSecs.Disable()
+		}
+		s.setRestartRequired()
+	})
+	skipSilence.Checked = s.config.Playback.SkipSilence
+
 	audioExclusive := widget.NewCheck(lang.L("Exclusive mode"), func(checked bool) {
 		s.config.LocalPlayback.AudioExclusive = checked
 		s.onAudioExclusiveSettingsChanged()
@@ -450,6 +520,9 @@ func (s *SettingsDialog) createPlaybackTab(isLocalPlayer, isReplayGainPlayer boo
 		replayGainSelect.Disable()
 		preventClipping.Disable()
 		preampGain.Disable()
+		analyzeLoudness.Disable()
+		targetLUFS.Disable()
+		normalizeRadio.Disable()
 	}
 
 	return container.NewTabItem(lang.L("Playback"), container.NewVBox(
@@ -457,6 +530,7 @@ func (s *SettingsDialog) createPlaybackTab(isLocalPlayer, isReplayGainPlayer boo
 		container.New(&layout.CustomPaddedLayout{TopPadding: 5},
 			container.New(layout.NewFormLayout(),
 				widget.NewLabel(lang.L("Audio device")), container.NewBorder(nil, nil, nil, util.NewHSpace(70), deviceSelect),
+				widget.NewLabel(lang.L("Device profile")), widget.NewLabelWithData(s.deviceProfile),
 				layout.NewSpacer(), audioExclusive,
 			)),
 		pauseFade,
@@ -470,6 +544,14 @@ func (s *SettingsDialog) createPlaybackTab(isLocalPlayer, isReplayGainPlayer boo
 			widget.NewLabel(lang.L("ReplayGain preamp")), container.NewHBox(preampGain, widget.NewLabel("dB")),
 			widget.NewLabel(lang.L("Prevent clipping")), preventClipping,
 		),
+		container.NewHBox(analyzeLoudness, widget.NewLabel(lang.L("Target")), targetLUFS, widget.NewLabel("LUFS")),
+		normalizeRadio,
+		s.newSectionSeparator(),
+		skipSilence,
+		container.NewHBox(
+			widget.NewLabel(lang.L("Silence threshold")), silenceThreshold, widget.NewLabel("dB"),
+			widget.NewLabel(lang.L("Minimum length")), silenceMinSecs, widget.NewLabel(lang.L("sec")),
+		),
 		s.newSectionSeparator(),
 		widget.NewLabelWithStyle(lang.L("When enqueuing random"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
 		widget.NewCheckWithData(lang.L("Skip one-star tracks"), binding.BindBool(&s.config.Playback.SkipOneStarWhenShuffling)),
@@ -498,7 +580,7 @@ func (s *SettingsDialog) createEqualizerTab(eqBands []string) *container.TabItem
 	geq := NewGraphicEqualizer(s.config.LocalPlayback.EqualizerPreamp,
 		eqBands,
 		s.config.LocalPlayback.GraphicEqualizerBands,
-		s.config.LocalPlayback.EqualizerType,
+		backend.GraphicEqualizerType(&s.config.LocalPlayback),
 		s.eqPresetManager,
 		s.window,
 		s.config.LocalPlayback.ActiveEQPresetName)
@@ -523,7 +605,9 @@ func (s *SettingsDialog) createEqualizerTab(eqBands []string) *container.TabItem
 		geq.ClearProfileLabel()
 	}
 	geq.OnLoadAutoEQProfile = func() {
-		s.openAutoEQBrowser(geq, debouncer)
+		s.openAutoEQBrowser(false, func(profile *backend.AutoEQProfile) {
+			s.applyAutoEQProfile(profile, geq, debouncer)
+		})
 	}
 	geq.OnPresetSelected = func(presetName string) {
 		// Save the active preset name in config
@@ -588,11 +672,126 @@ func (s *SettingsDialog) createEqualizerTab(eqBands []string) *container.TabItem
 		geq.SetProfileLabel(s.config.LocalPlayback.AutoEQProfileName)
 	}
 
-	cont := container.NewBorder(enabled, nil, nil, nil, geq)
+	peqCurve := newEQResponseCurve()
+	updatePEQCurve := func() {
+		peq := &mpv.ParametricEqualizer{
+			EQPreamp: s.config.LocalPlayback.ParametricEQPreamp,
+			Filters:  s.config.LocalPlayback.ParametricEQFilters,
+		}
+		peqCurve.SetResponse(func(freq float64) float64 {
+			return peq.EQPreamp + peq.Response(freq)
+		})
+	}
+	peqName := widget.NewLabel("")
+	peqName.SetText(s.config.LocalPlayback.ParametricEQName)
+	peqEditBtn := widget.NewButton(lang.L("Edit filters"), func() {
+		s.showParametricEQEditor(func() {
+			peqName.SetText(s.config.LocalPlayback.ParametricEQName)
+			updatePEQCurve()
+			debouncer()
+		})
+	})
+	peqPanel := container.NewBorder(container.NewHBox(peqName, layout.NewSpacer(), peqEditBtn),
+		nil, nil, nil, peqCurve)
+
+	setParametric := func(parametric bool) {
+		if parametric {
+			geq.Hide()
+			updatePEQCurve()
+			peqPanel.Show()
+		} else {
+			peqPanel.Hide()
+			geq.Show()
+		}
+	}
+	parametric := widget.NewCheck(lang.L("Parametric"), func(b bool) {
+		if b {
+			s.config.LocalPlayback.EqualizerType = backend.EqualizerTypeParametric
+		} else {
+			s.config.LocalPlayback.EqualizerType = backend.GraphicEqualizerType(&s.config.LocalPlayback)
+		}
+		setParametric(b)
+		if s.OnEqualizerSettingsChanged != nil {
+			s.OnEqualizerSettingsChanged()
+		}
+	})
+	parametric.Checked = s.config.LocalPlayback.EqualizerType == backend.EqualizerTypeParametric
+	setParametric(parametric.Checked)
+
+	dspBtn := widget.NewButton(lang.L("DSP"), s.showDSPChainEditor)
+	profile := container.NewHBox(widget.NewLabel(lang.L("Device profile")+":"), widget.NewLabelWithData(s.deviceProfile))
+	cont := container.NewBorder(container.NewHBox(enabled, parametric, layout.NewSpacer(), profile, dspBtn), nil, nil, nil,
+		container.NewStack(geq, peqPanel))
 	return container.NewTabItem(lang.L("Equalizer"), cont)
 }
 
-func (s *SettingsDialog) openAutoEQBrowser(geq *GraphicEqualizer, debouncer func()) {
+// refreshEqualizerTab rebuilds the equalizer tab from the config,
+// after switching to the EQ settings of another audio device.
+func (s *SettingsDialog) refreshEqualizerTab() {
+	if s.eqTab == nil {
+		return
+	}
+	bands := backend.NewGraphicEqualizerFromConfig(&s.config.LocalPlayback).BandFrequencies()
+	s.eqTab.Content = s.createEqualizerTab(bands).Content
+	s.tabs.Refresh()
+}
+
+func (s *SettingsDialog) showDSPChainEditor() {
+	editor := NewDSPChainEditor(s.config.LocalPlayback.DSPChain)
+	errText := widget.NewRichText(&widget.TextSegment{
+		Style: widget.RichTextStyle{ColorName: theme.ColorNameError},
+	})
+	errText.Wrapping = fyne.TextWrapWord
+	var pending []mpv.DSPStage
+	debouncer := util.NewDebouncer(350*time.Millisecond, func() {
+		// only save a chain the player accepts
+		var err error
+		if s.OnDSPSettingsChanged != nil {
+			err = s.OnDSPSettingsChanged(pending)
+		}
+		msg := ""
+		if err != nil {
+			msg = lang.L("The DSP chain could not be applied") + ": " + err.Error()
+		} else {
+			s.config.LocalPlayback.DSPChain = pending
+		}
+		errText.Segments[0].(*widget.TextSegment).Text = msg
+		errText.Refresh()
+	})
+	editor.OnChanged = func(chain []mpv.DSPStage) {
+		pending = chain
+		debouncer()
+	}
+
+	dlg := dialog.NewCustom(lang.L("DSP"), lang.L("Close"), container.NewBorder(nil, errText, nil, nil, editor), s.window)
+	dlg.Resize(fyne.NewSize(760, 520))
+	dlg.Show()
+}
+
+// showParametricEQEditor shows the parametric equalizer editor
+// in a dialog, calling onChanged after each edit is saved to the config.
+func (s *SettingsDialog) showParametricEQEditor(onChanged func()) {
+	lp := &s.config.LocalPlayback
+	peq := NewParametricEqualizer(lp.ParametricEQPreamp, lp.ParametricEQFilters, lp.ParametricEQName,
+		s.eqPresetManager, s.window)
+	peq.OnChanged = func(preamp float64, filters []mpv.ParametricFilter, name string) {
+		lp.ParametricEQPreamp = preamp
+		lp.ParametricEQFilters = filters
+		lp.ParametricEQName = name
+		onChanged()
+	}
+	peq.OnLoadAutoEQProfile = func() {
+		s.openAutoEQBrowser(true, func(profile *backend.AutoEQProfile) {
+			peq.Apply(profile.Preamp, profile.Filters, profile.Name)
+		})
+	}
+
+	dlg := dialog.NewCustom(lang.L("Parametric Equalizer"), lang.L("Close"), peq, s.window)
+	dlg.Resize(fyne.NewSize(720, 560))
+	dlg.Show()
+}
+
+func (s *SettingsDialog) openAutoEQBrowser(parametric bool, onSelected func(*backend.AutoEQProfile)) {
 	if s.autoEQManager == nil {
 		log.Printf("ERROR: AutoEQ manager not available (nil)")
 		return
@@ -603,13 +802,14 @@ func (s *SettingsDialog) openAutoEQBrowser(geq *GraphicEqualizer, debouncer func
 	}
 
 	browser := NewAutoEQBrowser(s.autoEQManager, s.imageManager, s.toastProvider)
+	browser.Parametric = parametric
 
 	// Show in a modal popup dialog
 	var popup *widget.PopUp
 	popup = widget.NewModalPopUp(browser.SearchDialog, s.window.Canvas())
 
 	browser.SetOnProfileSelected(func(profile *backend.AutoEQProfile) {
-		s.applyAutoEQProfile(profile, geq, debouncer)
+		onSelected(profile)
 		popup.Hide()
 	})
 	browser.SetOnDismiss(func() {
@@ -764,6 +964,14 @@ func (s *SettingsDialog) createAppearanceTab(window fyne.Window) *container.TabI
 	})
 	useWaveformSeekbar.Checked = s.config.Playback.UseWaveformSeekbar
 
+	waveformModes := []string{backend.WaveformModeMono, backend.WaveformModeStereo, backend.WaveformModeFrequency}
+	waveformMode := widget.NewSelect([]string{lang.L("Mono"), lang.L("Stereo"), lang.L("Frequency colored")}, nil)
+	waveformMode.SetSelectedIndex(max(0, slices.Index(waveformModes, s.config.Playback.WaveformMode)))
+	waveformMode.OnChanged = func(_ string) {
+		s.config.Playback.WaveformMode = waveformModes[waveformMode.SelectedIndex()]
+		s.setRestartRequired()
+	}
+
 	nowPlayingBackground := widget.NewCheckWithData(lang.L("Use blurred album cover for Now Playing page background"), binding.BindBool(&s.config.NowPlayingConfig.UseBackgroundImage))
 
 	useRoundedImageCorners := widget.NewCheck(lang.L("Use rounded image corners"), func(b bool) {
@@ -785,7 +993,7 @@ func (s *SettingsDialog) createAppearanceTab(window fyne.Window) *container.TabI
 		container.NewBorder(nil, nil, widget.NewLabel(lang.L("Grid card size")), nil, gridCardSize),
 		disableDPI,
 		s.newSectionSeparator(),
-		useWaveformSeekbar,
+		container.NewHBox(useWaveformSeekbar, layout.NewSpacer(), widget.NewLabel(lang.L("Waveform")), waveformMode),
 		nowPlayingBackground,
 		useRoundedImageCorners,
 		s.newSectionSeparator(),
@@ -829,6 +1037,30 @@ func (s *SettingsDialog) createAdvancedTab() *container.TabItem {
 		clearCaches,
 	)
 
+	waveformCacheEntry := widgets.NewTextRestrictedEntry(threeDigitValidator)
+	waveformCacheEntry.SetMinCharWidth(3)
+	waveformCacheEntry.OnChanged = func(str string) {
+		if i, err := strconv.Atoi(str); err == nil {
+			s.config.Playback.MaxWaveformCacheSizeMB = i
+			s.setRestartRequired()
+		}
+	}
+	waveformCacheEntry.Text = strconv.Itoa(s.config.Playback.MaxWaveformCacheSizeMB)
+
+	clearWaveformCache := widget.NewButton(lang.L("Clear waveform cache"), func() {
+		if s.OnClearWaveformCache != nil {
+			s.OnClearWaveformCache()
+		}
+	})
+
+	waveformCacheCfg := container.NewHBox(
+		widget.NewLabel(lang.L("Maximum waveform cache size")),
+		waveformCacheEntry,
+		widget.NewLabel("MB"),
+		layout.NewSpacer(),
+		clearWaveformCache,
+	)
+
 	osMediaAPIs := widget.NewCheck(lang.L("Enable OS media player integration"), func(b bool) {
 		s.config.Application.EnableOSMediaPlayerAPIs = b
 		s.setRestartRequired()
@@ -838,6 +1070,144 @@ func (s *SettingsDialog) createAdvancedTab() *container.TabItem {
 	preventScreensaver := widget.NewCheckWithData(lang.L("Prevent screensaver on Now Playing page"),
 		binding.BindBool(&s.config.Application.PreventScreensaverOnNowPlayingPage))
 
+	exportHistory := widget.NewButton(lang.L("Export"), func() {
+		if s.OnExportPlayHistory != nil {
+			s.OnExportPlayHistory()
+		}
+	})
+	importHistory := widget.NewButton(lang.L("Import"), func() {
+		if s.OnImportPlayHistory != nil {
+			s.OnImportPlayHistory()
+		}
+	})
+	playHistoryCfg := container.NewHBox(
+		widget.NewLabel(lang.L("Listening history")),
+		layout.NewSpacer(),
+		exportHistory,
+		importHistory,
+	)
+
+	remoteControl := widget.NewCheck(lang.L("Allow remote control from other devices"), func(b bool) {
+		s.config.RemoteControl.Enabled = b
+		s.setRestartRequired()
+	})
+	remoteControl.Checked = s.config.RemoteControl.Enabled
+	remoteTLS := widget.NewCheck(lang.L("Use HTTPS"), func(b bool) {
+		s.config.RemoteControl.UseTLS = b
+		s.setRestartRequired()
+	})
+	remoteTLS.Checked = s.config.RemoteControl.UseTLS
+	portEntry := widgets.NewTextRestrictedEntry(func(text, selText string, r rune) bool {
+		return unicode.IsDigit(r) && len(text)-len(selText) < 5
+	})
+	portEntry.SetMinCharWidth(5)
+	portEntry.Text = strconv.Itoa(s.config.RemoteControl.Port)
+	portEntry.OnChanged = func(str string) {
+		if i, err := strconv.Atoi(str); err == nil && i > 0 && i <= 65535 {
+			s.config.RemoteControl.Port = i
+			s.setRestartRequired()
+		}
+	}
+	pairDevice := widget.NewButton(lang.L("Pair device"), func() {
+		if s.OnShowRemoteControlPairing != nil {
+			s.OnShowRemoteControlPairing()
+		}
+	})
+	remoteControlCfg := container.NewHBox(
+		remoteControl,
+		layout.NewSpacer(),
+		widget.NewLabel(lang.L("Port")),
+		portEntry,
+		remoteTLS,
+		pairDevice,
+	)
+
+	mpdServer := widget.NewCheck(lang.L("Enable MPD protocol server"), func(b bool) {
+		s.config.MPDServer.Enabled = b
+		s.setRestartRequired()
+	})
+	mpdServer.Checked = s.config.MPDServer.Enabled
+
+	mqttBroker := widget.NewEntry()
+	mqttBroker.SetPlaceHolder("tcp://localhost:1883")
+	mqttBroker.Text = s.config.MQTT.BrokerURL
+	mqttBroker.OnChanged = func(str string) {
+		s.config.MQTT.BrokerURL = str
+		s.setRestartRequired()
+	}
+	mqttUsername := widget.NewEntry()
+	mqttUsername.Text = s.config.MQTT.Username
+	mqttUsername.OnChanged = func(str string) {
+		s.config.MQTT.Username = str
+		s.setRestartRequired()
+	}
+	s.mqttPassword = widget.NewPasswordEntry()
+	s.mqttPassword.OnChanged = func(str string) {
+		if s.OnMQTTPasswordChanged != nil {
+			s.OnMQTTPasswordChanged(str)
+		}
+		s.setRestartRequired()
+	}
+	mqttTopicPrefix := widget.NewEntry()
+	mqttTopicPrefix.SetPlaceHolder("supersonic")
+	mqttTopicPrefix.Text = s.config.MQTT.TopicPrefix
+	mqttTopicPrefix.OnChanged = func(str string) {
+		s.config.MQTT.TopicPrefix = str
+		s.setRestartRequired()
+	}
+	mqttDiscovery := widget.NewCheck(lang.L("Home Assistant discovery"), func(b bool) {
+		s.config.MQTT.HomeAssistantDiscovery = b
+		s.setRestartRequired()
+	})
+	mqttDiscovery.Checked = s.config.MQTT.HomeAssistantDiscovery
+	mqttWidgets := []fyne.Disableable{mqttBroker, mqttUsername, s.mqttPassword, mqttTopicPrefix, mqttDiscovery}
+	if !s.config.MQTT.Enabled {
+		for _, w := range mqttWidgets {
+			w.Disable()
+		}
+	}
+	mqtt := widget.NewCheck(lang.L("Enable MQTT (Home Assistant)"), func(b bool) {
+		s.config.MQTT.Enabled = b
+		for _, w := range mqttWidgets {
+			if b {
+				w.Enable()
+			} else {
+				w.Disable()
+			}
+		}
+		s.setRestartRequired()
+	})
+	mqtt.Checked = s.config.MQTT.Enabled
+	mqttCfg := container.NewBorder(nil, nil,
+		container.NewHBox(mqtt, widget.NewLabel(lang.L("Broker"))), nil, mqttBroker)
+	mqttAuthCfg := container.NewGridWithColumns(2,
+		container.NewBorder(nil, nil, widget.NewLabel(lang.L("Username")), nil, mqttUsername),
+		container.NewBorder(nil, nil, widget.NewLabel(lang.L("Password")), nil, s.mqttPassword))
+	mqttTopicCfg := container.NewBorder(nil, nil,
+		widget.NewLabel(lang.L("Topic prefix")), mqttDiscovery, mqttTopicPrefix)
+
+	discord := widget.NewCheck(lang.L("Show playing track on Discord"), func(b bool) {
+		s.config.DiscordRPC.Enabled = b
+		s.setRestartRequired()
+	})
+	discord.Checked = s.config.DiscordRPC.Enabled
+	discordAppID := widgets.NewTextRestrictedEntry(func(text, selText string, r rune) bool {
+		return unicode.IsDigit(r)
+	})
+	discordAppID.SetPlaceHolder(lang.L("Default"))
+	discordAppID.Text = s.config.DiscordRPC.ApplicationID
+	discordAppID.OnChanged = func(str string) {
+		s.config.DiscordRPC.ApplicationID = str
+		s.setRestartRequired()
+	}
+	discordPrivacy := widget.NewButton(lang.L("Privacy"), func() {
+		if s.OnShowDiscordPrivacy != nil {
+			s.OnShowDiscordPrivacy()
+		}
+	})
+	discordCfg := container.NewBorder(nil, nil,
+		container.NewHBox(discord, widget.NewLabel(lang.L("Application ID"))), discordPrivacy, discordAppID)
+
 	return container.NewTabItem(lang.L("Advanced"), container.NewVBox(
 		multi,
 		update,
@@ -845,9 +1215,26 @@ func (s *SettingsDialog) createAdvancedTab() *container.TabItem {
 		osMediaAPIs,
 		preventScreensaver,
 		imgCacheCfg,
+		waveformCacheCfg,
+		playHistoryCfg,
+		remoteControlCfg,
+		mpdServer,
+		mqttCfg,
+		mqttAuthCfg,
+		mqttTopicCfg,
+		discordCfg,
 	))
 }
 
+// SetMQTTPassword sets the MQTT broker password shown in the dialog,
+// which is stored separately from the config.
+func (s *SettingsDialog) SetMQTTPassword(pass string) {
+	onChanged := s.mqttPassword.OnChanged
+	s.mqttPassword.OnChanged = nil
+	s.mqttPassword.SetText(pass)
+	s.mqttPassword.OnChanged = onChanged
+}
+
 func (s *SettingsDialog) doChooseTTFFile(window fyne.Window, entry *widget.Entry) {
 	callback := func(urirc fyne.URIReadCloser, err error) {
 		if err == nil && urirc != nil {
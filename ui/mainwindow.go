@@ -125,6 +125,9 @@ func NewMainWindow(fyneApp fyne.App, appName, displayAppName, appVersion string,
 	app.ServerManager.OnServerConnected(func(conf *backend.ServerConfig) {
 		go m.RunOnServerConnectedTasks(conf, app, displayAppName)
 	})
+	app.OnRemotePlayQueue = func(queue *mediaprovider.SavedPlayQueue) {
+		fyne.Do(func() { m.Controller.ShowContinueFromDeviceDialog(queue) })
+	}
 	app.ServerManager.OnLogout(func() {
 		m.Toolbar.DisableNavigationButtons()
 		m.BrowsingPane.SetPage(nil)
@@ -139,7 +142,7 @@ func NewMainWindow(fyneApp fyne.App, appName, displayAppName, appVersion string,
 	m.Toolbar.AddSettingsMenuSeparator()
 	m.Toolbar.AddSettingsSubmenu(lang.L("Visualizations"), myTheme.VisualizationIcon,
 		fyne.NewMenu("", []*fyne.MenuItem{
-			fyne.NewMenuItem(lang.L("Peak Meter"), m.Controller.ShowPeakMeter),
+			fyne.NewMenuItem(lang.L("Visualizations"), m.Controller.ShowVisualizationWindow),
 		}...))
 	m.Toolbar.AddSettingsMenuSeparator()
 	m.Toolbar.AddSettingsMenuItem(lang.L("Check for Updates"), theme.DownloadIcon(), func() {
@@ -363,6 +366,7 @@ func (m *MainWindow) RunOnServerConnectedTasks(serverConf *backend.ServerConfig,
 	fyne.Do(func() {
 		m.Toolbar.EnableNavigationButtons()
 		m.Router.NavigateTo(m.StartupPage())
+		m.Controller.OpenPendingDeepLink()
 		_, canRate := m.App.ServerManager.Server.(mediaprovider.SupportsRating)
 		m.BottomPanel.NowPlaying.DisableRating = !canRate
 
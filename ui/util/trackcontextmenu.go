@@ -11,6 +11,7 @@ import (
 type TrackContextMenu struct {
 	ratingSubmenu     *fyne.MenuItem
 	shareMenuItem     *fyne.MenuItem
+	copyLinkMenuItem  *fyne.MenuItem
 	songRadioMenuItem *fyne.MenuItem
 	infoMenuItem      *fyne.MenuItem
 
@@ -21,6 +22,7 @@ type TrackContextMenu struct {
 	OnAddToPlaylist func()
 	OnShowInfo      func()
 	OnShare         func()
+	OnCopyLink      func()
 	OnFavorite      func(fav bool)
 	OnSetRating     func(rating int)
 
@@ -103,7 +105,13 @@ func NewTrackContextMenu(disablePlaybackMenu bool, auxItems []*fyne.MenuItem) *T
 		}
 	})
 	tcm.shareMenuItem.Icon = myTheme.ShareIcon
-	tcm.menu.Items = append(tcm.menu.Items, tcm.shareMenuItem)
+	tcm.copyLinkMenuItem = fyne.NewMenuItem(lang.L("Copy link"), func() {
+		if tcm.OnCopyLink != nil {
+			tcm.OnCopyLink()
+		}
+	})
+	tcm.copyLinkMenuItem.Icon = theme.ContentCopyIcon()
+	tcm.menu.Items = append(tcm.menu.Items, tcm.shareMenuItem, tcm.copyLinkMenuItem)
 	if disablePlaybackMenu {
 		tcm.menu.Items = append(tcm.menu.Items, tcm.songRadioMenuItem)
 	}
@@ -131,10 +139,17 @@ func (tcm *TrackContextMenu) SetShareDisabled(disabled bool) {
 	tcm.shareMenuItem.Disabled = disabled
 }
 
+func (tcm *TrackContextMenu) SetCopyLinkDisabled(disabled bool) {
+	tcm.copyLinkMenuItem.Disabled = disabled
+}
+
 func (tcm *TrackContextMenu) SetInfoDisabled(disabled bool) {
 	tcm.infoMenuItem.Disabled = disabled
 }
 
 func (tcm *TrackContextMenu) ShowAtPosition(pos fyne.Position, canvas fyne.Canvas) {
+	if tcm.OnCopyLink == nil {
+		tcm.copyLinkMenuItem.Disabled = true
+	}
 	widget.ShowPopUpMenuAtPosition(tcm.menu, canvas, pos)
 }
@@ -0,0 +1,212 @@
+package visualizations
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	myTheme "github.com/dweymouth/supersonic/ui/theme"
+)
+
+const (
+	loudnessMinLUFS = -60
+	loudnessMaxLUFS = 0
+	// EBU R128 target level, marked on the meter
+	loudnessTargetLUFS = -23
+)
+
+// LoudnessMeter displays an EBU R128 loudness measurement:
+// momentary and short-term loudness as bars, and integrated
+// loudness and loudness range as text.
+type LoudnessMeter struct {
+	widget.BaseWidget
+	momentary  float64
+	shortTerm  float64
+	integrated float64
+	lra        float64
+
+	// true iff only a layout and the text need to be updated,
+	// rather than a full refresh. cleared by the renderer
+	refreshLayoutOnly bool
+}
+
+func NewLoudnessMeter() *LoudnessMeter {
+	l := &LoudnessMeter{
+		momentary:  math.Inf(-1),
+		shortTerm:  math.Inf(-1),
+		integrated: math.Inf(-1),
+	}
+	l.ExtendBaseWidget(l)
+	return l
+}
+
+// UpdateLoudness updates the loudness that is displayed in the meter.
+// This function is expected to be called from a fyne.Animation callback.
+func (l *LoudnessMeter) UpdateLoudness(momentary, shortTerm, integrated, lra float64) {
+	if momentary == l.momentary && shortTerm == l.shortTerm && integrated == l.integrated && lra == l.lra {
+		return // ebur128 measures every 100ms
+	}
+	l.momentary = momentary
+	l.shortTerm = shortTerm
+	l.integrated = integrated
+	l.lra = lra
+	l.refreshLayoutOnly = true
+	l.BaseWidget.Refresh()
+}
+
+func (l *LoudnessMeter) CreateRenderer() fyne.WidgetRenderer {
+	return newLoudnessMeterRenderer(l)
+}
+
+func (l *LoudnessMeter) Refresh() {
+	l.refreshLayoutOnly = false
+	l.BaseWidget.Refresh()
+}
+
+type loudnessMeterRenderer struct {
+	l *LoudnessMeter
+
+	mLabel     canvas.Text
+	sLabel     canvas.Text
+	mRect      canvas.Rectangle
+	sRect      canvas.Rectangle
+	targetLine canvas.Rectangle
+	stats      canvas.Text
+
+	rulerLines  []canvas.Rectangle
+	rulerLabels []canvas.Text
+
+	objects []fyne.CanvasObject
+}
+
+func newLoudnessMeterRenderer(lm *LoudnessMeter) *loudnessMeterRenderer {
+	r := &loudnessMeterRenderer{l: lm}
+	r.mLabel.Text = "M"
+	r.sLabel.Text = "S"
+	numRules := (loudnessMaxLUFS - loudnessMinLUFS) / 10
+	r.rulerLines = make([]canvas.Rectangle, numRules)
+	r.rulerLabels = make([]canvas.Text, numRules)
+	for i := range r.rulerLabels {
+		r.rulerLabels[i].Text = fmt.Sprintf("%d", loudnessMaxLUFS-10*i)
+	}
+	r.updateStats()
+	r.updateColors()
+	return r
+}
+
+func (r *loudnessMeterRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(275, 100)
+}
+
+func (r *loudnessMeterRenderer) Layout(size fyne.Size) {
+	topSpacing := float32(5)
+	labelWidth := float32(20)
+	overflowWidth := float32(10)
+	ruleLabelHeight := float32(12)
+	barSpacing := float32(2)
+	statsHeight := r.stats.MinSize().Height + topSpacing
+	meterWidth := size.Width - labelWidth - overflowWidth - topSpacing
+	barHeight := (size.Height-statsHeight-ruleLabelHeight-topSpacing)/2 - barSpacing
+
+	pos := func(lufs float64) float32 {
+		f := (lufs - loudnessMinLUFS) / (loudnessMaxLUFS - loudnessMinLUFS)
+		return float32(math.Max(0, math.Min(1, f))) * meterWidth
+	}
+
+	labelMin := r.mLabel.MinSize()
+	r.mLabel.Move(fyne.NewPos(4, (barHeight-labelMin.Height)/2+topSpacing))
+	r.mLabel.Resize(labelMin)
+	r.sLabel.Move(fyne.NewPos(4, barHeight+barSpacing+topSpacing+(barHeight-labelMin.Height)/2))
+	r.sLabel.Resize(r.sLabel.MinSize())
+
+	r.mRect.Move(fyne.NewPos(labelWidth, topSpacing))
+	r.mRect.Resize(fyne.NewSize(pos(r.l.momentary), barHeight))
+	r.sRect.Move(fyne.NewPos(labelWidth, barHeight+barSpacing+topSpacing))
+	r.sRect.Resize(fyne.NewSize(pos(r.l.shortTerm), barHeight))
+
+	lineWidth := theme.SeparatorThicknessSize() * 2
+	bottom := (barHeight + barSpacing) * 2
+	r.targetLine.Move(fyne.NewPos(labelWidth+pos(loudnessTargetLUFS), topSpacing))
+	r.targetLine.Resize(fyne.NewSize(lineWidth, bottom))
+
+	ruleWidth := lineWidth * 0.667
+	for i := range r.rulerLines {
+		x := labelWidth + pos(float64(loudnessMaxLUFS-10*i))
+		r.rulerLines[i].Move(fyne.NewPos(x, topSpacing))
+		r.rulerLines[i].Resize(fyne.NewSize(ruleWidth, bottom))
+		r.rulerLabels[i].Resize(r.rulerLabels[i].MinSize())
+		r.rulerLabels[i].Move(fyne.NewPos(x-r.rulerLabels[i].MinSize().Width/2, bottom+topSpacing))
+	}
+
+	r.stats.Resize(r.stats.MinSize())
+	r.stats.Move(fyne.NewPos(labelWidth, size.Height-statsHeight+topSpacing/2))
+}
+
+func (r *loudnessMeterRenderer) updateStats() {
+	r.stats.Text = fmt.Sprintf("M: %s    S: %s    I: %s LUFS    LRA: %.1f LU",
+		formatLUFS(r.l.momentary), formatLUFS(r.l.shortTerm), formatLUFS(r.l.integrated), r.l.lra)
+}
+
+func formatLUFS(lufs float64) string {
+	if lufs < loudnessMinLUFS*2 {
+		return "-∞"
+	}
+	return fmt.Sprintf("%.1f", lufs)
+}
+
+func (r *loudnessMeterRenderer) Refresh() {
+	r.updateStats()
+	if r.l.refreshLayoutOnly {
+		canvas.Refresh(&r.stats)
+		r.l.refreshLayoutOnly = false
+		r.Layout(r.l.Size())
+		return
+	}
+	r.updateColors()
+	r.Layout(r.l.Size())
+}
+
+func (r *loudnessMeterRenderer) updateColors() {
+	foreground := theme.ForegroundColor()
+	c := color.NRGBAModel.Convert(theme.PrimaryColor()).(color.NRGBA)
+	c.A = 128
+	for _, t := range []*canvas.Text{&r.mLabel, &r.sLabel} {
+		t.Color = foreground
+		t.TextSize = 16
+		t.TextStyle.Bold = true
+	}
+	r.stats.Color = foreground
+	r.stats.TextSize = 14
+	r.mRect.FillColor = c
+	r.sRect.FillColor = c
+	r.targetLine.FillColor = theme.ErrorColor()
+
+	ruleColor := myTheme.BlendColors(foreground, theme.BackgroundColor(), 0.5)
+	for i := range r.rulerLines {
+		r.rulerLines[i].FillColor = ruleColor
+		r.rulerLabels[i].Color = foreground
+		r.rulerLabels[i].TextSize = 11
+	}
+}
+
+func (r *loudnessMeterRenderer) Objects() []fyne.CanvasObject {
+	if r.objects == nil {
+		r.objects = make([]fyne.CanvasObject, 0, 2*len(r.rulerLines)+6)
+		for i := range r.rulerLines {
+			r.objects = append(r.objects, &r.rulerLines[i], &r.rulerLabels[i])
+		}
+		r.objects = append(r.objects,
+			&r.mLabel, &r.sLabel,
+			&r.mRect, &r.sRect,
+			&r.targetLine, &r.stats)
+	}
+	return r.objects
+}
+
+func (r *loudnessMeterRenderer) Destroy() {
+}
@@ -0,0 +1,187 @@
+package visualizations
+
+import (
+	"image/color"
+	"math"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	myTheme "github.com/dweymouth/supersonic/ui/theme"
+)
+
+const (
+	spectrumRangeDB = 60
+	spectrumTopDB   = -6
+	// fraction of the previous level kept each frame when a band falls
+	spectrumFalloff = 0.85
+)
+
+// Spectrum displays the level of each frequency band as a bar.
+type Spectrum struct {
+	widget.BaseWidget
+	freqs  []float64
+	levels []float64 // smoothed level in dB of each band
+
+	// true iff only a layout is needed, rather than a full refresh.
+	// cleared by the renderer
+	refreshLayoutOnly bool
+}
+
+// NewSpectrum creates a spectrum analyzer for bands with the given center frequencies.
+func NewSpectrum(bandFreqs []float64) *Spectrum {
+	s := &Spectrum{
+		freqs:  bandFreqs,
+		levels: make([]float64, len(bandFreqs)),
+	}
+	for i := range s.levels {
+		s.levels[i] = spectrumTopDB - spectrumRangeDB
+	}
+	s.ExtendBaseWidget(s)
+	return s
+}
+
+// UpdateSpectrum updates the band levels (in dBFS) that are displayed.
+// This function is expected to be called from a fyne.Animation callback,
+// running at 60 Hz
+func (s *Spectrum) UpdateSpectrum(levels []float64) {
+	floor := float64(spectrumTopDB - spectrumRangeDB)
+	for i := range s.levels {
+		l := floor
+		if i < len(levels) {
+			l = math.Max(floor, levels[i])
+		}
+		// rise immediately, fall gradually
+		s.levels[i] = math.Max(l, floor+(s.levels[i]-floor)*spectrumFalloff)
+	}
+	s.refreshLayoutOnly = true
+	s.BaseWidget.Refresh()
+}
+
+func (s *Spectrum) CreateRenderer() fyne.WidgetRenderer {
+	return newSpectrumRenderer(s)
+}
+
+func (s *Spectrum) Refresh() {
+	s.refreshLayoutOnly = false
+	s.BaseWidget.Refresh()
+}
+
+var spectrumLabelFreqs = []float64{50, 100, 200, 500, 1000, 2000, 5000, 10000}
+
+type spectrumRenderer struct {
+	s *Spectrum
+
+	bars      []canvas.Rectangle
+	labels    []canvas.Text
+	baseline  canvas.Rectangle
+	labelPosX []float32 // relative position of each label along the axis
+
+	objects []fyne.CanvasObject
+}
+
+func newSpectrumRenderer(s *Spectrum) *spectrumRenderer {
+	r := &spectrumRenderer{s: s}
+	r.bars = make([]canvas.Rectangle, len(s.freqs))
+	if len(s.freqs) > 1 {
+		minF, maxF := math.Log(s.freqs[0]), math.Log(s.freqs[len(s.freqs)-1])
+		for _, f := range spectrumLabelFreqs {
+			if f < s.freqs[0] || f > s.freqs[len(s.freqs)-1] {
+				continue
+			}
+			text := canvas.Text{Text: formatBandFreq(f)}
+			r.labels = append(r.labels, text)
+			r.labelPosX = append(r.labelPosX, float32((math.Log(f)-minF)/(maxF-minF)))
+		}
+	}
+	r.updateColors()
+	return r
+}
+
+func formatBandFreq(f float64) string {
+	if f >= 1000 {
+		return strconv.Itoa(int(f/1000)) + "k"
+	}
+	return strconv.Itoa(int(f))
+}
+
+func (r *spectrumRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(275, 100)
+}
+
+func (r *spectrumRenderer) Layout(size fyne.Size) {
+	padding := float32(5)
+	labelHeight := float32(14)
+	barSpacing := float32(2)
+	height := size.Height - labelHeight - padding*2
+	width := size.Width - padding*2
+	n := float32(len(r.bars))
+	if n == 0 {
+		return
+	}
+	barWidth := (width - barSpacing*(n-1)) / n
+	bottom := padding + height
+
+	for i := range r.bars {
+		f := (r.s.levels[i] - (spectrumTopDB - spectrumRangeDB)) / spectrumRangeDB
+		h := float32(math.Max(0, math.Min(1, f))) * height
+		r.bars[i].Move(fyne.NewPos(padding+float32(i)*(barWidth+barSpacing), bottom-h))
+		r.bars[i].Resize(fyne.NewSize(barWidth, h))
+	}
+	r.baseline.Move(fyne.NewPos(padding, bottom))
+	r.baseline.Resize(fyne.NewSize(width, theme.SeparatorThicknessSize()))
+
+	// labels are centered under the band center frequencies,
+	// which lie from the middle of the first bar to the middle of the last
+	axisStart := padding + barWidth/2
+	axisWidth := width - barWidth
+	for i := range r.labels {
+		labelMin := r.labels[i].MinSize()
+		r.labels[i].Resize(labelMin)
+		r.labels[i].Move(fyne.NewPos(axisStart+r.labelPosX[i]*axisWidth-labelMin.Width/2, bottom+2))
+	}
+}
+
+func (r *spectrumRenderer) Refresh() {
+	if r.s.refreshLayoutOnly {
+		r.s.refreshLayoutOnly = false
+		r.Layout(r.s.Size())
+		return
+	}
+	r.updateColors()
+	r.Layout(r.s.Size())
+}
+
+func (r *spectrumRenderer) updateColors() {
+	foreground := theme.ForegroundColor()
+	c := color.NRGBAModel.Convert(theme.PrimaryColor()).(color.NRGBA)
+	c.A = 192
+	for i := range r.bars {
+		r.bars[i].FillColor = c
+	}
+	r.baseline.FillColor = myTheme.BlendColors(foreground, theme.BackgroundColor(), 0.5)
+	for i := range r.labels {
+		r.labels[i].Color = foreground
+		r.labels[i].TextSize = 11
+	}
+}
+
+func (r *spectrumRenderer) Objects() []fyne.CanvasObject {
+	if r.objects == nil {
+		r.objects = make([]fyne.CanvasObject, 0, len(r.bars)+len(r.labels)+1)
+		for i := range r.bars {
+			r.objects = append(r.objects, &r.bars[i])
+		}
+		for i := range r.labels {
+			r.objects = append(r.objects, &r.labels[i])
+		}
+		r.objects = append(r.objects, &r.baseline)
+	}
+	return r.objects
+}
+
+func (r *spectrumRenderer) Destroy() {
+}
@@ -0,0 +1,122 @@
+package widgets
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/dweymouth/supersonic/backend/player"
+	"github.com/dweymouth/supersonic/ui/util"
+)
+
+// ChapterList shows the chapters of the playing file,
+// with the current chapter highlighted.
+type ChapterList struct {
+	widget.BaseWidget
+
+	// Invoked with the index of the chapter the user tapped
+	OnChapterTapped   func(idx int)
+	OnPreviousChapter func()
+	OnNextChapter     func()
+
+	chapters       []player.Chapter
+	current        int
+	scrolledTo     int // current chapter the list was last scrolled to
+	list           *widget.List
+	noChapters     *widget.Label
+	previous, next *IconButton
+	container      *fyne.Container
+}
+
+func NewChapterList() *ChapterList {
+	c := &ChapterList{current: -1, scrolledTo: -1}
+	c.ExtendBaseWidget(c)
+	c.list = widget.NewList(
+		func() int { return len(c.chapters) },
+		func() fyne.CanvasObject {
+			time := widget.NewLabel("000:00")
+			time.Alignment = fyne.TextAlignTrailing
+			title := widget.NewLabel("")
+			title.Truncation = fyne.TextTruncateEllipsis
+			return container.NewBorder(nil, nil, time, nil, title)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			row := obj.(*fyne.Container)
+			title := row.Objects[0].(*widget.Label)
+			time := row.Objects[1].(*widget.Label)
+			time.SetText(util.SecondsToMMSS(c.chapters[id].Time))
+			title.TextStyle.Bold = id == c.current
+			title.SetText(ChapterTitle(c.chapters, id))
+		},
+	)
+	c.list.OnSelected = func(id widget.ListItemID) {
+		c.list.Unselect(id)
+		if c.OnChapterTapped != nil {
+			c.OnChapterTapped(id)
+		}
+	}
+	c.noChapters = widget.NewLabel(lang.L("No chapters"))
+	c.previous = NewIconButton(theme.MediaSkipPreviousIcon(), func() {
+		if c.OnPreviousChapter != nil {
+			c.OnPreviousChapter()
+		}
+	})
+	c.previous.SetToolTip(lang.L("Previous chapter"))
+	c.next = NewIconButton(theme.MediaSkipNextIcon(), func() {
+		if c.OnNextChapter != nil {
+			c.OnNextChapter()
+		}
+	})
+	c.next.SetToolTip(lang.L("Next chapter"))
+	c.container = container.NewBorder(
+		container.NewHBox(c.previous, c.next), nil, nil, nil,
+		container.NewStack(c.list, container.NewCenter(c.noChapters)),
+	)
+	c.updateEmpty()
+	return c
+}
+
+// ChapterTitle returns the title of the chapter at idx, or
+// a numbered placeholder if the chapter has no title.
+func ChapterTitle(chapters []player.Chapter, idx int) string {
+	if chapters[idx].Title != "" {
+		return chapters[idx].Title
+	}
+	return fmt.Sprintf("%s %d", lang.L("Chapter"), idx+1)
+}
+
+// SetChapters sets the chapters shown and the index of the current chapter.
+func (c *ChapterList) SetChapters(chapters []player.Chapter, current int) {
+	c.chapters = chapters
+	c.current = current
+	c.updateEmpty()
+	c.list.Refresh()
+	if current >= 0 && current != c.scrolledTo {
+		c.list.ScrollTo(current)
+		c.scrolledTo = current
+	}
+}
+
+func (c *ChapterList) updateEmpty() {
+	if len(c.chapters) == 0 {
+		c.noChapters.Show()
+		c.previous.Disable()
+		c.next.Disable()
+		c.scrolledTo = -1
+	} else {
+		c.noChapters.Hide()
+		c.previous.Enable()
+		c.next.Enable()
+	}
+}
+
+func (c *ChapterList) Scroll(delta float32) {
+	c.list.ScrollToOffset(c.list.GetScrollOffset() + delta)
+}
+
+func (c *ChapterList) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(c.container)
+}
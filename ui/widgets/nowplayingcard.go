@@ -34,6 +34,8 @@ type NowPlayingCard struct {
 	ratingMenu *fyne.MenuItem
 
 	albumYear string
+	title     string
+	chapter   string
 
 	OnTrackNameTapped  func()
 	OnArtistNameTapped func(artistID string)
@@ -139,7 +141,9 @@ func (n *NowPlayingCard) CreateRenderer() fyne.WidgetRenderer {
 }
 
 func (n *NowPlayingCard) Update(track mediaprovider.MediaItem) {
+	n.chapter = ""
 	if track == nil {
+		n.title = ""
 		n.trackName.SetTextAndToolTip("")
 		n.artistName.BuildSegments([]string{}, []string{})
 		n.albumName.BuildSegments([]string{}, []string{})
@@ -147,7 +151,8 @@ func (n *NowPlayingCard) Update(track mediaprovider.MediaItem) {
 		n.cover.Hidden = true
 	} else {
 		n.cover.Hidden = false
-		n.trackName.SetTextAndToolTip(track.Metadata().Name)
+		n.title = track.Metadata().Name
+		n.trackName.SetTextAndToolTip(n.title)
 		if tr, ok := track.(*mediaprovider.Track); ok {
 			n.artistName.BuildSegments(tr.ArtistNames, tr.ArtistIDs)
 			n.albumName.BuildSegments([]string{tr.Album}, []string{tr.AlbumID})
@@ -167,6 +172,20 @@ func (n *NowPlayingCard) Update(track mediaprovider.MediaItem) {
 	n.Refresh()
 }
 
+// SetChapter sets the title of the current chapter,
+// which is shown after the track name.
+func (n *NowPlayingCard) SetChapter(chapter string) {
+	if chapter == n.chapter {
+		return
+	}
+	n.chapter = chapter
+	if chapter == "" {
+		n.trackName.SetTextAndToolTip(n.title)
+	} else {
+		n.trackName.SetTextAndToolTip(n.title + " · " + chapter)
+	}
+}
+
 func (n *NowPlayingCard) SetImage(cover image.Image) {
 	n.cover.SetImage(cover, true)
 }
@@ -282,6 +282,18 @@ func (p *PlayerControls) UpdateWaveformImg(img *backend.WaveformImage) {
 	p.waveform.UpdateImage(img)
 }
 
+// SetChapterMarkers sets the positions (ratio from 0 to 1)
+// of chapter markers drawn on the waveform seekbar.
+func (p *PlayerControls) SetChapterMarkers(positions []float64) {
+	p.waveform.SetChapterMarkers(positions)
+}
+
+// SetWaveformColored sets whether the colors of the waveform images are kept,
+// e.g. for images colored by frequency content.
+func (p *PlayerControls) SetWaveformColored(colored bool) {
+	p.waveform.Colored = colored
+}
+
 func (p *PlayerControls) Refresh() {
 	p.waveform.Hidden = !p.UseWaveformSeekbar
 	p.slider.Hidden = p.UseWaveformSeekbar
@@ -69,6 +69,7 @@ type Tracklist struct {
 	OnSetRating         func(trackIDs []string, rating int)
 	OnDownload          func(tracks []*mediaprovider.Track, downloadName string)
 	OnShare             func(trackID string)
+	OnCopyLink          func(trackID string)
 	OnPlaySongRadio     func(track *mediaprovider.Track)
 	OnReorderTracks     func(trackIDs []string, insertPos int)
 	OnShowTrackInfo     func(track *mediaprovider.Track)
@@ -543,12 +544,20 @@ func (t *Tracklist) onShowContextMenu(e *fyne.PointEvent, trackIdx int) {
 		t.ctxMenu.OnShare = func() {
 			t.onShare(t.SelectedTracks())
 		}
+		if t.OnCopyLink != nil {
+			t.ctxMenu.OnCopyLink = func() {
+				if tracks := t.SelectedTracks(); len(tracks) > 0 {
+					t.OnCopyLink(tracks[0].ID)
+				}
+			}
+		}
 		t.ctxMenu.OnSetRating = func(rating int) {
 			t.onSetRatings(t.SelectedTracks(), rating, true /*needRefresh*/)
 		}
 	}
 	t.ctxMenu.SetRatingDisabled(t.Options.DisableRating)
 	t.ctxMenu.SetShareDisabled(t.Options.DisableSharing || len(t.SelectedTracks()) != 1)
+	t.ctxMenu.SetCopyLinkDisabled(len(t.SelectedTracks()) != 1)
 	t.ctxMenu.SetInfoDisabled(len(t.SelectedTracks()) != 1)
 	t.ctxMenu.ShowAtPosition(e.AbsolutePosition, fyne.CurrentApp().Driver().CanvasForObject(t))
 }
@@ -20,15 +20,21 @@ type WaveformSeekbar struct {
 
 	OnSeeked func(float64)
 
+	// If true, the colors of the waveform image are kept, and the
+	// unplayed part is dimmed rather than colored with the theme colors
+	Colored bool
+
+	srcImg           *backend.WaveformImage // the uncolored image, if Colored
 	imgColorL        color.Color
 	imgColorR        color.Color
 	imgProgressPixel int
 
 	focused bool
 
-	img    *canvas.Image
-	cursor *canvas.Rectangle
-	focus  *canvas.Rectangle
+	img     *canvas.Image
+	cursor  *canvas.Rectangle
+	focus   *canvas.Rectangle
+	markers *fyne.Container
 }
 
 func NewWaveformSeekbar() *WaveformSeekbar {
@@ -37,8 +43,9 @@ func NewWaveformSeekbar() *WaveformSeekbar {
 			ScaleMode: canvas.ImageScaleFastest,
 			Image:     backend.NewWaveformImage(),
 		},
-		cursor: canvas.NewRectangle(color.Transparent),
-		focus:  canvas.NewRectangle(color.Transparent),
+		cursor:  canvas.NewRectangle(color.Transparent),
+		focus:   canvas.NewRectangle(color.Transparent),
+		markers: container.New(&chapterMarkerLayout{}),
 	}
 	w.ExtendBaseWidget(w)
 	w.cursor.Hidden = true
@@ -47,9 +54,15 @@ func NewWaveformSeekbar() *WaveformSeekbar {
 }
 
 func (w *WaveformSeekbar) UpdateImage(img *backend.WaveformImage) {
-	prm, fg, _ := w.getThemeColors()
-	recolorWaveformImage(img, prm, fg, 0, w.imgProgressPixel, true)
-	w.img.Image = img
+	if w.Colored {
+		w.srcImg = img
+		w.img.Image = image.NewNRGBA(img.Rect)
+		dimWaveformImage(w.img.Image.(*image.NRGBA), img, 0, w.imgProgressPixel, true)
+	} else {
+		prm, fg, _ := w.getThemeColors()
+		recolorWaveformImage(img, prm, fg, 0, w.imgProgressPixel, true)
+		w.img.Image = img
+	}
 	w.img.Refresh()
 }
 
@@ -57,14 +70,38 @@ func (w *WaveformSeekbar) Refresh() {
 	w.cursor.Resize(fyne.NewSize(1, w.Size().Height-4))
 	w.focus.Resize(fyne.NewSize(3, w.Size().Height-2))
 	prm, fg, focus := w.getThemeColors()
-	img := w.img.Image.(*image.NRGBA)
-	recolorWaveformImage(img, prm, fg, w.imgProgressPixel, w.imgProgressPixel, true)
+	if !w.Colored {
+		img := w.img.Image.(*image.NRGBA)
+		recolorWaveformImage(img, prm, fg, w.imgProgressPixel, w.imgProgressPixel, true)
+	}
 	w.recolorCursor(prm, fg, w.cursor.Position().X)
 	w.focus.FillColor = focus
+	w.recolorMarkers(fg)
 
 	w.BaseWidget.Refresh()
 }
 
+// SetChapterMarkers sets the positions (ratio from 0 to 1)
+// of the chapter markers drawn on the seekbar.
+func (w *WaveformSeekbar) SetChapterMarkers(positions []float64) {
+	w.markers.Layout.(*chapterMarkerLayout).positions = positions
+	w.markers.Objects = make([]fyne.CanvasObject, len(positions))
+	for i := range positions {
+		w.markers.Objects[i] = canvas.NewRectangle(color.Transparent)
+	}
+	_, fg, _ := w.getThemeColors()
+	w.recolorMarkers(fg)
+	w.markers.Refresh()
+}
+
+func (w *WaveformSeekbar) recolorMarkers(fg color.Color) {
+	c := color.NRGBAModel.Convert(fg).(color.NRGBA)
+	c.A = 160
+	for _, o := range w.markers.Objects {
+		o.(*canvas.Rectangle).FillColor = c
+	}
+}
+
 var _ desktop.Hoverable = (*WaveformSeekbar)(nil)
 
 func (w *WaveformSeekbar) MouseIn(e *desktop.MouseEvent) {
@@ -139,6 +176,7 @@ func (w *WaveformSeekbar) CreateRenderer() fyne.WidgetRenderer {
 	return widget.NewSimpleRenderer(
 		container.NewStack(
 			container.New(layout.NewCustomPaddedLayout(4, 4, 0, 0), w.img),
+			container.New(layout.NewCustomPaddedLayout(4, 4, 0, 0), w.markers),
 			container.NewWithoutLayout(w.cursor, w.focus),
 		),
 	)
@@ -191,7 +229,13 @@ func (w *WaveformSeekbar) updateImageProgress(cL, cR color.Color, progress int)
 	}
 
 	img := w.img.Image.(*image.NRGBA)
-	recolorWaveformImage(img, cL, cR, w.imgProgressPixel, progress, false)
+	if w.Colored {
+		if w.srcImg != nil {
+			dimWaveformImage(img, w.srcImg, w.imgProgressPixel, progress, false)
+		}
+	} else {
+		recolorWaveformImage(img, cL, cR, w.imgProgressPixel, progress, false)
+	}
 	w.imgColorL, w.imgColorR = cL, cR
 	w.imgProgressPixel = progress
 	return true
@@ -220,9 +264,47 @@ func recolorWaveformImage(img *image.NRGBA, cL, cR color.Color, oldProgress, new
 	}
 }
 
+// dimWaveformImage copies src to img, reducing the opacity of the unplayed part
+func dimWaveformImage(img, src *image.NRGBA, oldProgress, newProgress int, fullRecolor bool) {
+	bnds := img.Rect.Bounds()
+	xMin, xMax := 0, bnds.Dx()
+	if !fullRecolor {
+		xMin = max(0, min(oldProgress, newProgress))
+		xMax = min(bnds.Dx(), max(oldProgress, newProgress))
+	}
+	for x := xMin; x < xMax; x++ {
+		for y := 0; y < bnds.Dy(); y++ {
+			offset := img.PixOffset(x, y)
+			copy(img.Pix[offset:offset+4], src.Pix[offset:offset+4])
+			if x >= newProgress {
+				img.Pix[offset+3] = byte(int(img.Pix[offset+3]) * 2 / 5)
+			}
+		}
+	}
+}
+
 func setPixelRGB(img *image.NRGBA, x, y int, r, g, b byte) {
 	offset := img.PixOffset(x, y)
 	img.Pix[offset+0] = r
 	img.Pix[offset+1] = g
 	img.Pix[offset+2] = b
 }
+
+// chapterMarkerLayout places each object as a vertical line
+// at the corresponding relative position along the width.
+type chapterMarkerLayout struct {
+	positions []float64
+}
+
+func (l *chapterMarkerLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	for i, o := range objects {
+		if i < len(l.positions) {
+			o.Move(fyne.NewPos(float32(l.positions[i])*size.Width, 0))
+			o.Resize(fyne.NewSize(theme.SeparatorThicknessSize()*2, size.Height))
+		}
+	}
+}
+
+func (l *chapterMarkerLayout) MinSize([]fyne.CanvasObject) fyne.Size {
+	return fyne.Size{}
+}
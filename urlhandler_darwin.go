@@ -0,0 +1,26 @@
+package main
+
+/*
+#cgo LDFLAGS: -framework Foundation -framework CoreServices
+void registerURLHandler(void);
+*/
+import "C"
+
+// invoked with the supersonic:// links macOS sends to the app
+var onOpenURL func(string)
+
+// registerURLHandler handles the Apple events with which macOS opens
+// supersonic:// links, both when launching the app and while it's running,
+// since links are not passed on the command line as on other platforms.
+func registerURLHandler(open func(link string)) {
+	onOpenURL = open
+	C.registerURLHandler()
+}
+
+//export goOpenURL
+func goOpenURL(url *C.char) {
+	if onOpenURL != nil {
+		// called on the main thread; don't block the event loop
+		go onOpenURL(C.GoString(url))
+	}
+}
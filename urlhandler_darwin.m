@@ -0,0 +1,29 @@
+//go:build darwin
+
+#import <Foundation/Foundation.h>
+#import <CoreServices/CoreServices.h>
+
+extern void goOpenURL(char* url);
+
+@interface SupersonicURLHandler : NSObject
+@end
+
+@implementation SupersonicURLHandler
+- (void)handleGetURLEvent:(NSAppleEventDescriptor*)event withReplyEvent:(NSAppleEventDescriptor*)reply {
+    NSString* url = [[event paramDescriptorForKeyword:keyDirectObject] stringValue];
+    if (url != nil) {
+        goOpenURL((char*)[url UTF8String]);
+    }
+}
+@end
+
+void registerURLHandler(void) {
+    static SupersonicURLHandler* handler = nil;
+    if (handler != nil) return;
+
+    handler = [[SupersonicURLHandler alloc] init];
+    [[NSAppleEventManager sharedAppleEventManager] setEventHandler:handler
+                                                       andSelector:@selector(handleGetURLEvent:withReplyEvent:)
+                                                     forEventClass:kInternetEventClass
+                                                        andEventID:kAEGetURL];
+}
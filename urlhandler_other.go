@@ -0,0 +1,7 @@
+//go:build !darwin
+
+package main
+
+// registerURLHandler does nothing here; supersonic:// links
+// are passed on the command line of a new instance.
+func registerURLHandler(open func(link string)) {}